@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// promptedCredentials adds the API keys typed in the runner and not saved, kept until oq
+// quits, to the credential store
+type promptedCredentials struct {
+	credentialStore
+	keys map[string]string
+}
+
+func (s promptedCredentials) get(key string) (string, error) {
+	if value, ok := s.keys[key]; ok {
+		return value, nil
+	}
+	return s.credentialStore.get(key)
+}
+
+// pendingAPIKey returns the apiKey scheme to ask for before sending req for op: the first one
+// without a credential in a requirement, unless another requirement is already met. A key
+// typed in the form, as a header or query parameter, counts as a credential
+func pendingAPIKey(req *http.Request, doc *v3.Document, op *v3.Operation, store credentialStore) (string, *v3.SecurityScheme, bool) {
+	if doc.Components == nil || doc.Components.SecuritySchemes == nil {
+		return "", nil, false
+	}
+	var pending string
+	for _, requirement := range effectiveSecurity(doc, op) {
+		if requirement == nil || requirement.Requirements == nil || requirement.Requirements.Len() == 0 {
+			return "", nil, false
+		}
+		met := true
+		for pair := requirement.Requirements.First(); pair != nil; pair = pair.Next() {
+			scheme := doc.Components.SecuritySchemes.GetOrZero(pair.Key())
+			isAPIKey := scheme != nil && strings.EqualFold(scheme.Type, "apikey")
+			if _, err := credentialValue(store, pair.Key()); err == nil || (isAPIKey && apiKeySet(req, scheme)) {
+				continue
+			}
+			met = false
+			if pending == "" && isAPIKey {
+				pending = pair.Key()
+			}
+		}
+		if met {
+			return "", nil, false
+		}
+	}
+	if pending == "" {
+		return "", nil, false
+	}
+	return pending, doc.Components.SecuritySchemes.GetOrZero(pending), true
+}
+
+// apiKeySet reports whether req already carries the key of an apiKey scheme
+func apiKeySet(req *http.Request, scheme *v3.SecurityScheme) bool {
+	switch scheme.In {
+	case "header":
+		return req.Header.Get(scheme.Name) != ""
+	case "query":
+		return req.URL.Query().Has(scheme.Name)
+	case "cookie":
+		_, err := req.Cookie(scheme.Name)
+		return err == nil
+	}
+	return false
+}
+
+// apiKeyPrompt asks for the key of an apiKey scheme in the runner, before the request is sent.
+// The key is kept for the session, or saved to the credential store when save is checked
+type apiKeyPrompt struct {
+	scheme    string
+	in, name  string
+	storeName string
+	input     textinput.Model
+	// onSave is set while the focus is on the save checkbox rather than the key
+	onSave bool
+	save   bool
+}
+
+func newAPIKeyPrompt(name string, scheme *v3.SecurityScheme, storeName string) *apiKeyPrompt {
+	input := textinput.New()
+	input.Prompt = ""
+	input.Placeholder = scheme.Name
+	input.EchoMode = textinput.EchoPassword
+	return &apiKeyPrompt{scheme: name, in: scheme.In, name: scheme.Name, storeName: storeName, input: input}
+}
+
+// updateAPIKeyPrompt edits the key, Enter sends the request with it
+func (m *Model) updateAPIKeyPrompt(msg tea.KeyMsg) tea.Cmd {
+	prompt := m.runner.apiKey
+	switch msg.String() {
+	case "esc":
+		m.runner.apiKey = nil
+		return m.runner.focusField(m.runner.focus)
+	case "tab", "shift+tab", "down", "up":
+		prompt.onSave = !prompt.onSave
+		if prompt.onSave {
+			prompt.input.Blur()
+			return nil
+		}
+		return prompt.input.Focus()
+	case "enter", "ctrl+s":
+		key := strings.TrimSpace(prompt.input.Value())
+		if key == "" {
+			prompt.onSave = false
+			return prompt.input.Focus()
+		}
+		m.runner.apiKey = nil
+		if prompt.save {
+			store, err := m.openCredentials()
+			if err == nil {
+				err = store.set(prompt.scheme, key)
+			}
+			if err != nil {
+				m.runner.err = fmt.Errorf("Error saving the API key for %s: %w", prompt.scheme, err)
+				return nil
+			}
+		} else {
+			if m.promptedKeys == nil {
+				m.promptedKeys = map[string]string{}
+			}
+			m.promptedKeys[prompt.scheme] = key
+		}
+		return m.dispatchRequest()
+	}
+	if prompt.onSave {
+		if msg.String() == " " || msg.String() == "space" {
+			prompt.save = !prompt.save
+		}
+		return nil
+	}
+	var cmd tea.Cmd
+	prompt.input, cmd = prompt.input.Update(msg)
+	return cmd
+}
+
+// renderAPIKeyPrompt renders the key field in place of the request form
+func (m Model) renderAPIKeyPrompt(innerWidth int) string {
+	prompt := m.runner.apiKey
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorBlue))
+	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray))
+
+	sentAs := fmt.Sprintf("the %s header", prompt.name)
+	switch prompt.in {
+	case "query":
+		sentAs = fmt.Sprintf("the %s query parameter", prompt.name)
+	case "cookie":
+		sentAs = fmt.Sprintf("the %s cookie", prompt.name)
+	}
+	keyMarker, saveMarker := "> ", "  "
+	if prompt.onSave {
+		keyMarker, saveMarker = "  ", "> "
+	}
+	checkbox := "[ ]"
+	if prompt.save {
+		checkbox = "[x]"
+	}
+	prompt.input.Width = max(10, innerWidth-18)
+	lines := []string{
+		fmt.Sprintf("%s needs an API key, sent as %s", prompt.scheme, sentAs),
+		"",
+		keyMarker + labelStyle.Width(14).Render("API key") + " " + prompt.input.View(),
+		saveMarker + checkbox + " Save to the " + prompt.storeName,
+		"",
+		grayStyle.Render(fmt.Sprintf("Unsaved keys are kept until oq quits, or save one with oq credentials set %s", prompt.scheme)),
+	}
+	return lipgloss.NewStyle().Width(innerWidth).Render(strings.Join(lines, "\n"))
+}
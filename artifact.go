@@ -0,0 +1,151 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// tarballSuffixes are the extensions of the tarballs specs are read out of
+var tarballSuffixes = []string{".tar", ".tar.gz", ".tgz"}
+
+// specFilePattern matches the top-level openapi or swagger key of a YAML or JSON spec, to
+// tell specs from the other files of an archive
+var specFilePattern = regexp.MustCompile(`(?m)^\s*\{?\s*["']?(openapi|swagger)["']?\s*:`)
+
+// isArtifactSpec reports whether the spec is read out of a build artifact instead of a spec
+// file or URL: an OCI image or artifact, or an S3 object
+func isArtifactSpec(path string) bool {
+	return strings.HasPrefix(path, "oci://") || strings.HasPrefix(path, "s3://")
+}
+
+// splitArtifactPath splits a source such as specs.tgz#api/openapi.yaml into the artifact and
+// the file picked in it, which is empty when the spec is found by its content
+func splitArtifactPath(source string) (string, string) {
+	artifact, file, _ := strings.Cut(source, "#")
+	return artifact, strings.TrimPrefix(path.Clean("/"+file), "/")
+}
+
+// isTarballSpec reports whether a spec file or URL is a tarball holding the spec
+func isTarballSpec(path string) bool {
+	artifact, _ := splitArtifactPath(path)
+	artifact, _, _ = strings.Cut(strings.ToLower(artifact), "?")
+	return slices.ContainsFunc(tarballSuffixes, func(suffix string) bool {
+		return strings.HasSuffix(artifact, suffix)
+	})
+}
+
+// specFile is the file on disk a spec is read from, the tarball for a spec in one
+func specFile(path string) string {
+	if isTarballSpec(path) {
+		path, _ = splitArtifactPath(path)
+	}
+	return path
+}
+
+// readSpecFile reads a spec from disk, out of the tarball it is in for specs.tgz#openapi.yaml
+func readSpecFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(specFile(path))
+	if err != nil {
+		return nil, fmt.Errorf("Error reading file: %w", err)
+	}
+	if isTarballSpec(path) {
+		_, file := splitArtifactPath(path)
+		return extractSpec(content, file, path)
+	}
+	return content, nil
+}
+
+// fetchArtifact reads a spec out of an OCI registry or S3 bucket
+func fetchArtifact(ctx context.Context, source string) ([]byte, error) {
+	if strings.HasPrefix(source, "s3://") {
+		return fetchS3Spec(ctx, source)
+	}
+	return fetchOCISpec(ctx, source)
+}
+
+// extractSpec returns a file of a tarball, gzipped or not. Without a file to pick, the
+// tarball must hold exactly one spec, found by its top-level openapi or swagger key
+func extractSpec(archive []byte, file, source string) ([]byte, error) {
+	specs, err := tarballSpecs(archive, file)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %w", source, err)
+	}
+	return pickSpec(specs, file, source)
+}
+
+// tarballFile is a file read out of a tarball
+type tarballFile struct {
+	name    string
+	content []byte
+}
+
+// tarballSpecs returns the file named file of a tarball, or every spec in it when file is empty
+func tarballSpecs(archive []byte, file string) ([]tarballFile, error) {
+	var r io.Reader = bytes.NewReader(archive)
+	if bytes.HasPrefix(archive, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var files []tarballFile
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(path.Clean("/"+header.Name), "/")
+		if header.Typeflag != tar.TypeReg || header.Size > maxRemoteSpecSize || (file != "" && name != file) {
+			continue
+		}
+		if file == "" && !slices.Contains([]string{".yaml", ".yml", ".json"}, strings.ToLower(path.Ext(name))) {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		if file == "" && !specFilePattern.Match(content) {
+			continue
+		}
+		files = append(files, tarballFile{name: name, content: content})
+	}
+	return files, nil
+}
+
+// pickSpec returns the only spec found in an artifact, asking for one to be picked with
+// #file when there are several. A file picked by name may be found more than once, the
+// last one wins as in the layers of an image
+func pickSpec(specs []tarballFile, file, source string) ([]byte, error) {
+	artifact, _ := splitArtifactPath(source)
+	switch {
+	case len(specs) == 1, file != "" && len(specs) > 0:
+		return specs[len(specs)-1].content, nil
+	case file != "":
+		return nil, fmt.Errorf("Error reading %s: no file %s in %s", source, file, artifact)
+	case len(specs) == 0:
+		return nil, fmt.Errorf("Error reading %s: no OpenAPI spec found, pick a file with %s#path/to/openapi.yaml", source, artifact)
+	}
+	var names []string
+	for _, spec := range specs {
+		names = append(names, spec.name)
+	}
+	return nil, fmt.Errorf("Error reading %s: several specs found (%s), pick one with %s#%s", source, strings.Join(names, ", "), artifact, names[0])
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// authHelper adds cloud credentials to the requests fetching a spec URL, picked with --auth
+// as its name, optionally followed by :arg
+type authHelper struct {
+	name      string
+	usage     string
+	authorize func(req *http.Request, arg string) error
+}
+
+var authHelpers = []authHelper{
+	{"aws", "aws[:service[:region]]", authorizeAWS},
+	{"gcp", "gcp", authorizeGCP},
+}
+
+// findAuthHelper returns the helper of an --auth value such as aws:execute-api
+func findAuthHelper(value string) (authHelper, string, error) {
+	name, arg, _ := strings.Cut(value, ":")
+	i := slices.IndexFunc(authHelpers, func(helper authHelper) bool { return helper.name == name })
+	if i < 0 {
+		var usages []string
+		for _, helper := range authHelpers {
+			usages = append(usages, helper.usage)
+		}
+		return authHelper{}, "", fmt.Errorf("unknown auth helper %q, expected %s", value, strings.Join(usages, " or "))
+	}
+	return authHelpers[i], arg, nil
+}
+
+// authorizeRequest adds the credentials of the --auth helper to a request, once its other
+// headers are set since signatures cover them
+func authorizeRequest(req *http.Request) error {
+	if remote.auth == "" {
+		return nil
+	}
+	helper, arg, err := findAuthHelper(remote.auth)
+	if err != nil {
+		return err
+	}
+	return helper.authorize(req, arg)
+}
+
+// authorizeAWS signs a request with Signature Version 4, for API Gateway, Lambda function
+// URLs or any AWS API. The service and region are read from the host unless given
+func authorizeAWS(req *http.Request, arg string) error {
+	creds, ok := loadAWSCredentials()
+	if !ok {
+		return fmt.Errorf("no AWS credentials found, set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or AWS_PROFILE")
+	}
+	service, region, _ := strings.Cut(arg, ":")
+	hostService, hostRegion := awsHostScope(req.URL.Hostname())
+	if service == "" {
+		service = hostService
+	}
+	if region == "" {
+		region = hostRegion
+	}
+	if service == "" {
+		return fmt.Errorf("can't tell the AWS service of %s, pass it as --auth aws:<service>", req.URL.Host)
+	}
+	if region == "" {
+		region = awsRegion()
+	}
+	signV4(req, creds, region, service, emptyPayloadHash, time.Now())
+	return nil
+}
+
+// awsHostScope reads the service and region of AWS endpoints such as
+// abc123.execute-api.us-east-1.amazonaws.com or abc123.lambda-url.eu-west-1.on.aws
+func awsHostScope(host string) (string, string) {
+	host = strings.ToLower(host)
+	labels := strings.Split(host, ".")
+	switch {
+	case strings.HasSuffix(host, ".on.aws") && len(labels) >= 5 && labels[len(labels)-4] == "lambda-url":
+		return "lambda", labels[len(labels)-3]
+	case strings.HasSuffix(host, ".amazonaws.com") && len(labels) >= 4:
+		service, region := labels[len(labels)-4], labels[len(labels)-3]
+		// Global endpoints such as iam.amazonaws.com have no region
+		if !strings.Contains(region, "-") {
+			return region, ""
+		}
+		return service, region
+	case strings.HasSuffix(host, ".amazonaws.com") && len(labels) == 3:
+		return labels[0], ""
+	}
+	return "", ""
+}
+
+// gcpScope is the OAuth scope tokens are asked for, the one gcloud uses
+const gcpScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// gcpTokenCache keeps the access token between fetches, such as those of --refresh-every and
+// of referenced files, until shortly before it expires
+var gcpTokenCache struct {
+	sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// authorizeGCP sends a Google Cloud access token, for Apigee, Cloud Endpoints, API Gateway or
+// Cloud Storage URLs
+func authorizeGCP(req *http.Request, _ string) error {
+	gcpTokenCache.Lock()
+	defer gcpTokenCache.Unlock()
+	if gcpTokenCache.token == "" || time.Now().After(gcpTokenCache.expires) {
+		token, expiresIn, err := gcpAccessToken(req.Context())
+		if err != nil {
+			return err
+		}
+		gcpTokenCache.token = token
+		gcpTokenCache.expires = time.Now().Add(expiresIn - time.Minute)
+	}
+	req.Header.Set("Authorization", "Bearer "+gcpTokenCache.token)
+	return nil
+}
+
+// gcpAccessToken finds a token the way the Google Cloud SDKs do: a token in the environment,
+// then the application default credentials, gcloud, and the metadata server on Google Cloud
+func gcpAccessToken(ctx context.Context) (string, time.Duration, error) {
+	for _, name := range []string{"GOOGLE_OAUTH_ACCESS_TOKEN", "CLOUDSDK_AUTH_ACCESS_TOKEN"} {
+		if token := os.Getenv(name); token != "" {
+			return token, time.Hour, nil
+		}
+	}
+
+	file := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if file == "" {
+		file = gcloudConfigPath("application_default_credentials.json")
+	}
+	if content, err := os.ReadFile(file); err == nil {
+		token, expiresIn, err := exchangeGCPCredentials(ctx, content)
+		if err != nil {
+			return "", 0, fmt.Errorf("reading GCP credentials %s: %w", file, err)
+		}
+		return token, expiresIn, nil
+	} else if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") != "" {
+		return "", 0, fmt.Errorf("reading GCP credentials: %w", err)
+	}
+
+	if _, err := exec.LookPath("gcloud"); err == nil {
+		out, err := exec.CommandContext(ctx, "gcloud", "auth", "print-access-token").Output()
+		if token := strings.TrimSpace(string(out)); err == nil && token != "" {
+			return token, 30 * time.Minute, nil
+		}
+	}
+
+	token, expiresIn, err := gcpMetadataToken(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("no GCP credentials found, run gcloud auth application-default login or set GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	return token, expiresIn, nil
+}
+
+// gcloudConfigPath is a file of the gcloud configuration directory
+func gcloudConfigPath(name string) string {
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return filepath.Join(dir, name)
+	}
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		return filepath.Join(appData, "gcloud", name)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gcloud", name)
+}
+
+// gcpCredentials is an application default credentials file, of a user logged in with gcloud
+// or of a service account
+type gcpCredentials struct {
+	Type         string `json:"type"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// exchangeGCPCredentials trades a credentials file for an access token: a refresh token for
+// users, a signed JWT assertion for service accounts
+func exchangeGCPCredentials(ctx context.Context, content []byte) (string, time.Duration, error) {
+	var creds gcpCredentials
+	if err := json.Unmarshal(content, &creds); err != nil {
+		return "", 0, err
+	}
+	tokenURI := creds.TokenURI
+	if tokenURI == "" {
+		tokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	form := url.Values{}
+	switch creds.Type {
+	case "authorized_user":
+		form.Set("grant_type", "refresh_token")
+		form.Set("client_id", creds.ClientID)
+		form.Set("client_secret", creds.ClientSecret)
+		form.Set("refresh_token", creds.RefreshToken)
+	case "service_account":
+		assertion, err := gcpAssertion(creds, tokenURI, time.Now())
+		if err != nil {
+			return "", 0, err
+		}
+		form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+		form.Set("assertion", assertion)
+	default:
+		return "", 0, fmt.Errorf("unsupported credentials type %q", creds.Type)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return requestGCPToken(req)
+}
+
+// gcpAssertion is the JWT a service account signs with its key to be granted a token
+func gcpAssertion(creds gcpCredentials, tokenURI string, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("private_key is not a PEM key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("private_key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("private_key is not an RSA key")
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": creds.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   creds.ClientEmail,
+		"scope": gcpScope,
+		"aud":   tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest.Sum(nil))
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// gcpMetadataToken asks the metadata server for the token of the instance's service account,
+// on Compute Engine, Cloud Run or GKE. GCE_METADATA_HOST overrides its address
+func gcpMetadataToken(ctx context.Context) (string, time.Duration, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	// Off Google Cloud the host doesn't resolve, or answers nothing
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return requestGCPToken(req)
+}
+
+// requestGCPToken sends a token request and reads the access token out of the response
+func requestGCPToken(req *http.Request) (string, time.Duration, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", 0, err
+	}
+	if token.AccessToken == "" {
+		return "", 0, errors.New("no access_token in the response")
+	}
+	expiresIn := time.Duration(token.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = time.Hour
+	}
+	return token.AccessToken, expiresIn, nil
+}
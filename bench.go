@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/pb33f/libopenapi"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+type benchPhase struct {
+	name     string
+	duration time.Duration
+}
+
+type schemaTiming struct {
+	name     string
+	duration time.Duration
+}
+
+// runBench implements `oq bench spec.yaml`, reporting how long each loading
+// phase takes and how much memory it allocates
+func runBench(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	top := fs.Int("top", 10, "number of slowest schemas to report")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq bench [flags] <spec>\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	path := fs.Arg(0)
+	content, err := readSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+
+	if err := benchSpec(ctx, os.Stdout, path, content, *top); err != nil {
+		return reportError(err)
+	}
+	return 0
+}
+
+func benchSpec(ctx context.Context, w io.Writer, path string, content []byte, top int) error {
+	var phases []benchPhase
+	measure := func(name string, fn func()) {
+		if ctx.Err() != nil {
+			return
+		}
+		start := time.Now()
+		fn()
+		phases = append(phases, benchPhase{name: name, duration: time.Since(start)})
+	}
+
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	var document libopenapi.Document
+	var err error
+	measure("Parse", func() {
+		document, err = libopenapi.NewDocumentWithConfiguration(content, newDocumentConfiguration(path))
+	})
+	if err != nil {
+		return fmt.Errorf("Error creating document: %w", err)
+	}
+
+	var buildErr error
+	var doc *v3.Document
+	measure("Build model", func() {
+		v3Model, err := document.BuildV3Model()
+		buildErr = err
+		if v3Model != nil {
+			doc = &v3Model.Model
+		}
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if doc == nil {
+		return fmt.Errorf("Error building model: %w", buildErr)
+	}
+
+	// Schemas are built lazily on first access, so resolving each component
+	// schema on its own shows which ones are expensive
+	var schemas []schemaTiming
+	measure("Resolve schemas", func() {
+		if doc.Components == nil || doc.Components.Schemas == nil {
+			return
+		}
+		for pair := doc.Components.Schemas.First(); pair != nil; pair = pair.Next() {
+			start := time.Now()
+			pair.Value().Schema()
+			schemas = append(schemas, schemaTiming{name: pair.Key(), duration: time.Since(start)})
+		}
+	})
+
+	var endpoints []endpoint
+	var components []component
+	var webhooks []webhook
+	measure("Extract endpoints", func() { endpoints = extractEndpoints(doc) })
+	measure("Extract components", func() { components = extractComponents(doc) })
+	measure("Extract webhooks", func() { webhooks = extractWebhooks(doc) })
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	fmt.Fprintf(w, "Spec: %s (%s)\n", path, formatBytes(uint64(len(content))))
+	if buildErr != nil {
+		fmt.Fprintf(w, "Warning: Spec has validation errors, timings cover partial data\n")
+	}
+	fmt.Fprintf(w, "Items: %d endpoints, %d components, %d webhooks\n\n", len(endpoints), len(components), len(webhooks))
+
+	var total time.Duration
+	for _, phase := range phases {
+		fmt.Fprintf(w, "%-20s %12s\n", phase.name, phase.duration.Round(time.Microsecond))
+		total += phase.duration
+	}
+	fmt.Fprintf(w, "%-20s %12s\n\n", "Total", total.Round(time.Microsecond))
+
+	fmt.Fprintf(w, "Memory:\n")
+	fmt.Fprintf(w, "  Allocated:   %s\n", formatBytes(after.TotalAlloc-before.TotalAlloc))
+	fmt.Fprintf(w, "  Allocations: %d\n", after.Mallocs-before.Mallocs)
+	fmt.Fprintf(w, "  Heap in use: %s\n", formatBytes(after.HeapInuse))
+	fmt.Fprintf(w, "  GC cycles:   %d\n", after.NumGC-before.NumGC)
+
+	if len(schemas) > 0 && top > 0 {
+		sort.SliceStable(schemas, func(i, j int) bool {
+			return schemas[i].duration > schemas[j].duration
+		})
+		fmt.Fprintf(w, "\nSlowest schemas to resolve:\n")
+		for i, s := range schemas[:min(top, len(schemas))] {
+			fmt.Fprintf(w, "  %2d. %-40s %12s\n", i+1, s.name, s.duration.Round(time.Microsecond))
+		}
+	}
+
+	return nil
+}
+
+// formatBytes renders a byte count using binary units
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+)
+
+// maxFormDepth is how deep nested objects are broken into fields, deeper ones are typed as JSON
+const maxFormDepth = 3
+
+// formKind is how a body property is edited in the form
+type formKind int
+
+const (
+	// formText is a string, sent quoted
+	formText formKind = iota
+	// formNumber is a number or integer, sent as typed
+	formNumber
+	// formChoice is an enum value or boolean, picked with ←/→
+	formChoice
+	// formJSON is an array or free-form value, typed as JSON
+	formJSON
+)
+
+// bodyField is a property of the request body in the form, by its path in the body
+type bodyField struct {
+	path     []string
+	kind     formKind
+	required bool
+	// options are the JSON values of a choice field, "" leaves the property out
+	options []string
+	choice  int
+	input   textinput.Model
+}
+
+func (f *bodyField) label() string {
+	return strings.Join(f.path, ".")
+}
+
+// bodyForm edits a JSON request body property by property. Read-only properties are left
+// out, as they aren't sent
+type bodyForm struct {
+	fields []bodyField
+}
+
+// newBodyForm lays out a form for an object schema, nil for other schemas, which are only
+// edited as JSON
+func newBodyForm(schema *base.Schema) *bodyForm {
+	if schema == nil || exampleType(schema) != "object" {
+		return nil
+	}
+	form := &bodyForm{}
+	form.addFields(schema, nil, 0)
+	if len(form.fields) == 0 {
+		return nil
+	}
+	return form
+}
+
+func (form *bodyForm) addFields(schema *base.Schema, path []string, depth int) {
+	schemas := []*base.Schema{schema}
+	for _, proxy := range schema.AllOf {
+		if proxy != nil && proxy.Schema() != nil {
+			schemas = append(schemas, proxy.Schema())
+		}
+	}
+	var required []string
+	for _, s := range schemas {
+		required = append(required, s.Required...)
+	}
+
+	for _, s := range schemas {
+		if s.Properties == nil {
+			continue
+		}
+		for pair := s.Properties.First(); pair != nil; pair = pair.Next() {
+			prop := pair.Value().Schema()
+			if prop == nil || (prop.ReadOnly != nil && *prop.ReadOnly) {
+				continue
+			}
+			fieldPath := append(slices.Clone(path), pair.Key())
+			if slices.ContainsFunc(form.fields, func(f bodyField) bool { return slices.Equal(f.path, fieldPath) }) {
+				continue
+			}
+			if exampleType(prop) == "object" && prop.Properties != nil && prop.Properties.Len() > 0 && depth < maxFormDepth {
+				form.addFields(prop, fieldPath, depth+1)
+				continue
+			}
+			form.fields = append(form.fields, newBodyField(prop, fieldPath, slices.Contains(required, pair.Key())))
+		}
+	}
+}
+
+func newBodyField(schema *base.Schema, path []string, required bool) bodyField {
+	field := bodyField{path: path, required: required}
+	input := textinput.New()
+	input.Prompt = ""
+	input.Placeholder = strings.Join(schema.Type, "|")
+	if schema.Format != "" {
+		input.Placeholder = schema.Format
+	}
+
+	switch t := exampleType(schema); {
+	case len(schema.Enum) > 0:
+		field.kind, field.options = formChoice, enumJSONValues(schema.Enum)
+	case t == "boolean":
+		field.kind, field.options = formChoice, []string{"true", "false"}
+	case t == "string":
+		field.kind = formText
+	case t == "number", t == "integer":
+		field.kind = formNumber
+	default:
+		field.kind = formJSON
+		input.Placeholder = "JSON"
+	}
+	if field.kind == formChoice && !required {
+		field.options = append([]string{""}, field.options...)
+	}
+	field.input = input
+	return field
+}
+
+// jsonValue is the field's value as JSON, nil when the property is left out
+func (f *bodyField) jsonValue() (json.RawMessage, string) {
+	value := f.input.Value()
+	switch f.kind {
+	case formChoice:
+		if f.options[f.choice] == "" {
+			return nil, ""
+		}
+		return json.RawMessage(f.options[f.choice]), ""
+	case formText:
+		if value == "" {
+			return nil, ""
+		}
+		quoted, _ := json.Marshal(value)
+		return quoted, ""
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, ""
+	}
+	if f.kind == formNumber {
+		if _, err := strconv.ParseFloat(value, 64); err != nil || !json.Valid([]byte(value)) {
+			return nil, f.label() + " must be a number"
+		}
+		return json.RawMessage(value), ""
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(value)); err != nil {
+		return nil, f.label() + " must be valid JSON"
+	}
+	return compact.Bytes(), ""
+}
+
+// setValue fills the field from its value in a body, clearing it when the body has none
+func (f *bodyField) setValue(raw json.RawMessage) {
+	f.input.SetValue("")
+	f.choice = 0
+	if raw == nil {
+		return
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return
+	}
+	switch f.kind {
+	case formChoice:
+		if i := slices.Index(f.options, compact.String()); i >= 0 {
+			f.choice = i
+		}
+	case formText:
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			f.input.SetValue(s)
+		} else {
+			f.input.SetValue(compact.String())
+		}
+	default:
+		f.input.SetValue(compact.String())
+	}
+}
+
+// cycle picks the next or previous option of a choice field
+func (f *bodyField) cycle(delta int) {
+	if f.kind == formChoice && len(f.options) > 0 {
+		f.choice = (f.choice + delta + len(f.options)) % len(f.options)
+	}
+}
+
+// body assembles the JSON body from the fields, in the order of the schema, returning the
+// values that couldn't be converted
+func (form *bodyForm) body() (string, []string) {
+	root := &formObject{}
+	var problems []string
+	for i := range form.fields {
+		field := &form.fields[i]
+		value, problem := field.jsonValue()
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+		if value == nil {
+			continue
+		}
+		obj := root
+		for _, key := range field.path[:len(field.path)-1] {
+			obj = obj.child(key)
+		}
+		obj.set(field.path[len(field.path)-1], value)
+	}
+	var buf bytes.Buffer
+	root.write(&buf)
+	var indented bytes.Buffer
+	if err := jsonIndent(&indented, buf.Bytes()); err != nil {
+		return buf.String(), problems
+	}
+	return indented.String(), problems
+}
+
+// setBody fills the form from a JSON body, returning an error when it isn't a JSON object
+func (form *bodyForm) setBody(body string) error {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &root); err != nil {
+		return err
+	}
+	for i := range form.fields {
+		form.fields[i].setValue(lookupRaw(root, form.fields[i].path))
+	}
+	return nil
+}
+
+func lookupRaw(obj map[string]json.RawMessage, path []string) json.RawMessage {
+	raw, ok := obj[path[0]]
+	if !ok || len(path) == 1 {
+		return raw
+	}
+	var nested map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &nested); err != nil {
+		return nil
+	}
+	return lookupRaw(nested, path[1:])
+}
+
+// formObject is a JSON object keeping the order its properties were set in
+type formObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func (o *formObject) set(key string, value any) {
+	if o.values == nil {
+		o.values = map[string]any{}
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *formObject) child(key string) *formObject {
+	if child, ok := o.values[key].(*formObject); ok {
+		return child
+	}
+	child := &formObject{}
+	o.set(key, child)
+	return child
+}
+
+func (o *formObject) write(buf *bytes.Buffer) {
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		quoted, _ := json.Marshal(key)
+		buf.Write(quoted)
+		buf.WriteByte(':')
+		switch value := o.values[key].(type) {
+		case *formObject:
+			value.write(buf)
+		case json.RawMessage:
+			buf.Write(value)
+		}
+	}
+	buf.WriteByte('}')
+}
+
+// renderBodyForm renders the body fields, scrolled to keep the focused one in view
+func (m Model) renderBodyForm(width, height int) []string {
+	runner := m.runner
+	fields := runner.form.fields
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorBlue))
+	choiceStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGreen))
+	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray))
+
+	labelWidth := 0
+	for i := range fields {
+		labelWidth = max(labelWidth, lipgloss.Width(fields[i].label())+1)
+	}
+	labelWidth = min(labelWidth, width/2)
+
+	focused := runner.focus - len(runner.fields)
+	start := max(0, min(focused-height+1, len(fields)-height))
+	var lines []string
+	for i := start; i < len(fields) && i < start+height; i++ {
+		field := fields[i]
+		label := field.label()
+		if field.required {
+			label += "*"
+		}
+		marker := "  "
+		if i == focused {
+			marker = "> "
+		}
+		value := ""
+		switch {
+		case field.kind == formChoice && field.options[field.choice] == "":
+			value = grayStyle.Render("◀ not set ▶")
+		case field.kind == formChoice:
+			value = choiceStyle.Render("◀ " + field.options[field.choice] + " ▶")
+		default:
+			field.input.Width = max(10, width-labelWidth-4)
+			value = field.input.View()
+		}
+		lines = append(lines, marker+labelStyle.Width(labelWidth).MaxWidth(labelWidth).Render(label)+" "+value)
+	}
+	if hidden := len(fields) - start - height; hidden > 0 {
+		lines = append(lines, grayStyle.Render(fmt.Sprintf("  … %d more %s", hidden, plural(hidden, "field", "fields"))))
+	}
+	return lines
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// specBudgets are limits governance teams set to notice specs growing unmanageable.
+// Zero means no limit
+type specBudgets struct {
+	maxOperations  int
+	maxSchemaDepth int
+	maxSpecSize    uint64
+	maxPayloadSize uint64
+}
+
+func budgetsFromConfig(cfg *Config) specBudgets {
+	// The setting was validated when the config was loaded
+	size, _ := parseByteSize(cfg.MaxSpecSize)
+	payload, _ := parseByteSize(cfg.MaxPayloadSize)
+	return specBudgets{maxOperations: cfg.MaxOperations, maxSchemaDepth: cfg.MaxSchemaDepth, maxSpecSize: size, maxPayloadSize: payload}
+}
+
+func (b specBudgets) isSet() bool {
+	return b.maxOperations > 0 || b.maxSchemaDepth > 0 || b.maxSpecSize > 0 || b.maxPayloadSize > 0
+}
+
+// checkBudgets returns a warning for every budget the spec exceeds. oversized are the
+// operations over the payload budget, from oversizedPayloads
+func checkBudgets(doc *v3.Document, size int, b specBudgets, oversized map[string]payloadSize) []string {
+	if !b.isSet() {
+		return nil
+	}
+
+	var warnings []string
+	if b.maxOperations > 0 {
+		if n := len(extractEndpoints(doc)); n > b.maxOperations {
+			warnings = append(warnings, fmt.Sprintf("%d operations (budget %d)", n, b.maxOperations))
+		}
+	}
+	if b.maxSchemaDepth > 0 {
+		if depth, where := deepestSchema(doc); depth > b.maxSchemaDepth {
+			warnings = append(warnings, fmt.Sprintf("schema depth %d in %s (budget %d)", depth, where, b.maxSchemaDepth))
+		}
+	}
+	if b.maxSpecSize > 0 && uint64(size) > b.maxSpecSize {
+		warnings = append(warnings, fmt.Sprintf("spec size %s (budget %s)", formatBytes(uint64(size)), formatBytes(b.maxSpecSize)))
+	}
+	if len(oversized) > 0 {
+		warnings = append(warnings, payloadWarning(doc, oversized, b.maxPayloadSize))
+	}
+	return warnings
+}
+
+// deepestSchema returns the deepest nesting of schemas in the spec, following $refs, and
+// the component or operation where it starts. A recursive schema counts up to where it
+// refers back to itself
+func deepestSchema(doc *v3.Document) (int, string) {
+	memo := map[string]int{}
+	visiting := map[string]bool{}
+	deepest, where := 0, ""
+
+	if doc.Components != nil && doc.Components.Schemas != nil {
+		for pair := doc.Components.Schemas.First(); pair != nil; pair = pair.Next() {
+			if depth := schemaDepth(pair.Value(), memo, visiting, 0); depth > deepest {
+				deepest, where = depth, pair.Key()
+			}
+		}
+	}
+	for _, ep := range extractEndpoints(doc) {
+		for _, proxy := range operationSchemas(ep.op) {
+			if depth := schemaDepth(proxy, memo, visiting, 0); depth > deepest {
+				deepest, where = depth, ep.method+" "+ep.path
+			}
+		}
+	}
+	return deepest, where
+}
+
+func schemaDepth(proxy *base.SchemaProxy, memo map[string]int, visiting map[string]bool, level int) int {
+	if proxy == nil || level > maxSchemaDepth {
+		return 0
+	}
+
+	ref := proxy.GetReference()
+	if ref != "" {
+		if depth, ok := memo[ref]; ok {
+			return depth
+		}
+		if visiting[ref] {
+			return 0
+		}
+		visiting[ref] = true
+		defer delete(visiting, ref)
+	}
+
+	s := proxy.Schema()
+	if s == nil {
+		return 0
+	}
+	depth := 0
+	for _, child := range schemaChildren(s) {
+		depth = max(depth, schemaDepth(child, memo, visiting, level+1))
+	}
+	depth++
+
+	if ref != "" {
+		memo[ref] = depth
+	}
+	return depth
+}
+
+// parseByteSize parses sizes such as 512KB, 2MB or a plain byte count. Units are binary,
+// like the sizes oq prints
+func parseByteSize(value string) (uint64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+
+	multiplier := uint64(1)
+	for i, unit := range []string{"K", "M", "G"} {
+		for _, suffix := range []string{unit + "IB", unit + "B", unit} {
+			if number, ok := strings.CutSuffix(value, suffix); ok {
+				value, multiplier = number, 1<<(10*(i+1))
+				break
+			}
+		}
+		if multiplier > 1 {
+			break
+		}
+	}
+	value = strings.TrimSuffix(strings.TrimSpace(value), "B")
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a size such as 512KB or 2MB")
+	}
+	return uint64(n * float64(multiplier)), nil
+}
+
+// updateBudgetWarnings re-checks the budgets against the loaded spec
+func (m *Model) updateBudgetWarnings() {
+	m.oversizedPayloads = oversizedPayloads(m.doc, m.budgets.maxPayloadSize)
+	m.budgetWarnings = checkBudgets(m.doc, m.specSize, m.budgets, m.oversizedPayloads)
+}
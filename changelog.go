@@ -0,0 +1,220 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// Extensions recording when an operation was introduced, deprecated and removed. The first
+// one present wins
+var (
+	sinceExtensions        = []string{"x-since", "x-introduced-in", "x-added-in"}
+	deprecatedAtExtensions = []string{"x-deprecated-at", "x-deprecated-since", "x-deprecated-in"}
+	sunsetExtensions       = []string{"x-sunset", "x-sunset-at", "x-removed-in"}
+)
+
+// lifecycleHeaderNames are the response headers announcing deprecation (RFC 9745) and
+// removal (RFC 8594) at runtime
+var lifecycleHeaderNames = []string{"Deprecation", "Sunset"}
+
+// changelog is the version history of an operation taken from its extensions
+type changelog struct {
+	since        string
+	deprecatedAt string
+	sunset       string
+}
+
+func (c changelog) empty() bool {
+	return c.since == "" && c.deprecatedAt == "" && c.sunset == ""
+}
+
+// sinceBadge renders e.g. "since v2.3"
+func (c changelog) sinceBadge() string {
+	if c.since == "" {
+		return ""
+	}
+	return "since " + displayVersion(c.since)
+}
+
+// deprecationBadge renders e.g. "deprecated since v3.0, sunset 2025-01-01"
+func (c changelog) deprecationBadge() string {
+	var parts []string
+	if c.deprecatedAt != "" {
+		parts = append(parts, "deprecated since "+displayVersion(c.deprecatedAt))
+	}
+	if c.sunset != "" {
+		parts = append(parts, "sunset "+displayVersion(c.sunset))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (c changelog) String() string {
+	var parts []string
+	for _, badge := range []string{c.sinceBadge(), c.deprecationBadge()} {
+		if badge != "" {
+			parts = append(parts, badge)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func findChangelog(op *v3.Operation) changelog {
+	if op == nil || op.Extensions == nil {
+		return changelog{}
+	}
+
+	first := func(names []string) string {
+		for _, name := range names {
+			if node := op.Extensions.GetOrZero(name); node != nil && node.Value != "" {
+				return strings.TrimSpace(node.Value)
+			}
+		}
+		return ""
+	}
+	return changelog{
+		since:        first(sinceExtensions),
+		deprecatedAt: first(deprecatedAtExtensions),
+		sunset:       first(sunsetExtensions),
+	}
+}
+
+// lifecycleHeader is a Deprecation or Sunset header documented on an operation's responses
+type lifecycleHeader struct {
+	name    string
+	codes   []string
+	example string
+}
+
+func (h lifecycleHeader) String() string {
+	s := h.name + " (" + strings.Join(h.codes, ", ")
+	if h.example != "" {
+		s += ", e.g. " + h.example
+	}
+	return s + ")"
+}
+
+// findLifecycleHeaders returns the Deprecation and Sunset headers of op with the responses
+// documenting them
+func findLifecycleHeaders(op *v3.Operation) []lifecycleHeader {
+	if op == nil || op.Responses == nil || op.Responses.Codes == nil {
+		return nil
+	}
+
+	var codes []string
+	for pair := op.Responses.Codes.First(); pair != nil; pair = pair.Next() {
+		codes = append(codes, pair.Key())
+	}
+	sortResponseCodes(codes)
+
+	var headers []lifecycleHeader
+	for _, name := range lifecycleHeaderNames {
+		header := lifecycleHeader{name: name}
+		for _, code := range codes {
+			resp := op.Responses.Codes.GetOrZero(code)
+			if resp == nil || resp.Headers == nil {
+				continue
+			}
+			for pair := resp.Headers.First(); pair != nil; pair = pair.Next() {
+				if !strings.EqualFold(pair.Key(), name) {
+					continue
+				}
+				header.codes = append(header.codes, code)
+				if h := pair.Value(); header.example == "" && h != nil && h.Example != nil && h.Example.Kind == yaml.ScalarNode {
+					header.example = h.Example.Value
+				}
+			}
+		}
+		if len(header.codes) > 0 {
+			headers = append(headers, header)
+		}
+	}
+	return headers
+}
+
+// deprecationWarning explains why a generated request may stop working, or returns an
+// empty string for operations that aren't deprecated
+func deprecationWarning(op *v3.Operation) string {
+	if op == nil {
+		return ""
+	}
+	changes := findChangelog(op)
+	if (op.Deprecated == nil || !*op.Deprecated) && changes.deprecatedAt == "" && changes.sunset == "" {
+		return ""
+	}
+
+	warning := "This endpoint is deprecated"
+	if changes.deprecatedAt != "" {
+		warning += " since " + displayVersion(changes.deprecatedAt)
+	}
+	if changes.sunset != "" {
+		warning += " and will be removed " + sunsetPhrase(changes.sunset)
+	}
+	warning += "."
+	var names []string
+	for _, header := range findLifecycleHeaders(op) {
+		names = append(names, header.name)
+	}
+	if len(names) > 0 {
+		warning += " Watch the " + strings.Join(names, " and ") + " response headers."
+	}
+	return warning
+}
+
+// sunsetPhrase reads "on 2025-01-01" for dates and "in v4.0" for versions
+func sunsetPhrase(sunset string) string {
+	if version := displayVersion(sunset); version != sunset {
+		return "in " + version
+	}
+	return "on " + sunset
+}
+
+// displayVersion adds a v to bare version numbers, dates such as 2025-01-01 and other values
+// are kept
+func displayVersion(version string) string {
+	isDate := len(version) >= 10 && version[4] == '-' && version[7] == '-'
+	if version != "" && version[0] >= '0' && version[0] <= '9' && !isDate {
+		return "v" + version
+	}
+	return version
+}
+
+// versionMatches reports whether version is want or one of its patch releases, so 2
+// matches 2.1 and 2.1.3 but not 20
+func versionMatches(version, want string) bool {
+	version = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v")
+	want = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(want)), "v")
+	if version == "" || want == "" {
+		return false
+	}
+	return version == want || strings.HasPrefix(version, want+".")
+}
+
+// renderChangelogBadges renders the badges shown after the name of an endpoint, webhook or
+// component row: deprecated ones get one even without a version. style carries the row background
+func (m Model) renderChangelogBadges(c changelog, deprecated bool, style lipgloss.Style) string {
+	var s strings.Builder
+	if badge := c.sinceBadge(); badge != "" {
+		s.WriteString(style.Render(" "))
+		s.WriteString(style.Foreground(lipgloss.Color(colorGreen)).Render("[" + badge + "]"))
+	}
+	badge := c.deprecationBadge()
+	if deprecated && c.deprecatedAt == "" {
+		badge = strings.TrimSuffix("deprecated, "+badge, ", ")
+	}
+	if badge != "" {
+		s.WriteString(style.Render(" "))
+		s.WriteString(style.Foreground(lipgloss.Color(colorYellow)).Render("[" + badge + "]"))
+	}
+	return s.String()
+}
+
+// deprecatedStyle strikes through and dims the name of a deprecated row
+func deprecatedStyle(style lipgloss.Style, deprecated bool) lipgloss.Style {
+	if !deprecated {
+		return style
+	}
+	return style.Strikethrough(true).Faint(true)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// embeddedSpecPattern matches the spec oapi-codegen embeds with its embedded-spec option: a
+// gzipped JSON spec in base64, split into lines of a string slice
+var embeddedSpecPattern = regexp.MustCompile(`(?s)var swaggerSpec = \[\]string\{(.*?)\n\}`)
+
+// generatedByPattern matches the header of the files oapi-codegen generates
+var generatedByPattern = regexp.MustCompile(`Code generated by (\S+) version (\S+)`)
+
+// clientSpec is the spec a generated client was built from, as baked into its sources
+type clientSpec struct {
+	// file is relative to the client directory
+	file      string
+	generator string
+	content   []byte
+}
+
+// findClientSpec finds the spec a generated client was built from: the one oapi-codegen
+// embeds in its Go sources, or the copy openapi-generator writes next to them, such as
+// api/openapi.yaml. Dependencies and hidden directories are skipped
+func findClientSpec(dir string) (clientSpec, error) {
+	var embedded, copies []clientSpec
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		if !slices.Contains([]string{".go", ".yaml", ".yml", ".json"}, ext) {
+			return nil
+		}
+		if info, err := entry.Info(); err != nil || info.Size() > maxRemoteSpecSize {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		file, _ := filepath.Rel(dir, path)
+		switch {
+		case ext == ".go":
+			if spec, ok, err := embeddedSpec(content); err != nil {
+				return fmt.Errorf("Error decoding the spec embedded in %s: %w", file, err)
+			} else if ok {
+				embedded = append(embedded, clientSpec{file: file, generator: generatedBy(content), content: spec})
+			}
+		case specFilePattern.Match(content):
+			copies = append(copies, clientSpec{file: file, content: content})
+		}
+		return nil
+	})
+	if err != nil {
+		return clientSpec{}, fmt.Errorf("Error reading %s: %w", dir, err)
+	}
+
+	found := embedded
+	if len(found) == 0 {
+		found = copies
+		// Copies deeper in the tree are more likely fixtures or docs than the client's spec
+		depth := func(spec clientSpec) int { return strings.Count(spec.file, string(filepath.Separator)) }
+		slices.SortStableFunc(found, func(a, b clientSpec) int { return depth(a) - depth(b) })
+		if len(found) > 0 {
+			found = slices.DeleteFunc(found, func(spec clientSpec) bool { return depth(spec) > depth(found[0]) })
+		}
+		if len(found) == 1 {
+			found[0].generator = openAPIGeneratorVersion(dir)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return clientSpec{}, fmt.Errorf("Error reading %s: no embedded spec found, pass the spec the client was generated from instead", dir)
+	case 1:
+		return found[0], nil
+	}
+	var names []string
+	for _, spec := range found {
+		names = append(names, spec.file)
+	}
+	return clientSpec{}, fmt.Errorf("Error reading %s: several specs found (%s), pass one of them instead of the directory", dir, strings.Join(names, ", "))
+}
+
+// embeddedSpec decodes the spec oapi-codegen embeds in a Go file, if it has one
+func embeddedSpec(source []byte) ([]byte, bool, error) {
+	match := embeddedSpecPattern.FindSubmatch(source)
+	if match == nil {
+		return nil, false, nil
+	}
+	var encoded strings.Builder
+	for _, line := range strings.Split(string(match[1]), "\n") {
+		line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+		encoded.WriteString(strings.Trim(line, `"`))
+	}
+	compressed, err := base64.StdEncoding.DecodeString(encoded.String())
+	if err != nil {
+		return nil, false, err
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, false, err
+	}
+	defer gz.Close()
+	spec, err := io.ReadAll(io.LimitReader(gz, maxRemoteSpecSize))
+	if err != nil {
+		return nil, false, err
+	}
+	return spec, true, nil
+}
+
+// generatedBy names the generator of a Go file from its header, such as oapi-codegen v2.4.1
+func generatedBy(source []byte) string {
+	match := generatedByPattern.FindSubmatch(source)
+	if match == nil {
+		return ""
+	}
+	return path.Base(strings.TrimSuffix(string(match[1]), "/v2")) + " " + string(match[2])
+}
+
+// openAPIGeneratorVersion names the openapi-generator version that generated dir, from the
+// VERSION file it writes, or "" for other generators
+func openAPIGeneratorVersion(dir string) string {
+	version, err := os.ReadFile(filepath.Join(dir, ".openapi-generator", "VERSION"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace("openapi-generator " + strings.TrimSpace(string(version)))
+}
+
+// loadClientSpec builds the spec embedded in a generated client, behind the loading screen,
+// and prints where it was found. Like loadSpec, specs with validation errors are still returned
+func loadClientSpec(ctx context.Context, dir string) (*v3.Document, error) {
+	type loaded struct {
+		spec  clientSpec
+		model *v3.Document
+		err   error
+	}
+	client, err := withLoading(ctx, "Reading the spec of "+dir+"...", func(ctx context.Context, step func(string)) (loaded, error) {
+		spec, err := findClientSpec(dir)
+		if err != nil {
+			return loaded{}, err
+		}
+		step("Resolving references...")
+		v3Model, err := buildModel(ctx, spec.content, filepath.Join(dir, spec.file))
+		if v3Model == nil {
+			return loaded{spec: spec, err: err}, nil
+		}
+		return loaded{spec: spec, model: &v3Model.Model, err: err}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if client.err != nil {
+		if client.model == nil {
+			return nil, client.err
+		}
+		debugLog.Warn("client spec has validation errors", "error", client.err)
+		fmt.Fprintf(os.Stderr, "Warning: Spec has validation errors: %v\n", client.err)
+	}
+
+	source := filepath.Join(dir, client.spec.file)
+	if client.spec.generator != "" {
+		source += ", generated by " + client.spec.generator
+	}
+	version := ""
+	if client.model.Info != nil && client.model.Info.Version != "" {
+		version = " " + client.model.Info.Version
+	}
+	fmt.Fprintf(os.Stderr, "Comparing with the client's spec%s (%s)\n", version, source)
+	return client.model, nil
+}
+
+// forClient words the changes for a generated client compared with the current spec:
+// added operations are ones the client doesn't know about, removed ones may still be called
+func (d *specDiff) forClient() {
+	for i, c := range d.changes {
+		if c.target != "operation" {
+			continue
+		}
+		switch c.kind {
+		case changeAdded:
+			d.changes[i].message = "operation missing from the client"
+		case changeRemoved:
+			d.changes[i].message = "operation removed, the client still calls it"
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"go.yaml.in/yaml/v4"
+)
+
+// copyToClipboard puts text on the clipboard. The OSC 52 sequence asks the terminal to do
+// it, which also works over SSH, and the native clipboard is set as well where there is one
+func copyToClipboard(text string) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	_, oscErr := seq.WriteTo(os.Stderr)
+	if err := clipboard.WriteAll(text); err != nil && oscErr != nil {
+		return err
+	}
+	return nil
+}
+
+// yank copies the selected item: the curl command when it is shown, the path of an endpoint
+// or webhook, a tag name, or a component as JSON
+func (m *Model) yank() {
+	var text, what string
+	switch {
+	case m.showCurl:
+		text, what = m.curlCommand, "the "+m.snippetTargetName()+" snippet"
+	case m.mode == viewEndpoints:
+		if ep, ok := m.selectedEndpoint(); ok {
+			text, what = ep.path, ep.path
+		}
+	case m.mode == viewWebhooks:
+		if hooks := m.getActiveWebhooks(); m.cursor >= 0 && m.cursor < len(hooks) {
+			text, what = hooks[m.cursor].name, "webhook "+hooks[m.cursor].name
+		}
+	case m.mode == viewTags:
+		if tags := m.getActiveTags(); m.cursor >= 0 && m.cursor < len(tags) {
+			text, what = tags[m.cursor].name, "tag "+tags[m.cursor].name
+		}
+	case m.mode == viewComponents:
+		if comps := m.getActiveComponents(); m.cursor >= 0 && m.cursor < len(comps) {
+			out, err := componentJSON(m.doc, comps[m.cursor])
+			if err != nil {
+				m.setStatus(fmt.Sprintf("Error rendering %s: %v", comps[m.cursor].name, err), true)
+				return
+			}
+			text, what = string(out), comps[m.cursor].name+" as JSON"
+		}
+	}
+	if text == "" {
+		return
+	}
+	if err := m.copyText(text); err != nil {
+		m.setStatus(fmt.Sprintf("Error copying to the clipboard: %v", err), true)
+		return
+	}
+	m.setStatus("Copied "+what, false)
+}
+
+// componentJSON renders a component as indented JSON, keeping the $refs it contains
+func componentJSON(doc *v3.Document, comp component) ([]byte, error) {
+	var render func() ([]byte, error)
+	if c := doc.Components; c != nil {
+		switch comp.compType {
+		case "Schema":
+			render = componentRenderer(c.Schemas, comp.name)
+		case "RequestBody":
+			render = componentRenderer(c.RequestBodies, comp.name)
+		case "Response":
+			render = componentRenderer(c.Responses, comp.name)
+		case "Parameter":
+			render = componentRenderer(c.Parameters, comp.name)
+		case "Header":
+			render = componentRenderer(c.Headers, comp.name)
+		case "SecurityScheme":
+			render = componentRenderer(c.SecuritySchemes, comp.name)
+		}
+	}
+	if render == nil {
+		return nil, fmt.Errorf("component not found")
+	}
+	out, err := render()
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(out, &node); err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		return nil, fmt.Errorf("component is empty")
+	}
+	return marshalFragmentJSON(node.Content[0])
+}
+
+// componentRenderer returns the Render method of a named component, nil when it is missing
+func componentRenderer[T any, P interface {
+	*T
+	Render() ([]byte, error)
+}](components *orderedmap.Map[string, P], name string) func() ([]byte, error) {
+	if components == nil {
+		return nil
+	}
+	if c := components.GetOrZero(name); c != nil {
+		return c.Render
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// codeOriginGenerators lists extension prefixes written by code-first generators.
+// An extension is either the bare prefix holding a string or mapping, e.g.
+// `x-fastapi: {router: users, handler: read_user}`, or carries the field in its
+// name, e.g. `x-nestjs-controller: UsersController`
+var codeOriginGenerators = []struct {
+	prefix    string
+	generator string
+}{
+	{"x-fastapi", "FastAPI"},
+	{"x-springdoc", "springdoc"},
+	{"x-nestjs", "NestJS"},
+	{"x-handler", ""},
+	{"x-controller", ""},
+}
+
+// Field names are checked in order, the first one present wins
+var (
+	codeOriginGroupFields   = []string{"controller", "class", "router", "module", "group"}
+	codeOriginHandlerFields = []string{"handler", "function", "method", "endpoint", "operation", "name"}
+)
+
+type codeOrigin struct {
+	generator  string
+	controller string
+	handler    string
+}
+
+func (o codeOrigin) String() string {
+	name := o.handler
+	if o.controller != "" && o.handler != "" {
+		name = o.controller + "." + o.handler
+	} else if o.controller != "" {
+		name = o.controller
+	}
+	if o.generator != "" {
+		name += " (" + o.generator + ")"
+	}
+	return name
+}
+
+// findCodeOrigin returns the handler that produced the operation, as recorded by the
+// generator that emitted the spec
+func findCodeOrigin(op *v3.Operation) (codeOrigin, bool) {
+	if op == nil || op.Extensions == nil {
+		return codeOrigin{}, false
+	}
+
+	for _, gen := range codeOriginGenerators {
+		fields := map[string]string{}
+		for pair := op.Extensions.First(); pair != nil; pair = pair.Next() {
+			key := pair.Key()
+			if !strings.HasPrefix(key, gen.prefix) {
+				continue
+			}
+
+			field := strings.TrimPrefix(strings.TrimPrefix(key, gen.prefix), "-")
+			collectCodeOriginFields(fields, field, gen.prefix, pair.Value())
+		}
+
+		origin := codeOrigin{
+			generator:  gen.generator,
+			controller: firstField(fields, codeOriginGroupFields),
+			handler:    firstField(fields, codeOriginHandlerFields),
+		}
+		if origin.controller != "" || origin.handler != "" {
+			return origin, true
+		}
+	}
+
+	return codeOrigin{}, false
+}
+
+func collectCodeOriginFields(fields map[string]string, field, prefix string, node *yaml.Node) {
+	if node == nil {
+		return
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if field == "" {
+			// A bare x-handler or x-controller names the thing itself
+			field = strings.TrimPrefix(prefix, "x-")
+			if field != "handler" && field != "controller" {
+				field = "handler"
+			}
+		}
+		fields[field] = node.Value
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if value := node.Content[i+1]; value.Kind == yaml.ScalarNode {
+				fields[node.Content[i].Value] = value.Value
+			}
+		}
+	}
+}
+
+func firstField(fields map[string]string, names []string) string {
+	for _, name := range names {
+		if v := fields[name]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// runCommand executes a line entered at the ':' prompt
+func (m *Model) runCommand(line string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+	debugLog.Debug("command", "name", name, "arg", arg)
+
+	switch name {
+	case "":
+		return
+
+	case "sort":
+		if arg == "" {
+			m.setStatus("Sorted by "+formatSortSpec(m.activeSortKeys()), false)
+			return
+		}
+		keys, err := parseSortSpec(arg)
+		if err != nil {
+			m.setStatus(err.Error(), true)
+			return
+		}
+		m.sortKeys = keys
+		m.applySort()
+		m.setStatus("Sorted by "+formatSortSpec(keys), false)
+
+	case "filter":
+		if err := m.filterCommand(arg); err != nil {
+			m.setStatus(err.Error(), true)
+		}
+
+	case "fragment":
+		m.exportOperation(arg)
+
+	case "http":
+		m.exportHTTPRequests(arg)
+
+	case "operation-ids":
+		m.openOperationIDReview()
+
+	default:
+		m.setStatus(fmt.Sprintf("Unknown command: %s", name), true)
+	}
+}
+
+func (m *Model) setStatus(message string, isError bool) {
+	m.statusMessage = message
+	m.statusError = isError
+}
+
+func (m *Model) activeSortKeys() []sortKey {
+	if m.sortKeys == nil {
+		return defaultSortSpec
+	}
+	return m.sortKeys
+}
+
+// applySort reorders endpoints by the active sort keys and moves the cursor to the top
+func (m *Model) applySort() {
+	if m.sortKeys != nil {
+		sortEndpoints(m.endpoints, m.sortKeys)
+	}
+	m.filterItems()
+	m.cursor = 0
+	m.scrollOffset = 0
+}
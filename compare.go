@@ -0,0 +1,541 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// readOnlyMethods are the operations `oq compare` sends, so pointing it at production is safe
+var readOnlyMethods = map[string]bool{"GET": true, "HEAD": true, "OPTIONS": true}
+
+// compareContext is how many unchanged lines are shown around differing body lines
+const compareContext = 2
+
+// maxCompareLines caps the bodies that are aligned line by line, longer ones are compared
+// line for line without looking for insertions
+const maxCompareLines = 2000
+
+var compareFormats = []string{"text", "json"}
+
+// defaultParallelism is how many operations `oq compare` sends at once unless configured
+const defaultParallelism = 4
+
+// operationFlags collects repeated --op flags, each an operationId or "METHOD /path"
+type operationFlags []string
+
+func (o *operationFlags) String() string {
+	return strings.Join(*o, ", ")
+}
+
+func (o *operationFlags) Set(value string) error {
+	*o = append(*o, strings.TrimSpace(value))
+	return nil
+}
+
+// matches reports whether ep was selected, every read-only operation when none were
+func (o operationFlags) matches(ep endpoint) bool {
+	if len(o) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(o, func(sel string) bool {
+		method, path, ok := strings.Cut(sel, " ")
+		if ok && strings.EqualFold(method, ep.method) && strings.TrimSpace(path) == ep.path {
+			return true
+		}
+		return ep.op.OperationId != "" && sel == ep.op.OperationId
+	})
+}
+
+// serverResponse is what one server answered, with the body normalized for comparison
+type serverResponse struct {
+	url         string
+	status      string
+	code        int
+	contentType string
+	lines       []string
+	duration    time.Duration
+	err         error
+	// retries counts the requests answered with 429 before this response
+	retries int
+}
+
+// serverComparison is the result of sending one operation to both servers
+type serverComparison struct {
+	ep          endpoint
+	skipped     string
+	base, other *serverResponse
+	differences []string
+	lines       []linePair
+}
+
+// linePair is one row of the side by side body diff. kind is ' ' for equal lines, '|' for
+// changed, '<' and '>' for lines only on the left or right, as in sdiff
+type linePair struct {
+	left, right string
+	kind        byte
+}
+
+func (c serverComparison) result() string {
+	switch {
+	case c.skipped != "":
+		return "skipped"
+	case len(c.differences) > 0:
+		return "differs"
+	}
+	return "same"
+}
+
+// compareServers sends the selected read-only operations to both servers, up to parallel
+// operations at a time, and returns the results in the order of eps. Operations with a path
+// parameter that has no example are skipped, as there is no sensible value to send. progress,
+// when set, is told when each operation starts and finishes, and pacer, when set, keeps the
+// requests within the rate limits
+func compareServers(ctx context.Context, doc *v3.Document, eps []endpoint, baseURL, otherURL string, ignore []string, store credentialStore, parallel int, progress *compareProgress, pacer *ratePacer) []serverComparison {
+	comparisons := make([]serverComparison, len(eps))
+	done := make([]bool, len(eps))
+	slots := make(chan struct{}, max(1, parallel))
+	var wg sync.WaitGroup
+	for i, ep := range eps {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			progress.start(i)
+			comparisons[i] = compareOperation(ctx, doc, ep, baseURL, otherURL, ignore, store, pacer)
+			done[i] = true
+			progress.finish(i, comparisons[i])
+		}()
+	}
+	wg.Wait()
+
+	// Operations not sent before the context was cancelled are left out
+	var sent []serverComparison
+	for i, c := range comparisons {
+		if done[i] {
+			sent = append(sent, c)
+		}
+	}
+	return sent
+}
+
+func compareOperation(ctx context.Context, doc *v3.Document, ep endpoint, baseURL, otherURL string, ignore []string, store credentialStore, pacer *ratePacer) serverComparison {
+	c := serverComparison{ep: ep}
+	var params []runParam
+	for _, p := range operationParameters(doc, ep) {
+		if p.in == "path" && p.value == "" {
+			c.skipped = "no example for path parameter " + p.name
+			return c
+		}
+		params = append(params, *p)
+	}
+	c.base = fetchForCompare(ctx, doc, ep, baseURL, params, ignore, store, pacer)
+	c.other = fetchForCompare(ctx, doc, ep, otherURL, params, ignore, store, pacer)
+	c.differences, c.lines = compareResponses(c.base, c.other)
+	return c
+}
+
+// fetchForCompare sends an operation to a server, retrying it after backing off when the
+// server answers that it is sent too many requests
+func fetchForCompare(ctx context.Context, doc *v3.Document, ep endpoint, server string, params []runParam, ignore []string, store credentialStore, pacer *ratePacer) *serverResponse {
+	resp := &serverResponse{}
+	req, err := newRunRequest(ep.method, server, ep.path, params, "", "")
+	if err != nil {
+		resp.err = err
+		return resp
+	}
+	resp.url = req.URL.Redacted()
+	if store != nil {
+		applyCredentials(req, doc, ep.op, store)
+	}
+	var result *runResult
+	for attempt := 0; ; attempt++ {
+		if err := pacer.wait(ctx, server, ep); err != nil {
+			resp.err = err
+			return resp
+		}
+		result, err = doRunRequest(req.Clone(ctx), remote.timeout)
+		if err != nil {
+			resp.err = err
+			return resp
+		}
+		if !pacer.backoff(server, attempt, result, time.Now()) {
+			break
+		}
+		resp.retries++
+	}
+	resp.status, resp.code, resp.duration = result.status, result.code, result.duration
+	resp.contentType = baseMediaType(result.headers.Get("Content-Type"))
+	resp.lines = strings.Split(normalizeCompareBody(resp.contentType, result.body, ignore), "\n")
+	return resp
+}
+
+// normalizeCompareBody renders a body so that equal content compares equal: JSON objects
+// get sorted keys and the ignored keys are dropped at any depth, as they hold values such as
+// timestamps or request ids that differ between any two calls
+func normalizeCompareBody(contentType string, body []byte, ignore []string) string {
+	if isJSONMediaType(contentType) {
+		var value any
+		if err := json.Unmarshal(body, &value); err == nil {
+			out, err := json.MarshalIndent(dropKeys(value, ignore), "", "  ")
+			if err == nil {
+				return string(out)
+			}
+		}
+	}
+	return formatResponseBody(contentType, body)
+}
+
+func dropKeys(value any, ignore []string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if slices.Contains(ignore, key) {
+				delete(v, key)
+				continue
+			}
+			v[key] = dropKeys(child, ignore)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = dropKeys(child, ignore)
+		}
+	}
+	return value
+}
+
+// compareResponses lists what differs between two responses and aligns their bodies
+func compareResponses(base, other *serverResponse) ([]string, []linePair) {
+	var differences []string
+	switch {
+	case base.err != nil || other.err != nil:
+		return []string{"request failed"}, nil
+	case base.code != other.code:
+		differences = append(differences, fmt.Sprintf("status %d vs %d", base.code, other.code))
+	}
+	if base.contentType != other.contentType {
+		differences = append(differences, fmt.Sprintf("content type %s vs %s", orNone(base.contentType), orNone(other.contentType)))
+	}
+	lines := alignLines(base.lines, other.lines)
+	if slices.ContainsFunc(lines, func(p linePair) bool { return p.kind != ' ' }) {
+		differences = append(differences, "body")
+	}
+	return differences, lines
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// alignLines pairs the lines of two bodies along their longest common subsequence. Runs
+// of removed and added lines are paired up as changed lines
+func alignLines(a, b []string) []linePair {
+	if len(a) > maxCompareLines || len(b) > maxCompareLines {
+		var pairs []linePair
+		for i := range max(len(a), len(b)) {
+			switch {
+			case i >= len(a):
+				pairs = append(pairs, linePair{right: b[i], kind: '>'})
+			case i >= len(b):
+				pairs = append(pairs, linePair{left: a[i], kind: '<'})
+			case a[i] == b[i]:
+				pairs = append(pairs, linePair{left: a[i], right: b[i], kind: ' '})
+			default:
+				pairs = append(pairs, linePair{left: a[i], right: b[i], kind: '|'})
+			}
+		}
+		return pairs
+	}
+
+	// lcs[i][j] is the length of the common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var pairs []linePair
+	var removed, added []string
+	flush := func() {
+		for k := range max(len(removed), len(added)) {
+			switch {
+			case k >= len(removed):
+				pairs = append(pairs, linePair{right: added[k], kind: '>'})
+			case k >= len(added):
+				pairs = append(pairs, linePair{left: removed[k], kind: '<'})
+			default:
+				pairs = append(pairs, linePair{left: removed[k], right: added[k], kind: '|'})
+			}
+		}
+		removed, added = nil, nil
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			pairs = append(pairs, linePair{left: a[i], right: b[j], kind: ' '})
+			i++
+			j++
+		case j >= len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, a[i])
+			i++
+		default:
+			added = append(added, b[j])
+			j++
+		}
+	}
+	flush()
+	return pairs
+}
+
+// compareSummary counts the results, e.g. "12 operations: 9 same, 2 differ, 1 skipped"
+func compareSummary(comparisons []serverComparison) string {
+	counts := map[string]int{}
+	for _, c := range comparisons {
+		counts[c.result()]++
+	}
+	return fmt.Sprintf("%d %s: %d same, %d %s, %d skipped", len(comparisons), plural(len(comparisons), "operation", "operations"),
+		counts["same"], counts["differs"], plural(counts["differs"], "differs", "differ"), counts["skipped"])
+}
+
+// writeCompareText prints each operation with its result and, for bodies that differ, the
+// differing lines side by side with some context
+func writeCompareText(w io.Writer, comparisons []serverComparison, baseURL, otherURL string, width int) {
+	column := max(20, (width-3)/2)
+	cell := func(s string) string {
+		s = strings.ReplaceAll(s, "\t", "  ")
+		if runes := []rune(s); len(runes) > column {
+			return string(runes[:column-1]) + "…"
+		}
+		return s + strings.Repeat(" ", column-len([]rune(s)))
+	}
+
+	for _, c := range comparisons {
+		label := c.ep.method + " " + c.ep.path
+		switch c.result() {
+		case "skipped":
+			fmt.Fprintf(w, "%s  skipped: %s\n", label, c.skipped)
+			continue
+		case "same":
+			fmt.Fprintf(w, "%s  same (%s)\n", label, responseLabel(c.base))
+			continue
+		}
+		fmt.Fprintf(w, "%s  differs: %s\n", label, strings.Join(c.differences, ", "))
+		fmt.Fprintf(w, "  %s   %s\n", cell(baseURL+": "+responseLabel(c.base)), otherURL+": "+responseLabel(c.other))
+		if !slices.Contains(c.differences, "body") {
+			continue
+		}
+		last := -1
+		for i, pair := range c.lines {
+			near := false
+			for k := max(0, i-compareContext); k <= min(len(c.lines)-1, i+compareContext); k++ {
+				near = near || c.lines[k].kind != ' '
+			}
+			if !near {
+				continue
+			}
+			if last >= 0 && i > last+1 {
+				fmt.Fprintf(w, "  %s\n", cell("..."))
+			}
+			last = i
+			fmt.Fprintf(w, "  %s %c %s\n", cell(pair.left), pair.kind, pair.right)
+		}
+	}
+	fmt.Fprintln(w, compareSummary(comparisons))
+}
+
+// responseLabel is the status and content type of a response, or why the request failed
+func responseLabel(resp *serverResponse) string {
+	if resp.err != nil {
+		return "error: " + resp.err.Error()
+	}
+	if resp.contentType == "" {
+		return resp.status + retriesLabel(resp.retries)
+	}
+	return resp.status + ", " + resp.contentType + retriesLabel(resp.retries)
+}
+
+type compareJSONResponse struct {
+	URL         string `json:"url,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	DurationMs  int64  `json:"durationMs,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Retries     int    `json:"retries,omitempty"`
+}
+
+type compareJSONResult struct {
+	Operation   string               `json:"operation"`
+	OperationID string               `json:"operationId,omitempty"`
+	Result      string               `json:"result"`
+	Reason      string               `json:"reason,omitempty"`
+	Differences []string             `json:"differences,omitempty"`
+	Base        *compareJSONResponse `json:"base,omitempty"`
+	Other       *compareJSONResponse `json:"other,omitempty"`
+}
+
+func compareJSONOf(resp *serverResponse) *compareJSONResponse {
+	if resp == nil {
+		return nil
+	}
+	return &compareJSONResponse{URL: resp.url, Status: resp.code, ContentType: resp.contentType, DurationMs: resp.duration.Milliseconds(), Error: errorText(resp.err), Retries: resp.retries}
+}
+
+func writeCompareJSON(w io.Writer, comparisons []serverComparison) error {
+	results := make([]compareJSONResult, 0, len(comparisons))
+	for _, c := range comparisons {
+		results = append(results, compareJSONResult{
+			Operation:   c.ep.method + " " + c.ep.path,
+			OperationID: c.ep.op.OperationId,
+			Result:      c.result(),
+			Reason:      c.skipped,
+			Differences: c.differences,
+			Base:        compareJSONOf(c.base),
+			Other:       compareJSONOf(c.other),
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// runCompare implements `oq compare spec.yaml https://api.example.com https://staging.example.com`
+func runCompare(ctx context.Context, cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	var ops operationFlags
+	fs.Var(&ops, "op", "operation to compare, an operationId or \"METHOD /path\" (repeatable, default all read-only operations)")
+	ignore := fs.String("ignore", "", "comma-separated JSON keys to leave out of the comparison, such as timestamps")
+	format := fs.String("format", "text", "output format: text or json")
+	failOnDiff := fs.Bool("fail-on-diff", false, "exit with 1 when any response differs")
+	parallel := fs.Int("parallel", defaultParallelism, "how many operations to send at the same time")
+	rate := fs.String("rate", "", "most requests to send to each server, such as 10/s or 100/min (default the x-ratelimit of the spec)")
+	retries := fs.Int("retries", defaultRetries, "how many times to retry a request answered with 429 Too Many Requests")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq compare [--op operation]... [--ignore keys] [--parallel n] [--rate n/unit] [--retries n] [--format text|json] [--fail-on-diff] <spec> <base URL> <other URL>\n\n")
+		fs.PrintDefaults()
+	}
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) != 3 || !slices.Contains(compareFormats, *format) || *parallel < 1 || *retries < 0 {
+		fs.Usage()
+		return 2
+	}
+	var limit rateLimit
+	if *rate != "" {
+		var ok bool
+		if limit, ok = parseRate(*rate); !ok {
+			fmt.Fprintf(os.Stderr, "Error: invalid rate %q, expected a number of requests per unit such as 10/s or 100/min\n", *rate)
+			return 2
+		}
+	}
+	if !flagWasSet(fs, "parallel") && cfg.Parallelism > 0 {
+		*parallel = cfg.Parallelism
+	}
+
+	_, doc, err := loadSpec(ctx, args[0])
+	if err != nil {
+		return reportError(err)
+	}
+
+	var eps []endpoint
+	for _, ep := range extractEndpoints(doc) {
+		if !ops.matches(ep) {
+			continue
+		}
+		if !readOnlyMethods[ep.method] {
+			if len(ops) > 0 {
+				fmt.Fprintf(os.Stderr, "Error: %s %s is not read-only, only GET, HEAD and OPTIONS are sent\n", ep.method, ep.path)
+				return 2
+			}
+			continue
+		}
+		eps = append(eps, ep)
+	}
+	if len(eps) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no read-only operations to compare\n")
+		return 1
+	}
+
+	var keys []string
+	for _, key := range strings.Split(*ignore, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	store, err := openCredentialStore(cfg)
+	if err != nil {
+		debugLog.Warn("opening credential store failed", "error", err)
+		store = nil
+	}
+
+	// The live table goes to stderr, so it stays out of redirected results
+	var progress *compareProgress
+	if term.IsTerminal(os.Stderr.Fd()) {
+		width, height, err := term.GetSize(os.Stderr.Fd())
+		if err != nil || height <= 0 {
+			width, height = 80, 24
+		}
+		progress = newCompareProgress(os.Stderr, eps, width, height)
+	}
+	comparisons := compareServers(ctx, doc, eps, args[1], args[2], keys, store, *parallel, progress, newRatePacer(doc, limit, *retries))
+	progress.clear()
+	if ctx.Err() != nil {
+		return reportError(ctx.Err())
+	}
+
+	if *format == "json" {
+		if err := writeCompareJSON(os.Stdout, comparisons); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			return 1
+		}
+	} else {
+		width := 160
+		if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+			width = w
+		}
+		writeCompareText(os.Stdout, comparisons, args[1], args[2], width)
+	}
+
+	if *failOnDiff && slices.ContainsFunc(comparisons, func(c serverComparison) bool { return c.result() == "differs" }) {
+		return 1
+	}
+	return 0
+}
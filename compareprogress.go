@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// compareProgress is the live table `oq compare` draws on the terminal while operations are
+// in flight, a row per operation with the status and latency of both servers
+type compareProgress struct {
+	mu      sync.Mutex
+	w       io.Writer
+	eps     []endpoint
+	started []time.Time
+	results []*serverComparison
+	width   int
+	height  int
+	drawn   int
+	stop    chan struct{}
+	cleared bool
+}
+
+// compareProgressTick is how often the table is redrawn to keep the running times current
+const compareProgressTick = 200 * time.Millisecond
+
+func newCompareProgress(w io.Writer, eps []endpoint, width, height int) *compareProgress {
+	p := &compareProgress{w: w, eps: eps, started: make([]time.Time, len(eps)), results: make([]*serverComparison, len(eps)), width: width, height: height, stop: make(chan struct{})}
+	p.draw()
+	go func() {
+		ticker := time.NewTicker(compareProgressTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.mu.Lock()
+				p.draw()
+				p.mu.Unlock()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+	return p
+}
+
+func (p *compareProgress) start(i int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.started[i] = time.Now()
+	p.draw()
+}
+
+func (p *compareProgress) finish(i int, c serverComparison) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results[i] = &c
+	p.draw()
+}
+
+// clear stops redrawing and erases the table, so the report is printed in its place
+func (p *compareProgress) clear() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.erase()
+	p.cleared = true
+}
+
+// erase moves back to the first line of the table and clears it to the end of the screen
+func (p *compareProgress) erase() {
+	if p.drawn > 0 {
+		fmt.Fprintf(p.w, "\x1b[%dA", p.drawn)
+	}
+	fmt.Fprint(p.w, "\r\x1b[J")
+	p.drawn = 0
+}
+
+func (p *compareProgress) draw() {
+	if p.cleared {
+		return
+	}
+	lines := p.lines()
+	p.erase()
+	for _, line := range lines {
+		if runes := []rune(line); p.width > 0 && len(runes) >= p.width {
+			line = string(runes[:p.width-2]) + "…"
+		}
+		fmt.Fprintln(p.w, line)
+	}
+	p.drawn = len(lines)
+}
+
+// lines renders a row per operation. When they don't fit the terminal, only the operations
+// in flight are listed above the summary
+func (p *compareProgress) lines() []string {
+	labelWidth := 0
+	for _, ep := range p.eps {
+		labelWidth = max(labelWidth, len(ep.method)+1+len(ep.path))
+	}
+	labelWidth = min(labelWidth, max(20, p.width/2))
+
+	done := 0
+	for _, c := range p.results {
+		if c != nil {
+			done++
+		}
+	}
+	all := len(p.eps)+1 < p.height
+	var lines []string
+	for i, ep := range p.eps {
+		running := !p.started[i].IsZero() && p.results[i] == nil
+		if !all && !running {
+			continue
+		}
+		label := ep.method + " " + ep.path
+		lines = append(lines, fmt.Sprintf("%-*s  %s", labelWidth, label, p.row(i)))
+	}
+	lines = append(lines, fmt.Sprintf("%d/%d operations done", done, len(p.eps)))
+	return lines
+}
+
+// row is the state of an operation: waiting, running for how long, or its result with the
+// status and latency of each server
+func (p *compareProgress) row(i int) string {
+	c := p.results[i]
+	switch {
+	case p.started[i].IsZero():
+		return "waiting"
+	case c == nil:
+		return "running " + time.Since(p.started[i]).Round(100*time.Millisecond).String()
+	case c.skipped != "":
+		return "skipped"
+	}
+	return fmt.Sprintf("%-7s  %s  %s", c.result(), progressCell(c.base), progressCell(c.other))
+}
+
+// progressCell is the status code and latency of a response, e.g. "200 84ms"
+func progressCell(resp *serverResponse) string {
+	if resp.err != nil {
+		return "error"
+	}
+	return strings.TrimSpace(fmt.Sprintf("%d %s", resp.code, resp.duration.Round(time.Millisecond)))
+}
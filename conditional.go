@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// responseHeaderNames returns the lower-cased names of the headers any response of op defines
+func responseHeaderNames(op *v3.Operation) map[string]bool {
+	names := map[string]bool{}
+	if op == nil || op.Responses == nil {
+		return names
+	}
+
+	responses := []*v3.Response{op.Responses.Default}
+	if op.Responses.Codes != nil {
+		for pair := op.Responses.Codes.First(); pair != nil; pair = pair.Next() {
+			responses = append(responses, pair.Value())
+		}
+	}
+	for _, resp := range responses {
+		if resp == nil || resp.Headers == nil {
+			continue
+		}
+		for pair := resp.Headers.First(); pair != nil; pair = pair.Next() {
+			names[strings.ToLower(pair.Key())] = true
+		}
+	}
+	return names
+}
+
+func hasResponseCode(op *v3.Operation, code string) bool {
+	return op != nil && op.Responses != nil && op.Responses.Codes != nil && op.Responses.Codes.GetOrZero(code) != nil
+}
+
+// conditionalHint introduces the headers of conditionalHeaders in generated snippets
+const conditionalHint = "Conditional request, expect 304 Not Modified when unchanged:"
+
+// conditionalHeaders returns the headers for the conditional requests an operation supports,
+// based on ETag / Last-Modified response headers and 304 responses. Snippets show them
+// commented out
+func conditionalHeaders(op *v3.Operation) []snippetHeader {
+	headers := responseHeaderNames(op)
+	notModified := hasResponseCode(op, "304")
+
+	var hints []snippetHeader
+	if headers["etag"] || notModified {
+		hints = append(hints, snippetHeader{name: "If-None-Match", value: `"<etag from a previous response>"`})
+	}
+	if headers["last-modified"] || (notModified && !headers["etag"]) {
+		hints = append(hints, snippetHeader{name: "If-Modified-Since", value: "<Last-Modified from a previous response>"})
+	}
+	return hints
+}
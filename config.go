@@ -0,0 +1,594 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Config holds user settings loaded from config.yaml in the oq config directory
+type Config struct {
+	DefaultView string `yaml:"default_view,omitempty"`
+	Debug       bool   `yaml:"debug,omitempty"`
+	DebugFile   string `yaml:"debug_file,omitempty"`
+	AutoReload  bool   `yaml:"auto_reload,omitempty"`
+	SplitView   bool   `yaml:"split_view,omitempty"`
+	// MethodColors and MethodLabels are keyed by upper-case HTTP method
+	MethodColors    map[string]string `yaml:"method_colors,omitempty"`
+	MethodLabels    map[string]string `yaml:"method_labels,omitempty"`
+	CredentialStore string            `yaml:"credential_store,omitempty"`
+	// Budgets warn when a spec grows past them, zero means no limit
+	MaxOperations  int    `yaml:"max_operations,omitempty"`
+	MaxSchemaDepth int    `yaml:"max_schema_depth,omitempty"`
+	MaxSpecSize    string `yaml:"max_spec_size,omitempty"`
+	MaxPayloadSize string `yaml:"max_payload_size,omitempty"`
+	HTTPTimeout    string `yaml:"http_timeout,omitempty"`
+	// PIITerms replace the property names the PII scan looks for
+	PIITerms []string `yaml:"pii_terms,omitempty"`
+	Theme    string   `yaml:"theme,omitempty"`
+	// Keymap picks a preset of bindings, KeyBindings maps keys to the built-in key they act as
+	Keymap        string            `yaml:"keymap,omitempty"`
+	KeyBindings   map[string]string `yaml:"key_bindings,omitempty"`
+	DefaultServer string            `yaml:"default_server,omitempty"`
+	CurlOptions   string            `yaml:"curl_options,omitempty"`
+	Parallelism   int               `yaml:"parallelism,omitempty"`
+	// Footer lists the footer modules, left and right of a |
+	Footer string `yaml:"footer,omitempty"`
+	// Locale picks the separators of numbers shown in details
+	Locale string `yaml:"locale,omitempty"`
+}
+
+// configSetting describes a single key that can be inspected and changed with `oq config`.
+// set parses and validates the value, so it is also used to validate hand-edited files
+type configSetting struct {
+	key         string
+	description string
+	get         func(c *Config) string
+	set         func(c *Config, value string) error
+}
+
+var configSettings = []configSetting{
+	{
+		key:         "default_view",
+		description: "view shown on startup: endpoints, components, webhooks, tags or overview",
+		get:         func(c *Config) string { return c.DefaultView },
+		set: func(c *Config, value string) error {
+			if value != "" && value != "overview" {
+				if _, ok := parseViewMode(value); !ok {
+					return fmt.Errorf("must be one of endpoints, components, webhooks, tags, overview")
+				}
+			}
+			c.DefaultView = value
+			return nil
+		},
+	},
+	{
+		key:         "theme",
+		description: "colors: " + themeNames() + " (default: dark or light, matching the terminal background)",
+		get:         func(c *Config) string { return c.Theme },
+		set: func(c *Config, value string) error {
+			if _, ok := themes[value]; value != "" && !ok {
+				return fmt.Errorf("must be one of %s", themeNames())
+			}
+			c.Theme = value
+			return nil
+		},
+	},
+	{
+		key:         "keymap",
+		description: "key binding preset on top of the vim-style keys: " + keymapNames(),
+		get:         func(c *Config) string { return c.Keymap },
+		set: func(c *Config, value string) error {
+			if _, ok := keyPresets[value]; value != "" && !ok {
+				return fmt.Errorf("must be one of %s", keymapNames())
+			}
+			c.Keymap = value
+			return nil
+		},
+	},
+	{
+		key:         "key_bindings",
+		description: "keys acting as built-in keys, e.g. ctrl+n=j,ctrl+p=k,q=none",
+		get: func(c *Config) string {
+			keys := slices.Sorted(maps.Keys(c.KeyBindings))
+			pairs := make([]string, len(keys))
+			for i, key := range keys {
+				pairs[i] = key + "=" + c.KeyBindings[key]
+			}
+			return strings.Join(pairs, ",")
+		},
+		set: func(c *Config, value string) error {
+			bindings := map[string]string{}
+			for _, pair := range strings.Split(value, ",") {
+				if strings.TrimSpace(pair) == "" {
+					continue
+				}
+				key, target, ok := strings.Cut(pair, "=")
+				if !ok {
+					return fmt.Errorf("expected key=builtin pairs, got %q", pair)
+				}
+				key, target = strings.TrimSpace(key), strings.TrimSpace(target)
+				if err := validateKeyBinding(key, target); err != nil {
+					return err
+				}
+				bindings[key] = target
+			}
+			if len(bindings) == 0 {
+				bindings = nil
+			}
+			c.KeyBindings = bindings
+			return nil
+		},
+	},
+	{
+		key:         "default_server",
+		description: "server URL for requests and curl commands instead of the spec's first server",
+		get:         func(c *Config) string { return c.DefaultServer },
+		set: func(c *Config, value string) error {
+			if value != "" {
+				if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("must be an http:// or https:// URL")
+				}
+			}
+			c.DefaultServer = value
+			return nil
+		},
+	},
+	{
+		key:         "curl_options",
+		description: "extra options for generated curl commands, e.g. -sS --compressed",
+		get:         func(c *Config) string { return c.CurlOptions },
+		set: func(c *Config, value string) error {
+			if strings.ContainsAny(value, "\n'") {
+				return fmt.Errorf("must be a single line without single quotes")
+			}
+			c.CurlOptions = strings.TrimSpace(value)
+			return nil
+		},
+	},
+	boolSetting("auto_reload", "reload the spec automatically when the file changes on disk",
+		func(c *Config) *bool { return &c.AutoReload }),
+	boolSetting("split_view", "show details in a pane next to the list instead of unfolding items",
+		func(c *Config) *bool { return &c.SplitView }),
+	boolSetting("debug", "always write debug logs, as if --debug was set",
+		func(c *Config) *bool { return &c.Debug }),
+	{
+		key:         "debug_file",
+		description: "file to write debug logs to",
+		get:         func(c *Config) string { return c.DebugFile },
+		set: func(c *Config, value string) error {
+			c.DebugFile = value
+			return nil
+		},
+	},
+	{
+		key:         "http_timeout",
+		description: "timeout for fetching specs given as URLs, e.g. 10s",
+		get:         func(c *Config) string { return c.HTTPTimeout },
+		set: func(c *Config, value string) error {
+			if value != "" {
+				if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+					return fmt.Errorf("must be a positive duration such as 10s or 1m")
+				}
+			}
+			c.HTTPTimeout = value
+			return nil
+		},
+	},
+	{
+		key:         "credential_store",
+		description: "where API keys and tokens are kept: auto, keychain or file",
+		get:         func(c *Config) string { return c.CredentialStore },
+		set: func(c *Config, value string) error {
+			switch value {
+			case "", "auto", "keychain", "file":
+				c.CredentialStore = value
+				return nil
+			}
+			return fmt.Errorf("must be one of auto, keychain, file")
+		},
+	},
+	{
+		key:         "pii_terms",
+		description: "property names the PII scan flags, comma separated, replacing the defaults",
+		get:         func(c *Config) string { return strings.Join(c.PIITerms, ",") },
+		set: func(c *Config, value string) error {
+			var terms []string
+			for _, term := range strings.Split(value, ",") {
+				if term = strings.TrimSpace(term); term != "" {
+					if normalizePIIName(term) == "" {
+						return fmt.Errorf("%q has no letters or digits", term)
+					}
+					terms = append(terms, term)
+				}
+			}
+			c.PIITerms = terms
+			return nil
+		},
+	},
+	methodMapSetting("method_colors", "colors per method, e.g. QUERY=#14B8A6,LINK=214",
+		func(c *Config) *map[string]string { return &c.MethodColors }, validateColor),
+	methodMapSetting("method_labels", "labels per method, e.g. DELETE=DEL,QUERY=QRY",
+		func(c *Config) *map[string]string { return &c.MethodLabels }, validateLabel),
+	{
+		key:         "footer",
+		description: "footer modules left and right of a |, e.g. hints,counts | environment,clock",
+		get:         func(c *Config) string { return c.Footer },
+		set: func(c *Config, value string) error {
+			if _, err := parseFooter(value); err != nil {
+				return err
+			}
+			c.Footer = strings.TrimSpace(value)
+			return nil
+		},
+	},
+	{
+		key:         "locale",
+		description: "separators of numbers shown in details: a language such as de or fr-CA, or auto for $LANG",
+		get:         func(c *Config) string { return c.Locale },
+		set: func(c *Config, value string) error {
+			if _, ok := parseLocale(value); !ok {
+				return fmt.Errorf("unknown locale %q, expected auto or a language such as en, de or fr", value)
+			}
+			c.Locale = value
+			return nil
+		},
+	},
+	intSetting("parallelism", "operations oq compare sends at the same time, 0 for the default of 4",
+		func(c *Config) *int { return &c.Parallelism }),
+	intSetting("max_operations", "warn when the spec has more operations, 0 for no limit",
+		func(c *Config) *int { return &c.MaxOperations }),
+	intSetting("max_schema_depth", "warn when schemas nest deeper, 0 for no limit",
+		func(c *Config) *int { return &c.MaxSchemaDepth }),
+	{
+		key:         "max_spec_size",
+		description: "warn when the spec file is larger, e.g. 2MB",
+		get:         func(c *Config) string { return c.MaxSpecSize },
+		set: func(c *Config, value string) error {
+			if _, err := parseByteSize(value); err != nil {
+				return err
+			}
+			c.MaxSpecSize = value
+			return nil
+		},
+	},
+	{
+		key:         "max_payload_size",
+		description: "flag operations whose example request or response is larger, e.g. 64KB",
+		get:         func(c *Config) string { return c.MaxPayloadSize },
+		set: func(c *Config, value string) error {
+			if _, err := parseByteSize(value); err != nil {
+				return err
+			}
+			c.MaxPayloadSize = value
+			return nil
+		},
+	},
+}
+
+func boolSetting(key, description string, field func(c *Config) *bool) configSetting {
+	return configSetting{
+		key:         key,
+		description: description,
+		get:         func(c *Config) string { return strconv.FormatBool(*field(c)) },
+		set: func(c *Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("must be true or false")
+			}
+			*field(c) = b
+			return nil
+		},
+	}
+}
+
+func intSetting(key, description string, field func(c *Config) *int) configSetting {
+	return configSetting{
+		key:         key,
+		description: description,
+		get:         func(c *Config) string { return strconv.Itoa(*field(c)) },
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("must be a number of 0 or more")
+			}
+			*field(c) = n
+			return nil
+		},
+	}
+}
+
+// methodMapSetting exposes a per-method map as a comma separated list of METHOD=value pairs.
+// Setting an empty value clears the map
+func methodMapSetting(key, description string, field func(c *Config) *map[string]string, validate func(string) error) configSetting {
+	return configSetting{
+		key:         key,
+		description: description,
+		get: func(c *Config) string {
+			m := *field(c)
+			methods := make([]string, 0, len(m))
+			for method := range m {
+				methods = append(methods, method)
+			}
+			sort.Strings(methods)
+
+			pairs := make([]string, len(methods))
+			for i, method := range methods {
+				pairs[i] = method + "=" + m[method]
+			}
+			return strings.Join(pairs, ",")
+		},
+		set: func(c *Config, value string) error {
+			m := map[string]string{}
+			for _, pair := range strings.Split(value, ",") {
+				if strings.TrimSpace(pair) == "" {
+					continue
+				}
+				method, v, ok := strings.Cut(pair, "=")
+				method = strings.ToUpper(strings.TrimSpace(method))
+				if !ok || method == "" {
+					return fmt.Errorf("expected METHOD=value pairs, got %q", pair)
+				}
+				if err := validate(v); err != nil {
+					return fmt.Errorf("%s: %w", method, err)
+				}
+				m[method] = v
+			}
+			if len(m) == 0 {
+				m = nil
+			}
+			*field(c) = m
+			return nil
+		},
+	}
+}
+
+func findConfigSetting(key string) (configSetting, bool) {
+	for _, s := range configSettings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return configSetting{}, false
+}
+
+// viewModeNames are the names of the views in the config and the session state
+var viewModeNames = map[viewMode]string{viewEndpoints: "endpoints", viewWebhooks: "webhooks", viewComponents: "components", viewTags: "tags"}
+
+func parseViewMode(name string) (viewMode, bool) {
+	switch name {
+	case "endpoints":
+		return viewEndpoints, true
+	case "components":
+		return viewComponents, true
+	case "webhooks":
+		return viewWebhooks, true
+	case "tags":
+		return viewTags, true
+	}
+	return viewEndpoints, false
+}
+
+// configDir returns the oq directory inside the user config directory
+func configDir() (string, error) {
+	// On macOS ~/.config/oq is used when it exists, as many keep their dotfiles there
+	if home, err := os.UserHomeDir(); err == nil && runtime.GOOS == "darwin" {
+		if info, err := os.Stat(filepath.Join(home, ".config", "oq")); err == nil && info.IsDir() {
+			return filepath.Join(home, ".config", "oq"), nil
+		}
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "oq"), nil
+}
+
+func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// loadConfig reads the config file, returning an empty config when there is none yet
+func loadConfig() (*Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	return loadConfigFile(path)
+}
+
+func loadConfigFile(path string) (*Config, error) {
+	cfg := &Config{}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("Error parsing config %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("Invalid config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// validate re-applies every setting's current value to catch invalid hand edits
+func (c *Config) validate() error {
+	var errs []error
+	scratch := &Config{}
+	for _, s := range configSettings {
+		if err := s.set(scratch, s.get(c)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Config) save(path string) error {
+	content, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o600)
+}
+
+// runConfig implements `oq config get|set|list|edit|path`
+func runConfig(args []string) int {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq config list\n")
+		fmt.Fprintf(fs.Output(), "       oq config get <key>\n")
+		fmt.Fprintf(fs.Output(), "       oq config set <key> <value>\n")
+		fmt.Fprintf(fs.Output(), "       oq config edit\n")
+		fmt.Fprintf(fs.Output(), "       oq config path\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	path, err := configPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error locating config directory: %v\n", err)
+		return 1
+	}
+
+	switch cmd, rest := fs.Arg(0), fs.Args()[1:]; {
+	case cmd == "path" && len(rest) == 0:
+		fmt.Println(path)
+		return 0
+	case cmd == "list" && len(rest) == 0:
+		return configList(os.Stdout, path)
+	case cmd == "get" && len(rest) == 1:
+		return configGet(os.Stdout, path, rest[0])
+	case cmd == "set" && len(rest) == 2:
+		return configSet(path, rest[0], rest[1])
+	case cmd == "edit" && len(rest) == 0:
+		return configEdit(path)
+	}
+
+	fs.Usage()
+	return 2
+}
+
+func configList(w io.Writer, path string) int {
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	for _, s := range configSettings {
+		fmt.Fprintf(w, "%-16s %-20s # %s\n", s.key, s.get(cfg), s.description)
+	}
+	return 0
+}
+
+func configGet(w io.Writer, path, key string) int {
+	setting, ok := findConfigSetting(key)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown config key %q, run 'oq config list' to see all keys\n", key)
+		return 1
+	}
+
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	fmt.Fprintln(w, setting.get(cfg))
+	return 0
+}
+
+func configSet(path, key, value string) int {
+	setting, ok := findConfigSetting(key)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown config key %q, run 'oq config list' to see all keys\n", key)
+		return 1
+	}
+
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+
+	if err := setting.set(cfg, value); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid value for %s: %v\n", key, err)
+		return 1
+	}
+
+	if err := cfg.save(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing config: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// configEdit opens the config file in $VISUAL or $EDITOR and validates the result
+func configEdit(path string) int {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if err := (&Config{}).save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating config: %v\n", err)
+			return 1
+		}
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// The editor variable may carry arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error running editor: %v\n", err)
+		return 1
+	}
+
+	if _, err := loadConfigFile(path); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+func TestConfigSetAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oq", "config.yaml")
+
+	if code := configSet(path, "default_view", "components"); code != 0 {
+		t.Fatalf("Expected set to succeed, got exit code %d", code)
+	}
+	if code := configSet(path, "default_view", "nope"); code == 0 {
+		t.Error("Expected set to reject an unknown view")
+	}
+	if code := configSet(path, "no_such_key", "x"); code == 0 {
+		t.Error("Expected set to reject an unknown key")
+	}
+
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("Error loading config: %v", err)
+	}
+	if cfg.DefaultView != "components" {
+		t.Errorf("Expected default_view to be components, got %q", cfg.DefaultView)
+	}
+}
+
+func TestConfigRejectsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("default_view: sideways\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadConfigFile(path); err == nil {
+		t.Error("Expected an error for an invalid default_view")
+	}
+}
+
+func TestMissingConfigFileIsEmpty(t *testing.T) {
+	cfg, err := loadConfigFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("Expected no error for a missing config, got %v", err)
+	}
+	if cfg.DefaultView != "" || cfg.Debug {
+		t.Errorf("Expected an empty config, got %+v", cfg)
+	}
+}
+
+func TestFileCredentialStore(t *testing.T) {
+	dir := t.TempDir()
+	sealer, err := newAESSealer(filepath.Join(dir, "credentials.key"))
+	if err != nil {
+		t.Fatalf("Error creating sealer: %v", err)
+	}
+	store := &fileCredentialStore{path: filepath.Join(dir, "credentials.yaml"), sealer: sealer}
+
+	if err := store.set("petstore", "secret-token"); err != nil {
+		t.Fatalf("Error storing credential: %v", err)
+	}
+	content, err := os.ReadFile(store.path)
+	if err != nil {
+		t.Fatalf("Error reading credentials file: %v", err)
+	}
+	if strings.Contains(string(content), "secret-token") {
+		t.Error("Expected the credentials file not to contain the plaintext value")
+	}
+
+	if value, err := store.get("petstore"); err != nil || value != "secret-token" {
+		t.Errorf("Expected secret-token, got %q (%v)", value, err)
+	}
+	if err := store.delete("petstore"); err != nil {
+		t.Fatalf("Error deleting credential: %v", err)
+	}
+	if _, err := store.get("petstore"); !errors.Is(err, errCredentialNotFound) {
+		t.Errorf("Expected errCredentialNotFound after delete, got %v", err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	for value, want := range map[string]uint64{"": 0, "2048": 2048, "512KB": 512 << 10, "2MB": 2 << 20, "1.5 MiB": 3 << 19, "1g": 1 << 30} {
+		got, err := parseByteSize(value)
+		if err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v, expected %d", value, got, err, want)
+		}
+	}
+	if _, err := parseByteSize("lots"); err == nil {
+		t.Error("Expected an error for an invalid size")
+	}
+}
+
+func TestKeyBindingsAndCurlSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oq", "config.yaml")
+	if code := configSet(path, "key_bindings", "ctrl+j=j,q=none"); code != 0 {
+		t.Fatalf("Expected set to succeed, got exit code %d", code)
+	}
+	if code := configSet(path, "key_bindings", "ctrl+j=fly"); code == 0 {
+		t.Error("Expected set to reject an unknown built-in key")
+	}
+	if code := configSet(path, "theme", "neon"); code == 0 {
+		t.Error("Expected set to reject an unknown theme")
+	}
+	if code := configSet(path, "default_server", "localhost:8080"); code == 0 {
+		t.Error("Expected set to reject a server without a scheme")
+	}
+
+	cfg := &Config{Keymap: "emacs", KeyBindings: map[string]string{"q": "none", "ctrl+n": "j"}}
+	m := Model{keyBindings: keyBindingsFromConfig(cfg)}
+	for key, want := range map[string]string{"ctrl+n": "j", "ctrl+p": "up", "q": "", "x": "x"} {
+		if got := m.bindKey(key); got != want {
+			t.Errorf("bindKey(%q) = %q, expected %q", key, got, want)
+		}
+	}
+
+	ep := endpoint{path: "/pets", method: "GET", op: &v3.Operation{}}
+	curl := generateCurl(ep, &v3.Document{}, curlSettings{server: "http://localhost:8080/", options: "-sS"})
+	if !strings.HasPrefix(curl, "curl -sS -X GET 'http://localhost:8080/pets'") {
+		t.Errorf("Expected the configured server and options, got %q", curl)
+	}
+}
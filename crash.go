@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// exitCrashed is returned when oq recovered from a panic, EX_SOFTWARE from sysexits.h so
+// scripts can tell a crash from the usage errors that exit with 2
+const exitCrashed = 70
+
+// crashReporter records the first panic raised anywhere in oq and writes it to a crash report.
+// The report contains a fingerprint of the spec, never its content, so it is safe to attach
+// to a public issue
+type crashReporter struct {
+	mu          sync.Mutex
+	value       any
+	stack       []byte
+	fingerprint string
+}
+
+// setSpec records the fingerprint of the loaded spec for inclusion in the report
+func (c *crashReporter) setSpec(content []byte) {
+	sum := sha256.Sum256(content)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fingerprint = fmt.Sprintf("sha256:%s (%d bytes)", hex.EncodeToString(sum[:8]), len(content))
+}
+
+func (c *crashReporter) record(value any, stack []byte) {
+	if p, ok := value.(*backgroundPanic); ok {
+		value, stack = p.value, p.stack
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.value == nil {
+		c.value = value
+		c.stack = stack
+	}
+}
+
+// capture must be deferred directly. It records a panic and re-raises it so bubbletea can
+// still restore the terminal before the program exits
+func (c *crashReporter) capture() {
+	if r := recover(); r != nil {
+		c.record(r, debug.Stack())
+		panic(r)
+	}
+}
+
+func (c *crashReporter) crashed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value != nil
+}
+
+// report writes the crash report to a temp file, tells the user where it is and returns the exit code
+func (c *crashReporter) report() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(os.Stderr, "\noq crashed: %v\n", c.value)
+
+	f, err := os.CreateTemp("", "oq-crash-*.txt")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to write crash report: %v\n\n%s", err, c.stack)
+		return exitCrashed
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "oq crash report\n\n")
+	fmt.Fprintf(f, "Time:    %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "Version: %s\n", buildVersion())
+	fmt.Fprintf(f, "Go:      %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(f, "Args:    %d\n", len(os.Args)-1)
+	if c.fingerprint != "" {
+		fmt.Fprintf(f, "Spec:    %s\n", c.fingerprint)
+	}
+	fmt.Fprintf(f, "\nPanic: %v\n\n%s", c.value, c.stack)
+
+	fmt.Fprintf(os.Stderr, "A crash report was written to %s\n", f.Name())
+	fmt.Fprintf(os.Stderr, "Please attach it when opening an issue at https://github.com/plutov/oq/issues\n")
+	return exitCrashed
+}
+
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" {
+		return "unknown"
+	}
+	return strings.TrimSpace(info.Main.Version)
+}
+
+// guardedModel wraps the TUI model so panics in Update, View, and commands are recorded
+// by the crash reporter before bubbletea's own recovery restores the terminal
+type guardedModel struct {
+	tea.Model
+	crash *crashReporter
+}
+
+func (g guardedModel) Init() tea.Cmd {
+	defer g.crash.capture()
+	return g.wrap(g.Model.Init())
+}
+
+func (g guardedModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer g.crash.capture()
+	m, cmd := g.Model.Update(msg)
+	return guardedModel{Model: m, crash: g.crash}, g.wrap(cmd)
+}
+
+func (g guardedModel) View() string {
+	defer g.crash.capture()
+	return g.Model.View()
+}
+
+func (g guardedModel) wrap(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		defer g.crash.capture()
+		msg := cmd()
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			wrapped := make(tea.BatchMsg, len(batch))
+			for i, c := range batch {
+				wrapped[i] = g.wrap(c)
+			}
+			return wrapped
+		}
+		return msg
+	}
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"go.yaml.in/yaml/v4"
+)
+
+// credentialService is the service name secrets are stored under in the OS keychain
+const credentialService = "oq"
+
+var errCredentialNotFound = errors.New("credential not found")
+
+// credentialStore keeps environment tokens and API keys out of the plaintext config
+type credentialStore interface {
+	name() string
+	get(key string) (string, error)
+	set(key, value string) error
+	delete(key string) error
+}
+
+// openCredentialStore returns the store selected by the credential_store setting. By default
+// the OS keychain is used when available, with the encrypted file as a fallback
+func openCredentialStore(cfg *Config) (credentialStore, error) {
+	switch cfg.CredentialStore {
+	case "", "auto":
+		if store, ok := newKeychainStore(); ok {
+			return store, nil
+		}
+		return newFileCredentialStore()
+	case "keychain":
+		if store, ok := newKeychainStore(); ok {
+			return store, nil
+		}
+		return nil, fmt.Errorf("no OS keychain available, set credential_store to file or auto")
+	case "file":
+		return newFileCredentialStore()
+	}
+	return nil, fmt.Errorf("unknown credential store %q", cfg.CredentialStore)
+}
+
+// sealer encrypts values for the credentials file
+type sealer interface {
+	seal(plaintext []byte) ([]byte, error)
+	open(ciphertext []byte) ([]byte, error)
+}
+
+// fileCredentialStore keeps sealed values in credentials.yaml next to the config file
+type fileCredentialStore struct {
+	path   string
+	sealer sealer
+}
+
+func newFileCredentialStore() (credentialStore, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	s, err := newAESSealer(filepath.Join(dir, "credentials.key"))
+	if err != nil {
+		return nil, err
+	}
+	return &fileCredentialStore{path: filepath.Join(dir, "credentials.yaml"), sealer: s}, nil
+}
+
+func (s *fileCredentialStore) name() string {
+	return "encrypted file " + s.path
+}
+
+func (s *fileCredentialStore) load() (map[string]string, error) {
+	entries := map[string]string{}
+	content, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %w", s.path, err)
+	}
+	return entries, nil
+}
+
+func (s *fileCredentialStore) save(entries map[string]string) error {
+	content, err := yaml.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, content, 0o600)
+}
+
+func (s *fileCredentialStore) get(key string) (string, error) {
+	entries, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	encoded, ok := entries[key]
+	if !ok {
+		return "", errCredentialNotFound
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("corrupt credential %q: %w", key, err)
+	}
+	value, err := s.sealer.open(sealed)
+	if err != nil {
+		return "", fmt.Errorf("Error decrypting credential %q: %w", key, err)
+	}
+	return string(value), nil
+}
+
+func (s *fileCredentialStore) set(key, value string) error {
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	sealed, err := s.sealer.seal([]byte(value))
+	if err != nil {
+		return err
+	}
+	entries[key] = base64.StdEncoding.EncodeToString(sealed)
+	return s.save(entries)
+}
+
+func (s *fileCredentialStore) delete(key string) error {
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[key]; !ok {
+		return errCredentialNotFound
+	}
+	delete(entries, key)
+	return s.save(entries)
+}
+
+func (s *fileCredentialStore) keys() ([]string, error) {
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// aesSealer encrypts with AES-GCM using a random key kept in a separate 0600 file. It keeps
+// secrets out of the config and backups of it, but not away from someone who can read both files
+type aesSealer struct {
+	key []byte
+}
+
+func newAESSealer(keyPath string) (*aesSealer, error) {
+	key, err := os.ReadFile(keyPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(keyPath), 0o700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(keyPath, key, 0o600); err != nil {
+			return nil, err
+		}
+		return &aesSealer{key: key}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid credentials key in %s", keyPath)
+	}
+	return &aesSealer{key: key}, nil
+}
+
+func (s *aesSealer) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *aesSealer) seal(plaintext []byte) ([]byte, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (s *aesSealer) open(ciphertext []byte) ([]byte, error) {
+	gcm, err := s.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, nil)
+}
+
+// readSecret reads a secret from the terminal without echoing it, or from stdin when piped
+func readSecret(prompt string) (string, error) {
+	if term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Fprint(os.Stderr, prompt)
+		value, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		return string(value), err
+	}
+	value, err := io.ReadAll(os.Stdin)
+	return strings.TrimRight(string(value), "\r\n"), err
+}
+
+// runCredentials implements `oq credentials set|get|delete|list`
+func runCredentials(cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("credentials", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq credentials set <name>     (reads the value from the terminal or stdin)\n")
+		fmt.Fprintf(fs.Output(), "       oq credentials get <name>\n")
+		fmt.Fprintf(fs.Output(), "       oq credentials delete <name>\n")
+		fmt.Fprintf(fs.Output(), "       oq credentials list            (encrypted file store only)\n")
+		fmt.Fprintf(fs.Output(), "       oq credentials where\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	store, err := openCredentialStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening credential store: %v\n", err)
+		return 1
+	}
+
+	switch cmd, rest := fs.Arg(0), fs.Args()[1:]; {
+	case cmd == "where" && len(rest) == 0:
+		fmt.Println(store.name())
+		return 0
+	case cmd == "set" && len(rest) == 1:
+		value, err := readSecret(fmt.Sprintf("Value for %s: ", rest[0]))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading value: %v\n", err)
+			return 1
+		}
+		if value == "" {
+			fmt.Fprintf(os.Stderr, "Error: empty value\n")
+			return 1
+		}
+		if err := store.set(rest[0], value); err != nil {
+			fmt.Fprintf(os.Stderr, "Error storing %s: %v\n", rest[0], err)
+			return 1
+		}
+		return 0
+	case cmd == "get" && len(rest) == 1:
+		value, err := store.get(rest[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", rest[0], err)
+			return 1
+		}
+		fmt.Println(value)
+		return 0
+	case cmd == "delete" && len(rest) == 1:
+		if err := store.delete(rest[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error deleting %s: %v\n", rest[0], err)
+			return 1
+		}
+		return 0
+	case cmd == "list" && len(rest) == 0:
+		file, ok := store.(*fileCredentialStore)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: listing is not supported by the %s\n", store.name())
+			return 1
+		}
+		keys, err := file.keys()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading credentials: %v\n", err)
+			return 1
+		}
+		for _, key := range keys {
+			fmt.Println(key)
+		}
+		return 0
+	}
+
+	fs.Usage()
+	return 2
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// debugLog is the structured debug logger. The TUI owns the terminal, so logs are written
+// to a file, and they are discarded entirely unless --debug is set
+var debugLog = slog.New(slog.DiscardHandler)
+
+// defaultDebugLogPath is in the user's state directory rather than a shared temporary
+// directory, where another user could create the file or a link in its place first
+func defaultDebugLogPath() string {
+	dir, err := stateDir()
+	if err != nil {
+		return "oq-debug.log"
+	}
+	return filepath.Join(dir, "debug.log")
+}
+
+// enableDebugLog points debugLog at the given file, appending to it so that several
+// runs can be compared in one bug report
+func enableDebugLog(path string) (io.Closer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	debugLog = slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	debugLog.Info("debug logging enabled", "version", buildVersion())
+	return f, nil
+}
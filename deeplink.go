@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// findOperation resolves an --open target: "METHOD /path", a bare path for its first
+// operation, or an operationId. Paths match with the parameter names ignored, and concrete
+// paths such as /users/42 match their template, so targets can come from logs
+func findOperation(eps []endpoint, target string) (endpoint, error) {
+	target = strings.TrimSpace(target)
+	method, path, ok := strings.Cut(target, " ")
+	if !ok {
+		method, path = "", target
+	}
+	method, path = strings.ToUpper(method), strings.TrimSpace(path)
+
+	if !strings.HasPrefix(path, "/") {
+		if method == "" {
+			for _, ep := range eps {
+				if ep.op.OperationId == target {
+					return ep, nil
+				}
+			}
+		}
+		return endpoint{}, fmt.Errorf("Error: no operation matches %q, expected \"METHOD /path\", a path or an operationId", target)
+	}
+
+	for _, match := range []func(template string) bool{
+		func(template string) bool { return template == path },
+		func(template string) bool { return pathSegmentsMatch(template, path, false) },
+		func(template string) bool { return pathSegmentsMatch(template, path, true) },
+	} {
+		for _, ep := range eps {
+			if (method == "" || ep.method == method) && match(ep.path) {
+				return ep, nil
+			}
+		}
+	}
+	return endpoint{}, fmt.Errorf("Error: no operation matches %q", target)
+}
+
+// pathSegmentsMatch compares a path template with a path segment by segment. Parameters
+// in the template match parameters of any name, or with concrete any value
+func pathSegmentsMatch(template, path string, concrete bool) bool {
+	want, got := strings.Split(template, "/"), strings.Split(path, "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		param := strings.HasPrefix(want[i], "{") && strings.HasSuffix(want[i], "}")
+		switch {
+		case want[i] == got[i]:
+		case param && strings.HasPrefix(got[i], "{") && strings.HasSuffix(got[i], "}"):
+		case param && concrete && got[i] != "":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// findComponent resolves an --open-component target: a name, preferring schemas when
+// several kinds of component share it, "schemas/User" or "#/components/schemas/User"
+func findComponent(comps []component, target string) (component, error) {
+	name := strings.TrimPrefix(strings.TrimSpace(target), "#/components/")
+	compType := ""
+	if kind, rest, ok := strings.Cut(name, "/"); ok {
+		if t, known := componentTypes[kind]; known {
+			compType, name = t, rest
+		}
+	}
+
+	var matches []component
+	for _, comp := range comps {
+		if comp.name == name && (compType == "" || comp.compType == compType) {
+			matches = append(matches, comp)
+		}
+	}
+	if len(matches) == 0 {
+		return component{}, fmt.Errorf("Error: no component named %q", target)
+	}
+	if i := slices.IndexFunc(matches, func(comp component) bool { return comp.compType == "Schema" }); i >= 0 {
+		return matches[i], nil
+	}
+	return matches[0], nil
+}
+
+// openTarget starts the TUI on the operation or component named by --open or
+// --open-component, unfolded and selected
+func (m *Model) openTarget(operation, componentName string) error {
+	if operation != "" {
+		ep, err := findOperation(m.endpoints, operation)
+		if err != nil {
+			return err
+		}
+		m.jumpToEndpoint(ep)
+	}
+	if componentName != "" {
+		m.loadComponents()
+		comp, err := findComponent(m.components, componentName)
+		if err != nil {
+			return err
+		}
+		m.jumpToComponent(comp.compType, comp.name)
+	}
+	return nil
+}
@@ -0,0 +1,651 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+)
+
+type changeKind string
+
+const (
+	changeAdded   changeKind = "added"
+	changeRemoved changeKind = "removed"
+	changeChanged changeKind = "changed"
+)
+
+// specChange is one difference between two versions of a spec. Breaking changes can fail
+// existing clients, such as removed operations or newly required parameters
+type specChange struct {
+	kind     changeKind
+	target   string
+	location string
+	message  string
+	breaking bool
+}
+
+func (c specChange) marker() string {
+	switch c.kind {
+	case changeAdded:
+		return "+"
+	case changeRemoved:
+		return "-"
+	}
+	return "~"
+}
+
+// maxDiffDepth bounds how deep inline schemas are compared
+const maxDiffDepth = 8
+
+type specDiff struct {
+	changes []specChange
+}
+
+func (d *specDiff) add(kind changeKind, target, location, message string, breaking bool) {
+	d.changes = append(d.changes, specChange{kind: kind, target: target, location: location, message: message, breaking: breaking})
+}
+
+func (d *specDiff) breaking() int {
+	n := 0
+	for _, c := range d.changes {
+		if c.breaking {
+			n++
+		}
+	}
+	return n
+}
+
+// diffSpecs compares operations with their parameters, request bodies and response codes,
+// then the component schemas
+func diffSpecs(before, after *v3.Document) *specDiff {
+	d := &specDiff{}
+
+	oldOps := map[string]endpoint{}
+	for _, ep := range extractEndpoints(before) {
+		oldOps[ep.method+" "+ep.path] = ep
+	}
+	newOps := map[string]endpoint{}
+	ordered := extractEndpoints(after)
+	for _, ep := range ordered {
+		newOps[ep.method+" "+ep.path] = ep
+	}
+	for _, ep := range extractEndpoints(before) {
+		if _, ok := newOps[ep.method+" "+ep.path]; !ok {
+			ordered = append(ordered, ep)
+		}
+	}
+	slices.SortStableFunc(ordered, func(a, b endpoint) int {
+		return strings.Compare(a.path, b.path)
+	})
+
+	for _, ep := range ordered {
+		key := ep.method + " " + ep.path
+		oldEp, inOld := oldOps[key]
+		newEp, inNew := newOps[key]
+		switch {
+		case !inOld:
+			d.add(changeAdded, "operation", key, "operation added", false)
+		case !inNew:
+			d.add(changeRemoved, "operation", key, "operation removed", true)
+		default:
+			d.diffOperation(key, before, after, oldEp, newEp)
+		}
+	}
+
+	oldSchemas, newSchemas := componentSchemas(before), componentSchemas(after)
+	var names []string
+	for name := range oldSchemas {
+		names = append(names, name)
+	}
+	for name := range newSchemas {
+		if _, ok := oldSchemas[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		oldSchema, newSchema := oldSchemas[name], newSchemas[name]
+		location := "schema " + name
+		switch {
+		case oldSchema == nil:
+			d.add(changeAdded, "schema", location, "schema added", false)
+		case newSchema == nil:
+			d.add(changeRemoved, "schema", location, "schema removed", true)
+		default:
+			d.diffSchema(location, "", "", oldSchema, newSchema, 0)
+		}
+	}
+	return d
+}
+
+func componentSchemas(doc *v3.Document) map[string]*base.SchemaProxy {
+	schemas := map[string]*base.SchemaProxy{}
+	if doc.Components != nil && doc.Components.Schemas != nil {
+		for pair := doc.Components.Schemas.First(); pair != nil; pair = pair.Next() {
+			schemas[pair.Key()] = pair.Value()
+		}
+	}
+	return schemas
+}
+
+func (d *specDiff) diffOperation(location string, before, after *v3.Document, oldEp, newEp endpoint) {
+	oldOp, newOp := oldEp.op, newEp.op
+	deprecated := func(op *v3.Operation) bool { return op.Deprecated != nil && *op.Deprecated }
+	if !deprecated(oldOp) && deprecated(newOp) {
+		d.add(changeChanged, "operation", location, "operation deprecated", false)
+	}
+
+	paramKey := func(p *v3.Parameter) string { return p.In + " parameter " + p.Name }
+	oldParams := map[string]*v3.Parameter{}
+	for _, p := range declaredParameters(before, oldEp) {
+		oldParams[paramKey(p)] = p
+	}
+	newParams := declaredParameters(after, newEp)
+	for _, p := range newParams {
+		old, ok := oldParams[paramKey(p)]
+		required := p.Required != nil && *p.Required
+		switch {
+		case !ok && required:
+			d.add(changeAdded, "parameter", location, "required "+paramKey(p)+" added", true)
+		case !ok:
+			d.add(changeAdded, "parameter", location, paramKey(p)+" added", false)
+		default:
+			wasRequired := old.Required != nil && *old.Required
+			if required && !wasRequired {
+				d.add(changeChanged, "parameter", location, paramKey(p)+" is now required", true)
+			} else if !required && wasRequired {
+				d.add(changeChanged, "parameter", location, paramKey(p)+" is now optional", false)
+			}
+			if from, to := resolvedSchemaType(old.Schema, 0), resolvedSchemaType(p.Schema, 0); from != to {
+				d.add(changeChanged, "parameter", location, fmt.Sprintf("%s type changed from %s to %s", paramKey(p), from, to), true)
+			}
+		}
+	}
+	for _, p := range declaredParameters(before, oldEp) {
+		if !slices.ContainsFunc(newParams, func(n *v3.Parameter) bool { return paramKey(n) == paramKey(p) }) {
+			d.add(changeRemoved, "parameter", location, paramKey(p)+" removed", true)
+		}
+	}
+
+	d.diffRequestBody(location, oldOp.RequestBody, newOp.RequestBody)
+
+	oldCodes, newCodes := responseCodes(oldOp), responseCodes(newOp)
+	for _, code := range newCodes {
+		if !slices.Contains(oldCodes, code) {
+			d.add(changeAdded, "response", location, "response "+code+" added", false)
+		}
+	}
+	for _, code := range oldCodes {
+		if !slices.Contains(newCodes, code) {
+			d.add(changeRemoved, "response", location, "response "+code+" removed", true)
+			continue
+		}
+		oldResponse, newResponse := operationResponse(oldOp, code), operationResponse(newOp, code)
+		if oldResponse != nil && newResponse != nil {
+			d.diffContent(location, "response "+code, oldResponse.Content, newResponse.Content)
+		}
+	}
+}
+
+// operationResponse returns the response of op for a code from responseCodes
+func operationResponse(op *v3.Operation, code string) *v3.Response {
+	if code == "default" {
+		return op.Responses.Default
+	}
+	return op.Responses.Codes.GetOrZero(code)
+}
+
+// diffContent compares the schemas of the media types both versions of a body have
+func (d *specDiff) diffContent(location, subject string, before, after *orderedmap.Map[string, *v3.MediaType]) {
+	if before == nil || after == nil {
+		return
+	}
+	for pair := before.First(); pair != nil; pair = pair.Next() {
+		if newMediaType := after.GetOrZero(pair.Key()); pair.Value() != nil && newMediaType != nil {
+			d.diffSchema(location, subject+" "+pair.Key(), "", pair.Value().Schema, newMediaType.Schema, 0)
+		}
+	}
+}
+
+func (d *specDiff) diffRequestBody(location string, before, after *v3.RequestBody) {
+	required := func(body *v3.RequestBody) bool { return body != nil && body.Required != nil && *body.Required }
+	switch {
+	case before == nil && after == nil:
+		return
+	case before == nil:
+		d.add(changeAdded, "request body", location, requestBodyLabel(required(after))+" added", required(after))
+		return
+	case after == nil:
+		d.add(changeRemoved, "request body", location, "request body removed", true)
+		return
+	}
+	if required(after) && !required(before) {
+		d.add(changeChanged, "request body", location, "request body is now required", true)
+	}
+
+	mediaTypes := func(body *v3.RequestBody) []string {
+		var types []string
+		if body.Content != nil {
+			for pair := body.Content.First(); pair != nil; pair = pair.Next() {
+				types = append(types, pair.Key())
+			}
+		}
+		return types
+	}
+	oldTypes, newTypes := mediaTypes(before), mediaTypes(after)
+	for _, mediaType := range newTypes {
+		if !slices.Contains(oldTypes, mediaType) {
+			d.add(changeAdded, "request body", location, "request body "+mediaType+" added", false)
+		}
+	}
+	for _, mediaType := range oldTypes {
+		if !slices.Contains(newTypes, mediaType) {
+			d.add(changeRemoved, "request body", location, "request body "+mediaType+" removed", true)
+		}
+	}
+	d.diffContent(location, "request body", before.Content, after.Content)
+}
+
+func requestBodyLabel(required bool) string {
+	if required {
+		return "required request body"
+	}
+	return "request body"
+}
+
+// schemaType describes the type of a schema, or the schema it refers to
+func schemaType(proxy *base.SchemaProxy) string {
+	if proxy == nil {
+		return "any"
+	}
+	if proxy.IsReference() {
+		if name, ok := componentSchemaName(proxy.GetReference()); ok {
+			return name
+		}
+		return proxy.GetReference()
+	}
+	schema := proxy.Schema()
+	if schema == nil || len(schema.Type) == 0 {
+		return "any"
+	}
+	if slices.Contains(schema.Type, "array") && schema.Items != nil && schema.Items.IsA() {
+		return "array of " + schemaType(schema.Items.A)
+	}
+	return strings.Join(schema.Type, "|")
+}
+
+// resolvedSchemaType describes the type of a schema after following its $ref, so moving a
+// schema into a component doesn't change its type
+func resolvedSchemaType(proxy *base.SchemaProxy, depth int) string {
+	if proxy == nil {
+		return "any"
+	}
+	schema := proxy.Schema()
+	if schema == nil || len(schema.Type) == 0 {
+		return "any"
+	}
+	if slices.Contains(schema.Type, "array") && schema.Items != nil && schema.Items.IsA() && depth < maxDiffDepth {
+		return "array of " + resolvedSchemaType(schema.Items.A, depth+1)
+	}
+	return strings.Join(schema.Type, "|")
+}
+
+// diffSchema compares the resolved types, properties, required properties and enums of two
+// schemas. subject is the body they describe, such as request body application/json, and
+// empty for components. Where both refer to the same component, it is diffed on its own
+func (d *specDiff) diffSchema(location, subject, path string, before, after *base.SchemaProxy, depth int) {
+	label := func(path, what string) string {
+		if path != "" {
+			what = "property " + path + " " + what
+		}
+		if subject != "" {
+			what = subject + " " + what
+		}
+		return what
+	}
+	if from, to := resolvedSchemaType(before, 0), resolvedSchemaType(after, 0); from != to {
+		d.add(changeChanged, "schema", location, label(path, fmt.Sprintf("type changed from %s to %s", from, to)), true)
+		return
+	}
+	if before == nil || after == nil || depth > maxDiffDepth {
+		return
+	}
+	if before.IsReference() && after.IsReference() && before.GetReference() == after.GetReference() {
+		if _, ok := componentSchemaName(before.GetReference()); ok {
+			return
+		}
+	}
+	oldSchema, newSchema := before.Schema(), after.Schema()
+	if oldSchema == nil || newSchema == nil {
+		return
+	}
+
+	for _, value := range enumValues(oldSchema) {
+		if !slices.Contains(enumValues(newSchema), value) {
+			d.add(changeRemoved, "schema", location, label(path, "enum value "+value+" removed"), true)
+		}
+	}
+	for _, value := range enumValues(newSchema) {
+		if !slices.Contains(enumValues(oldSchema), value) {
+			d.add(changeAdded, "schema", location, label(path, "enum value "+value+" added"), false)
+		}
+	}
+
+	child := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+	oldProps, newProps := schemaProperties(oldSchema), schemaProperties(newSchema)
+	for _, name := range propertyNames(newSchema) {
+		if _, ok := oldProps[name]; !ok {
+			d.add(changeAdded, "property", location, label(child(name), "added"), slices.Contains(newSchema.Required, name))
+		}
+	}
+	for _, name := range propertyNames(oldSchema) {
+		newProp, ok := newProps[name]
+		if !ok {
+			d.add(changeRemoved, "property", location, label(child(name), "removed"), true)
+			continue
+		}
+		d.diffSchema(location, subject, child(name), oldProps[name], newProp, depth+1)
+	}
+	for _, name := range newSchema.Required {
+		if _, existed := oldProps[name]; existed && !slices.Contains(oldSchema.Required, name) {
+			d.add(changeChanged, "property", location, label(child(name), "is now required"), true)
+		}
+	}
+	for _, name := range oldSchema.Required {
+		if _, kept := newProps[name]; kept && !slices.Contains(newSchema.Required, name) {
+			d.add(changeChanged, "property", location, label(child(name), "is now optional"), false)
+		}
+	}
+
+	if oldSchema.Items != nil && newSchema.Items != nil && oldSchema.Items.IsA() && newSchema.Items.IsA() {
+		d.diffSchema(location, subject, child("[]"), oldSchema.Items.A, newSchema.Items.A, depth+1)
+	}
+}
+
+func schemaProperties(schema *base.Schema) map[string]*base.SchemaProxy {
+	props := map[string]*base.SchemaProxy{}
+	if schema.Properties != nil {
+		for pair := schema.Properties.First(); pair != nil; pair = pair.Next() {
+			props[pair.Key()] = pair.Value()
+		}
+	}
+	return props
+}
+
+func propertyNames(schema *base.Schema) []string {
+	var names []string
+	if schema.Properties != nil {
+		for pair := schema.Properties.First(); pair != nil; pair = pair.Next() {
+			names = append(names, pair.Key())
+		}
+	}
+	return names
+}
+
+func enumValues(schema *base.Schema) []string {
+	var values []string
+	for _, node := range schema.Enum {
+		if node != nil {
+			values = append(values, node.Value)
+		}
+	}
+	return values
+}
+
+// diffFormats are the values `oq diff --format` accepts, without one the TUI opens
+var diffFormats = []string{"text", "json", "markdown"}
+
+func writeDiffText(w io.Writer, d *specDiff) {
+	if len(d.changes) == 0 {
+		fmt.Fprintln(w, "No changes")
+		return
+	}
+	for _, c := range d.changes {
+		line := fmt.Sprintf("%s %s: %s", c.marker(), c.location, c.message)
+		if c.breaking {
+			line += " (breaking)"
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "\n%s\n", diffSummary(d))
+}
+
+func diffSummary(d *specDiff) string {
+	return fmt.Sprintf("%d %s, %d breaking", len(d.changes), plural(len(d.changes), "change", "changes"), d.breaking())
+}
+
+// jsonSpecChange is a change in `oq diff --format json`
+type jsonSpecChange struct {
+	Kind     string `json:"kind"`
+	Target   string `json:"target"`
+	Location string `json:"location"`
+	Message  string `json:"message"`
+	Breaking bool   `json:"breaking"`
+}
+
+func writeDiffJSON(w io.Writer, d *specDiff) error {
+	out := struct {
+		Breaking int              `json:"breaking"`
+		Changes  []jsonSpecChange `json:"changes"`
+	}{Breaking: d.breaking(), Changes: []jsonSpecChange{}}
+	for _, c := range d.changes {
+		out.Changes = append(out.Changes, jsonSpecChange{Kind: string(c.kind), Target: c.target, Location: c.location, Message: c.message, Breaking: c.breaking})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// writeDiffMarkdown lists breaking changes first, for pull request comments
+func writeDiffMarkdown(w io.Writer, d *specDiff) {
+	fmt.Fprintf(w, "## API changes\n\n")
+	if len(d.changes) == 0 {
+		fmt.Fprintf(w, "No changes\n")
+		return
+	}
+	fmt.Fprintf(w, "%s\n", diffSummary(d))
+	for _, section := range []struct {
+		title    string
+		breaking bool
+	}{{"Breaking changes", true}, {"Other changes", false}} {
+		var lines []string
+		for _, c := range d.changes {
+			if c.breaking == section.breaking {
+				lines = append(lines, fmt.Sprintf("- `%s` %s", c.location, c.message))
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(w, "\n### %s\n\n%s\n", section.title, strings.Join(lines, "\n"))
+		}
+	}
+}
+
+// runDiff implements `oq diff old.yaml new.yaml`, opening the changes in a TUI or printing
+// them with --format. --fail-on-breaking exits with 1 when a change is breaking. The old
+// spec may be the directory of a generated client, to find the drift of its embedded spec
+func runDiff(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := fs.String("format", "", "print the changes as text, json or markdown instead of opening the TUI")
+	failOnBreaking := fs.Bool("fail-on-breaking", false, "exit with 1 when there are breaking changes")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq diff [--format text|json|markdown] [--fail-on-breaking] <old spec or client directory> <new spec>\n\n")
+		fs.PrintDefaults()
+	}
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) != 2 || (*format != "" && !slices.Contains(diffFormats, *format)) {
+		fs.Usage()
+		return 2
+	}
+
+	// A directory is a generated client, compared by the spec baked into it
+	var before *v3.Document
+	info, err := os.Stat(args[0])
+	client := err == nil && info.IsDir()
+	if client {
+		before, err = loadClientSpec(ctx, args[0])
+	} else {
+		_, before, err = loadSpec(ctx, args[0])
+	}
+	if err != nil {
+		return reportError(err)
+	}
+	_, after, err := loadSpec(ctx, args[1])
+	if err != nil {
+		return reportError(err)
+	}
+	d := diffSpecs(before, after)
+	if client {
+		d.forClient()
+	}
+
+	switch *format {
+	case "json":
+		if err := writeDiffJSON(os.Stdout, d); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			return 1
+		}
+	case "markdown":
+		writeDiffMarkdown(os.Stdout, d)
+	case "text":
+		writeDiffText(os.Stdout, d)
+	default:
+		if !term.IsTerminal(os.Stdout.Fd()) || len(d.changes) == 0 {
+			writeDiffText(os.Stdout, d)
+			break
+		}
+		model := diffModel{diff: d, title: args[0] + " → " + args[1], width: 80, height: 24}
+		if _, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); err != nil {
+			if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+				return exitCancelled
+			}
+			fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+			return 1
+		}
+	}
+
+	if *failOnBreaking && d.breaking() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// diffModel is the TUI listing the changes between two specs
+type diffModel struct {
+	diff          *specDiff
+	title         string
+	breakingOnly  bool
+	cursor        int
+	width, height int
+}
+
+func (m diffModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m diffModel) visible() []specChange {
+	if !m.breakingOnly {
+		return m.diff.changes
+	}
+	var changes []specChange
+	for _, c := range m.diff.changes {
+		if c.breaking {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+func (m diffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		last := max(0, len(m.visible())-1)
+		page := max(1, m.height/2)
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			m.cursor = max(0, m.cursor-1)
+		case "down", "j":
+			m.cursor = min(last, m.cursor+1)
+		case "ctrl+u":
+			m.cursor = max(0, m.cursor-page)
+		case "ctrl+d":
+			m.cursor = min(last, m.cursor+page)
+		case "g":
+			m.cursor = 0
+		case "G":
+			m.cursor = last
+		case "b":
+			m.breakingOnly = !m.breakingOnly
+			m.cursor = 0
+		}
+	}
+	return m, nil
+}
+
+func (m diffModel) View() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	markerColors := map[changeKind]string{changeAdded: colorGreen, changeRemoved: colorRed, changeChanged: colorYellow}
+	breakingStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorRed)).Bold(true)
+
+	changes := m.visible()
+	locationWidth := min(max(20, m.width/3), 50)
+	bodyHeight := max(1, m.height-5)
+	start := max(0, min(m.cursor-bodyHeight+1, len(changes)-bodyHeight))
+	var lines []string
+	for i := start; i < len(changes) && i < start+bodyHeight; i++ {
+		c := changes[i]
+		background := lipgloss.NewStyle()
+		if i == m.cursor {
+			background = highlight(background)
+		}
+		line := background.Foreground(lipgloss.Color(markerColors[c.kind])).Bold(true).Render(c.marker() + " ")
+		line += background.Width(locationWidth).MaxWidth(locationWidth).Render(c.location) + background.Render("  ")
+		line += background.Render(c.message)
+		if c.breaking {
+			line += background.Render("  ") + breakingStyle.Inherit(background).Render("BREAKING")
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(m.width).Render(line))
+	}
+	if len(changes) == 0 {
+		lines = append(lines, instructionStyle.Render("No breaking changes"))
+	}
+
+	title := titleStyle.Render(fmt.Sprintf("%s: %s", m.title, diffSummary(m.diff)))
+	filter := "b breaking only"
+	if m.breakingOnly {
+		filter = "b all changes"
+	}
+	instruction := instructionStyle.Render("j/k move · " + filter + " · q quit")
+	return title + "\n\n" + strings.Join(lines, "\n") + "\n\n" + instruction
+}
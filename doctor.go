@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// doctorHint is a finding of `oq doctor` with the feature that helps with it. Field names
+// are part of the JSON output
+type doctorHint struct {
+	Check      string `json:"check"`
+	Finding    string `json:"finding"`
+	Suggestion string `json:"suggestion"`
+}
+
+// doctorCheck is a check `oq doctor` can run, in the order of its suggestions
+type doctorCheck struct {
+	name        string
+	description string
+}
+
+var doctorChecks = []doctorCheck{
+	{"swagger", "Swagger 2.0 specs"},
+	{"refs", "$refs to other files or URLs"},
+	{"webhooks", "Webhooks"},
+	{"tags", "Tags to group endpoints by"},
+	{"deprecated", "Deprecated operations"},
+	{"examples", "Operations without examples"},
+	{"media", "Non-JSON request or response bodies"},
+	{"servers", "Several servers"},
+	{"ratelimit", "Documented rate limits"},
+	{"oauth", "OAuth2 flows oq can get tokens with"},
+	{"scopes", "OAuth scopes"},
+	{"recursion", "Recursive schemas"},
+	{"pii", "Properties that look like PII"},
+	{"duplicates", "Duplicate schemas"},
+	{"inline", "Repeated inline schemas"},
+}
+
+// parseDoctorChecks parses the comma-separated check names of --checks
+func parseDoctorChecks(value string) ([]string, error) {
+	var checks []string
+	for name := range strings.SplitSeq(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.ContainsFunc(doctorChecks, func(c doctorCheck) bool { return c.name == name }) {
+			return nil, fmt.Errorf("Error: unknown check %q", name)
+		}
+		checks = append(checks, name)
+	}
+	return checks, nil
+}
+
+// diagnoseSpec inspects a spec for what oq has a view or command for, in the order a new
+// user is likely to need them. root is the parsed content, which the checks may modify.
+// Only the named checks run, or all of them when checks is nil
+func diagnoseSpec(doc *v3.Document, root *yaml.Node, cfg *Config, checks []string) []doctorHint {
+	run := func(check string) bool {
+		return checks == nil || slices.Contains(checks, check)
+	}
+	var hints []doctorHint
+	hint := func(check, finding, suggestion string) {
+		if run(check) {
+			hints = append(hints, doctorHint{Check: check, Finding: finding, Suggestion: suggestion})
+		}
+	}
+	eps := extractEndpoints(doc)
+
+	if mappingValue(root.Content[0], "swagger") != nil {
+		hint("swagger", "Swagger 2.0 spec", "oq converts it to OpenAPI 3.0 when loading, so every view and command works on it")
+	}
+	if refs := externalRefs(root); len(refs) > 0 && !resolveRefs {
+		hint("refs", fmt.Sprintf("%d %s to other files or URLs", len(refs), plural(len(refs), "$ref", "$refs")), "run with --resolve-refs to follow them")
+	}
+	if hooks := extractWebhooks(doc); len(hooks) > 0 {
+		hint("webhooks", fmt.Sprintf("%d %s", len(hooks), plural(len(hooks), "webhook", "webhooks")), "press Tab to open the Webhooks view")
+	}
+
+	tags := map[string]bool{}
+	var deprecated, missingExamples, nonJSON int
+	for _, ep := range eps {
+		for _, tag := range ep.op.Tags {
+			tags[tag] = true
+		}
+		if isDeprecated(ep.op) {
+			deprecated++
+		}
+		if countExamples(ep.op).missing() {
+			missingExamples++
+		}
+		for contentType := range operationMediaTypes(ep.op) {
+			if !isJSONMediaType(contentType) {
+				nonJSON++
+				break
+			}
+		}
+	}
+	if len(tags) > 1 {
+		hint("tags", fmt.Sprintf("%d tags", len(tags)), "press t to group endpoints by tag, or run oq split --by tag for a spec per tag")
+	}
+	if deprecated > 0 {
+		hint("deprecated", fmt.Sprintf("%d deprecated %s", deprecated, plural(deprecated, "operation", "operations")), "press X to show only deprecated items, and D for the overview with their sunsets")
+	}
+	if missingExamples > 0 {
+		hint("examples", fmt.Sprintf("%d %s without examples", missingExamples, plural(missingExamples, "operation", "operations")), "press e to list them, snippets and requests use examples generated from the schemas")
+	}
+	if nonJSON > 0 {
+		hint("media", fmt.Sprintf("%d %s with non-JSON bodies", nonJSON, plural(nonJSON, "operation", "operations")), "press M or run oq media to see the content types a client needs")
+	}
+
+	if servers := collectServers(doc, eps); len(servers) > 1 {
+		hint("servers", fmt.Sprintf("%d servers", len(servers)), "press S to pick the one requests go to, or run oq compare to compare their responses")
+	}
+	if !documentRateLimit(doc).empty() ||
+		slices.ContainsFunc(eps, func(ep endpoint) bool { return !findRateLimit(ep.op.Extensions).empty() }) {
+		hint("ratelimit", "documented rate limits", "oq compare paces its requests by them")
+	}
+
+	if doc.Components != nil && doc.Components.SecuritySchemes != nil {
+		var scoped, grants bool
+		for pair := doc.Components.SecuritySchemes.First(); pair != nil; pair = pair.Next() {
+			scoped = scoped || len(declaredScopes(pair.Value())) > 0
+			if _, ok := oauthGrantFor(pair.Key(), pair.Value(), nil); ok {
+				grants = true
+			}
+		}
+		if grants {
+			hint("oauth", "OAuth2 client credentials or device flow", "press x to send a request, oq gets a token and stores it with your credentials")
+		}
+		if scoped {
+			hint("scopes", "OAuth scopes", "press A or run oq scopes for the scopes each operation needs")
+		}
+	}
+
+	// The slower checks are skipped rather than filtered
+	if run("recursion") {
+		if cycles := schemaCycles(doc); len(cycles) > 0 {
+			hint("recursion", fmt.Sprintf("%d recursive %s", len(cycles), plural(len(cycles), "schema", "schemas")), "they are badged [recursive] in the Components view, with the cycle in their details")
+		}
+	}
+	if run("pii") {
+		if findings := scanPII(doc, eps, piiTermsFromConfig(cfg)); len(findings) > 0 {
+			hint("pii", fmt.Sprintf("%d %s like PII", len(findings), plural(len(findings), "property looks", "properties look")), "press P or run oq pii to review them")
+		}
+	}
+	if run("duplicates") {
+		if clusters := findDuplicateSchemas(root, 0.9); len(clusters) > 0 {
+			hint("duplicates", fmt.Sprintf("%d %s of duplicate schemas", len(clusters), plural(len(clusters), "group", "groups")), "run oq duplicates to see which to keep")
+		}
+	}
+	// Last, as extracting rewrites root
+	if run("inline") {
+		if extractions := extractInlineSchemas(root); len(extractions) > 0 {
+			hint("inline", fmt.Sprintf("%d repeated inline %s", len(extractions), plural(len(extractions), "schema", "schemas")), "run oq refactor extract-inline-schemas to move them to components")
+		}
+	}
+	return hints
+}
+
+// externalRefs lists the $refs of a parsed spec that point to other files or URLs
+func externalRefs(node *yaml.Node) []string {
+	var refs []string
+	var walk func(*yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode {
+			if ref := mappingValue(node, "$ref"); ref != nil && isExternalRef(ref.Value) {
+				refs = append(refs, ref.Value)
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(node)
+	return refs
+}
+
+func writeDoctorText(w io.Writer, doc *v3.Document, hints []doctorHint) {
+	title := "The spec"
+	if doc.Info != nil && doc.Info.Title != "" {
+		title = doc.Info.Title + " " + doc.Info.Version
+	}
+	if len(hints) == 0 {
+		fmt.Fprintf(w, "%s (OpenAPI %s) uses nothing oq has a dedicated view or command for\n", title, doc.Version)
+		return
+	}
+	fmt.Fprintf(w, "%s (OpenAPI %s): %d %s\n\n", title, doc.Version, len(hints), plural(len(hints), "suggestion", "suggestions"))
+	for _, hint := range hints {
+		fmt.Fprintf(w, "  %s — %s\n", hint.Finding, hint.Suggestion)
+	}
+}
+
+// runDoctor implements `oq doctor [--format text|json] [--checks names] [spec]`, suggesting
+// the views and commands that fit what the spec contains. On a terminal without --format or
+// --checks, it first asks which checks to run
+func runDoctor(ctx context.Context, cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	format := fs.String("format", "", "print the suggestions as text or json instead of choosing the checks first")
+	checksFlag := fs.String("checks", "", "comma-separated checks to run, default all")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq doctor [--format text|json] [--checks names] [spec]\n\n")
+		fs.PrintDefaults()
+		names := make([]string, len(doctorChecks))
+		for i, check := range doctorChecks {
+			names[i] = check.name
+		}
+		fmt.Fprintf(fs.Output(), "\nChecks: %s\n", strings.Join(names, ", "))
+	}
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) > 1 || (*format != "" && *format != "text" && *format != "json") {
+		fs.Usage()
+		return 2
+	}
+	checks, err := parseDoctorChecks(*checksFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	}
+	content, doc, err := loadSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil || len(root.Content) == 0 {
+		fmt.Fprintf(os.Stderr, "Error parsing spec: %v\n", err)
+		return 1
+	}
+
+	if *format == "" && checks == nil && term.IsTerminal(os.Stdout.Fd()) {
+		final, err := tea.NewProgram(newDoctorPicker(), tea.WithContext(ctx)).Run()
+		if err != nil {
+			if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+				return exitCancelled
+			}
+			fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+			return 1
+		}
+		picker := final.(doctorPicker)
+		if !picker.confirmed {
+			return 0
+		}
+		checks = picker.checks()
+	}
+
+	hints := diagnoseSpec(doc, &root, cfg, checks)
+	if *format == "json" {
+		if hints == nil {
+			hints = []doctorHint{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(hints); err != nil {
+			return reportError(err)
+		}
+		return 0
+	}
+	writeDoctorText(os.Stdout, doc, hints)
+	if checks != nil && *checksFlag == "" && len(checks) < len(doctorChecks) {
+		fmt.Printf("\nTo run these checks again: %s\n", strings.TrimSpace("oq doctor --checks "+strings.Join(checks, ",")+" "+path))
+	}
+	return 0
+}
+
+// doctorPicker asks which checks `oq doctor` runs, all of them selected at first
+type doctorPicker struct {
+	selected  []bool
+	cursor    int
+	confirmed bool
+}
+
+func newDoctorPicker() doctorPicker {
+	selected := make([]bool, len(doctorChecks))
+	for i := range selected {
+		selected[i] = true
+	}
+	return doctorPicker{selected: selected}
+}
+
+// checks returns the names of the selected checks, never nil so that none selected runs none
+func (m doctorPicker) checks() []string {
+	checks := []string{}
+	for i, check := range doctorChecks {
+		if m.selected[i] {
+			checks = append(checks, check.name)
+		}
+	}
+	return checks
+}
+
+func (m doctorPicker) Init() tea.Cmd {
+	return nil
+}
+
+func (m doctorPicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	last := len(doctorChecks) - 1
+	switch key.String() {
+	case "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	case "enter":
+		m.confirmed = true
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor = max(0, m.cursor-1)
+	case "down", "j":
+		m.cursor = min(last, m.cursor+1)
+	case "g":
+		m.cursor = 0
+	case "G":
+		m.cursor = last
+	case " ", "x":
+		m.selected[m.cursor] = !m.selected[m.cursor]
+	case "a":
+		// Select all, or none when all are selected already
+		all := !slices.Contains(m.selected, false)
+		for i := range m.selected {
+			m.selected[i] = !all
+		}
+	}
+	return m, nil
+}
+
+func (m doctorPicker) View() string {
+	if m.confirmed {
+		return ""
+	}
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	selected := len(m.checks())
+	lines := []string{titleStyle.Render(fmt.Sprintf("Choose the checks to run: %d of %d selected", selected, len(doctorChecks))), ""}
+	for i, check := range doctorChecks {
+		box := "[ ]"
+		if m.selected[i] {
+			box = "[x]"
+		}
+		style := lipgloss.NewStyle()
+		if i == m.cursor {
+			style = highlight(style)
+		}
+		lines = append(lines, style.Render(fmt.Sprintf("%s %-10s  %s", box, check.name, check.description)))
+	}
+	lines = append(lines, "", instructionStyle.Render("j/k move · space toggle · a all · enter run · q quit"))
+	return strings.Join(lines, "\n") + "\n"
+}
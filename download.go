@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxInlineResponseSize is the largest body the runner renders, larger ones are only saved
+const maxInlineResponseSize = 1 << 20
+
+// savePrompt asks where to save a response body, suggesting the name the server gave
+type savePrompt struct {
+	input textinput.Model
+	// exists is set once the file was found to exist, Enter again overwrites it
+	exists bool
+}
+
+// shownInline reports whether the runner renders a response body, rather than offering to
+// save it: binary bodies other than images and bodies over maxInlineResponseSize aren't shown
+func (r *runResult) shownInline() bool {
+	if r.size > maxInlineResponseSize {
+		return false
+	}
+	mediaType := baseMediaType(r.headers.Get("Content-Type"))
+	return !isBinaryBody(mediaType, r.body) || strings.HasPrefix(mediaType, "image/")
+}
+
+// responseFileName suggests a file name for a response body: the one of its
+// Content-Disposition without its control characters, else the last segment of the request
+// path with an extension for its media type
+func responseFileName(result *runResult, requestPath string) string {
+	if _, params, err := mime.ParseMediaType(result.headers.Get("Content-Disposition")); err == nil {
+		// Only the name is kept, a server doesn't get to pick the directory
+		if name := filepath.Base(filepath.Clean("/" + stripControl(params["filename"]))); name != "/" && name != "." {
+			return name
+		}
+	}
+	name := path.Base(requestPath)
+	if name == "/" || name == "." || strings.ContainsAny(name, "{}") {
+		name = "response"
+	}
+	if path.Ext(name) == "" {
+		ext := ".bin"
+		mediaType := baseMediaType(result.headers.Get("Content-Type"))
+		switch {
+		case isJSONMediaType(mediaType):
+			ext = ".json"
+		case isXMLMediaType(mediaType):
+			ext = ".xml"
+		default:
+			if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+				ext = exts[0]
+			}
+		}
+		name += ext
+	}
+	return name
+}
+
+// truncated reports whether only the first maxResponseSize of the body was read
+func (r *runResult) truncated() bool {
+	return int64(len(r.body)) < r.size
+}
+
+// openSavePrompt asks where to save the response, in the working directory by default. A
+// truncated body isn't saved, the file would look complete
+func (r *requestRunner) openSavePrompt() tea.Cmd {
+	if r.result.truncated() {
+		r.saved = fmt.Sprintf("Error saving the response: only the first %s of %s were read, download it with the curl command from r", formatBytes(uint64(len(r.result.body))), formatBytes(uint64(r.result.size)))
+		return nil
+	}
+	input := textinput.New()
+	input.Prompt = ""
+	input.SetValue(responseFileName(r.result, r.ep.path))
+	r.save = &savePrompt{input: input}
+	r.saved = ""
+	return r.save.input.Focus()
+}
+
+// updateSavePrompt edits the file name, Enter writes the body to it
+func (m *Model) updateSavePrompt(msg tea.KeyMsg) tea.Cmd {
+	runner := m.runner
+	prompt := runner.save
+	switch msg.String() {
+	case "esc":
+		runner.save = nil
+		return nil
+	case "enter":
+		name := strings.TrimSpace(prompt.input.Value())
+		if name == "" {
+			return nil
+		}
+		err := saveResponseBody(name, runner.result.body, prompt.exists)
+		if errors.Is(err, fs.ErrExist) {
+			prompt.exists = true
+			return nil
+		}
+		runner.save = nil
+		if err != nil {
+			runner.saved = fmt.Sprintf("Error saving the response: %v", err)
+			return nil
+		}
+		runner.saved = fmt.Sprintf("Saved %s to %s", formatBytes(uint64(len(runner.result.body))), name)
+		return nil
+	}
+	prompt.exists = false
+	var cmd tea.Cmd
+	prompt.input, cmd = prompt.input.Update(msg)
+	return cmd
+}
+
+// saveResponseBody writes body to name, failing with fs.ErrExist for an existing file unless
+// overwrite is set
+func saveResponseBody(name string, body []byte, overwrite bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(name, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// documentationKeywords don't change what a schema accepts, so copies that only differ in
+// them still count as identical
+var documentationKeywords = map[string]bool{
+	"title": true, "description": true, "example": true, "examples": true,
+	"externalDocs": true, "$comment": true, "xml": true,
+}
+
+// minSchemaFeatures skips trivial schemas such as `type: string`, which are identical
+// everywhere without being worth consolidating
+const minSchemaFeatures = 3
+
+// schemaFeatures flattens a schema into the set of constraints it declares, e.g.
+// `properties/id/type=string`. Sets like required and enum are order independent
+func schemaFeatures(node *yaml.Node) map[string]bool {
+	features := map[string]bool{}
+	addSchemaFeatures(node, "", features, 0)
+	return features
+}
+
+func addSchemaFeatures(node *yaml.Node, path string, features map[string]bool, depth int) {
+	if node == nil || depth > maxSchemaDepth {
+		return
+	}
+	switch node.Kind {
+	case yaml.AliasNode:
+		addSchemaFeatures(node.Alias, path, features, depth+1)
+	case yaml.ScalarNode:
+		features[path+"="+node.ShortTag()+":"+node.Value] = true
+	case yaml.SequenceNode:
+		set := strings.HasSuffix(path, "/required") || strings.HasSuffix(path, "/enum") || strings.HasSuffix(path, "/type")
+		for i, item := range node.Content {
+			itemPath := path + "/" + strconv.Itoa(i)
+			if set {
+				itemPath = path + "/*"
+			}
+			addSchemaFeatures(item, itemPath, features, depth+1)
+		}
+	case yaml.MappingNode:
+		// The titles of const branches name enum members, so enums with the same values
+		// but different members stay apart
+		named := mappingValue(node, "const") != nil
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if (documentationKeywords[key] && !(named && key == "title")) || strings.HasPrefix(key, "x-") {
+				continue
+			}
+			addSchemaFeatures(node.Content[i+1], path+"/"+escapePointer(key), features, depth+1)
+		}
+	}
+}
+
+// jaccard is the share of features two schemas have in common
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	common := 0
+	for feature := range a {
+		if b[feature] {
+			common++
+		}
+	}
+	union := len(a) + len(b) - common
+	if union == 0 {
+		return 1
+	}
+	return float64(common) / float64(union)
+}
+
+// schemaCluster is a group of component schemas that are copies or near copies of each other
+type schemaCluster struct {
+	Schemas    []string `json:"schemas"`
+	Similarity float64  `json:"similarity"`
+	Identical  bool     `json:"identical"`
+	Keep       string   `json:"keep"`
+}
+
+// findDuplicateSchemas clusters the component schemas whose similarity is at least
+// threshold. A cluster's similarity is the lowest similarity of the pairs linking it
+func findDuplicateSchemas(root *yaml.Node, threshold float64) []schemaCluster {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	schemas := mappingValue(mappingValue(root, "components"), "schemas")
+	if schemas == nil {
+		return nil
+	}
+
+	type candidate struct {
+		name     string
+		features map[string]bool
+	}
+	var candidates []candidate
+	for i := 0; i+1 < len(schemas.Content); i += 2 {
+		features := schemaFeatures(schemas.Content[i+1])
+		if len(features) >= minSchemaFeatures {
+			candidates = append(candidates, candidate{schemas.Content[i].Value, features})
+		}
+	}
+	// With candidates sorted by size, the size ratio bounds the similarity and ends the
+	// inner loop early
+	sort.SliceStable(candidates, func(i, j int) bool { return len(candidates[i].features) < len(candidates[j].features) })
+
+	parent := make([]int, len(candidates))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	lowest := map[int]float64{}
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			if float64(len(candidates[i].features))/float64(len(candidates[j].features)) < threshold {
+				break
+			}
+			similarity := jaccard(candidates[i].features, candidates[j].features)
+			if similarity < threshold {
+				continue
+			}
+			a, b := find(i), find(j)
+			score := similarity
+			for _, root := range []int{a, b} {
+				if s, ok := lowest[root]; ok && s < score {
+					score = s
+				}
+			}
+			delete(lowest, a)
+			delete(lowest, b)
+			parent[a] = b
+			lowest[b] = score
+		}
+	}
+
+	members := map[int][]string{}
+	for i, c := range candidates {
+		members[find(i)] = append(members[find(i)], c.name)
+	}
+	uses := countSchemaRefs(root)
+
+	var clusters []schemaCluster
+	for root, names := range members {
+		if len(names) < 2 {
+			continue
+		}
+		sort.Strings(names)
+		keep := names[0]
+		for _, name := range names[1:] {
+			if uses[name] > uses[keep] {
+				keep = name
+			}
+		}
+		similarity := float64(int(lowest[root]*1000+0.5)) / 10
+		clusters = append(clusters, schemaCluster{Schemas: names, Similarity: similarity, Identical: lowest[root] == 1, Keep: keep})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Similarity != clusters[j].Similarity {
+			return clusters[i].Similarity > clusters[j].Similarity
+		}
+		return clusters[i].Schemas[0] < clusters[j].Schemas[0]
+	})
+	return clusters
+}
+
+// countSchemaRefs counts the $refs to each component schema below node
+func countSchemaRefs(node *yaml.Node) map[string]int {
+	uses := map[string]int{}
+	var walk func(*yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode {
+			if ref := mappingValue(node, "$ref"); ref != nil {
+				if name, ok := componentSchemaName(ref.Value); ok {
+					uses[name]++
+				}
+			}
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(node)
+	return uses
+}
+
+// runDuplicates implements `oq duplicates [--threshold 0.9] [--format text|json] [spec]`
+func runDuplicates(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("duplicates", flag.ContinueOnError)
+	threshold := fs.Float64("threshold", 0.9, "minimum similarity between 0 and 1 for schemas to be reported")
+	format := fs.String("format", "text", "output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq duplicates [flags] [spec]\n\n")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) > 1 || *threshold <= 0 || *threshold > 1 || (*format != "text" && *format != "json") {
+		fs.Usage()
+		return 2
+	}
+
+	var path string
+	if len(positional) > 0 {
+		path = positional[0]
+	}
+	content, _, err := loadSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing spec: %v\n", err)
+		return 1
+	}
+
+	clusters := findDuplicateSchemas(&root, *threshold)
+	if *format == "json" {
+		if clusters == nil {
+			clusters = []schemaCluster{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(clusters); err != nil {
+			return reportError(err)
+		}
+		return 0
+	}
+
+	if len(clusters) == 0 {
+		fmt.Printf("No duplicate schemas found at %.0f%% similarity\n", *threshold*100)
+		return 0
+	}
+	for _, c := range clusters {
+		if c.Identical {
+			fmt.Printf("100%%  %s\n      structurally identical, keep %s and $ref it from the others\n", strings.Join(c.Schemas, ", "), c.Keep)
+			continue
+		}
+		fmt.Printf("%3.0f%%  %s\n      nearly identical, consider consolidating into %s\n", c.Similarity, strings.Join(c.Schemas, ", "), c.Keep)
+	}
+	return 0
+}
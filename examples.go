@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+)
+
+type exampleCount struct {
+	request  int
+	response int
+}
+
+func (c exampleCount) missing() bool {
+	return c.request == 0 && c.response == 0
+}
+
+func (c exampleCount) String() string {
+	if c.missing() {
+		return "none"
+	}
+	return fmt.Sprintf("%d request, %d response", c.request, c.response)
+}
+
+// countExamples counts the `example` and `examples` declared on the media types of an
+// operation's request body and responses, including those on their top-level schemas
+func countExamples(op *v3.Operation) exampleCount {
+	var count exampleCount
+	if op == nil {
+		return count
+	}
+
+	if op.RequestBody != nil {
+		count.request = countContentExamples(op.RequestBody.Content)
+	}
+
+	if op.Responses != nil {
+		if op.Responses.Codes != nil {
+			for pair := op.Responses.Codes.First(); pair != nil; pair = pair.Next() {
+				if resp := pair.Value(); resp != nil {
+					count.response += countContentExamples(resp.Content)
+				}
+			}
+		}
+		if op.Responses.Default != nil {
+			count.response += countContentExamples(op.Responses.Default.Content)
+		}
+	}
+
+	return count
+}
+
+func countContentExamples(content *orderedmap.Map[string, *v3.MediaType]) int {
+	if content == nil {
+		return 0
+	}
+
+	n := 0
+	for pair := content.First(); pair != nil; pair = pair.Next() {
+		mediaType := pair.Value()
+		if mediaType == nil {
+			continue
+		}
+		if mediaType.Example != nil {
+			n++
+		}
+		if mediaType.Examples != nil {
+			n += mediaType.Examples.Len()
+		}
+		if mediaType.Schema != nil {
+			if schema := mediaType.Schema.Schema(); schema != nil {
+				if schema.Example != nil {
+					n++
+				}
+				n += len(schema.Examples)
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,452 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+var exportFormats = []string{"markdown", "html", "postman", "http"}
+
+// exportPage is the documentation written by `oq export`, built once and rendered as
+// Markdown or HTML. The details are the same text the TUI shows when an item is expanded
+type exportPage struct {
+	Title       string
+	Version     string
+	Description string
+	Groups      []exportGroup
+	Components  []exportComponent
+	Webhooks    []exportOperation
+}
+
+type exportGroup struct {
+	Tag         string
+	Description string
+	Operations  []exportOperation
+}
+
+type exportOperation struct {
+	ID          string
+	Heading     string
+	Details     string
+	RequestType string
+	Request     string
+	Responses   []exportExample
+}
+
+type exportExample struct {
+	Code      string
+	MediaType string
+	Body      string
+}
+
+type exportComponent struct {
+	ID          string
+	Type        string
+	Name        string
+	Description string
+	Details     string
+}
+
+// buildExportPage collects endpoints grouped by tag in the order the spec declares them,
+// components and webhooks
+func buildExportPage(doc *v3.Document) exportPage {
+	var page exportPage
+	if doc.Info != nil {
+		page.Title, page.Version, page.Description = doc.Info.Title, doc.Info.Version, doc.Info.Description
+	}
+	if page.Title == "" {
+		page.Title = "API"
+	}
+
+	eps := extractEndpoints(doc)
+	groups := map[string][]endpoint{}
+	for _, ep := range eps {
+		if len(ep.op.Tags) == 0 {
+			groups[untaggedGroup] = append(groups[untaggedGroup], ep)
+		}
+		for _, tag := range ep.op.Tags {
+			groups[tag] = append(groups[tag], ep)
+		}
+	}
+	tagDescriptions := map[string]string{}
+	for _, tag := range doc.Tags {
+		if tag != nil {
+			tagDescriptions[tag.Name] = tag.Description
+		}
+	}
+	for _, tag := range append(specTags(doc, eps), untaggedGroup) {
+		if len(groups[tag]) == 0 {
+			continue
+		}
+		group := exportGroup{Tag: tag, Description: tagDescriptions[tag]}
+		for _, ep := range groups[tag] {
+			op := exportOperation{
+				ID:      anchorID(tag + " " + ep.method + " " + ep.path),
+				Heading: ep.method + " " + ep.path,
+				Details: formatEndpointDetails(ep),
+			}
+			op.RequestType, op.Request, _ = exampleRequestBody(doc, ep.op)
+			op.Responses = exampleResponses(doc, ep.op)
+			group.Operations = append(group.Operations, op)
+		}
+		page.Groups = append(page.Groups, group)
+	}
+
+	for _, comp := range extractComponents(doc) {
+		page.Components = append(page.Components, exportComponent{
+			ID:          anchorID(comp.compType + " " + comp.name),
+			Type:        comp.compType,
+			Name:        comp.name,
+			Description: comp.description,
+			Details:     comp.details,
+		})
+	}
+
+	for _, hook := range extractWebhooks(doc) {
+		op := exportOperation{
+			ID:      anchorID("webhook " + hook.method + " " + hook.name),
+			Heading: hook.method + " " + hook.name,
+			Details: formatWebhookDetails(hook),
+		}
+		op.RequestType, op.Request, _ = exampleRequestBody(doc, hook.op)
+		page.Webhooks = append(page.Webhooks, op)
+	}
+	return page
+}
+
+// exampleResponses generates an example body for every response with a JSON schema
+func exampleResponses(doc *v3.Document, op *v3.Operation) []exportExample {
+	var examples []exportExample
+	for _, code := range responseCodes(op) {
+		resp := op.Responses.Default
+		if code != "default" {
+			resp = op.Responses.Codes.GetOrZero(code)
+		}
+		if resp == nil || resp.Content == nil {
+			continue
+		}
+		for pair := resp.Content.First(); pair != nil; pair = pair.Next() {
+			media := pair.Value()
+			if !isJSONMediaType(pair.Key()) || media == nil || media.Schema == nil || media.Schema.Schema() == nil {
+				continue
+			}
+			body := generateExampleJSON(media.Schema.Schema(), doc, 0)
+			var indented bytes.Buffer
+			if err := jsonIndent(&indented, []byte(body)); err == nil {
+				body = indented.String()
+			}
+			examples = append(examples, exportExample{Code: code, MediaType: pair.Key(), Body: body})
+			break
+		}
+	}
+	return examples
+}
+
+// anchorID turns a heading into a fragment identifier such as get-pets-petid
+func anchorID(s string) string {
+	return strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// writeExportMarkdown renders the page as one Markdown document
+func writeExportMarkdown(w io.Writer, page exportPage) {
+	title := page.Title
+	if page.Version != "" {
+		title += " " + page.Version
+	}
+	fmt.Fprintf(w, "# %s\n\n", title)
+	if page.Description != "" {
+		fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(page.Description))
+	}
+
+	if len(page.Groups) > 0 {
+		fmt.Fprintf(w, "## Endpoints\n\n")
+	}
+	for _, group := range page.Groups {
+		fmt.Fprintf(w, "### %s\n\n", group.Tag)
+		if group.Description != "" {
+			fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(group.Description))
+		}
+		for _, op := range group.Operations {
+			writeMarkdownOperation(w, op)
+		}
+	}
+
+	if len(page.Components) > 0 {
+		fmt.Fprintf(w, "## Components\n\n")
+	}
+	for _, comp := range page.Components {
+		fmt.Fprintf(w, "### %s %s\n\n", comp.Type, comp.Name)
+		if comp.Description != "" {
+			fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(comp.Description))
+		}
+		writeMarkdownBlock(w, "text", comp.Details)
+	}
+
+	if len(page.Webhooks) > 0 {
+		fmt.Fprintf(w, "## Webhooks\n\n")
+	}
+	for _, hook := range page.Webhooks {
+		writeMarkdownOperation(w, hook)
+	}
+}
+
+func writeMarkdownOperation(w io.Writer, op exportOperation) {
+	fmt.Fprintf(w, "#### `%s`\n\n", op.Heading)
+	writeMarkdownBlock(w, "text", op.Details)
+	if op.Request != "" {
+		fmt.Fprintf(w, "Example request (%s):\n\n", op.RequestType)
+		writeMarkdownBlock(w, "json", op.Request)
+	}
+	for _, resp := range op.Responses {
+		fmt.Fprintf(w, "Example response %s (%s):\n\n", resp.Code, resp.MediaType)
+		writeMarkdownBlock(w, "json", resp.Body)
+	}
+}
+
+// writeMarkdownBlock writes a fenced code block, with a longer fence when the content
+// itself contains one, as descriptions written in Markdown may
+func writeMarkdownBlock(w io.Writer, lang, content string) {
+	content = strings.TrimRight(content, "\n")
+	if content == "" {
+		return
+	}
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(w, "%s%s\n%s\n%s\n\n", fence, lang, content, fence)
+}
+
+var exportHTMLTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; max-width: 960px; margin: 2rem auto; padding: 0 1rem; color: #24292f; }
+h1, h2, h3 { color: #7d56f4; }
+nav ul { columns: 2; }
+pre { background: #f6f8fa; padding: 0.75rem; overflow-x: auto; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; }
+p.description { white-space: pre-line; }
+section { border-top: 1px solid #d0d7de; margin-top: 1.5rem; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}{{if .Version}} <small>{{.Version}}</small>{{end}}</h1>
+{{if .Description}}<p class="description">{{.Description}}</p>{{end}}
+<nav>
+<ul>
+{{range .Groups}}{{range .Operations}}<li><a href="#{{.ID}}"><code>{{.Heading}}</code></a></li>
+{{end}}{{end}}</ul>
+</nav>
+{{if .Groups}}<h2>Endpoints</h2>{{end}}
+{{range .Groups}}<h3>{{.Tag}}</h3>
+{{if .Description}}<p class="description">{{.Description}}</p>{{end}}
+{{range .Operations}}{{template "operation" .}}{{end}}{{end}}
+{{if .Components}}<h2>Components</h2>{{end}}
+{{range .Components}}<section id="{{.ID}}">
+<h3>{{.Type}} {{.Name}}</h3>
+{{if .Description}}<p class="description">{{.Description}}</p>{{end}}
+{{if .Details}}<pre>{{.Details}}</pre>{{end}}
+</section>
+{{end}}
+{{if .Webhooks}}<h2>Webhooks</h2>{{end}}
+{{range .Webhooks}}{{template "operation" .}}{{end}}
+</body>
+</html>
+{{define "operation"}}<section id="{{.ID}}">
+<h4><code>{{.Heading}}</code></h4>
+<pre>{{.Details}}</pre>
+{{if .Request}}<p>Example request ({{.RequestType}}):</p>
+<pre><code>{{.Request}}</code></pre>
+{{end}}{{range .Responses}}<p>Example response {{.Code}} ({{.MediaType}}):</p>
+<pre><code>{{.Body}}</code></pre>
+{{end}}</section>
+{{end}}`))
+
+// writeExportHTML renders the page as a single self-contained HTML file
+func writeExportHTML(w io.Writer, page exportPage) error {
+	return exportHTMLTemplate.Execute(w, page)
+}
+
+// runExport implements `oq export spec.yaml --format markdown|html|postman -o docs/`. Postman
+// collections are written to stdout unless -o is given
+func runExport(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "markdown", "export format: markdown, html, postman or http")
+	outDir := fs.String("o", ".", "directory to write the documentation to")
+	var ops operationFlags
+	fs.Var(&ops, "op", "operation to export as http, an operationId or \"METHOD /path\" (repeatable, default all)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq export [--format markdown|html|postman|http] [--op operation]... [-o dir] [spec]\n\n")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) > 1 || !slices.Contains(exportFormats, *format) {
+		fs.Usage()
+		return 2
+	}
+
+	var path string
+	if len(positional) > 0 {
+		path = positional[0]
+	}
+	_, doc, err := loadSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+	if *format == "postman" {
+		return exportPostman(doc, path, *outDir, flagWasSet(fs, "o"))
+	}
+	if *format == "http" {
+		return exportHTTP(doc, path, ops, *outDir, flagWasSet(fs, "o"))
+	}
+	page := buildExportPage(doc)
+
+	var out bytes.Buffer
+	name := "index.md"
+	if *format == "html" {
+		name = "index.html"
+		if err := writeExportHTML(&out, page); err != nil {
+			fmt.Fprintf(os.Stderr, "Error rendering HTML: %v\n", err)
+			return 1
+		}
+	} else {
+		writeExportMarkdown(&out, page)
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", *outDir, err)
+		return 1
+	}
+	target := filepath.Join(*outDir, name)
+	if err := os.WriteFile(target, out.Bytes(), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", target, err)
+		return 1
+	}
+	operations := 0
+	for _, group := range page.Groups {
+		operations += len(group.Operations)
+	}
+	fmt.Printf("%s\t%d operations\n", target, operations)
+	return 0
+}
+
+// exportPostman writes the Postman collection to stdout, or to collection.json in outDir
+// along with environment.json
+func exportPostman(doc *v3.Document, specPath, outDir string, toDir bool) int {
+	collection := buildPostmanCollection(doc, specPath)
+	if !toDir {
+		if err := writePostmanJSON(os.Stdout, collection); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing the collection: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", outDir, err)
+		return 1
+	}
+	files := []struct {
+		name    string
+		content any
+		summary string
+	}{
+		{"collection.json", collection, fmt.Sprintf("%d operations", len(extractEndpoints(doc)))},
+		{"environment.json", buildPostmanEnvironment(collection), fmt.Sprintf("%d variables", len(collection.Variable))},
+	}
+	for _, file := range files {
+		var out bytes.Buffer
+		if err := writePostmanJSON(&out, file.content); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", file.name, err)
+			return 1
+		}
+		target := filepath.Join(outDir, file.name)
+		if err := os.WriteFile(target, out.Bytes(), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", target, err)
+			return 1
+		}
+		fmt.Printf("%s\t%s\n", target, file.summary)
+	}
+	return 0
+}
+
+// exportHTTP writes the selected operations as an .http file to stdout, declaring the
+// variables it uses, or to outDir along with the environment files of the JetBrains HTTP
+// client that set them
+func exportHTTP(doc *v3.Document, specPath string, ops operationFlags, outDir string, toDir bool) int {
+	var eps []endpoint
+	for _, ep := range extractEndpoints(doc) {
+		if ops.matches(ep) {
+			eps = append(eps, ep)
+		}
+	}
+	if len(eps) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no operation matches %s\n", ops.String())
+		return 1
+	}
+	requests := buildHTTPRequests(doc, eps, specPath)
+	if !toDir {
+		if err := requests.write(os.Stdout, true); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing the requests: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", outDir, err)
+		return 1
+	}
+	name := "api.http"
+	if specPath != "" && !isRemoteSpec(specPath) {
+		name = strings.TrimSuffix(filepath.Base(specPath), filepath.Ext(specPath)) + ".http"
+	}
+	var out bytes.Buffer
+	if err := requests.write(&out, false); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing the requests: %v\n", err)
+		return 1
+	}
+	public, private := requests.httpEnvironments(doc, specPath)
+	files := []struct {
+		name    string
+		content any
+		summary string
+	}{
+		{name, nil, fmt.Sprintf("%d operations", len(eps))},
+		{"http-client.env.json", public, fmt.Sprintf("%d %s", len(public), plural(len(public), "environment", "environments"))},
+		{"http-client.private.env.json", private, fmt.Sprintf("%d %s", len(requests.credentials), plural(len(requests.credentials), "credential", "credentials"))},
+	}
+	for _, file := range files {
+		if file.content != nil {
+			out.Reset()
+			if err := writePostmanJSON(&out, file.content); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", file.name, err)
+				return 1
+			}
+		}
+		target := filepath.Join(outDir, file.name)
+		if err := os.WriteFile(target, out.Bytes(), 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", target, err)
+			return 1
+		}
+		fmt.Printf("%s\t%s\n", target, file.summary)
+	}
+	return 0
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// listFilters holds the filters applied on top of the search query
+type listFilters struct {
+	tag             string
+	method          string
+	deprecated      bool
+	hideDeprecated  bool
+	missingExamples bool
+	since           string
+	deprecatedAt    string
+	pinned          bool
+}
+
+func (f listFilters) active() bool {
+	return f.tag != "" || f.method != "" || f.deprecated || f.hideDeprecated || f.missingExamples || f.since != "" || f.deprecatedAt != "" || f.pinned
+}
+
+// matchesOperation reports whether an operation passes every active filter
+func (f listFilters) matchesOperation(method string, op *v3.Operation) bool {
+	if f.tag != "" && !slices.ContainsFunc(op.Tags, func(t string) bool { return strings.EqualFold(t, f.tag) }) {
+		return false
+	}
+	if f.method != "" && !strings.EqualFold(method, f.method) {
+		return false
+	}
+	if f.deprecated && !isDeprecated(op) {
+		return false
+	}
+	if f.hideDeprecated && isDeprecated(op) {
+		return false
+	}
+	if f.missingExamples && !countExamples(op).missing() {
+		return false
+	}
+	if f.since != "" && !versionMatches(findChangelog(op).since, f.since) {
+		return false
+	}
+	if f.deprecatedAt != "" && !versionMatches(findChangelog(op).deprecatedAt, f.deprecatedAt) {
+		return false
+	}
+	return true
+}
+
+// matchesComponent reports whether a component passes the deprecation filters, the only
+// ones that apply to components
+func (f listFilters) matchesComponent(comp component) bool {
+	return (!f.deprecated || comp.deprecated) && (!f.hideDeprecated || !comp.deprecated)
+}
+
+// isDeprecated reports whether op is marked deprecated
+func isDeprecated(op *v3.Operation) bool {
+	return op != nil && op.Deprecated != nil && *op.Deprecated
+}
+
+// cycleDeprecatedFilter steps X through all items, only deprecated ones and no deprecated ones
+func (m *Model) cycleDeprecatedFilter() {
+	switch {
+	case m.filters.deprecated:
+		m.filters.deprecated, m.filters.hideDeprecated = false, true
+	case m.filters.hideDeprecated:
+		m.filters.hideDeprecated = false
+	default:
+		m.filters.deprecated = true
+	}
+	m.refilter()
+}
+
+// quickMethods are the keys pressed after F to filter the list to a method, in hint order
+var quickMethods = []struct{ key, method string }{
+	{"g", "GET"}, {"p", "POST"}, {"u", "PUT"}, {"a", "PATCH"}, {"d", "DELETE"}, {"h", "HEAD"}, {"o", "OPTIONS"},
+}
+
+func quickMethodHint() string {
+	parts := make([]string, len(quickMethods))
+	for i, quick := range quickMethods {
+		parts[i] = quick.key + " " + quick.method
+	}
+	return "Filter by method: " + strings.Join(parts, ", ") + ", F clear"
+}
+
+// quickMethodFilter handles the key after F. Pressing the key of the active method, or F
+// again, removes the method filter
+func (m *Model) quickMethodFilter(key string) {
+	method := ""
+	if key != "F" {
+		i := slices.IndexFunc(quickMethods, func(q struct{ key, method string }) bool { return q.key == key })
+		if i < 0 {
+			return
+		}
+		method = quickMethods[i].method
+	}
+	if method == m.filters.method {
+		method = ""
+	}
+	m.filters.method = method
+	m.refilter()
+}
+
+// filterChip is one active filter shown under the header. remove clears just that filter
+type filterChip struct {
+	label  string
+	remove func(m *Model)
+}
+
+// filterChips lists the active filters in a stable order, numbered from 1 in the UI
+func (m *Model) filterChips() []filterChip {
+	var chips []filterChip
+	if query := m.searchInput.Value(); query != "" {
+		chips = append(chips, filterChip{label: "/" + query, remove: func(m *Model) { m.searchInput.SetValue("") }})
+	}
+	if m.filters.tag != "" {
+		chips = append(chips, filterChip{label: "tag:" + m.filters.tag, remove: func(m *Model) { m.filters.tag = "" }})
+	}
+	if m.filters.method != "" {
+		chips = append(chips, filterChip{label: "method:" + m.filters.method, remove: func(m *Model) { m.filters.method = "" }})
+	}
+	if m.filters.deprecated {
+		chips = append(chips, filterChip{label: "deprecated", remove: func(m *Model) { m.filters.deprecated = false }})
+	}
+	if m.filters.hideDeprecated {
+		chips = append(chips, filterChip{label: "hide deprecated", remove: func(m *Model) { m.filters.hideDeprecated = false }})
+	}
+	if m.filters.missingExamples {
+		chips = append(chips, filterChip{label: "missing examples", remove: func(m *Model) { m.filters.missingExamples = false }})
+	}
+	if m.filters.since != "" {
+		chips = append(chips, filterChip{label: "since:" + displayVersion(m.filters.since), remove: func(m *Model) { m.filters.since = "" }})
+	}
+	if m.filters.deprecatedAt != "" {
+		chips = append(chips, filterChip{label: "deprecated-at:" + displayVersion(m.filters.deprecatedAt), remove: func(m *Model) { m.filters.deprecatedAt = "" }})
+	}
+	if m.filters.pinned {
+		chips = append(chips, filterChip{label: "pinned", remove: func(m *Model) { m.filters.pinned = false }})
+	}
+	return chips
+}
+
+// removeFilterChip removes the chip at the given 1-based position
+func (m *Model) removeFilterChip(n int) bool {
+	chips := m.filterChips()
+	if n < 1 || n > len(chips) {
+		return false
+	}
+	chips[n-1].remove(m)
+	m.refilter()
+	return true
+}
+
+// refilter reapplies all filters and moves the cursor back to the top
+func (m *Model) refilter() {
+	m.filterItems()
+	m.cursor = 0
+	m.scrollOffset = 0
+}
+
+// filterCommand handles `:filter tag <name>`, `:filter method <verb>`,
+// `:filter deprecated`, `:filter hide-deprecated`, `:filter missing-examples`,
+// `:filter since <version>`, `:filter deprecated-at <version>`, `:filter pinned` and
+// `:filter clear`
+func (m *Model) filterCommand(arg string) error {
+	kind, value, _ := strings.Cut(arg, " ")
+	value = strings.TrimSpace(value)
+
+	switch kind {
+	case "tag":
+		if value == "" {
+			return fmt.Errorf("usage: filter tag <name>")
+		}
+		m.filters.tag = value
+	case "method":
+		if value == "" {
+			return fmt.Errorf("usage: filter method <verb>")
+		}
+		m.filters.method = strings.ToUpper(value)
+	case "deprecated":
+		m.filters.deprecated, m.filters.hideDeprecated = true, false
+	case "hide-deprecated":
+		m.filters.deprecated, m.filters.hideDeprecated = false, true
+	case "missing-examples":
+		m.filters.missingExamples = true
+	case "since":
+		if value == "" {
+			return fmt.Errorf("usage: filter since <version>")
+		}
+		m.filters.since = value
+	case "deprecated-at":
+		if value == "" {
+			return fmt.Errorf("usage: filter deprecated-at <version>")
+		}
+		m.filters.deprecatedAt = value
+	case "pinned":
+		m.filters.pinned = true
+	case "clear":
+		m.filters = listFilters{}
+		m.searchInput.SetValue("")
+	default:
+		return fmt.Errorf("usage: filter tag|method|deprecated|hide-deprecated|missing-examples|since|deprecated-at|pinned|clear")
+	}
+
+	m.refilter()
+	return nil
+}
+
+// renderFilterChips renders active filters as numbered chips, or an empty string when there are none
+func (m Model) renderFilterChips() string {
+	chips := m.filterChips()
+	if len(chips) == 0 {
+		return ""
+	}
+
+	labelStyle := highlight(lipgloss.NewStyle()).
+		Foreground(lipgloss.Color(colorWhite))
+	numberStyle := labelStyle.
+		Foreground(lipgloss.Color(colorBlue)).
+		Bold(true)
+
+	var parts []string
+	for i, chip := range chips {
+		parts = append(parts, labelStyle.Render(" ")+numberStyle.Render(fmt.Sprintf("%d", i+1))+labelStyle.Render(" "+chip.label+" × "))
+	}
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray)).Render("  press a number to remove")
+
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(strings.Join(parts, " ") + hint)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultFooter is the footer used unless configured: the key hints left, the spec title right
+const defaultFooter = "hints | title"
+
+// footerModule is a widget the footer can show, by the name used in the footer setting
+type footerModule struct {
+	name   string
+	render func(m *Model) string
+}
+
+var footerModules = []footerModule{
+	{"hints", (*Model).footerHints},
+	{"counts", (*Model).footerCounts},
+	{"title", (*Model).footerTitle},
+	{"environment", (*Model).footerEnvironment},
+	{"clock", func(*Model) string { return time.Now().Format("15:04") }},
+	{"last_request", (*Model).footerLastRequest},
+}
+
+// footerLayout lists the modules shown on the left and right of the footer
+type footerLayout struct {
+	left, right []string
+}
+
+// parseFooter reads a footer setting such as "hints,counts | environment,clock". Modules
+// before the | are shown on the left, those after it on the right
+func parseFooter(value string) (footerLayout, error) {
+	if strings.TrimSpace(value) == "" {
+		value = defaultFooter
+	}
+	sides := strings.Split(value, "|")
+	if len(sides) > 2 {
+		return footerLayout{}, fmt.Errorf("must have at most one | between the left and right modules")
+	}
+	var layout footerLayout
+	for i, side := range sides {
+		var names []string
+		for _, name := range strings.Split(side, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if _, ok := findFooterModule(name); !ok {
+				return footerLayout{}, fmt.Errorf("unknown footer module %q, must be one of %s", name, strings.Join(footerModuleNames(), ", "))
+			}
+			names = append(names, name)
+		}
+		if i == 0 {
+			layout.left = names
+		} else {
+			layout.right = names
+		}
+	}
+	return layout, nil
+}
+
+func findFooterModule(name string) (footerModule, bool) {
+	i := slices.IndexFunc(footerModules, func(module footerModule) bool { return module.name == name })
+	if i < 0 {
+		return footerModule{}, false
+	}
+	return footerModules[i], true
+}
+
+func footerModuleNames() []string {
+	var names []string
+	for _, module := range footerModules {
+		names = append(names, module.name)
+	}
+	return names
+}
+
+func (l footerLayout) has(name string) bool {
+	return slices.Contains(l.left, name) || slices.Contains(l.right, name)
+}
+
+// renderModules joins the non-empty output of the modules
+func (m *Model) renderModules(names []string) string {
+	var parts []string
+	for _, name := range names {
+		if module, ok := findFooterModule(name); ok {
+			if text := module.render(m); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+func (m *Model) footerHints() string {
+	if m.showHelp {
+		return ""
+	}
+	return "Press '?' for help | '/' to search"
+}
+
+// footerCounts is the number of items in the view, and how many match while filtering
+func (m *Model) footerCounts() string {
+	var shown, total int
+	var noun string
+	switch m.mode {
+	case viewEndpoints:
+		shown, total, noun = len(m.getActiveEndpoints()), len(m.endpoints), "endpoints"
+	case viewComponents:
+		shown, total, noun = len(m.getActiveComponents()), len(m.components), "components"
+	case viewWebhooks:
+		shown, total, noun = len(m.getActiveWebhooks()), len(m.webhooks), "webhooks"
+	case viewTags:
+		shown, total, noun = len(m.getActiveTags()), len(m.tags), "tags"
+	}
+	if m.isFiltering() {
+		return fmt.Sprintf("%d/%d %s", shown, total, noun)
+	}
+	return fmt.Sprintf("%d %s", total, noun)
+}
+
+func (m *Model) footerTitle() string {
+	return fmt.Sprintf("%s v%s", m.doc.Info.Title, m.doc.Info.Version)
+}
+
+// footerEnvironment names the server requests go to: the description the spec gives it, such
+// as "Production", or else its host
+func (m *Model) footerEnvironment() string {
+	server := m.activeServer
+	if server == "" {
+		server = m.curl.server
+	}
+	if server == "" && len(m.doc.Servers) > 0 && m.doc.Servers[0] != nil {
+		server = resolveServerURL(m.doc.Servers[0], nil, m.specPath)
+	}
+	if server == "" {
+		return ""
+	}
+	for _, entry := range collectServers(m.doc, m.endpoints) {
+		if entry.server.Description != "" && resolveServerURL(entry.server, m.serverValues[entry.server.URL], m.specPath) == server {
+			return entry.server.Description
+		}
+	}
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return server
+}
+
+// lastRequest is the outcome of the last request sent from the request form
+type lastRequest struct {
+	ep       endpoint
+	status   string
+	duration time.Duration
+	err      error
+}
+
+func (m *Model) footerLastRequest() string {
+	if m.lastRequest == nil {
+		return ""
+	}
+	label := m.lastRequest.ep.method + " " + m.lastRequest.ep.path
+	if m.lastRequest.err != nil {
+		return label + " failed"
+	}
+	return fmt.Sprintf("%s %s %s", label, m.lastRequest.status, m.lastRequest.duration.Round(time.Millisecond))
+}
+
+// footerClockMsg redraws the footer clock at the start of each minute
+type footerClockMsg struct{}
+
+func tickFooterClock() tea.Cmd {
+	now := time.Now()
+	return tea.Tick(now.Truncate(time.Minute).Add(time.Minute).Sub(now), func(time.Time) tea.Msg {
+		return footerClockMsg{}
+	})
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// componentKinds lists the sections of components in the order they are written
+var componentKinds = []string{
+	"schemas", "responses", "parameters", "examples", "requestBodies",
+	"headers", "securitySchemes", "links", "callbacks", "pathItems",
+}
+
+// standardOperationKeys are the operation fields of a path item, anything else lives
+// under additionalOperations or x-methods
+var standardOperationKeys = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true, "options": true,
+	"head": true, "patch": true, "trace": true, "query": true,
+}
+
+// pathItemFields are copied from the original path item along with the selected operations
+var pathItemFields = []string{"summary", "description", "servers", "parameters"}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func mappingValueFold(node *yaml.Node, key string) (string, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return "", nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, key) {
+			return node.Content[i].Value, node.Content[i+1]
+		}
+	}
+	return "", nil
+}
+
+func newMapping() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// setMapping sets key in a mapping node. The previous value is replaced, not modified,
+// because it may be shared with the source document
+func setMapping(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// documentRoot returns the top-level mapping of the source document
+func documentRoot(doc *v3.Document) (*yaml.Node, error) {
+	if doc.GoLow() == nil || doc.GoLow().Index == nil || doc.GoLow().Index.GetRootNode() == nil {
+		return nil, fmt.Errorf("source document is not available")
+	}
+	root := doc.GoLow().Index.GetRootNode()
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	return root, nil
+}
+
+// collectComponentRefs records every local `#/components/<kind>/<name>` reference below node
+func collectComponentRefs(node *yaml.Node, refs map[string]map[string]bool) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "$ref" && value.Kind == yaml.ScalarNode {
+				parts := strings.SplitN(strings.TrimPrefix(value.Value, "#/components/"), "/", 3)
+				if strings.HasPrefix(value.Value, "#/components/") && len(parts) >= 2 {
+					if refs[parts[0]] == nil {
+						refs[parts[0]] = map[string]bool{}
+					}
+					refs[parts[0]][parts[1]] = true
+				}
+			}
+		}
+	}
+	for _, child := range node.Content {
+		collectComponentRefs(child, refs)
+	}
+}
+
+// securityNames adds the security scheme names used by a security requirement list
+func securityNames(node *yaml.Node, refs map[string]map[string]bool) {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, req := range node.Content {
+		if req.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i < len(req.Content); i += 2 {
+			if refs["securitySchemes"] == nil {
+				refs["securitySchemes"] = map[string]bool{}
+			}
+			refs["securitySchemes"][req.Content[i].Value] = true
+		}
+	}
+}
+
+// buildFragment returns a standalone spec with only the given operations and the components
+// they reference, directly or through other components
+func buildFragment(doc *v3.Document, eps []endpoint, title string) (*yaml.Node, error) {
+	root, err := documentRoot(doc)
+	if err != nil {
+		return nil, err
+	}
+	srcPaths := mappingValue(root, "paths")
+
+	refs := map[string]map[string]bool{}
+	usedTags := map[string]bool{}
+	usesDefaultSecurity := false
+
+	paths := newMapping()
+	for _, ep := range eps {
+		srcItem := mappingValue(srcPaths, ep.path)
+		if srcItem == nil {
+			return nil, fmt.Errorf("path %s not found in the source document", ep.path)
+		}
+
+		item := mappingValue(paths, ep.path)
+		if item == nil {
+			item = newMapping()
+			for _, field := range pathItemFields {
+				if value := mappingValue(srcItem, field); value != nil {
+					setMapping(item, field, value)
+				}
+			}
+			setMapping(paths, ep.path, item)
+		}
+
+		opNode, err := copyOperation(srcItem, item, ep.method)
+		if err != nil {
+			return nil, err
+		}
+		if mappingValue(opNode, "security") == nil {
+			usesDefaultSecurity = true
+		}
+		securityNames(mappingValue(opNode, "security"), refs)
+		for _, tag := range ep.op.Tags {
+			usedTags[tag] = true
+		}
+	}
+	collectComponentRefs(paths, refs)
+
+	out := newMapping()
+	for _, field := range []string{"openapi", "info", "jsonSchemaDialect", "servers"} {
+		if value := mappingValue(root, field); value != nil {
+			setMapping(out, field, value)
+		}
+	}
+	if info := mappingValue(out, "info"); info != nil && title != "" {
+		titled := &yaml.Node{Kind: yaml.MappingNode, Tag: info.Tag, Content: append([]*yaml.Node{}, info.Content...)}
+		setMapping(titled, "title", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: title})
+		setMapping(out, "info", titled)
+	}
+	if security := mappingValue(root, "security"); security != nil && usesDefaultSecurity {
+		setMapping(out, "security", security)
+		securityNames(security, refs)
+	}
+
+	if srcTags := mappingValue(root, "tags"); srcTags != nil && srcTags.Kind == yaml.SequenceNode {
+		tags := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, tag := range srcTags.Content {
+			if name := mappingValue(tag, "name"); name != nil && usedTags[name.Value] {
+				tags.Content = append(tags.Content, tag)
+			}
+		}
+		if len(tags.Content) > 0 {
+			setMapping(out, "tags", tags)
+		}
+	}
+
+	setMapping(out, "paths", paths)
+	if components := copyComponents(mappingValue(root, "components"), refs); components != nil {
+		setMapping(out, "components", components)
+	}
+
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{out}}, nil
+}
+
+// copyOperation copies the operation for method from src into dst, keeping it under the
+// same field, additionalOperations or x-methods, as in the source
+func copyOperation(src, dst *yaml.Node, method string) (*yaml.Node, error) {
+	key := strings.ToLower(method)
+	if standardOperationKeys[key] {
+		if op := mappingValue(src, key); op != nil {
+			setMapping(dst, key, op)
+			return op, nil
+		}
+	}
+
+	for _, container := range []string{"additionalOperations", customMethodsExtension} {
+		name, op := mappingValueFold(mappingValue(src, container), method)
+		if op == nil {
+			continue
+		}
+		ops := mappingValue(dst, container)
+		if ops == nil {
+			ops = newMapping()
+			setMapping(dst, container, ops)
+		}
+		setMapping(ops, name, op)
+		return op, nil
+	}
+
+	return nil, fmt.Errorf("operation %s not found in the source document", method)
+}
+
+// copyComponents returns the referenced components, following references between them
+func copyComponents(src *yaml.Node, refs map[string]map[string]bool) *yaml.Node {
+	if src == nil {
+		return nil
+	}
+
+	// Referenced components can reference more components, repeat until nothing new shows up
+	for seen := -1; seen != countRefs(refs); {
+		seen = countRefs(refs)
+		for kind, names := range refs {
+			section := mappingValue(src, kind)
+			for name := range names {
+				collectComponentRefs(mappingValue(section, name), refs)
+			}
+		}
+	}
+
+	components := newMapping()
+	for _, kind := range componentKinds {
+		section := mappingValue(src, kind)
+		if section == nil || len(refs[kind]) == 0 {
+			continue
+		}
+		out := newMapping()
+		for i := 0; i+1 < len(section.Content); i += 2 {
+			if refs[kind][section.Content[i].Value] {
+				out.Content = append(out.Content, section.Content[i], section.Content[i+1])
+			}
+		}
+		if len(out.Content) > 0 {
+			setMapping(components, kind, out)
+		}
+	}
+	if len(components.Content) == 0 {
+		return nil
+	}
+	return components
+}
+
+func countRefs(refs map[string]map[string]bool) int {
+	n := 0
+	for _, names := range refs {
+		n += len(names)
+	}
+	return n
+}
+
+func marshalFragment(node *yaml.Node) ([]byte, error) {
+	var out strings.Builder
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(out.String()), nil
+}
+
+// marshalFragmentJSON writes a fragment as indented JSON, keeping the key order of the source
+func marshalFragmentJSON(node *yaml.Node) ([]byte, error) {
+	var compact bytes.Buffer
+	if err := writeJSONNode(&compact, node); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+func writeJSONNode(w *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			w.WriteString("null")
+			return nil
+		}
+		return writeJSONNode(w, node.Content[0])
+	case yaml.AliasNode:
+		return writeJSONNode(w, node.Alias)
+	case yaml.MappingNode:
+		w.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			key, _ := json.Marshal(node.Content[i].Value)
+			w.Write(key)
+			w.WriteByte(':')
+			if err := writeJSONNode(w, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		w.WriteByte('}')
+	case yaml.SequenceNode:
+		w.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := writeJSONNode(w, item); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+	case yaml.ScalarNode:
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		if node.ShortTag() == "!!str" || node.ShortTag() == "!!timestamp" {
+			value = node.Value
+		}
+		// Numbers keep the precision they are written with, 9.90 stays 9.90
+		if (node.ShortTag() == "!!int" || node.ShortTag() == "!!float") && json.Valid([]byte(node.Value)) {
+			w.WriteString(node.Value)
+			return nil
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		w.Write(encoded)
+	}
+	return nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fragmentFileName suggests a file name for an exported operation
+func fragmentFileName(ep endpoint) string {
+	name := ep.op.OperationId
+	if name == "" {
+		name = strings.ToLower(ep.method) + "-" + ep.path
+	}
+	name = strings.Trim(unsafeFileChars.ReplaceAllString(name, "-"), "-")
+	return name + ".yaml"
+}
+
+// exportOperation writes the operation under the cursor as a standalone spec, to path or
+// to a file named after the operation
+func (m *Model) exportOperation(path string) {
+	ep, ok := m.selectedEndpoint()
+	if m.mode != viewEndpoints || !ok {
+		m.setStatus("Select an endpoint to export", true)
+		return
+	}
+	if path == "" {
+		path = fragmentFileName(ep)
+	}
+
+	title := ep.method + " " + ep.path
+	if m.doc.Info != nil && m.doc.Info.Title != "" {
+		title = m.doc.Info.Title + ": " + title
+	}
+	fragment, err := buildFragment(m.doc, []endpoint{ep}, title)
+	if err == nil {
+		var content []byte
+		if content, err = marshalFragment(fragment); err == nil {
+			err = os.WriteFile(path, content, 0o644)
+		}
+	}
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Error exporting %s %s: %v", ep.method, ep.path, err), true)
+		return
+	}
+	m.setStatus(fmt.Sprintf("Exported %s %s to %s", ep.method, ep.path, path), false)
+}
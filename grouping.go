@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// endpointRow is a line of the endpoints view: an endpoint, or a tag header when the view is
+// grouped by tag
+type endpointRow struct {
+	tag   string
+	count int
+	// index points into getActiveEndpoints, it is -1 for tag headers
+	index int
+}
+
+func (r endpointRow) isHeader() bool {
+	return r.index < 0
+}
+
+// endpointRows lays out the endpoints view. When grouped, operations with several tags are
+// listed under each of them, and operations without tags come last
+func (m *Model) endpointRows() []endpointRow {
+	eps := m.getActiveEndpoints()
+	if !m.groupedByTag {
+		rows := make([]endpointRow, len(eps))
+		for i := range eps {
+			rows[i] = endpointRow{index: i}
+		}
+		return rows
+	}
+
+	groups := map[string][]int{}
+	for i, ep := range eps {
+		if len(ep.op.Tags) == 0 {
+			groups[untaggedGroup] = append(groups[untaggedGroup], i)
+		}
+		for _, tag := range ep.op.Tags {
+			if !slices.Contains(groups[tag], i) {
+				groups[tag] = append(groups[tag], i)
+			}
+		}
+	}
+
+	var rows []endpointRow
+	for _, tag := range append(specTags(m.doc, eps), untaggedGroup) {
+		members := groups[tag]
+		if len(members) == 0 {
+			continue
+		}
+		rows = append(rows, endpointRow{tag: tag, count: len(members), index: -1})
+		if m.collapsedTags[tag] {
+			continue
+		}
+		for _, i := range members {
+			rows = append(rows, endpointRow{tag: tag, index: i})
+		}
+	}
+	return rows
+}
+
+// selectedEndpoint returns the endpoint under the cursor, false on a tag header
+func (m *Model) selectedEndpoint() (endpoint, bool) {
+	rows := m.endpointRows()
+	if m.cursor < 0 || m.cursor >= len(rows) || rows[m.cursor].isHeader() {
+		return endpoint{}, false
+	}
+	return m.getActiveEndpoints()[rows[m.cursor].index], true
+}
+
+// selectedTagGroup returns the tag header under the cursor
+func (m *Model) selectedTagGroup() (endpointRow, bool) {
+	rows := m.endpointRows()
+	if m.cursor < 0 || m.cursor >= len(rows) || !rows[m.cursor].isHeader() {
+		return endpointRow{}, false
+	}
+	return rows[m.cursor], true
+}
+
+// endpointRowIndex returns the first row showing the endpoint at index in getActiveEndpoints,
+// expanding its group if needed
+func (m *Model) endpointRowIndex(index int) int {
+	rows := m.endpointRows()
+	for i, row := range rows {
+		if row.index == index {
+			return i
+		}
+	}
+	eps := m.getActiveEndpoints()
+	if index < len(eps) && m.groupedByTag {
+		tag := untaggedGroup
+		if len(eps[index].op.Tags) > 0 {
+			tag = eps[index].op.Tags[0]
+		}
+		delete(m.collapsedTags, tag)
+		for i, row := range m.endpointRows() {
+			if row.index == index {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// toggleGrouping switches between the flat list and the list grouped by tag, keeping the
+// selected endpoint under the cursor
+func (m *Model) toggleGrouping() {
+	index := -1
+	if rows := m.endpointRows(); m.cursor >= 0 && m.cursor < len(rows) {
+		index = rows[m.cursor].index
+	}
+	m.groupedByTag = !m.groupedByTag
+	m.cursor = 0
+	if index >= 0 {
+		m.cursor = m.endpointRowIndex(index)
+	}
+	m.scrollOffset = 0
+	m.ensureCursorVisible()
+}
+
+// toggleTagGroup collapses or expands the group of the tag header under the cursor
+func (m *Model) toggleTagGroup(tag string) {
+	if m.collapsedTags == nil {
+		m.collapsedTags = map[string]bool{}
+	}
+	m.collapsedTags[tag] = !m.collapsedTags[tag]
+	m.ensureCursorVisible()
+}
+
+// tagGroupDetails describes a tag header for the split view
+func (m *Model) tagGroupDetails(row endpointRow) string {
+	var details []string
+	for _, tag := range m.doc.Tags {
+		if tag != nil && tag.Name == row.tag && tag.Description != "" {
+			details = append(details, "Description: "+tag.Description)
+		}
+	}
+	details = append(details, fmt.Sprintf("Operations: %d", row.count))
+	return strings.Join(details, "\n")
+}
+
+// renderTagHeader renders the header line of a tag group with its operation count
+func (m Model) renderTagHeader(row endpointRow, selected bool, width int) string {
+	style := lipgloss.NewStyle()
+	if selected {
+		style = highlight(style)
+	}
+	foldIcon := "▼"
+	if m.collapsedTags[row.tag] {
+		foldIcon = "▶"
+	}
+	title := style.Foreground(lipgloss.Color(colorThemePurple)).Bold(true).Render(row.tag)
+	if row.tag == untaggedGroup {
+		title = style.Foreground(lipgloss.Color(colorGray)).Italic(true).Render(row.tag)
+	}
+	count := style.Foreground(lipgloss.Color(colorGray)).Render(fmt.Sprintf(" (%d)", row.count))
+	return style.Render(foldIcon+" ") + title + count + style.Render(strings.Repeat(" ", width))
+}
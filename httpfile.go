@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// httpVariableChars are the characters not allowed in a variable of an .http file
+var httpVariableChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// credentialUse is where an operation sends the credential of one of its security schemes
+type credentialUse struct {
+	scheme string
+	// in is header, query or cookie, name the header, parameter or cookie it goes in
+	in, name string
+	prefix   string
+}
+
+// operationCredentials returns where the schemes of an operation's first security
+// requirement put their credentials, leaving out schemes that can't be sent as a value
+func operationCredentials(doc *v3.Document, op *v3.Operation) []credentialUse {
+	if doc.Components == nil || doc.Components.SecuritySchemes == nil {
+		return nil
+	}
+	var uses []credentialUse
+	for _, name := range postmanSecurity(effectiveSecurity(doc, op)) {
+		scheme := doc.Components.SecuritySchemes.GetOrZero(name)
+		if scheme == nil {
+			continue
+		}
+		switch strings.ToLower(scheme.Type) {
+		case "http":
+			prefix := "Bearer "
+			if strings.EqualFold(scheme.Scheme, "basic") {
+				prefix = "Basic "
+			}
+			uses = append(uses, credentialUse{scheme: name, in: "header", name: "Authorization", prefix: prefix})
+		case "oauth2", "openidconnect":
+			uses = append(uses, credentialUse{scheme: name, in: "header", name: "Authorization", prefix: "Bearer "})
+		case "apikey":
+			if scheme.In == "header" || scheme.In == "query" || scheme.In == "cookie" {
+				uses = append(uses, credentialUse{scheme: name, in: scheme.In, name: scheme.Name})
+			}
+		}
+	}
+	return uses
+}
+
+// httpRequests are operations written as requests of an .http file, the format of the VS
+// Code REST Client and the JetBrains HTTP client
+type httpRequests struct {
+	title    string
+	baseURL  string
+	requests []string
+	// credentials are the variables holding the credentials the requests send, by scheme
+	credentials []string
+}
+
+// buildHTTPRequests writes each operation as a request to {{baseUrl}}, the first server of
+// the spec, unless its path or operation declares its own servers. Parameters with an
+// example or default are filled in, and credentials come from a variable named after the
+// security scheme
+func buildHTTPRequests(doc *v3.Document, eps []endpoint, specPath string) httpRequests {
+	h := httpRequests{title: "API", baseURL: "https://api.example.com"}
+	if doc.Info != nil && doc.Info.Title != "" {
+		h.title = doc.Info.Title
+	}
+	if server := defaultServerURL(doc, specPath); server != "" {
+		h.baseURL = strings.TrimSuffix(server, "/")
+	}
+
+	for _, ep := range eps {
+		var b strings.Builder
+		name := ep.method + " " + ep.path
+		if ep.op.Summary != "" {
+			name = ep.op.Summary
+		}
+		fmt.Fprintf(&b, "### %s\n", strings.Join(strings.Fields(name), " "))
+		if ep.op.OperationId != "" {
+			fmt.Fprintf(&b, "# @name %s\n", httpVariableChars.ReplaceAllString(ep.op.OperationId, "_"))
+		}
+
+		host := "{{baseUrl}}"
+		if server := operationServer(doc, ep); server != nil && !slices.Contains(doc.Servers, server) {
+			host = strings.TrimSuffix(resolveServerURL(server, nil, specPath), "/")
+		}
+		path := ep.path
+		var query, headers, cookies []string
+		for _, p := range declaredParameters(doc, ep) {
+			value := parameterExample(p)
+			switch {
+			case p.In == "path" && value == "":
+				// Left for the client to prompt for, or to be set as a variable
+				path = strings.ReplaceAll(path, "{"+p.Name+"}", "{{"+httpVariableChars.ReplaceAllString(p.Name, "_")+"}}")
+			case p.In == "path":
+				path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(value))
+			case value == "":
+			case p.In == "query":
+				query = append(query, url.QueryEscape(p.Name)+"="+url.QueryEscape(value))
+			case p.In == "header":
+				headers = append(headers, p.Name+": "+value)
+			case p.In == "cookie":
+				cookies = append(cookies, p.Name+"="+value)
+			}
+		}
+		for _, use := range operationCredentials(doc, ep.op) {
+			variable := httpVariableChars.ReplaceAllString(use.scheme, "_")
+			value := use.prefix + "{{" + variable + "}}"
+			switch use.in {
+			case "header":
+				headers = append(headers, use.name+": "+value)
+			case "query":
+				query = append(query, url.QueryEscape(use.name)+"="+value)
+			case "cookie":
+				cookies = append(cookies, use.name+"="+value)
+			}
+			if !slices.Contains(h.credentials, variable) {
+				h.credentials = append(h.credentials, variable)
+			}
+		}
+		if len(cookies) > 0 {
+			headers = append(headers, "Cookie: "+strings.Join(cookies, "; "))
+		}
+
+		target := host + path
+		if len(query) > 0 {
+			target += "?" + strings.Join(query, "&")
+		}
+		fmt.Fprintf(&b, "%s %s\n", ep.method, target)
+		mediaType, body, ok := exampleRequestBody(doc, ep.op)
+		if ok {
+			headers = append(headers, "Content-Type: "+mediaType)
+		}
+		for _, header := range headers {
+			b.WriteString(header + "\n")
+		}
+		if ok {
+			b.WriteString("\n" + strings.TrimRight(body, "\n") + "\n")
+		}
+		h.requests = append(h.requests, b.String())
+	}
+	slices.Sort(h.credentials)
+	return h
+}
+
+// write writes the requests, separated by ###. With declare, the file starts with the
+// variables they use: the base URL and an empty credential per security scheme to fill in.
+// Without, they are expected to come from an environment file
+func (h httpRequests) write(w io.Writer, declare bool) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", h.title)
+	if declare {
+		fmt.Fprintf(&b, "\n@baseUrl = %s\n", h.baseURL)
+		for _, variable := range h.credentials {
+			fmt.Fprintf(&b, "@%s =\n", variable)
+		}
+	}
+	for _, request := range h.requests {
+		b.WriteString("\n" + request)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// httpEnvironments returns the environments of an http-client.env.json file, one per server
+// of the spec named after its description or host, and the private environments with the
+// credentials left empty, for http-client.private.env.json
+func (h httpRequests) httpEnvironments(doc *v3.Document, specPath string) (map[string]map[string]string, map[string]map[string]string) {
+	public, private := map[string]map[string]string{}, map[string]map[string]string{}
+	for _, server := range doc.Servers {
+		if server == nil {
+			continue
+		}
+		serverURL := strings.TrimSuffix(resolveServerURL(server, nil, specPath), "/")
+		name := server.Description
+		if u, err := url.Parse(serverURL); name == "" && err == nil && u.Host != "" {
+			name = u.Host
+		}
+		if name == "" {
+			name = serverURL
+		}
+		for base, n := name, 2; public[name] != nil; n++ {
+			name = fmt.Sprintf("%s %d", base, n)
+		}
+		public[name] = map[string]string{"baseUrl": serverURL}
+	}
+	if len(public) == 0 {
+		public["default"] = map[string]string{"baseUrl": h.baseURL}
+	}
+	for name := range public {
+		private[name] = map[string]string{}
+		for _, variable := range h.credentials {
+			private[name][variable] = ""
+		}
+	}
+	return public, private
+}
+
+// exportHTTPRequests writes the endpoints in the view, as searched and filtered, to an .http
+// file at path or named after the spec
+func (m *Model) exportHTTPRequests(path string) {
+	eps := m.getActiveEndpoints()
+	if m.mode != viewEndpoints || len(eps) == 0 {
+		m.setStatus("Show the endpoints to export as requests", true)
+		return
+	}
+	if path == "" {
+		path = m.exportPath("requests.http")
+	}
+
+	f, err := os.Create(path)
+	if err == nil {
+		err = buildHTTPRequests(m.doc, eps, m.specPath).write(f, true)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Error exporting requests: %v", err), true)
+		return
+	}
+	m.setStatus(fmt.Sprintf("Exported %d %s to %s", len(eps), plural(len(eps), "request", "requests"), path), false)
+}
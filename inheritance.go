@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"github.com/pb33f/libopenapi/orderedmap"
+)
+
+type schemaMap = *orderedmap.Map[string, *base.SchemaProxy]
+
+// schemaParent returns the component schema a schema extends: the first $ref of its allOf
+func schemaParent(proxy *base.SchemaProxy) string {
+	if proxy == nil || proxy.Schema() == nil {
+		return ""
+	}
+	for _, member := range proxy.Schema().AllOf {
+		if member != nil && member.IsReference() {
+			if name, ok := componentSchemaName(member.GetReference()); ok {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// schemaExtensions lists the component schemas that extend name directly, sorted by name
+func schemaExtensions(schemas schemaMap, name string) []string {
+	var names []string
+	for pair := schemas.First(); pair != nil; pair = pair.Next() {
+		if schemaParent(pair.Value()) == name {
+			names = append(names, pair.Key())
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// inheritanceChain lists the schemas name extends and is extended by, from the base down.
+// Below name the chain follows the first schema extending each level
+func inheritanceChain(schemas schemaMap, name string) []string {
+	chain := []string{name}
+	for parent := schemaParent(schemas.GetOrZero(name)); parent != "" && !slices.Contains(chain, parent); parent = schemaParent(schemas.GetOrZero(parent)) {
+		chain = slices.Insert(chain, 0, parent)
+	}
+	for {
+		children := schemaExtensions(schemas, chain[len(chain)-1])
+		if len(children) == 0 || slices.Contains(chain, children[0]) {
+			return chain
+		}
+		chain = append(chain, children[0])
+	}
+}
+
+// levelNodes builds the inheritance view of a schema: a group per level of the chain down to
+// the schema, holding the properties that level declares itself or in its inline allOf members
+func levelNodes(schemas schemaMap, chain []string) []*schemaNode {
+	var groups []*schemaNode
+	for i, name := range chain {
+		s := schemas.GetOrZero(name).Schema()
+		if s == nil {
+			continue
+		}
+		refs := chain[:i+1]
+		group := &schemaNode{label: "from " + name, level: name, depth: 1, refs: refs, loaded: true, expanded: true}
+		levelSchemas := []*base.Schema{s}
+		for _, member := range s.AllOf {
+			if member != nil && !member.IsReference() && member.Schema() != nil {
+				levelSchemas = append(levelSchemas, member.Schema())
+			}
+		}
+		for _, ls := range levelSchemas {
+			if ls.Properties == nil {
+				continue
+			}
+			for pair := ls.Properties.First(); pair != nil; pair = pair.Next() {
+				if pair.Value() != nil {
+					group.children = append(group.children, &schemaNode{label: pair.Key(), proxy: pair.Value(), required: slices.Contains(ls.Required, pair.Key()), depth: 2, refs: refs})
+				}
+			}
+		}
+		group.expanded = len(group.children) > 0
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// levelSummary is shown after a level of the inheritance view, e.g. "2 properties"
+func (n *schemaNode) levelSummary() string {
+	return fmt.Sprintf("%d %s", len(n.children), plural(len(n.children), "property", "properties"))
+}
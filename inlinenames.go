@@ -0,0 +1,80 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+)
+
+// operationName names an operation after its operationId, or else after the one the naming
+// convention proposes for its method and path, such as GetUserById
+func operationName(method, path, operationID string) string {
+	if operationID == "" {
+		operationID = operationIDFor(method, path)
+	}
+	return pascalCase(operationID)
+}
+
+// isNamedInline reports whether an inline schema is worth a name of its own: objects,
+// compositions, enums and arrays of them, not plain scalars or references
+func isNamedInline(proxy *base.SchemaProxy) bool {
+	if proxy == nil || proxy.IsReference() || proxy.Schema() == nil {
+		return false
+	}
+	s := proxy.Schema()
+	if s.Items != nil && s.Items.IsA() {
+		return isNamedInline(s.Items.A)
+	}
+	return (s.Properties != nil && s.Properties.Len() > 0) || len(s.AllOf) > 0 || len(s.OneOf) > 0 ||
+		len(s.AnyOf) > 0 || len(s.Enum) > 0 || slices.Contains(s.Type, "object")
+}
+
+// schemaDisplayName names the schema of a body: its component for a $ref, name for inline
+// objects, or its type, such as string, for plain schemas
+func schemaDisplayName(proxy *base.SchemaProxy, name string) string {
+	if isNamedInline(proxy) {
+		return name
+	}
+	return schemaType(proxy)
+}
+
+// inlineSchemaNames lists the names the inline request and response schemas of an operation
+// are shown under, such as CreateUserRequestBody and GetUserResponse200
+func inlineSchemaNames(ep endpoint) []string {
+	name := operationName(ep.method, ep.path, ep.op.OperationId)
+	var names []string
+	if ep.op.RequestBody != nil && isNamedInline(mediaSchema(ep.op.RequestBody.Content)) {
+		names = append(names, name+"RequestBody")
+	}
+	if ep.op.Responses != nil && ep.op.Responses.Codes != nil {
+		for pair := ep.op.Responses.Codes.First(); pair != nil; pair = pair.Next() {
+			if pair.Value() != nil && isNamedInline(mediaSchema(pair.Value().Content)) {
+				names = append(names, name+"Response"+pascalCase(pair.Key()))
+			}
+		}
+	}
+	if ep.op.Responses != nil && ep.op.Responses.Default != nil && isNamedInline(mediaSchema(ep.op.Responses.Default.Content)) {
+		names = append(names, name+"ResponseDefault")
+	}
+	return names
+}
+
+// mediaSchema returns the schema of a body, the one of its JSON media type when there are
+// several
+func mediaSchema(content *orderedmap.Map[string, *v3.MediaType]) *base.SchemaProxy {
+	var schema *base.SchemaProxy
+	for pair := content.First(); pair != nil; pair = pair.Next() {
+		if pair.Value() == nil || pair.Value().Schema == nil {
+			continue
+		}
+		if isJSONMediaType(pair.Key()) {
+			return pair.Value().Schema
+		}
+		if schema == nil {
+			schema = pair.Value().Schema
+		}
+	}
+	return schema
+}
@@ -0,0 +1,60 @@
+//go:build darwin
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// macKeychain stores secrets as generic passwords in the login keychain via security(1)
+type macKeychain struct{}
+
+func newKeychainStore() (credentialStore, bool) {
+	if _, err := exec.LookPath("security"); err != nil {
+		return nil, false
+	}
+	return macKeychain{}, true
+}
+
+func (macKeychain) name() string {
+	return "macOS Keychain"
+}
+
+func (macKeychain) get(key string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", credentialService, "-a", key, "-w").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+		return "", errCredentialNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// set passes the command through stdin so the secret doesn't show up in the process list
+func (macKeychain) set(key, value string) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader("add-generic-password -U -s " + securityQuote(credentialService) +
+		" -a " + securityQuote(key) + " -w " + securityQuote(value) + "\n")
+	if out, err := cmd.CombinedOutput(); err != nil || len(out) > 0 && strings.Contains(string(out), "error") {
+		return errors.New(strings.TrimSpace("security: " + string(out)))
+	}
+	return nil
+}
+
+func (macKeychain) delete(key string) error {
+	err := exec.Command("security", "delete-generic-password", "-s", credentialService, "-a", key).Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+		return errCredentialNotFound
+	}
+	return err
+}
+
+// securityQuote quotes a value for the security(1) interactive command parser
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
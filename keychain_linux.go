@@ -0,0 +1,101 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// newKeychainStore prefers the Secret Service (GNOME Keyring, KWallet) and falls back to the
+// kernel user keyring, which only lasts until the user logs out
+func newKeychainStore() (credentialStore, bool) {
+	if _, err := exec.LookPath("secret-tool"); err == nil {
+		return secretService{}, true
+	}
+	if _, err := exec.LookPath("keyctl"); err == nil {
+		return kernelKeyring{}, true
+	}
+	return nil, false
+}
+
+// secretService stores secrets through secret-tool(1) from libsecret
+type secretService struct{}
+
+func (secretService) name() string {
+	return "Secret Service keyring"
+}
+
+func (secretService) get(key string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", credentialService, "account", key).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(out) == 0 {
+		return "", errCredentialNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (secretService) set(key, value string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", credentialService+" "+key, "service", credentialService, "account", key)
+	cmd.Stdin = strings.NewReader(value)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.New(strings.TrimSpace("secret-tool: " + string(out)))
+	}
+	return nil
+}
+
+func (secretService) delete(key string) error {
+	if _, err := (secretService{}).get(key); err != nil {
+		return err
+	}
+	return exec.Command("secret-tool", "clear", "service", credentialService, "account", key).Run()
+}
+
+// kernelKeyring stores secrets as user keys in the session's user keyring via keyctl(1)
+type kernelKeyring struct{}
+
+func (kernelKeyring) name() string {
+	return "kernel user keyring (cleared on logout)"
+}
+
+func keyringDescription(key string) string {
+	return credentialService + ":" + key
+}
+
+func (kernelKeyring) id(key string) (string, error) {
+	out, err := exec.Command("keyctl", "search", "@u", "user", keyringDescription(key)).Output()
+	if err != nil {
+		return "", errCredentialNotFound
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (k kernelKeyring) get(key string) (string, error) {
+	id, err := k.id(key)
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.Command("keyctl", "pipe", id).Output()
+	return string(out), err
+}
+
+func (kernelKeyring) set(key, value string) error {
+	cmd := exec.Command("keyctl", "padd", "user", keyringDescription(key), "@u")
+	cmd.Stdin = strings.NewReader(value)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.New(strings.TrimSpace("keyctl: " + string(out)))
+	}
+	return nil
+}
+
+func (k kernelKeyring) delete(key string) error {
+	id, err := k.id(key)
+	if err != nil {
+		return err
+	}
+	return exec.Command("keyctl", "unlink", id, "@u").Run()
+}
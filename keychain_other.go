@@ -0,0 +1,7 @@
+//go:build !darwin && !linux && !windows
+
+package main
+
+func newKeychainStore() (credentialStore, bool) {
+	return nil, false
+}
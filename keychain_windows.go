@@ -0,0 +1,51 @@
+//go:build windows
+
+package main
+
+import (
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// newKeychainStore keeps secrets in the credentials file, sealed with DPAPI so only the
+// current Windows user can decrypt them
+func newKeychainStore() (credentialStore, bool) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, false
+	}
+	return &fileCredentialStore{path: filepath.Join(dir, "credentials.dpapi.yaml"), sealer: dpapiSealer{}}, true
+}
+
+type dpapiSealer struct{}
+
+func newBlob(data []byte) *windows.DataBlob {
+	if len(data) == 0 {
+		return &windows.DataBlob{}
+	}
+	return &windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+}
+
+func (b dpapiSealer) seal(plaintext []byte) ([]byte, error) {
+	var out windows.DataBlob
+	if err := windows.CryptProtectData(newBlob(plaintext), nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return nil, err
+	}
+	return takeBlob(out), nil
+}
+
+func (b dpapiSealer) open(ciphertext []byte) ([]byte, error) {
+	var out windows.DataBlob
+	if err := windows.CryptUnprotectData(newBlob(ciphertext), nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out); err != nil {
+		return nil, err
+	}
+	return takeBlob(out), nil
+}
+
+// takeBlob copies a DPAPI result into Go memory and frees it
+func takeBlob(blob windows.DataBlob) []byte {
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(blob.Data)))
+	return append([]byte(nil), unsafe.Slice(blob.Data, blob.Size)...)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// keyPresets are named sets of bindings added to the built-in vim-style keys, picked with
+// the keymap setting. key_bindings from the config go on top
+var keyPresets = map[string]map[string]string{
+	"vim": {},
+	"emacs": {
+		"ctrl+n": "down",
+		"ctrl+p": "up",
+		"ctrl+v": "ctrl+d",
+		"alt+v":  "ctrl+u",
+		"alt+<":  "gg",
+		"alt+>":  "G",
+		"ctrl+s": "/",
+		"ctrl+g": "esc",
+	},
+}
+
+// bindableKeys are the built-in keys a binding can act as. gg is the two-key sequence
+// moving to the top, and none disables the bound key
+var bindableKeys = []string{
+	"up", "down", "k", "j", "gg", "g", "G", "ctrl+u", "ctrl+d", "ctrl+f", "ctrl+b",
+	"tab", "shift+tab", "L", "H", "enter", "space", "esc", "q", "?", "/", ":",
+	"h", "l", "e", "E", "F", "u", "r", "x", "b", "O", "T", "A", "M", "P", "I", "y", "Y", "R", "t", "v", "p", "S", "W", "J", "K", "o", "D", "X",
+	"1", "2", "3", "4", "5", "6", "7", "8", "9", "none",
+}
+
+func keymapNames() string {
+	return strings.Join(slices.Sorted(maps.Keys(keyPresets)), ", ")
+}
+
+// validateKeyBinding checks a key and the built-in key it should act as
+func validateKeyBinding(key, target string) error {
+	switch {
+	case key == "" || strings.ContainsAny(key, " \t\n,="):
+		return fmt.Errorf("%q is not a key, use names such as ctrl+n, alt+v or F", key)
+	case key == "ctrl+c":
+		return fmt.Errorf("ctrl+c always quits")
+	case !slices.Contains(bindableKeys, target):
+		return fmt.Errorf("%s: %q is not a built-in key, expected one of %s", key, target, strings.Join(bindableKeys, " "))
+	}
+	return nil
+}
+
+// keyBindingsFromConfig merges the keymap preset and the configured bindings
+func keyBindingsFromConfig(cfg *Config) map[string]string {
+	bindings := map[string]string{}
+	maps.Copy(bindings, keyPresets[cfg.Keymap])
+	maps.Copy(bindings, cfg.KeyBindings)
+	if len(bindings) == 0 {
+		return nil
+	}
+	// Space arrives as a literal space
+	normalized := make(map[string]string, len(bindings))
+	for key, target := range bindings {
+		if key == "space" {
+			key = " "
+		}
+		if target == "space" {
+			target = " "
+		}
+		normalized[key] = target
+	}
+	return normalized
+}
+
+// bindKey returns the built-in key a pressed key acts as, empty when it is disabled
+func (m Model) bindKey(key string) string {
+	target, ok := m.keyBindings[key]
+	if !ok {
+		return key
+	}
+	if target == "none" {
+		return ""
+	}
+	return target
+}
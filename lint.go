@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// lintSeverity orders issues the way Spectral numbers them, errors first
+type lintSeverity int
+
+const (
+	severityError lintSeverity = iota
+	severityWarn
+	severityInfo
+	severityHint
+)
+
+var severityNames = []string{"error", "warn", "info", "hint"}
+
+func (s lintSeverity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return "unknown"
+	}
+	return severityNames[s]
+}
+
+func parseLintSeverity(name string) (lintSeverity, bool) {
+	for i, severityName := range severityNames {
+		if name == severityName {
+			return lintSeverity(i), true
+		}
+	}
+	return 0, false
+}
+
+// lintIssue is a rule violation at a position in the spec as written
+type lintIssue struct {
+	rule     string
+	severity lintSeverity
+	message  string
+	path     []string
+	line     int
+	column   int
+}
+
+func (i lintIssue) location() string {
+	if i.line == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d:%d", i.line, i.column)
+}
+
+// lintSummary counts the issues, as in "3 problems (1 error, 2 warnings)"
+func lintSummary(issues []lintIssue) string {
+	counts := make([]int, len(severityNames))
+	for _, issue := range issues {
+		counts[issue.severity]++
+	}
+	parts := []string{
+		fmt.Sprintf("%d %s", counts[severityError], plural(counts[severityError], "error", "errors")),
+		fmt.Sprintf("%d %s", counts[severityWarn], plural(counts[severityWarn], "warning", "warnings")),
+	}
+	if counts[severityInfo] > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[severityInfo], plural(counts[severityInfo], "info", "infos")))
+	}
+	if counts[severityHint] > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[severityHint], plural(counts[severityHint], "hint", "hints")))
+	}
+	return fmt.Sprintf("%d %s (%s)", len(issues), plural(len(issues), "problem", "problems"), strings.Join(parts, ", "))
+}
+
+// lintSpec parses the spec as written, so positions match the file, and runs the ruleset
+func lintSpec(content []byte, ruleset *spectralRuleset) ([]lintIssue, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("Error parsing spec: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("Error: spec is empty")
+	}
+	return ruleset.evaluate(doc.Content[0]), nil
+}
+
+func writeLintText(w io.Writer, issues []lintIssue) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, issue := range issues {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", issue.location(), issue.severity, issue.rule, issue.message, strings.Join(issue.path, "."))
+	}
+	tw.Flush()
+}
+
+// jsonLintIssue is an issue in `oq lint --format json`
+type jsonLintIssue struct {
+	Rule     string   `json:"rule"`
+	Severity string   `json:"severity"`
+	Message  string   `json:"message"`
+	Path     []string `json:"path"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+}
+
+func writeLintJSON(w io.Writer, issues []lintIssue) error {
+	out := make([]jsonLintIssue, len(issues))
+	for i, issue := range issues {
+		out[i] = jsonLintIssue{
+			Rule:     issue.rule,
+			Severity: issue.severity.String(),
+			Message:  issue.message,
+			Path:     issue.path,
+			Line:     issue.line,
+			Column:   issue.column,
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// lintFormats are the values `oq lint --format` accepts
+var lintFormats = []string{"text", "json", "sarif"}
+
+// budgetRule names the issues for budgets the spec exceeds, see specBudgets
+const budgetRule = "oq-budget"
+
+// budgetIssues turns the warnings of checkBudgets into errors, as the budgets cover the
+// whole spec rather than a position in it
+func budgetIssues(warnings []string) []lintIssue {
+	issues := make([]lintIssue, len(warnings))
+	for i, warning := range warnings {
+		issues[i] = lintIssue{rule: budgetRule, severity: severityError, message: "Over budget: " + warning}
+	}
+	return issues
+}
+
+// runLint implements `oq lint spec.yaml`, evaluating a Spectral ruleset and the budgets in
+// the config. It exits with 1 when an issue is at least as severe as --fail-severity
+func runLint(ctx context.Context, cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	rulesetFlag := fs.String("ruleset", "", "Spectral ruleset file (default .spectral.yaml, .spectral.yml or .spectral.json)")
+	format := fs.String("format", "text", "output format: text, json or sarif")
+	failSeverity := fs.String("fail-severity", "error", "exit with 1 for issues of this severity or worse: error, warn, info or hint")
+	watch := fs.Bool("watch", false, "lint again whenever the spec or the ruleset changes, printing new and fixed issues")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq lint [--ruleset file] [--format text|json|sarif] [--fail-severity error] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq lint --watch [--ruleset file] <spec>\n\n")
+		fs.PrintDefaults()
+	}
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	failAt, ok := parseLintSeverity(*failSeverity)
+	if len(args) > 1 || !slices.Contains(lintFormats, *format) || !ok {
+		fs.Usage()
+		return 2
+	}
+
+	rulesetPath, err := findRuleset(*rulesetFlag)
+	if err != nil {
+		return reportError(err)
+	}
+	budgets := budgetsFromConfig(cfg)
+	if rulesetPath == "" && (!budgets.isSet() || *watch) {
+		fmt.Fprintf(os.Stderr, "Error: no ruleset, pass --ruleset or add %s\n", defaultRulesetFiles[0])
+		return 2
+	}
+	// Without a ruleset only the budgets are checked
+	ruleset := &spectralRuleset{}
+	if rulesetPath != "" {
+		ruleset, err = loadRuleset(rulesetPath)
+		if err != nil {
+			return reportError(err)
+		}
+	}
+	for _, skipped := range ruleset.skipped {
+		fmt.Fprintf(os.Stderr, "Skipping %s\n", skipped)
+	}
+
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if *watch {
+		if path == "" || isRemoteSpec(path) || isTarballSpec(path) {
+			fmt.Fprintf(os.Stderr, "Error: --watch needs a local spec file\n")
+			return 2
+		}
+		if *format != "text" {
+			fmt.Fprintf(os.Stderr, "Error: --watch only prints text\n")
+			return 2
+		}
+		return watchLint(ctx, path, rulesetPath, ruleset, os.Stdout)
+	}
+
+	var content []byte
+	var budgetWarnings []string
+	if budgets.isSet() {
+		// The budgets need the built spec, following $refs for the schema depth
+		var doc *v3.Document
+		content, doc, err = loadSpec(ctx, path)
+		if err != nil {
+			return reportError(err)
+		}
+		budgetWarnings = checkBudgets(doc, len(content), budgets, oversizedPayloads(doc, budgets.maxPayloadSize))
+	} else if content, err = readSpec(ctx, path); err != nil {
+		return reportError(err)
+	}
+	issues, err := lintSpec(content, ruleset)
+	if err != nil {
+		return reportError(err)
+	}
+	issues = append(issues, budgetIssues(budgetWarnings)...)
+
+	switch {
+	case *format == "json":
+		if err := writeLintJSON(os.Stdout, issues); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			return 1
+		}
+	case *format == "sarif":
+		if err := writeLintSARIF(os.Stdout, issues, ruleset, path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing SARIF: %v\n", err)
+			return 1
+		}
+	case len(issues) == 0 && rulesetPath == "":
+		fmt.Println("No problems found, the spec is within its budgets")
+	case len(issues) == 0:
+		fmt.Printf("No problems found by %d %s\n", len(ruleset.rules), plural(len(ruleset.rules), "rule", "rules"))
+	default:
+		writeLintText(os.Stdout, issues)
+		fmt.Printf("\n%s\n", lintSummary(issues))
+	}
+
+	for _, issue := range issues {
+		if issue.severity <= failAt {
+			return 1
+		}
+	}
+	return 0
+}
+
+// issuesPane lists the problems found in the loaded spec and the ruleset violations
+type issuesPane struct {
+	issues []lintIssue
+	cursor int
+}
+
+// openIssues lists the problems found when the spec was loaded, together with the ruleset
+// issues of the spec as it is on screen
+func (m *Model) openIssues() {
+	issues := slices.Clone(m.problems)
+	if m.ruleset != nil {
+		ruleIssues, err := lintSpec(m.specContent, m.ruleset)
+		if err != nil {
+			m.setStatus(err.Error(), true)
+			return
+		}
+		issues = append(issues, ruleIssues...)
+		sortIssues(issues)
+	}
+	if len(issues) == 0 {
+		if m.ruleset != nil {
+			m.setStatus(fmt.Sprintf("No problems found by %d %s", len(m.ruleset.rules), plural(len(m.ruleset.rules), "rule", "rules")), false)
+		} else {
+			m.setStatus(fmt.Sprintf("No problems found, pass --ruleset or add %s for more rules", defaultRulesetFiles[0]), false)
+		}
+		return
+	}
+	m.issues = &issuesPane{issues: issues}
+}
+
+// updateIssues handles keys while the issues pane is open
+func (m *Model) updateIssues(key string) {
+	pane := m.issues
+	page := max(1, m.height/2)
+	switch key {
+	case "esc", "q", "I":
+		m.issues = nil
+	case "up", "k":
+		pane.cursor = max(0, pane.cursor-1)
+	case "down", "j":
+		pane.cursor = min(len(pane.issues)-1, pane.cursor+1)
+	case "ctrl+u":
+		pane.cursor = max(0, pane.cursor-page)
+	case "ctrl+d":
+		pane.cursor = min(len(pane.issues)-1, pane.cursor+page)
+	case "g":
+		pane.cursor = 0
+	case "G":
+		pane.cursor = len(pane.issues) - 1
+	case "enter":
+		issue := pane.issues[pane.cursor]
+		if m.jumpToIssue(issue) {
+			m.issues = nil
+		} else {
+			m.setStatus("Nothing to show for "+strings.Join(issue.path, "."), true)
+		}
+	}
+}
+
+// componentTypes maps the sections of components to the types the components view shows
+var componentTypes = map[string]string{
+	"schemas":         "Schema",
+	"requestBodies":   "RequestBody",
+	"responses":       "Response",
+	"parameters":      "Parameter",
+	"headers":         "Header",
+	"securitySchemes": "SecurityScheme",
+}
+
+// jumpToIssue shows the operation or component an issue is in
+func (m *Model) jumpToIssue(issue lintIssue) bool {
+	path := issue.path
+	switch {
+	case len(path) >= 2 && path[0] == "paths":
+		method := ""
+		if len(path) >= 3 {
+			method = strings.ToUpper(path[2])
+		}
+		var fallback *endpoint
+		for i, ep := range m.endpoints {
+			if ep.path != path[1] {
+				continue
+			}
+			if ep.method == method {
+				m.jumpToEndpoint(ep)
+				return true
+			}
+			if fallback == nil {
+				fallback = &m.endpoints[i]
+			}
+		}
+		// Issues on the path item itself, such as shared parameters, show its first operation
+		if fallback != nil {
+			m.jumpToEndpoint(*fallback)
+			return true
+		}
+	case len(path) >= 3 && path[0] == "components":
+		if compType, ok := componentTypes[path[1]]; ok {
+			return m.jumpToComponent(compType, path[2])
+		}
+	}
+	return false
+}
+
+func (m Model) renderIssuesPane() string {
+	pane := m.issues
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	detailStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorDetailGray))
+
+	severityColors := []string{colorRed, colorYellow, colorThemePurple, colorGray}
+
+	selected := pane.issues[pane.cursor]
+	details := []string{selected.message}
+	if len(selected.path) > 0 {
+		details = append(details, "at "+strings.Join(selected.path, "."))
+	}
+	if m.ruleset != nil && len(m.ruleset.skipped) > 0 {
+		details = append(details, fmt.Sprintf("%d %s of %s skipped, see oq lint", len(m.ruleset.skipped), plural(len(m.ruleset.skipped), "entry", "entries"), m.ruleset.path))
+	}
+
+	ruleWidth := min(max(16, m.width/4), 36)
+	bodyHeight := max(1, m.height-8-len(details))
+	start := max(0, min(pane.cursor-bodyHeight+1, len(pane.issues)-bodyHeight))
+	var lines []string
+	for i := start; i < len(pane.issues) && i < start+bodyHeight; i++ {
+		issue := pane.issues[i]
+		background := lipgloss.NewStyle()
+		if i == pane.cursor {
+			background = highlight(background)
+		}
+		line := background.Foreground(lipgloss.Color(severityColors[issue.severity])).Bold(true).Width(7).Render(issue.severity.String())
+		line += background.Foreground(lipgloss.Color(colorGray)).Width(9).Render(issue.location())
+		// Long rule names are cut rather than wrapped onto a second line
+		rule := lipgloss.NewStyle().MaxWidth(ruleWidth - 1).Render(issue.rule)
+		line += background.Width(ruleWidth).Render(rule) + background.Render("  ")
+		line += background.Render(issue.message)
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(m.width).Render(line))
+	}
+
+	title := titleStyle.Render("Problems: " + lintSummary(pane.issues))
+	detail := detailStyle.Width(max(20, m.width)).Render(strings.Join(details, "\n"))
+	instruction := instructionStyle.Render("j/k move · Enter show in spec · Esc close")
+
+	return lipgloss.NewStyle().MaxHeight(m.height).Render(title + "\n\n" + strings.Join(lines, "\n") + "\n\n" + detail + "\n\n" + instruction)
+}
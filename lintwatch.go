@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// lintWatcher re-runs the ruleset whenever the spec or the ruleset changes on disk, and
+// reports which issues appeared or were fixed since the previous run
+type lintWatcher struct {
+	specPath    string
+	rulesetPath string
+	ruleset     *spectralRuleset
+	out         io.Writer
+	now         func() time.Time
+
+	specModTime    time.Time
+	rulesetModTime time.Time
+	issues         []lintIssue
+	ran            bool
+}
+
+// lintIssueKey identifies an issue across edits, ignoring its position which moves as
+// lines are added above it
+func lintIssueKey(issue lintIssue) string {
+	return issue.rule + "\x00" + strings.Join(issue.path, "\x00") + "\x00" + issue.message
+}
+
+// watchLint implements `oq lint --watch`, running until ctx is cancelled
+func watchLint(ctx context.Context, specPath, rulesetPath string, ruleset *spectralRuleset, out io.Writer) int {
+	w := &lintWatcher{specPath: specPath, rulesetPath: rulesetPath, ruleset: ruleset, out: out, now: time.Now}
+	w.check()
+	fmt.Fprintf(out, "Watching %s for changes, press Ctrl+C to stop\n", specPath)
+
+	ticker := time.NewTicker(specWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// check lints again when either file's modification time moved since the last run
+func (w *lintWatcher) check() {
+	specInfo, err := os.Stat(w.specPath)
+	if err != nil {
+		return
+	}
+	rulesetInfo, err := os.Stat(w.rulesetPath)
+	if err != nil {
+		return
+	}
+	specChanged := !specInfo.ModTime().Equal(w.specModTime)
+	rulesetChanged := !rulesetInfo.ModTime().Equal(w.rulesetModTime)
+	if w.ran && !specChanged && !rulesetChanged {
+		return
+	}
+	w.specModTime, w.rulesetModTime = specInfo.ModTime(), rulesetInfo.ModTime()
+
+	stamp := w.now().Format("15:04:05")
+	if w.ran && rulesetChanged {
+		ruleset, err := loadRuleset(w.rulesetPath)
+		if err != nil {
+			// Keep the previous rules while the ruleset is being edited
+			fmt.Fprintf(w.out, "[%s] %v\n", stamp, err)
+			return
+		}
+		w.ruleset = ruleset
+	}
+
+	content, err := os.ReadFile(w.specPath)
+	if err != nil {
+		fmt.Fprintf(w.out, "[%s] Error reading spec: %v\n", stamp, err)
+		return
+	}
+	issues, err := lintSpec(content, w.ruleset)
+	if err != nil {
+		fmt.Fprintf(w.out, "[%s] %v\n", stamp, err)
+		return
+	}
+	w.report(stamp, issues)
+}
+
+// report prints every issue on the first run, and afterwards only the new and fixed ones
+func (w *lintWatcher) report(stamp string, issues []lintIssue) {
+	if !w.ran {
+		w.ran = true
+		w.issues = issues
+		fmt.Fprintf(w.out, "[%s] %s\n", stamp, lintSummary(issues))
+		if len(issues) > 0 {
+			writeLintText(w.out, issues)
+		}
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, issue := range w.issues {
+		seen[lintIssueKey(issue)] = true
+	}
+	current := map[string]bool{}
+	var added []lintIssue
+	for _, issue := range issues {
+		current[lintIssueKey(issue)] = true
+		if !seen[lintIssueKey(issue)] {
+			added = append(added, issue)
+		}
+	}
+	var fixed []lintIssue
+	for _, issue := range w.issues {
+		if !current[lintIssueKey(issue)] {
+			fixed = append(fixed, issue)
+		}
+	}
+	w.issues = issues
+
+	fmt.Fprintf(w.out, "[%s] %s", stamp, lintSummary(issues))
+	if len(added) == 0 && len(fixed) == 0 {
+		fmt.Fprintf(w.out, ", no changes\n")
+		return
+	}
+	fmt.Fprintf(w.out, ", %d new, %d fixed\n", len(added), len(fixed))
+	for _, issue := range fixed {
+		fmt.Fprintf(w.out, "  fixed  %s  %s  %s\n", issue.rule, issue.message, strings.Join(issue.path, "."))
+	}
+	for _, issue := range added {
+		fmt.Fprintf(w.out, "  new    %s  %s  %s  %s  %s\n", issue.location(), issue.severity, issue.rule, issue.message, strings.Join(issue.path, "."))
+	}
+}
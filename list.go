@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// runList implements `oq list spec.yaml`, printing endpoints in the same order the TUI
+// shows them for the given sort spec
+func runList(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	sortSpec := fs.String("sort", formatSortSpec(defaultSortSpec), "comma separated sort fields: path, method, tag, id, summary (prefix with - to reverse)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq list [flags] [spec]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	keys, err := parseSortSpec(*sortSpec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --sort: %v\n", err)
+		return 2
+	}
+
+	_, doc, err := loadSpec(ctx, fs.Arg(0))
+	if err != nil {
+		return reportError(err)
+	}
+
+	eps := extractEndpoints(doc)
+	sortEndpoints(eps, keys)
+	writeEndpointList(os.Stdout, eps)
+	return 0
+}
+
+func writeEndpointList(w io.Writer, eps []endpoint) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, ep := range eps {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", ep.method, ep.path, strings.Join(ep.op.Tags, ","), ep.op.Summary)
+	}
+	tw.Flush()
+}
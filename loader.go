@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/utils"
+)
+
+// readSpec reads the spec from the given file path or URL, or from stdin when path is empty.
+// It returns ctx.Err() as soon as ctx is cancelled, even if the read is still blocked
+func readSpec(ctx context.Context, path string) ([]byte, error) {
+	return runWithContext(ctx, func() ([]byte, error) {
+		start := time.Now()
+		if isArtifactSpec(path) {
+			content, err := fetchArtifact(ctx, path)
+			if err != nil {
+				return nil, err
+			}
+			debugLog.Debug("fetched spec artifact", "source", path, "bytes", len(content), "took", time.Since(start))
+			return content, nil
+		}
+		if isRemoteSpec(path) {
+			archive, file := splitArtifactPath(path)
+			if !isTarballSpec(path) {
+				archive = path
+			}
+			content, err := fetchSpec(ctx, archive)
+			if err == nil && isTarballSpec(path) {
+				content, err = extractSpec(content, file, path)
+			}
+			if err != nil {
+				return nil, err
+			}
+			debugLog.Debug("fetched spec", "url", path, "bytes", len(content), "took", time.Since(start))
+			return content, nil
+		}
+		if path != "" {
+			content, err := readSpecFile(path)
+			if err != nil {
+				return nil, err
+			}
+			debugLog.Debug("read spec file", "path", path, "bytes", len(content), "took", time.Since(start))
+			return content, nil
+		}
+
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading from stdin: %w", err)
+		}
+		debugLog.Debug("read spec from stdin", "bytes", len(content), "took", time.Since(start))
+		return content, nil
+	})
+}
+
+// loadSpec reads and builds the spec at path for non-interactive commands, behind the loading
+// screen. Specs with validation errors are still returned, after printing a warning to stderr
+func loadSpec(ctx context.Context, path string) ([]byte, *v3.Document, error) {
+	type loaded struct {
+		content []byte
+		model   *libopenapi.DocumentModel[v3.Document]
+		err     error
+	}
+	spec, err := withLoading(ctx, loadingMessage(path), func(ctx context.Context, step func(string)) (loaded, error) {
+		content, err := readSpec(ctx, path)
+		if err != nil {
+			return loaded{}, err
+		}
+		step("Resolving references...")
+		v3Model, err := buildModel(ctx, content, path)
+		return loaded{content: content, model: v3Model, err: err}, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, v3Model, err := spec.content, spec.model, spec.err
+	if err != nil {
+		if v3Model == nil {
+			return nil, nil, err
+		}
+		debugLog.Warn("spec has validation errors", "error", err)
+		fmt.Fprintf(os.Stderr, "Warning: Spec has validation errors: %v\n", err)
+	}
+
+	return content, &v3Model.Model, nil
+}
+
+// buildModel parses content read from path and builds the v3 model. Like BuildV3Model, it may return
+// both a model and an error when the spec has validation errors but is still usable.
+// A nil model with ctx.Err() is returned when ctx is cancelled first
+func buildModel(ctx context.Context, content []byte, path string) (*libopenapi.DocumentModel[v3.Document], error) {
+	return runWithContext(ctx, func() (*libopenapi.DocumentModel[v3.Document], error) {
+		start := time.Now()
+		progress := newRefProgress(ctx, content, path)
+		cfg := newDocumentConfiguration(path)
+		progress.watch(cfg)
+		prefetch := prefetchReferences(ctx, cfg, content, path)
+		prefetch.serve(cfg)
+		source := content
+		document, err := libopenapi.NewDocumentWithConfiguration(source, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("Error creating document: %w", err)
+		}
+		debugLog.Debug("parsed document", "version", document.GetVersion(), "took", time.Since(start))
+
+		// Swagger 2.0 specs are upgraded to OpenAPI 3.0 so every view works the same for them
+		if info := document.GetSpecInfo(); info != nil && info.SpecType == utils.OpenApi2 {
+			start = time.Now()
+			converted, err := convertSwagger2(content)
+			if err != nil {
+				return nil, fmt.Errorf("Error converting Swagger 2.0 spec: %w", err)
+			}
+			cfg = newDocumentConfiguration(path)
+			progress.watch(cfg)
+			prefetch.serve(cfg)
+			source = converted
+			document, err = libopenapi.NewDocumentWithConfiguration(source, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("Error creating document: %w", err)
+			}
+			debugLog.Debug("converted Swagger 2.0 spec", "took", time.Since(start))
+		}
+
+		start = time.Now()
+		model, err := document.BuildV3Model()
+		debugLog.Debug("built v3 model", "took", time.Since(start), "ok", model != nil, "error", err)
+		if model == nil && progress.skippedAny() {
+			// A skipped document referenced from a fetched one fails the whole build, which is
+			// then done again without following references. libopenapi looks them up wherever
+			// a base or a handler is set
+			cfg.AllowFileReferences, cfg.AllowRemoteReferences = false, false
+			cfg.RemoteURLHandler, cfg.BaseURL, cfg.BasePath, cfg.SpecFilePath = nil, nil, "", ""
+			progress.rebuilt = true
+			if document, err = libopenapi.NewDocumentWithConfiguration(source, cfg); err != nil {
+				return nil, fmt.Errorf("Error creating document: %w", err)
+			}
+			model, err = document.BuildV3Model()
+		}
+		return model, progress.done(err)
+	})
+}
+
+// runWithContext runs fn in the background and waits for it or for ctx, whichever finishes first.
+// libopenapi and blocking reads don't accept a context, so a cancelled fn is left to finish on its
+// own and its result is dropped into a buffered channel. A panic in fn is re-raised in the
+// caller's goroutine as a *backgroundPanic so the crash reporter sees it
+func runWithContext[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	type result struct {
+		value    T
+		err      error
+		panicked *backgroundPanic
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{panicked: &backgroundPanic{value: r, stack: debug.Stack()}}
+			}
+		}()
+		value, err := fn()
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	case r := <-done:
+		if r.panicked != nil {
+			panic(r.panicked)
+		}
+		return r.value, r.err
+	}
+}
+
+// backgroundPanic carries a panic and the stack of the goroutine it happened in
+type backgroundPanic struct {
+	value any
+	stack []byte
+}
+
+func (p *backgroundPanic) String() string {
+	return fmt.Sprint(p.value)
+}
+
+// newDocumentConfiguration returns the libopenapi configuration for the spec read from path
+func newDocumentConfiguration(path string) *datamodel.DocumentConfiguration {
+	cfg := &datamodel.DocumentConfiguration{
+		AllowFileReferences:   false,
+		AllowRemoteReferences: false,
+		BypassDocumentCheck:   true, // Allow parsing specs with errors
+		Logger:                debugLog.With("component", "libopenapi"),
+	}
+	configureReferences(cfg, path)
+	return cfg
+}
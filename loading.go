@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+)
+
+// loadingDelay is how long work may take before the loading screen shows, so specs that load
+// at once don't flash it
+const loadingDelay = 150 * time.Millisecond
+
+// loadingIndicator is a spinner with a message, shown while oq waits on something that may
+// take a while: fetching and resolving a spec, or a request sent from the form
+type loadingIndicator struct {
+	spinner spinner.Model
+	message string
+}
+
+func newLoadingIndicator(message string) loadingIndicator {
+	s := spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(lipgloss.NewStyle().Foreground(lipgloss.Color(colorThemePurple))))
+	return loadingIndicator{spinner: s, message: message}
+}
+
+func (l loadingIndicator) tick() tea.Cmd {
+	return l.spinner.Tick
+}
+
+// update advances the spinner on its own ticks and ignores other messages
+func (l *loadingIndicator) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	l.spinner, cmd = l.spinner.Update(msg)
+	return cmd
+}
+
+// view renders the spinner and message, followed by how to cancel
+func (l loadingIndicator) view(hint string) string {
+	view := l.spinner.View() + " " + l.message
+	if hint != "" {
+		view += lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray)).Italic(true).Render("  " + hint)
+	}
+	return view
+}
+
+// loadingScreen is the program shown on stderr while withLoading waits, before the TUI starts
+type loadingScreen struct {
+	indicator loadingIndicator
+	cancel    context.CancelFunc
+	skip      loadingSkipMsg
+	done      bool
+}
+
+// loadingStepMsg replaces the message, loadingDoneMsg ends the program once the work is done
+type loadingStepMsg string
+
+type loadingDoneMsg struct{}
+
+// loadingSkipMsg offers to skip the rest of what the work is waiting on with s, such as
+// references still to fetch, and continue without it. A nil skip withdraws the offer
+type loadingSkipMsg struct {
+	hint string
+	skip func()
+}
+
+// loadingControlKey is the context key of the loadingControl given to withLoading's work
+type loadingControlKey struct{}
+
+// loadingControl lets code deep in withLoading's work, such as building the model, update
+// the loading screen without step being passed down to it
+type loadingControl struct {
+	step  func(string)
+	offer func(hint string, skip func())
+}
+
+// loadingFrom returns the loading screen's control, or nil when nothing is shown
+func loadingFrom(ctx context.Context) *loadingControl {
+	control, _ := ctx.Value(loadingControlKey{}).(*loadingControl)
+	return control
+}
+
+func (l loadingScreen) Init() tea.Cmd {
+	return l.indicator.tick()
+}
+
+func (l loadingScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" || msg.String() == "esc" {
+			l.cancel()
+			l.indicator.message = "Cancelling..."
+		}
+		if msg.String() == "s" && l.skip.skip != nil {
+			l.skip.skip()
+			l.skip = loadingSkipMsg{}
+			l.indicator.message = "Skipping..."
+		}
+		return l, nil
+	case loadingStepMsg:
+		l.indicator.message = string(msg)
+		return l, nil
+	case loadingSkipMsg:
+		l.skip = msg
+		return l, nil
+	case loadingDoneMsg:
+		l.done = true
+		return l, tea.Quit
+	}
+	return l, l.indicator.update(msg)
+}
+
+func (l loadingScreen) View() string {
+	if l.done {
+		return ""
+	}
+	hint := "ctrl+c to cancel"
+	if l.skip.skip != nil {
+		hint += ", s " + l.skip.hint
+	}
+	return l.indicator.view(hint) + "\n"
+}
+
+// withLoading runs work, showing the loading screen with message when it takes longer than
+// loadingDelay. work reports what it is doing with step, or with the loadingControl of its
+// context, which is cancelled when the user cancels. Nothing is shown when stderr isn't a
+// terminal
+func withLoading[T any](ctx context.Context, message string, work func(ctx context.Context, step func(string)) (T, error)) (T, error) {
+	if !term.IsTerminal(os.Stderr.Fd()) {
+		return work(ctx, func(string) {})
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	current := message
+	var skip loadingSkipMsg
+	var program *tea.Program
+	step := func(message string) {
+		mu.Lock()
+		defer mu.Unlock()
+		current = message
+		if program != nil {
+			program.Send(loadingStepMsg(message))
+		}
+	}
+	offer := func(hint string, fn func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		skip = loadingSkipMsg{hint: hint, skip: fn}
+		if program != nil {
+			program.Send(skip)
+		}
+	}
+	ctx = context.WithValue(ctx, loadingControlKey{}, &loadingControl{step: step, offer: offer})
+
+	var value T
+	var err error
+	var panicked *backgroundPanic
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Panics are raised again in the caller's goroutine, for the crash reporter
+		defer func() {
+			if r := recover(); r != nil {
+				if p, ok := r.(*backgroundPanic); ok {
+					panicked = p
+				} else {
+					panicked = &backgroundPanic{value: r, stack: debug.Stack()}
+				}
+			}
+		}()
+		value, err = work(ctx, step)
+	}()
+	wait := func() (T, error) {
+		<-done
+		if panicked != nil {
+			panic(panicked)
+		}
+		return value, err
+	}
+
+	select {
+	case <-done:
+		return wait()
+	case <-time.After(loadingDelay):
+	}
+
+	// Signals are left to the caller's context, and a spec piped on stdin isn't read as keys
+	options := []tea.ProgramOption{tea.WithOutput(os.Stderr), tea.WithoutSignalHandler()}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		options = append(options, tea.WithInput(nil))
+	}
+	mu.Lock()
+	program = tea.NewProgram(loadingScreen{indicator: newLoadingIndicator(current), cancel: cancel, skip: skip}, options...)
+	mu.Unlock()
+	go func() {
+		<-done
+		program.Send(loadingDoneMsg{})
+	}()
+	if _, err := program.Run(); err != nil {
+		debugLog.Warn("loading screen failed", "error", err)
+	}
+	return wait()
+}
+
+// loadingMessage is what the loading screen says while a spec is read
+func loadingMessage(path string) string {
+	switch {
+	case path == "":
+		return "Reading the spec from stdin..."
+	case isRemoteSpec(path):
+		return "Fetching " + path + "..."
+	}
+	return "Reading " + path + "..."
+}
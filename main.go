@@ -1,62 +1,358 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"runtime/debug"
+	"syscall"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/pb33f/libopenapi"
-	"github.com/pb33f/libopenapi/datamodel"
+	"github.com/charmbracelet/lipgloss"
 )
 
 func main() {
-	var content []byte
-	var err error
+	// Cancelled on ctrl+c or SIGTERM so long-running work stops cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if len(os.Args) > 1 {
-		content, err = os.ReadFile(os.Args[1])
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-			os.Exit(1)
+	os.Exit(run(ctx))
+}
+
+func run(ctx context.Context) (code int) {
+	crash := &crashReporter{}
+	defer func() {
+		if r := recover(); r != nil {
+			crash.record(r, debug.Stack())
+			code = crash.report()
+		}
+	}()
+
+	fs := flag.NewFlagSet("oq", flag.ContinueOnError)
+	debug := fs.Bool("debug", false, "write debug logs to a file")
+	debugFile := fs.String("debug-file", defaultDebugLogPath(), "file to write debug logs to when --debug is set")
+	headers := headerFlags{}
+	fs.Var(headers, "header", "header sent when fetching a spec URL, as \"Name: value\" with $VARS expanded (repeatable)")
+	timeout := fs.Duration("timeout", defaultRemoteTimeout, "timeout for fetching a spec URL")
+	auth := fs.String("auth", "", "add cloud credentials when fetching a spec URL: aws[:service[:region]] for SigV4 signing or gcp for a Google Cloud token")
+	fs.BoolVar(&resolveRefs, "resolve-refs", false, "follow $refs to other files and URLs, relative to the spec")
+	refreshEvery := fs.Duration("refresh-every", 0, "fetch a spec URL again at this interval, e.g. 30s, and reload it when it changed")
+	write := fs.Bool("write", false, "allow editing the spec file from the TUI")
+	query := fs.String("query", "", "print the parts of the spec matching a jq-style path such as 'paths[*].get' instead of opening the TUI")
+	fs.StringVar(query, "q", "", "shorthand for --query")
+	list := fs.String("list", "", "print the endpoints, components, webhooks or tags instead of opening the TUI")
+	rulesetFile := fs.String("ruleset", "", "Spectral ruleset for the issues pane (default .spectral.yaml, .spectral.yml or .spectral.json)")
+	patchFile := fs.String("patch", "", "preview the spec with a JSON Patch or merge patch file applied, without writing it")
+	openOperation := fs.String("open", "", "start on an operation, as \"METHOD /path\", a path or an operationId")
+	openComponent := fs.String("open-component", "", "start on a component, as a name such as User or schemas/User")
+	theme := fs.String("theme", "", "color theme: "+themeNames()+" (default theme setting or $OQ_THEME)")
+	notesFile := fs.String("notes", "", "YAML file with annotations keyed by operationId, \"METHOD /path\" or path (default <spec>.notes.yaml)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq [flags] [spec file, URL, tarball, oci:// or s3:// source]...\n")
+		fmt.Fprintf(fs.Output(), "       oq [spec] --query <path> | --list endpoints|components|webhooks|tags\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] bench <spec>\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] list [--sort fields] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] stats [--format text|json] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] doctor [--format text|json] [--checks names] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] scopes [--format table|csv] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] media [--format table|csv] [--type content-type] [--direction consumes|produces] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] pii [--format table|csv] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] lint [--ruleset file] [--format text|json|sarif] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] diff [--format text|json|markdown] [--fail-on-breaking] <old> <new>\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] compare [--op operation]... [--ignore keys] <spec> <base URL> <other URL>\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] export [--format markdown|html|postman|http] [--op operation]... [-o dir] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] duplicates [--threshold 0.9] [--format text|json] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] fmt [-w] [--check] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] split [spec] --by tag -o <dir>\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] mergetool [-o merged] <ours> <theirs> <base>\n")
+		fmt.Fprintf(fs.Output(), "       oq [flags] refactor extract-inline-schemas [-o file | -w] [--dry-run] [spec]\n")
+		fmt.Fprintf(fs.Output(), "       oq config list|get|set|edit|path\n")
+		fmt.Fprintf(fs.Output(), "       oq credentials set|get|delete|list|where <name>\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	args := fs.Args()
+	if len(args) > 1 && !subcommands[args[0]] {
+		// Flags may also follow the spec, as in `oq spec.yaml -q info.title`
+		var err error
+		if args, err = parseInterspersed(fs, args); err != nil {
+			return 2
 		}
-	} else {
-		content, err = io.ReadAll(os.Stdin)
+	}
+	if len(args) > 0 && args[0] == "config" {
+		// A broken config must not prevent fixing it
+		return runConfig(args[1:])
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return reportError(err)
+	}
+	// --theme wins over OQ_THEME, which wins over the config
+	themeName := cfg.Theme
+	if env := os.Getenv("OQ_THEME"); env != "" {
+		themeName = env
+	}
+	if flagWasSet(fs, "theme") {
+		themeName = *theme
+	}
+	if _, ok := themes[themeName]; themeName != "" && !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown theme %q, expected one of %s\n", themeName, themeNames())
+		return 2
+	}
+	applyTheme(resolveTheme(themeName, os.Getenv("NO_COLOR") != "", lipgloss.HasDarkBackground))
+	// Validated when the config was loaded
+	displayLocale, _ = parseLocale(cfg.Locale)
+
+	if _, _, err := findAuthHelper(*auth); *auth != "" && err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	remote = remoteOptions{timeout: *timeout, headers: http.Header(headers), auth: *auth}
+	if !flagWasSet(fs, "timeout") && cfg.HTTPTimeout != "" {
+		// Validated when the config was loaded
+		remote.timeout, _ = time.ParseDuration(cfg.HTTPTimeout)
+	}
+
+	debugPath := *debugFile
+	if !flagWasSet(fs, "debug-file") && cfg.DebugFile != "" {
+		debugPath = cfg.DebugFile
+	}
+	if *debug || cfg.Debug {
+		closer, err := enableDebugLog(debugPath)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading from stdin: %v\n", err)
-			os.Exit(1)
+			fmt.Fprintf(os.Stderr, "Error opening debug log: %v\n", err)
+			return 1
 		}
+		defer closer.Close()
+		fmt.Fprintf(os.Stderr, "Writing debug logs to %s\n", debugPath)
 	}
 
-	config := &datamodel.DocumentConfiguration{
-		AllowFileReferences:   false,
-		AllowRemoteReferences: false,
-		BypassDocumentCheck:   true, // Allow parsing specs with errors
+	if len(args) > 0 {
+		switch args[0] {
+		case "bench":
+			return runBench(ctx, args[1:])
+		case "list":
+			return runList(ctx, args[1:])
+		case "split":
+			return runSplit(ctx, args[1:])
+		case "duplicates":
+			return runDuplicates(ctx, args[1:])
+		case "fmt":
+			return runFmt(ctx, args[1:])
+		case "mergetool":
+			return runMergetool(ctx, args[1:])
+		case "refactor":
+			return runRefactor(ctx, args[1:])
+		case "stats":
+			return runStats(ctx, cfg, args[1:])
+		case "doctor":
+			return runDoctor(ctx, cfg, args[1:])
+		case "scopes":
+			return runScopes(ctx, args[1:])
+		case "media":
+			return runMedia(ctx, args[1:])
+		case "pii":
+			return runPII(ctx, cfg, args[1:])
+		case "lint":
+			return runLint(ctx, cfg, args[1:])
+		case "diff":
+			return runDiff(ctx, args[1:])
+		case "compare":
+			return runCompare(ctx, cfg, args[1:])
+		case "export":
+			return runExport(ctx, args[1:])
+		case "credentials":
+			return runCredentials(cfg, args[1:])
+		}
 	}
 
-	document, err := libopenapi.NewDocumentWithConfiguration(content, config)
+	paths, err := expandSpecArgs(args)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating document: %v\n", err)
-		os.Exit(1)
+		return reportError(err)
+	}
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+	path := paths[0]
+	if len(paths) > 1 && (*query != "" || *list != "" || *write || *patchFile != "" || *refreshEvery != 0 || *openOperation != "" || *openComponent != "") {
+		fmt.Fprintf(os.Stderr, "Error: --query, --list, --write, --patch, --refresh-every and --open need a single spec\n")
+		return 2
+	}
+	if *openOperation != "" && *openComponent != "" {
+		fmt.Fprintf(os.Stderr, "Error: --open and --open-component can't be combined\n")
+		return 2
+	}
+	if *query != "" {
+		return runQuery(ctx, path, *query)
+	}
+	if *list != "" {
+		return runListKind(ctx, path, *list)
+	}
+	if *write && (path == "" || isRemoteSpec(path) || isTarballSpec(path)) {
+		fmt.Fprintf(os.Stderr, "Error: --write needs a local spec file\n")
+		return 2
+	}
+	if *refreshEvery < 0 || (*refreshEvery > 0 && !isRemoteSpec(path)) {
+		fmt.Fprintf(os.Stderr, "Error: --refresh-every needs a spec URL and a positive interval\n")
+		return 2
+	}
+	if *write && *patchFile != "" {
+		fmt.Fprintf(os.Stderr, "Error: --patch only previews changes and can't be combined with --write\n")
+		return 2
+	}
+	var patch *specPatch
+	if *patchFile != "" {
+		if patch, err = loadPatch(*patchFile); err != nil {
+			return reportError(err)
+		}
 	}
 
-	v3Model, err := document.BuildV3Model()
+	specs, err := withLoading(ctx, loadingMessage(path), func(ctx context.Context, step func(string)) ([]*workspaceSpec, error) {
+		var specs []*workspaceSpec
+		for i, path := range paths {
+			specStep := step
+			if len(paths) > 1 {
+				specStep = func(message string) { step(fmt.Sprintf("%d/%d %s", i+1, len(paths), message)) }
+				specStep(loadingMessage(path))
+			}
+			spec, err := openSpec(ctx, path, patch, *notesFile, specStep)
+			if err != nil {
+				return nil, err
+			}
+			specs = append(specs, spec)
+		}
+		return specs, nil
+	})
 	if err != nil {
-		// Show warning but try to continue if we have any model
-		fmt.Fprintf(os.Stderr, "Warning: Spec has validation errors: %v\n", err)
-		fmt.Fprintf(os.Stderr, "Attempting to continue with partial data...\n\n")
+		return reportError(err)
+	}
+	first := specs[0]
+	crash.setSpec(first.content)
 
-		// If we can't build the model at all, exit
-		if v3Model == nil {
-			os.Exit(1)
+	var ruleset *spectralRuleset
+	rulesetPath, err := findRuleset(*rulesetFile)
+	if err != nil {
+		return reportError(err)
+	}
+	if rulesetPath != "" {
+		if ruleset, err = loadRuleset(rulesetPath); err != nil {
+			return reportError(err)
 		}
 	}
 
-	m := NewModel(&v3Model.Model)
-	p := tea.NewProgram(m, tea.WithAltScreen())
+	m := NewModel(first.doc)
+	m.writeMode = *write
+	m.applyConfig(cfg)
+	m.team = first.team
+	m.setNotes(first.notes)
+	m.ruleset = ruleset
+	m.problems = first.problems
+	if len(first.problems) > 0 {
+		m.setStatus(fmt.Sprintf("The spec has %s, press I to list them", lintSummary(first.problems)), true)
+	}
+	m.watchSpec(path, first.content, cfg.AutoReload)
+	m.refreshEvery = *refreshEvery
+	m.patch, m.patchBadges, m.specContent = patch, first.patchBadges, first.specContent
+
+	for _, spec := range specs {
+		spec.session = loadSpecSession(spec.path)
+	}
+	if first.session != nil {
+		m.restoreSession(*first.session)
+	}
+	if err := m.openTarget(*openOperation, *openComponent); err != nil {
+		return reportError(err)
+	}
+	if len(specs) > 1 {
+		m.workspace = &workspace{specs: specs}
+		if len(first.problems) == 0 {
+			m.setStatus(fmt.Sprintf("%d specs open, press W to switch between them", len(specs)), false)
+		}
+	}
+	p := tea.NewProgram(guardedModel{Model: m, crash: crash}, tea.WithAltScreen(), tea.WithContext(ctx))
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
+		if crash.crashed() {
+			return crash.report()
+		}
+		if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+			return exitCancelled
+		}
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
-		os.Exit(1)
+		return 1
+	}
+
+	if last, ok := final.(guardedModel).Model.(Model); ok {
+		for specPath, state := range last.sessions() {
+			file, err := sessionPath(specPath)
+			if err != nil || file == "" {
+				continue
+			}
+			if err := state.save(file); err != nil {
+				fmt.Fprintf(os.Stderr, "Error saving session state: %v\n", err)
+			}
+		}
+	}
+	return 0
+}
+
+// exitCancelled is the conventional exit code for a process stopped by SIGINT
+const exitCancelled = 130
+
+// reportError prints err to stderr and returns the exit code matching it
+func reportError(err error) int {
+	debugLog.Error("exiting", "error", err)
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "Cancelled")
+		return exitCancelled
+	}
+	fmt.Fprintf(os.Stderr, "%v\n", err)
+	return 1
+}
+
+// flagWasSet reports whether the named flag was passed explicitly, so config values
+// only apply when the command line doesn't override them
+func flagWasSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// subcommands are dispatched on the first argument, anything else names the spec
+var subcommands = map[string]bool{
+	"bench": true, "compare": true, "config": true, "credentials": true, "diff": true, "doctor": true, "duplicates": true, "export": true, "fmt": true,
+	"lint": true, "list": true, "media": true, "mergetool": true, "pii": true, "refactor": true, "scopes": true, "split": true, "stats": true,
+}
+
+// parseInterspersed parses flags that may come after positional arguments, as in
+// `oq split spec.yaml --by tag`, and returns the positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
 	}
 }
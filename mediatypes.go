@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// mediaUse says whether an operation consumes a content type in its request body, produces
+// it in a response, or both
+type mediaUse int
+
+const (
+	mediaConsumes mediaUse = 1 << iota
+	mediaProduces
+)
+
+func (u mediaUse) String() string {
+	switch u {
+	case mediaConsumes:
+		return "in"
+	case mediaProduces:
+		return "out"
+	case mediaConsumes | mediaProduces:
+		return "in/out"
+	}
+	return ""
+}
+
+// mediaFilter narrows the matrix to a content type and to request or response bodies. A
+// content type with a / must match exactly, anything else is matched as part of one, so
+// "xml" finds application/xml and text/xml
+type mediaFilter struct {
+	contentType string
+	direction   mediaUse
+}
+
+func (f mediaFilter) active() bool {
+	return f.contentType != "" || f.direction != 0
+}
+
+func (f mediaFilter) matches(contentType string) bool {
+	switch {
+	case f.contentType == "":
+		return true
+	case strings.Contains(f.contentType, "/"):
+		return strings.EqualFold(contentType, f.contentType)
+	}
+	return strings.Contains(strings.ToLower(contentType), strings.ToLower(f.contentType))
+}
+
+func (f mediaFilter) String() string {
+	var parts []string
+	if f.contentType != "" {
+		parts = append(parts, f.contentType)
+	}
+	switch f.direction {
+	case mediaConsumes:
+		parts = append(parts, "consumed")
+	case mediaProduces:
+		parts = append(parts, "produced")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseMediaDirection reads the --direction flag
+func parseMediaDirection(value string) (mediaUse, bool) {
+	switch value {
+	case "", "both":
+		return 0, true
+	case "consumes", "request":
+		return mediaConsumes, true
+	case "produces", "response":
+		return mediaProduces, true
+	}
+	return 0, false
+}
+
+// operationMediaTypes returns the content types of op's request body and responses
+func operationMediaTypes(op *v3.Operation) map[string]mediaUse {
+	uses := map[string]mediaUse{}
+	if op.RequestBody != nil && op.RequestBody.Content != nil {
+		for pair := op.RequestBody.Content.First(); pair != nil; pair = pair.Next() {
+			uses[pair.Key()] |= mediaConsumes
+		}
+	}
+	if op.Responses != nil {
+		var responses []*v3.Response
+		if op.Responses.Codes != nil {
+			for pair := op.Responses.Codes.First(); pair != nil; pair = pair.Next() {
+				responses = append(responses, pair.Value())
+			}
+		}
+		responses = append(responses, op.Responses.Default)
+		for _, resp := range responses {
+			if resp == nil || resp.Content == nil {
+				continue
+			}
+			for pair := resp.Content.First(); pair != nil; pair = pair.Next() {
+				uses[pair.Key()] |= mediaProduces
+			}
+		}
+	}
+	return uses
+}
+
+// mediaRow holds the content types one operation uses, by column
+type mediaRow struct {
+	ep    endpoint
+	cells map[int]mediaUse
+}
+
+type mediaMatrix struct {
+	columns []string
+	rows    []mediaRow
+}
+
+// buildMediaMatrix lays out the content types every operation consumes and produces, the
+// most used first. While filtering, operations using none of the remaining types are left out
+func buildMediaMatrix(eps []endpoint, filter mediaFilter) mediaMatrix {
+	uses := make([]map[string]mediaUse, len(eps))
+	counts := map[string]int{}
+	for i, ep := range eps {
+		uses[i] = map[string]mediaUse{}
+		for contentType, use := range operationMediaTypes(ep.op) {
+			if filter.direction != 0 {
+				use &= filter.direction
+			}
+			if use == 0 || !filter.matches(contentType) {
+				continue
+			}
+			uses[i][contentType] = use
+			counts[contentType]++
+		}
+	}
+
+	matrix := mediaMatrix{columns: slices.Collect(maps.Keys(counts))}
+	slices.SortFunc(matrix.columns, func(a, b string) int {
+		return cmp.Or(counts[b]-counts[a], strings.Compare(a, b))
+	})
+	index := map[string]int{}
+	for i, contentType := range matrix.columns {
+		index[contentType] = i
+	}
+	for i, ep := range eps {
+		if filter.active() && len(uses[i]) == 0 {
+			continue
+		}
+		row := mediaRow{ep: ep, cells: map[int]mediaUse{}}
+		for contentType, use := range uses[i] {
+			row.cells[index[contentType]] = use
+		}
+		matrix.rows = append(matrix.rows, row)
+	}
+	return matrix
+}
+
+// writeMediaCSV writes the matrix with one row per operation and one column per content type
+func writeMediaCSV(w io.Writer, matrix mediaMatrix) error {
+	cw := csv.NewWriter(w)
+	header := append([]string{"method", "path", "operationId"}, matrix.columns...)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, row := range matrix.rows {
+		record := []string{row.ep.method, row.ep.path, row.ep.op.OperationId}
+		for i := range matrix.columns {
+			record = append(record, row.cells[i].String())
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeMediaTable(w io.Writer, matrix mediaMatrix) {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(append([]string{"METHOD", "PATH"}, matrix.columns...), "\t"))
+	for _, row := range matrix.rows {
+		record := []string{row.ep.method, row.ep.path}
+		for i := range matrix.columns {
+			record = append(record, row.cells[i].String())
+		}
+		fmt.Fprintln(tw, strings.Join(record, "\t"))
+	}
+	tw.Flush()
+
+	// Empty cells at the end of a row would leave trailing padding
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line != "" {
+			fmt.Fprintln(w, strings.TrimRight(line, " \n"))
+		}
+	}
+}
+
+// runMedia implements `oq media spec.yaml`, printing the media-type matrix as a table or CSV
+func runMedia(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("media", flag.ContinueOnError)
+	format := fs.String("format", "table", "output format: table or csv")
+	contentType := fs.String("type", "", "only list a content type, or those containing the value, e.g. xml")
+	direction := fs.String("direction", "both", "only list request bodies (consumes), responses (produces) or both")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq media [--format table|csv] [--type content-type] [--direction consumes|produces] [spec]\n\n")
+		fs.PrintDefaults()
+	}
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	use, ok := parseMediaDirection(*direction)
+	if len(args) > 1 || (*format != "table" && *format != "csv") || !ok {
+		fs.Usage()
+		return 2
+	}
+
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	}
+	_, doc, err := loadSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+
+	matrix := buildMediaMatrix(extractEndpoints(doc), mediaFilter{contentType: *contentType, direction: use})
+	if *format == "csv" {
+		if err := writeMediaCSV(os.Stdout, matrix); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	writeMediaTable(os.Stdout, matrix)
+	return 0
+}
+
+// mediaPane shows the media-type matrix of the listed endpoints, scrolling in both directions
+type mediaPane struct {
+	eps    []endpoint
+	filter mediaFilter
+	matrix mediaMatrix
+	row    int
+	column int
+}
+
+// openMediaMatrix opens the media-type matrix for the endpoints currently listed
+func (m *Model) openMediaMatrix() {
+	eps := m.getActiveEndpoints()
+	if len(eps) == 0 {
+		m.setStatus("No endpoints to show", true)
+		return
+	}
+	m.mediaTypes = &mediaPane{eps: eps, matrix: buildMediaMatrix(eps, mediaFilter{})}
+}
+
+// setFilter rebuilds the matrix for a new filter, keeping the row in range
+func (pane *mediaPane) setFilter(filter mediaFilter) {
+	pane.filter = filter
+	pane.matrix = buildMediaMatrix(pane.eps, filter)
+	pane.row = max(0, min(pane.row, len(pane.matrix.rows)-1))
+	pane.column = 0
+}
+
+// updateMediaTypes handles keys while the media-type matrix is open
+func (m *Model) updateMediaTypes(key string) {
+	pane := m.mediaTypes
+	rows, columns := len(pane.matrix.rows), len(pane.matrix.columns)
+	page := max(1, m.height/2)
+	switch key {
+	case "esc", "q", "M":
+		m.mediaTypes = nil
+	case "up", "k":
+		pane.row = max(0, pane.row-1)
+	case "down", "j":
+		pane.row = max(0, min(rows-1, pane.row+1))
+	case "ctrl+u":
+		pane.row = max(0, pane.row-page)
+	case "ctrl+d":
+		pane.row = max(0, min(rows-1, pane.row+page))
+	case "g":
+		pane.row = 0
+	case "G":
+		pane.row = max(0, rows-1)
+	case "left", "h":
+		pane.column = max(0, pane.column-1)
+	case "right", "l":
+		pane.column = min(max(0, columns-1), pane.column+1)
+	case "f":
+		filter := pane.filter
+		switch {
+		case filter.contentType != "":
+			filter.contentType = ""
+		case columns > 0:
+			filter.contentType = pane.matrix.columns[pane.column]
+		}
+		pane.setFilter(filter)
+	case "d":
+		filter := pane.filter
+		filter.direction = map[mediaUse]mediaUse{0: mediaConsumes, mediaConsumes: mediaProduces, mediaProduces: 0}[filter.direction]
+		pane.setFilter(filter)
+	case "w":
+		path := m.exportPath("media-types.csv")
+		f, err := os.Create(path)
+		if err == nil {
+			err = writeMediaCSV(f, pane.matrix)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			m.setStatus(fmt.Sprintf("Error exporting media types: %v", err), true)
+			return
+		}
+		m.setStatus(fmt.Sprintf("Exported the media-type matrix to %s", path), false)
+	}
+}
+
+func (m Model) renderMediaPane() string {
+	pane := m.mediaTypes
+	matrix := pane.matrix
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	headerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorBlue)).
+		Bold(true)
+
+	// The operation column stays put while content type columns scroll horizontally
+	methodWidth := m.methodWidth(endpointMethods(matrix.endpoints()))
+	opWidth := min(max(20, m.width/3), 50)
+	var columnWidths []int
+	for _, contentType := range matrix.columns {
+		columnWidths = append(columnWidths, min(max(lipgloss.Width(contentType), 6), 32))
+	}
+	visibleColumns := 0
+	used := opWidth
+	for i := pane.column; i < len(matrix.columns) && used+columnWidths[i]+2 <= m.width; i++ {
+		used += columnWidths[i] + 2
+		visibleColumns++
+	}
+
+	cellStyle := func(width int) lipgloss.Style {
+		return lipgloss.NewStyle().Width(width).MaxWidth(width).Align(lipgloss.Center)
+	}
+
+	// The first visible column is the one f filters by
+	header := lipgloss.NewStyle().Width(opWidth).Render("")
+	for i := pane.column; i < pane.column+visibleColumns; i++ {
+		style := headerStyle.Inherit(cellStyle(columnWidths[i]))
+		if i == pane.column {
+			style = style.Underline(true)
+		}
+		header += style.Render(matrix.columns[i]) + "  "
+	}
+
+	useColors := map[mediaUse]string{mediaConsumes: colorYellow, mediaProduces: colorGreen, mediaConsumes | mediaProduces: colorBlue}
+	bodyHeight := max(1, m.height-6)
+	start := max(0, min(pane.row-bodyHeight+1, len(matrix.rows)-bodyHeight))
+	var lines []string
+	for r := start; r < len(matrix.rows) && r < start+bodyHeight; r++ {
+		row := matrix.rows[r]
+		background := lipgloss.NewStyle()
+		if r == pane.row {
+			background = highlight(background)
+		}
+		methodStyle := background.
+			Foreground(m.methodColor(row.ep.method)).
+			Bold(true).
+			Width(methodWidth)
+		op := methodStyle.Render(m.methodLabel(row.ep.method)) + background.Render(" "+row.ep.path)
+		line := background.Width(opWidth).MaxWidth(opWidth).Render(op)
+		for i := pane.column; i < pane.column+visibleColumns; i++ {
+			use := row.cells[i]
+			line += background.Foreground(lipgloss.Color(useColors[use])).Inherit(cellStyle(columnWidths[i])).Render(use.String()) + background.Render("  ")
+		}
+		lines = append(lines, line)
+	}
+	if len(matrix.rows) == 0 {
+		empty := "No operation has a request or response body"
+		if pane.filter.active() {
+			empty = "No operation matches the filter"
+		}
+		lines = append(lines, instructionStyle.Render(empty))
+	}
+
+	more := ""
+	if hidden := len(matrix.columns) - pane.column - visibleColumns; hidden == 1 {
+		more = " · 1 more column →"
+	} else if hidden > 1 {
+		more = fmt.Sprintf(" · %d more columns →", hidden)
+	}
+	title := fmt.Sprintf("Media types (%d operations, %d content types)", len(matrix.rows), len(matrix.columns))
+	if pane.filter.active() {
+		title += " · " + pane.filter.String()
+	}
+	instruction := instructionStyle.Render("j/k rows · h/l columns · in request, out response · f filter by the first column · d consumed/produced · w export CSV · Esc close" + more)
+
+	return lipgloss.NewStyle().MaxHeight(m.height).Render(titleStyle.Render(title) + "\n\n" + header + "\n" + strings.Join(lines, "\n") + "\n\n" + instruction)
+}
+
+func (matrix mediaMatrix) endpoints() []endpoint {
+	eps := make([]endpoint, len(matrix.rows))
+	for i, row := range matrix.rows {
+		eps[i] = row.ep
+	}
+	return eps
+}
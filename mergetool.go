@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.yaml.in/yaml/v4"
+)
+
+// mergeUnit is a part of the spec merged as a whole: an operation or path item field, a
+// component, a webhook or a top-level section
+type mergeUnit struct {
+	path               []string
+	base, ours, theirs *yaml.Node
+	result             *yaml.Node
+	// fromTheirs is set when only theirs changed the unit
+	fromTheirs bool
+	conflict   bool
+	resolved   bool
+	choice     string
+}
+
+// label names the unit in the conflict list, e.g. "GET /pets" or "components/schemas/Pet"
+func (u *mergeUnit) label() string {
+	if len(u.path) == 3 && u.path[0] == "paths" {
+		if standardOperationKeys[u.path[2]] {
+			return strings.ToUpper(u.path[2]) + " " + u.path[1]
+		}
+		return u.path[1] + " " + u.path[2]
+	}
+	return strings.Join(u.path, "/")
+}
+
+// merge decides the unit the way a three-way text merge decides a hunk: a side that didn't
+// change gives way to the side that did, and two different changes conflict
+func (u *mergeUnit) merge() {
+	base, ours, theirs := mergeKey(u.base), mergeKey(u.ours), mergeKey(u.theirs)
+	switch {
+	case ours == theirs, theirs == base:
+		u.result = u.ours
+	case ours == base:
+		u.result = u.theirs
+		u.fromTheirs = true
+	default:
+		u.conflict = true
+	}
+}
+
+func mergeKey(node *yaml.Node) string {
+	if node == nil {
+		return "\x00absent"
+	}
+	return canonicalNode(node)
+}
+
+// resolve settles a conflict with one side
+func (u *mergeUnit) resolve(choice string) {
+	switch choice {
+	case "ours":
+		u.result = u.ours
+	case "theirs":
+		u.result = u.theirs
+	case "base":
+		u.result = u.base
+	default:
+		return
+	}
+	u.choice = choice
+	u.resolved = true
+}
+
+// mergeUnits lists the units of the three documents in the order they appear, ours first
+func mergeUnits(base, ours, theirs *yaml.Node) []*mergeUnit {
+	var units []*mergeUnit
+	seen := map[string]bool{}
+	add := func(path ...string) {
+		key := strings.Join(path, "\x00")
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		units = append(units, &mergeUnit{
+			path: slices.Clone(path),
+			base: nodeAt(base, path), ours: nodeAt(ours, path), theirs: nodeAt(theirs, path),
+		})
+	}
+
+	for _, doc := range []*yaml.Node{ours, theirs, base} {
+		if doc == nil || doc.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(doc.Content); i += 2 {
+			key, value := doc.Content[i].Value, doc.Content[i+1]
+			switch {
+			case (key == "paths" || key == "components") && value.Kind == yaml.MappingNode:
+				for j := 0; j+1 < len(value.Content); j += 2 {
+					name, child := value.Content[j].Value, value.Content[j+1]
+					if child.Kind != yaml.MappingNode {
+						add(key, name)
+						continue
+					}
+					for k := 0; k+1 < len(child.Content); k += 2 {
+						add(key, name, child.Content[k].Value)
+					}
+				}
+			case key == "webhooks" && value.Kind == yaml.MappingNode:
+				for j := 0; j+1 < len(value.Content); j += 2 {
+					add(key, value.Content[j].Value)
+				}
+			default:
+				add(key)
+			}
+		}
+	}
+	return units
+}
+
+func nodeAt(root *yaml.Node, path []string) *yaml.Node {
+	node := root
+	for _, key := range path {
+		if node = mappingValue(node, key); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// setAt sets the value at path, creating the mappings leading to it
+func setAt(root *yaml.Node, path []string, value *yaml.Node) {
+	node := root
+	for _, key := range path[:len(path)-1] {
+		next := mappingValue(node, key)
+		if next == nil || next.Kind != yaml.MappingNode {
+			next = newMapping()
+			setMapping(node, key, next)
+		}
+		node = next
+	}
+	setMapping(node, path[len(path)-1], value)
+}
+
+// deleteAt removes the value at path, and the path items or component sections it leaves empty
+func deleteAt(root *yaml.Node, path []string) {
+	for depth := len(path); depth > 0; depth-- {
+		parent := nodeAt(root, path[:depth-1])
+		if parent == nil || parent.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(parent.Content); i += 2 {
+			if parent.Content[i].Value == path[depth-1] {
+				parent.Content = slices.Delete(parent.Content, i, i+2)
+				break
+			}
+		}
+		// Top-level sections stay, even when empty
+		if len(parent.Content) > 0 || depth <= 2 {
+			return
+		}
+	}
+}
+
+// applyMerge writes the merged units into ours
+func applyMerge(ours *yaml.Node, units []*mergeUnit) {
+	for _, u := range units {
+		if !u.fromTheirs && !u.resolved {
+			continue
+		}
+		if u.result == nil {
+			deleteAt(ours, u.path)
+		} else if u.result != u.ours {
+			setAt(ours, u.path, u.result)
+		}
+	}
+}
+
+func parseMergeInput(path string) (*yaml.Node, []byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error reading file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, nil, fmt.Errorf("Error parsing %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		// An empty base, as git passes for files added on both sides
+		return newMapping(), content, nil
+	}
+	return doc.Content[0], content, nil
+}
+
+// runMergetool implements `oq mergetool ours.yaml theirs.yaml base.yaml [-o merged.yaml]`
+func runMergetool(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("mergetool", flag.ContinueOnError)
+	output := fs.String("o", "", "file to write the merge result to (default ours)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq mergetool [flags] <ours> <theirs> <base>\n\n")
+		fmt.Fprintf(fs.Output(), "As a git mergetool:\n")
+		fmt.Fprintf(fs.Output(), "  git config mergetool.oq.cmd 'oq mergetool \"$LOCAL\" \"$REMOTE\" \"$BASE\" -o \"$MERGED\"'\n\n")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 3 {
+		fs.Usage()
+		return 2
+	}
+
+	ours, oursContent, err := parseMergeInput(positional[0])
+	if err != nil {
+		return reportError(err)
+	}
+	theirs, _, err := parseMergeInput(positional[1])
+	if err != nil {
+		return reportError(err)
+	}
+	base, _, err := parseMergeInput(positional[2])
+	if err != nil {
+		return reportError(err)
+	}
+
+	units := mergeUnits(base, ours, theirs)
+	var conflicts []*mergeUnit
+	taken := 0
+	for _, u := range units {
+		u.merge()
+		if u.conflict {
+			conflicts = append(conflicts, u)
+		} else if u.fromTheirs {
+			taken++
+		}
+	}
+
+	if len(conflicts) > 0 {
+		final, err := tea.NewProgram(newMergeModel(conflicts), tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+		if err != nil {
+			if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+				return exitCancelled
+			}
+			fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
+			return 1
+		}
+		if !final.(mergeModel).done {
+			fmt.Fprintf(os.Stderr, "Merge aborted, %d conflicts left unresolved\n", len(conflicts))
+			return 1
+		}
+	}
+
+	applyMerge(ours, units)
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{ours}}
+	var out []byte
+	if bytes.HasPrefix(bytes.TrimSpace(oursContent), []byte("{")) {
+		out, err = marshalFragmentJSON(doc)
+	} else {
+		out, err = marshalFragment(doc)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing merge result: %v\n", err)
+		return 1
+	}
+
+	target := *output
+	if target == "" {
+		target = positional[0]
+	}
+	if err := os.WriteFile(target, out, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", target, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Merged %d changes from theirs and resolved %d conflicts into %s\n", taken, len(conflicts), target)
+	return 0
+}
+
+// mergeModel is the TUI for resolving merge conflicts one unit at a time
+type mergeModel struct {
+	conflicts []*mergeUnit
+	cursor    int
+	scroll    int
+	width     int
+	height    int
+	done      bool
+}
+
+func newMergeModel(conflicts []*mergeUnit) mergeModel {
+	return mergeModel{conflicts: conflicts, width: 80, height: 24}
+}
+
+func (m mergeModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m mergeModel) unresolved() int {
+	n := 0
+	for _, u := range m.conflicts {
+		if !u.resolved {
+			n++
+		}
+	}
+	return n
+}
+
+func (m mergeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		u := m.conflicts[m.cursor]
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			m.cursor = (m.cursor - 1 + len(m.conflicts)) % len(m.conflicts)
+			m.scroll = 0
+		case "down", "j":
+			m.cursor = (m.cursor + 1) % len(m.conflicts)
+			m.scroll = 0
+		case "ctrl+d":
+			m.scroll += max(1, m.height/2)
+		case "ctrl+u":
+			m.scroll = max(0, m.scroll-max(1, m.height/2))
+		case "o", "t", "b":
+			choice := map[string]string{"o": "ours", "t": "theirs", "b": "base"}[msg.String()]
+			u.resolve(choice)
+			// Move on to the next unresolved conflict
+			for i := 1; i <= len(m.conflicts); i++ {
+				next := (m.cursor + i) % len(m.conflicts)
+				if !m.conflicts[next].resolved {
+					m.cursor, m.scroll = next, 0
+					break
+				}
+			}
+		case "O", "T":
+			choice := map[string]string{"O": "ours", "T": "theirs"}[msg.String()]
+			for _, c := range m.conflicts {
+				if !c.resolved {
+					c.resolve(choice)
+				}
+			}
+		case "w", "enter":
+			if m.unresolved() == 0 {
+				m.done = true
+				return m, tea.Quit
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m mergeModel) View() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	listWidth := min(40, max(20, m.width/4))
+	columnWidth := max(20, (m.width-listWidth-6)/2)
+	bodyHeight := max(3, m.height-5)
+
+	var rows []string
+	for i, u := range m.conflicts {
+		marker := lipgloss.NewStyle().Foreground(lipgloss.Color(colorRed)).Render("✗")
+		if u.resolved {
+			marker = lipgloss.NewStyle().Foreground(lipgloss.Color(colorGreen)).Render(u.choice[:1])
+		}
+		lineStyle := lipgloss.NewStyle().Width(listWidth).MaxWidth(listWidth)
+		if i == m.cursor {
+			lineStyle = highlight(lineStyle)
+		}
+		rows = append(rows, lineStyle.Render(marker+" "+u.label()))
+	}
+	list := truncateLines(strings.Join(rows, "\n"), bodyHeight)
+
+	u := m.conflicts[m.cursor]
+	column := func(title string, node *yaml.Node, chosen bool) string {
+		style := titleStyle
+		if chosen {
+			style = style.Underline(true)
+		}
+		body := "(deleted)"
+		if node != nil {
+			if out, err := marshalFragment(node); err == nil {
+				body = strings.TrimRight(string(out), "\n")
+			}
+		}
+		lines := strings.Split(body, "\n")
+		lines = lines[min(m.scroll, len(lines)-1):]
+		return lipgloss.NewStyle().Width(columnWidth).MaxWidth(columnWidth).Render(
+			style.Render(title) + "\n" + truncateLines(strings.Join(lines, "\n"), bodyHeight-1))
+	}
+
+	body := lipgloss.JoinHorizontal(lipgloss.Top,
+		list, "  ",
+		column("ours (o)", u.ours, u.choice == "ours"), "  ",
+		column("theirs (t)", u.theirs, u.choice == "theirs"))
+
+	header := titleStyle.Render(fmt.Sprintf("Merge conflicts: %d of %d resolved", len(m.conflicts)-m.unresolved(), len(m.conflicts)))
+	instruction := "j/k move • o ours • t theirs • b base • O/T all ours/theirs • Ctrl+D/U scroll • q abort"
+	if m.unresolved() == 0 {
+		instruction = "All conflicts resolved • w write the result • " + instruction
+	}
+	return header + "\n\n" + body + "\n\n" + instructionStyle.Render(instruction)
+}
+
+// truncateLines keeps the first n lines of s
+func truncateLines(s string, n int) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	return strings.Join(lines, "\n")
+}
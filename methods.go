@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/datamodel/low"
+	lowv3 "github.com/pb33f/libopenapi/datamodel/low/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// customMethodsExtension maps custom verbs to operation objects on a path item, for
+// specs older than 3.2 which have no additionalOperations
+const customMethodsExtension = "x-methods"
+
+// methodColumnWidth is the minimum width of the method column in the lists
+const methodColumnWidth = 7
+
+type methodOperation struct {
+	method string
+	op     *v3.Operation
+	// key is the JSON Pointer of the operation within its path item, e.g. get
+	key string
+}
+
+// pathItemOperations returns every operation of a path item: the fixed fields, QUERY and
+// additionalOperations from 3.2, and custom verbs declared under x-methods
+func pathItemOperations(item *v3.PathItem) []methodOperation {
+	if item == nil {
+		return nil
+	}
+
+	var ops []methodOperation
+	add := func(method, key string, op *v3.Operation) {
+		if op != nil {
+			ops = append(ops, methodOperation{method: strings.ToUpper(method), op: op, key: key})
+		}
+	}
+
+	add("GET", "get", item.Get)
+	add("POST", "post", item.Post)
+	add("PUT", "put", item.Put)
+	add("DELETE", "delete", item.Delete)
+	add("PATCH", "patch", item.Patch)
+	add("HEAD", "head", item.Head)
+	add("OPTIONS", "options", item.Options)
+	add("TRACE", "trace", item.Trace)
+	add("QUERY", "query", item.Query)
+
+	if item.AdditionalOperations != nil {
+		for pair := item.AdditionalOperations.First(); pair != nil; pair = pair.Next() {
+			add(pair.Key(), "additionalOperations/"+escapePointer(pair.Key()), pair.Value())
+		}
+	} else if lowItem := item.GoLow(); lowItem != nil && lowItem.AdditionalOperations.Value != nil {
+		// libopenapi builds additionalOperations without key/value nodes, so the high level
+		// model treats them as empty and drops them. Read them from the low level model instead
+		for key, op := range lowItem.AdditionalOperations.Value.FromOldest() {
+			if op.Value != nil {
+				add(key.Value, "additionalOperations/"+escapePointer(key.Value), v3.NewOperation(op.Value))
+			}
+		}
+	}
+
+	// Verbs already defined by the spec itself take precedence over the extension
+	for _, custom := range extensionOperations(item) {
+		if !slices.ContainsFunc(ops, func(o methodOperation) bool { return o.method == custom.method }) {
+			ops = append(ops, custom)
+		}
+	}
+
+	return ops
+}
+
+// extensionOperations builds the operations declared under x-methods. Entries that fail to
+// build are skipped, like other parts of a partially valid spec
+func extensionOperations(item *v3.PathItem) []methodOperation {
+	if item.Extensions == nil {
+		return nil
+	}
+	node, ok := item.Extensions.Get(customMethodsExtension)
+	if !ok || node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	lowItem := item.GoLow()
+	if lowItem == nil {
+		return nil
+	}
+
+	var ops []methodOperation
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		if valueNode.Kind != yaml.MappingNode {
+			continue
+		}
+
+		var op lowv3.Operation
+		if err := low.BuildModel(valueNode, &op); err != nil {
+			debugLog.Debug("skipping custom method", "method", keyNode.Value, "error", err)
+			continue
+		}
+		if err := op.Build(lowItem.GetContext(), keyNode, valueNode, lowItem.GetIndex()); err != nil {
+			debugLog.Debug("skipping custom method", "method", keyNode.Value, "error", err)
+			continue
+		}
+		ops = append(ops, methodOperation{method: strings.ToUpper(keyNode.Value), op: v3.NewOperation(&op), key: customMethodsExtension + "/" + escapePointer(keyNode.Value)})
+	}
+	return ops
+}
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validateColor accepts the hex and ANSI 256 colors lipgloss understands
+func validateColor(value string) error {
+	if hexColorPattern.MatchString(value) {
+		return nil
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 255 {
+		return nil
+	}
+	return fmt.Errorf("%q is not a color, expected #RRGGBB, #RGB or an ANSI code 0-255", value)
+}
+
+func validateLabel(value string) error {
+	if strings.TrimSpace(value) == "" || strings.ContainsAny(value, "\n\t") {
+		return fmt.Errorf("%q is not a valid label", value)
+	}
+	return nil
+}
+
+// upperKeys normalizes method keys from a hand-edited config file
+func upperKeys(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	upper := make(map[string]string, len(m))
+	for k, v := range m {
+		upper[strings.ToUpper(k)] = v
+	}
+	return upper
+}
+
+// methodColor returns the configured color for a method, falling back to the built-in
+// palette and a neutral gray for methods it doesn't know
+func (m Model) methodColor(method string) lipgloss.Color {
+	if color, ok := m.methodColors[method]; ok {
+		return lipgloss.Color(color)
+	}
+	if color, ok := methodColors[method]; ok {
+		return color
+	}
+	return lipgloss.Color(colorGray)
+}
+
+// methodLabel returns the text shown for a method in the lists
+func (m Model) methodLabel(method string) string {
+	if label, ok := m.methodLabels[method]; ok {
+		return label
+	}
+	return method
+}
+
+// methodWidth returns the method column width that fits every label, so long custom verbs
+// don't wrap
+func (m Model) methodWidth(methods []string) int {
+	width := methodColumnWidth
+	for _, method := range methods {
+		width = max(width, lipgloss.Width(m.methodLabel(method))+1)
+	}
+	return width
+}
+
+func endpointMethods(eps []endpoint) []string {
+	methods := make([]string, len(eps))
+	for i, ep := range eps {
+		methods[i] = ep.method
+	}
+	return methods
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// modalContent is what a modal shows, laid out as a bordered box or, when the terminal is
+// too short for the box, as a full-screen view. The instruction is plain text
+type modalContent struct {
+	title       string
+	body        string
+	instruction string
+	width       int
+}
+
+func (c modalContent) box() string {
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(colorThemePurple)).
+		Padding(1, 2).
+		Width(c.width)
+
+	inner := c.title + "\n\n" + c.body
+	if c.instruction != "" {
+		instructionStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(colorGray)).
+			Italic(true)
+		inner += "\n\n" + instructionStyle.Render(c.instruction)
+	}
+	return modalStyle.Render(inner)
+}
+
+// openModal returns the content of the help or snippet modal, whichever is open
+func (m Model) openModal() (modalContent, bool) {
+	switch {
+	case m.showHelp:
+		return m.helpModal(), true
+	case m.showCurl:
+		return m.snippetModal(), true
+	}
+	return modalContent{}, false
+}
+
+// modalLines wraps the body of a modal to the terminal width and returns it with the number
+// of lines that fit below the title and above the instruction
+func (m Model) modalLines(c modalContent) ([]string, int) {
+	body := lipgloss.NewStyle().Width(max(20, m.width)).Render(c.body)
+	height := max(1, m.height-lipgloss.Height(c.title)-4)
+	return strings.Split(body, "\n"), height
+}
+
+// fitsScreen reports whether the box of a modal fits the terminal
+func (m Model) fitsScreen(c modalContent) bool {
+	return lipgloss.Height(c.box()) <= m.height
+}
+
+// renderModal centers the modal box, or fills the screen with it on short terminals so
+// nothing is clipped, scrolled with j/k
+func (m Model) renderModal(c modalContent) string {
+	if m.fitsScreen(c) {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, c.box())
+	}
+
+	lines, height := m.modalLines(c)
+	scroll := max(0, min(m.modalScroll, len(lines)-height))
+	visible := lines[scroll:min(len(lines), scroll+height)]
+
+	instruction := "j/k scroll · Esc close"
+	if c.instruction != "" {
+		instruction = "j/k scroll · " + c.instruction
+	}
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	return lipgloss.NewStyle().MaxHeight(m.height).MaxWidth(m.width).Render(
+		c.title + "\n\n" + strings.Join(visible, "\n") + "\n\n" + instructionStyle.Render(instruction))
+}
+
+// scrollModal scrolls a modal shown full-screen and reports whether it handled the key.
+// Modals that fit the terminal leave the keys to the list
+func (m *Model) scrollModal(key string) bool {
+	c, ok := m.openModal()
+	if !ok || m.fitsScreen(c) {
+		return false
+	}
+	lines, height := m.modalLines(c)
+	last := max(0, len(lines)-height)
+	page := max(1, height/2)
+
+	switch key {
+	case "up", "k":
+		m.modalScroll = max(0, m.modalScroll-1)
+	case "down", "j":
+		m.modalScroll = min(last, m.modalScroll+1)
+	case "ctrl+u":
+		m.modalScroll = max(0, m.modalScroll-page)
+	case "ctrl+d":
+		m.modalScroll = min(last, m.modalScroll+page)
+	case "g":
+		m.modalScroll = 0
+	case "G":
+		m.modalScroll = last
+	default:
+		return false
+	}
+	return true
+}
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
 )
 
 type viewMode int
@@ -17,6 +21,7 @@ const (
 	viewEndpoints viewMode = iota
 	viewComponents
 	viewWebhooks
+	viewTags
 )
 
 const keySequenceThreshold = 500 * time.Millisecond
@@ -46,10 +51,13 @@ func calculateContentWidth(totalWidth int) int {
 }
 
 type webhook struct {
-	name   string
-	method string
-	op     *v3.Operation
-	folded bool
+	name      string
+	method    string
+	op        *v3.Operation
+	folded    bool
+	signature *webhookSignature
+	// pointer is the JSON Pointer of the operation in the spec
+	pointer string
 }
 
 type endpoint struct {
@@ -57,6 +65,11 @@ type endpoint struct {
 	method string
 	op     *v3.Operation
 	folded bool
+	notes  []string
+	// pointer is the JSON Pointer of the operation in the spec, e.g. #/paths/~1users/get
+	pointer string
+	// shared are the parameters of the path item, inherited by every operation on the path
+	shared []*v3.Parameter
 }
 
 type component struct {
@@ -65,6 +78,10 @@ type component struct {
 	description string
 	details     string
 	folded      bool
+	// cycle is how a recursive schema refers back to itself
+	cycle string
+	// deprecated is set for schemas, parameters, headers and security schemes marked deprecated
+	deprecated bool
 }
 
 type Model struct {
@@ -72,6 +89,7 @@ type Model struct {
 	endpoints          []endpoint
 	components         []component
 	webhooks           []webhook
+	tags               []tagEntry
 	cursor             int
 	mode               viewMode
 	width              int
@@ -85,23 +103,115 @@ type Model struct {
 	filteredEndpoints  []endpoint
 	filteredComponents []component
 	filteredWebhooks   []webhook
+	filteredTags       []tagEntry
 	showCurl           bool
+	modalScroll        int
 	curlCommand        string
+	curlWarning        string
+	snippetRequest     snippetRequest
+	snippetTarget      int
+	snippetSuffix      string
+	methodPrefix       bool
+	searchAll          bool
+	searchCursor       int
+	specPath           string
+	specHash           string
+	specModTime        time.Time
+	specChanged        bool
+	refreshEvery       time.Duration
+	specRefreshedAt    time.Time
+	autoReload         bool
+	reloadErr          error
+	filters            listFilters
+	commandMode        bool
+	commandInput       textinput.Model
+	sortKeys           []sortKey
+	statusMessage      string
+	statusError        bool
+	notes              specNotes
+	usages             *usagesPane
+	responses          *responsesPane
+	methodColors       map[string]string
+	methodLabels       map[string]string
+	specSize           int
+	budgets            specBudgets
+	budgetWarnings     []string
+	oversizedPayloads  map[string]payloadSize
+	writeMode          bool
+	tagPicker          *tagPicker
+	scopes             *scopePane
+	mediaTypes         *mediaPane
+	runner             *requestRunner
+	openCredentials    func() (credentialStore, error)
+	promptedKeys       map[string]string
+	keyBindings        map[string]string
+	curl               curlSettings
+	latencies          map[string]time.Duration
+	team               *teamFile
+	splitView          bool
+	pii                *piiPane
+	piiTerms           []piiTerm
+	groupedByTag       bool
+	collapsedTags      map[string]bool
+	detailKey          string
+	detailScroll       int
+	pinned             *pinnedDetails
+	servers            *serversPane
+	schemaTree         *schemaTree
+	activeServer       string
+	serverValues       map[string]map[string]string
+	specContent        []byte
+	ruleset            *spectralRuleset
+	problems           []lintIssue
+	issues             *issuesPane
+	copyText           func(string) error
+	patch              *specPatch
+	patchBadges        map[string]string
+	reloadBadges       map[string]string
+	footer             footerLayout
+	lastRequest        *lastRequest
+	workspace          *workspace
+	specSwitcher       *specSwitcher
+	operationIDs       *operationIDReview
+	// outline lists the sections of an operation's details to jump to
+	outline *outlineMenu
+	// componentsLoaded is set once the components are extracted, which waits until they are
+	// shown or searched
+	componentsLoaded bool
+	// detailCache holds the details of endpoints built on first unfold, by endpoint key
+	detailCache map[string]string
+	// overview summarizes the spec, on D or as the landing page
+	overview *overviewPane
+}
+
+// contentHeight returns the lines available to the list, accounting for the filter chips line
+func (m *Model) contentHeight() int {
+	height := calculateContentHeight(m.height)
+	if len(m.filterChips()) > 0 {
+		height = max(1, height-1)
+	}
+	return height
 }
 
 func (m *Model) getItemHeight(index int) int {
+	if m.useSplitView() {
+		return 1 // Details are shown in their own pane
+	}
 	switch m.mode {
 	case viewEndpoints:
-		eps := m.getActiveEndpoints()
-		if index >= len(eps) {
+		rows := m.endpointRows()
+		if index >= len(rows) || rows[index].isHeader() {
 			return 1
 		}
-		ep := eps[index]
+		ep := m.getActiveEndpoints()[rows[index].index]
 		if ep.folded {
 			return 1 // Just the main line when folded
 		}
 		// When unfolded, count main line + detail lines
-		details := formatEndpointDetails(ep)
+		details, skipped := m.inlineDetails(ep, index == m.cursor)
+		if skipped > 0 {
+			return 1 + strings.Count(details, "\n") + 2 // and the line telling the skipped lines
+		}
 		return 1 + strings.Count(details, "\n") + 1 // +1 for main line, +1 for the detail section
 	case viewComponents:
 		comps := m.getActiveComponents()
@@ -126,26 +236,70 @@ func (m *Model) getItemHeight(index int) int {
 		// When unfolded, count main line + detail lines
 		details := formatWebhookDetails(hook)
 		return 1 + strings.Count(details, "\n") + 1 // +1 for main line, +1 for the detail section
+	case viewTags:
+		tags := m.getActiveTags()
+		if index >= len(tags) || tags[index].folded {
+			return 1
+		}
+		return 1 + strings.Count(m.formatTagDetails(tags[index]), "\n") + 1
 	}
 	return 1
 }
 
+// isFiltering reports whether any filter is active, in which case the filtered lists are shown
+func (m *Model) isFiltering() bool {
+	return m.searchInput.Value() != "" || m.filters.active()
+}
+
 func (m *Model) getActiveEndpoints() []endpoint {
-	if m.searchInput.Value() != "" {
+	if m.isFiltering() {
 		return m.filteredEndpoints
 	}
 	return m.endpoints
 }
 
+// loadComponents extracts the components on first use. Huge specs hold thousands of them,
+// which startup doesn't need
+func (m *Model) loadComponents() {
+	if m.componentsLoaded {
+		return
+	}
+	m.components = extractComponents(m.doc)
+	m.componentsLoaded = true
+}
+
+// componentCount is the number of components, counted in the spec until they are extracted
+func (m *Model) componentCount() int {
+	if m.componentsLoaded {
+		return len(m.components)
+	}
+	return countComponents(m.doc)
+}
+
+// endpointDetails returns the details of an endpoint, built when first shown and cached until
+// the spec or its notes change
+func (m *Model) endpointDetails(ep endpoint) string {
+	if m.detailCache == nil {
+		return formatEndpointDetails(ep)
+	}
+	key := "endpoint " + ep.method + " " + ep.path
+	details, ok := m.detailCache[key]
+	if !ok {
+		details = formatEndpointDetails(ep)
+		m.detailCache[key] = details
+	}
+	return details
+}
+
 func (m *Model) getActiveComponents() []component {
-	if m.searchInput.Value() != "" {
+	if m.isFiltering() {
 		return m.filteredComponents
 	}
 	return m.components
 }
 
 func (m *Model) getActiveWebhooks() []webhook {
-	if m.searchInput.Value() != "" {
+	if m.isFiltering() {
 		return m.filteredWebhooks
 	}
 	return m.webhooks
@@ -154,11 +308,13 @@ func (m *Model) getActiveWebhooks() []webhook {
 func (m *Model) getMaxItems() int {
 	switch m.mode {
 	case viewEndpoints:
-		return len(m.getActiveEndpoints()) - 1
+		return len(m.endpointRows()) - 1
 	case viewComponents:
 		return len(m.getActiveComponents()) - 1
 	case viewWebhooks:
 		return len(m.getActiveWebhooks()) - 1
+	case viewTags:
+		return len(m.getActiveTags()) - 1
 	default:
 		return -1
 	}
@@ -166,7 +322,7 @@ func (m *Model) getMaxItems() int {
 
 func (m *Model) ensureCursorVisible() {
 	// Calculate available content height using shared function
-	contentHeight := calculateContentHeight(m.height)
+	contentHeight := m.contentHeight()
 
 	// Special case: if cursor is at 0, ensure we scroll to the very top
 	if m.cursor == 0 {
@@ -178,9 +334,9 @@ func (m *Model) ensureCursorVisible() {
 	var items []interface{}
 	switch m.mode {
 	case viewEndpoints:
-		eps := m.getActiveEndpoints()
-		for i := range eps {
-			items = append(items, eps[i])
+		rows := m.endpointRows()
+		for i := range rows {
+			items = append(items, rows[i])
 		}
 	case viewComponents:
 		comps := m.getActiveComponents()
@@ -192,6 +348,11 @@ func (m *Model) ensureCursorVisible() {
 		for i := range hooks {
 			items = append(items, hooks[i])
 		}
+	case viewTags:
+		tags := m.getActiveTags()
+		for i := range tags {
+			items = append(items, tags[i])
+		}
 	}
 
 	if len(items) == 0 {
@@ -256,69 +417,102 @@ func generateExampleJSON(schema *base.Schema, doc *v3.Document, depth int) strin
 		return "{}"
 	}
 
-	// Handle schema with example
-	if schema.Example != nil {
-		return fmt.Sprintf("%v", schema.Example)
+	// A const is the only valid value, then the schema's example, then the first of its
+	// OpenAPI 3.1 examples
+	examples := []*yaml.Node{schema.Const, schema.Example}
+	examples = append(examples, schema.Examples...)
+	for _, node := range examples {
+		if node == nil {
+			continue
+		}
+		var example bytes.Buffer
+		if err := writeJSONNode(&example, node); err == nil {
+			return example.String()
+		}
 	}
 
 	// Handle different schema types
-	if len(schema.Type) > 0 {
-		switch schema.Type[0] {
-		case "object":
-			var props []string
-			if schema.Properties != nil {
-				for pair := schema.Properties.First(); pair != nil; pair = pair.Next() {
-					propName := pair.Key()
-					propSchema := pair.Value()
-
-					// Generate value for this property
-					var value string
-					if propSchema.Schema() != nil {
-						value = generateExampleJSON(propSchema.Schema(), doc, depth+1)
-					} else {
-						value = "\"example\""
-					}
-					props = append(props, fmt.Sprintf("\"%s\": %s", propName, value))
-				}
-			}
-			if len(props) > 0 {
-				return "{ " + strings.Join(props, ", ") + " }"
+	switch exampleType(schema) {
+	case "object":
+		var names []string
+		values := map[string]string{}
+		addProperties := func(s *base.Schema) {
+			if s == nil || s.Properties == nil {
+				return
 			}
-			return "{}"
-
-		case "array":
-			if schema.Items != nil && schema.Items.IsA() {
-				itemSchema := schema.Items.A.Schema()
-				if itemSchema != nil {
-					return "[ " + generateExampleJSON(itemSchema, doc, depth+1) + " ]"
+			for pair := s.Properties.First(); pair != nil; pair = pair.Next() {
+				value := "\"example\""
+				if pair.Value().Schema() != nil {
+					value = generateExampleJSON(pair.Value().Schema(), doc, depth+1)
+				}
+				if _, ok := values[pair.Key()]; !ok {
+					names = append(names, pair.Key())
 				}
+				values[pair.Key()] = value
 			}
-			return "[]"
+		}
+		addProperties(schema)
+		// With if/then the example meets the condition, usually a const, and takes the then branch
+		if schema.If != nil && schema.Then != nil {
+			addProperties(schema.If.Schema())
+			addProperties(schema.Then.Schema())
+		}
 
-		case "string":
-			if len(schema.Enum) > 0 {
-				return fmt.Sprintf("\"%v\"", schema.Enum[0])
-			}
-			if schema.Format == "date" {
-				return "\"2024-01-01\""
-			}
-			if schema.Format == "date-time" {
-				return "\"2024-01-01T00:00:00Z\""
+		var props []string
+		for _, name := range names {
+			props = append(props, fmt.Sprintf("\"%s\": %s", name, values[name]))
+		}
+		if len(props) > 0 {
+			return "{ " + strings.Join(props, ", ") + " }"
+		}
+		return "{}"
+
+	case "array":
+		// prefixItems describe the leading items of a tuple one by one
+		var items []string
+		for _, proxy := range schema.PrefixItems {
+			if proxy != nil && proxy.Schema() != nil {
+				items = append(items, generateExampleJSON(proxy.Schema(), doc, depth+1))
 			}
-			if schema.Format == "email" {
-				return "\"user@example.com\""
+		}
+		if len(items) == 0 && schema.Items != nil && schema.Items.IsA() {
+			itemSchema := schema.Items.A.Schema()
+			if itemSchema != nil {
+				items = append(items, generateExampleJSON(itemSchema, doc, depth+1))
 			}
-			return "\"string\""
+		}
+		if len(items) > 0 {
+			return "[ " + strings.Join(items, ", ") + " ]"
+		}
+		return "[]"
 
-		case "number", "integer":
-			return "0"
+	case "string":
+		if len(schema.Enum) > 0 && schema.Enum[0] != nil {
+			quoted, _ := json.Marshal(schema.Enum[0].Value)
+			return string(quoted)
+		}
+		if schema.Format == "date" {
+			return "\"2024-01-01\""
+		}
+		if schema.Format == "date-time" {
+			return "\"2024-01-01T00:00:00Z\""
+		}
+		if schema.Format == "time" {
+			return "\"00:00:00Z\""
+		}
+		if schema.Format == "email" {
+			return "\"user@example.com\""
+		}
+		return "\"string\""
 
-		case "boolean":
-			return "false"
+	case "number", "integer":
+		return exampleNumber(schema)
 
-		case "null":
-			return "null"
-		}
+	case "boolean":
+		return "false"
+
+	case "null":
+		return "null"
 	}
 
 	// Handle $ref
@@ -342,89 +536,58 @@ func generateExampleJSON(schema *base.Schema, doc *v3.Document, depth int) strin
 	return "{}"
 }
 
-func generateCurl(ep endpoint, doc *v3.Document) string {
-	var curl strings.Builder
-
-	// Start with curl command
-	curl.WriteString("curl -X " + ep.method)
-
-	// Add URL - use first server if available, otherwise placeholder
-	baseURL := "https://api.example.com"
-	if len(doc.Servers) > 0 {
-		baseURL = doc.Servers[0].URL
-	}
-	curl.WriteString(" '" + baseURL + ep.path + "'")
-
-	// Add common headers
-	headers := make(map[string]string)
-
-	// Check if endpoint has request body (POST, PUT, PATCH typically)
-	if ep.op.RequestBody != nil {
-		headers["Content-Type"] = "application/json"
-	}
-
-	// Add security headers if defined
-	if len(ep.op.Security) > 0 {
-		// Check for common auth types
-		for _, secReq := range ep.op.Security {
-			for pair := secReq.Requirements.First(); pair != nil; pair = pair.Next() {
-				secName := pair.Key()
-				if doc.Components != nil && doc.Components.SecuritySchemes != nil {
-					if scheme := doc.Components.SecuritySchemes.GetOrZero(secName); scheme != nil {
-						switch scheme.Type {
-						case "http":
-							if scheme.Scheme == "bearer" {
-								headers["Authorization"] = "Bearer YOUR_TOKEN"
-							} else if scheme.Scheme == "basic" {
-								headers["Authorization"] = "Basic YOUR_CREDENTIALS"
-							}
-						case "apiKey":
-							if scheme.In == "header" {
-								headers[scheme.Name] = "YOUR_API_KEY"
-							}
-						}
-					}
-				}
-			}
+// exampleType is the type an example of the schema takes. Of an OpenAPI 3.1 type array such
+// as [string, null] the first type other than null is used, and schemas without a type are
+// taken as objects or arrays when they declare properties or items
+func exampleType(schema *base.Schema) string {
+	for _, t := range schema.Type {
+		if t != "null" {
+			return t
 		}
 	}
-
-	// Add headers to curl
-	for key, value := range headers {
-		curl.WriteString(" \\\n  -H '" + key + ": " + value + "'")
+	switch {
+	case len(schema.Type) > 0:
+		return "null"
+	case schema.Properties != nil && schema.Properties.Len() > 0:
+		return "object"
+	case schema.Items != nil, len(schema.PrefixItems) > 0:
+		return "array"
 	}
+	return ""
+}
 
-	// Add request body example if present
-	if ep.op.RequestBody != nil && ep.op.RequestBody.Content != nil {
-		if jsonContent := ep.op.RequestBody.Content.GetOrZero("application/json"); jsonContent != nil {
-			var bodyJSON string
-			if jsonContent.Schema != nil && jsonContent.Schema.Schema() != nil {
-				bodyJSON = generateExampleJSON(jsonContent.Schema.Schema(), doc, 0)
-			} else {
-				bodyJSON = "{}"
-			}
-			curl.WriteString(" \\\n  -d '" + bodyJSON + "'")
-		}
-	}
+// curlSettings are the configured default server and extra curl options
+type curlSettings struct {
+	server  string
+	options string
+}
 
-	return curl.String()
+func generateCurl(ep endpoint, doc *v3.Document, settings curlSettings) string {
+	return curlEmitter{options: settings.options}.emit(buildSnippetRequest(ep, doc, settings.server))
 }
 
 func NewModel(doc *v3.Document) Model {
+	// Components are extracted when first shown, see loadComponents
 	endpoints := extractEndpoints(doc)
-	components := extractComponents(doc)
 	webhooks := extractWebhooks(doc)
+	debugLog.Debug("extracted items", "endpoints", len(endpoints), "webhooks", len(webhooks))
 
 	ti := textinput.New()
 	ti.Placeholder = "Search..."
 	ti.CharLimit = 100
 	ti.Width = 50
 
+	ci := textinput.New()
+	ci.Placeholder = "sort tag,path"
+	ci.CharLimit = 100
+	ci.Width = 50
+
 	return Model{
+		commandInput: ci,
 		doc:          doc,
 		endpoints:    endpoints,
-		components:   components,
 		webhooks:     webhooks,
+		tags:         extractTags(doc, endpoints),
 		cursor:       0,
 		mode:         viewEndpoints,
 		width:        80,
@@ -434,39 +597,82 @@ func NewModel(doc *v3.Document) Model {
 		searchMode:   false,
 		searchInput:  ti,
 		showCurl:     false,
+		copyText:     copyToClipboard,
+		detailCache:  map[string]string{},
+	}
+}
+
+// applyConfig applies user settings that affect the initial state of the TUI
+func (m *Model) applyConfig(cfg *Config) {
+	if mode, ok := parseViewMode(cfg.DefaultView); ok && (mode != viewWebhooks || m.hasWebhooks()) {
+		m.mode = mode
+		if mode == viewComponents {
+			m.loadComponents()
+		}
+	}
+	m.methodColors = upperKeys(cfg.MethodColors)
+	m.methodLabels = upperKeys(cfg.MethodLabels)
+	m.budgets = budgetsFromConfig(cfg)
+	m.splitView = cfg.SplitView
+	m.piiTerms = piiTermsFromConfig(cfg)
+	m.openCredentials = func() (credentialStore, error) {
+		return openCredentialStore(cfg)
 	}
+	m.keyBindings = keyBindingsFromConfig(cfg)
+	m.curl = curlSettings{server: cfg.DefaultServer, options: cfg.CurlOptions}
+	m.footer, _ = parseFooter(cfg.Footer)
+	if cfg.DefaultView == "overview" {
+		m.openOverview()
+	}
+}
+
+// setNotes attaches sidecar annotations to the endpoints they describe
+func (m *Model) setNotes(notes specNotes) {
+	m.notes = notes
+	attachNotes(m.endpoints, notes)
+	m.detailCache = map[string]string{}
+	m.filterItems()
 }
 
 func (m *Model) hasWebhooks() bool {
 	return len(m.webhooks) > 0
 }
 
+// endpointMatches reports whether every term of the query matches the endpoint, see
+// searchQuery.matchesOperation
+func endpointMatches(ep endpoint, query searchQuery) bool {
+	return query.matchesOperation(ep.method, ep.path, ep.op, func() []string { return inlineSchemaNames(ep) })
+}
+
 func (m *Model) filterItems() {
-	query := strings.ToLower(m.searchInput.Value())
-	if query == "" {
+	query := parseSearchQuery(m.searchInput.Value())
+	if !m.isFiltering() {
 		m.filteredEndpoints = nil
 		m.filteredComponents = nil
 		m.filteredWebhooks = nil
+		m.filteredTags = nil
 		return
 	}
 
 	// Filter endpoints
 	m.filteredEndpoints = nil
 	for _, ep := range m.endpoints {
-		if strings.Contains(strings.ToLower(ep.path), query) ||
-			strings.Contains(strings.ToLower(ep.method), query) ||
-			(ep.op.Summary != "" && strings.Contains(strings.ToLower(ep.op.Summary), query)) ||
-			(ep.op.Description != "" && strings.Contains(strings.ToLower(ep.op.Description), query)) {
+		if !m.filters.matchesOperation(ep.method, ep.op) || (m.filters.pinned && !m.team.pinned(ep)) {
+			continue
+		}
+		if endpointMatches(ep, query) {
 			m.filteredEndpoints = append(m.filteredEndpoints, ep)
 		}
 	}
 
-	// Filter components
+	// Filter components, which searches extract
+	m.loadComponents()
 	m.filteredComponents = nil
 	for _, comp := range m.components {
-		if strings.Contains(strings.ToLower(comp.name), query) ||
-			strings.Contains(strings.ToLower(comp.compType), query) ||
-			strings.Contains(strings.ToLower(comp.description), query) {
+		if !m.filters.matchesComponent(comp) {
+			continue
+		}
+		if query.matchesText(comp.name, comp.compType, comp.description) {
 			m.filteredComponents = append(m.filteredComponents, comp)
 		}
 	}
@@ -474,32 +680,154 @@ func (m *Model) filterItems() {
 	// Filter webhooks
 	m.filteredWebhooks = nil
 	for _, hook := range m.webhooks {
-		if strings.Contains(strings.ToLower(hook.name), query) ||
-			strings.Contains(strings.ToLower(hook.method), query) ||
-			(hook.op.Summary != "" && strings.Contains(strings.ToLower(hook.op.Summary), query)) ||
-			(hook.op.Description != "" && strings.Contains(strings.ToLower(hook.op.Description), query)) {
+		// Only endpoints can be pinned
+		if !m.filters.matchesOperation(hook.method, hook.op) || m.filters.pinned {
+			continue
+		}
+		if query.matchesOperation(hook.method, hook.name, hook.op, nil) {
 			m.filteredWebhooks = append(m.filteredWebhooks, hook)
 		}
 	}
+
+	m.filterTags(query)
+
+	debugLog.Debug("filtered items", "query", query,
+		"endpoints", len(m.filteredEndpoints),
+		"components", len(m.filteredComponents),
+		"webhooks", len(m.filteredWebhooks),
+		"tags", len(m.filteredTags))
 }
 
 func (m Model) Init() tea.Cmd {
-	return nil
+	var cmds []tea.Cmd
+	if m.footer.has("clock") {
+		cmds = append(cmds, tickFooterClock())
+	}
+	if m.specPath != "" && !isRemoteSpec(m.specPath) {
+		cmds = append(cmds, checkSpecLater())
+	} else if isRemoteSpec(m.specPath) && m.refreshEvery > 0 {
+		cmds = append(cmds, refreshSpecLater(m.refreshEvery))
+	}
+	return tea.Batch(cmds...)
+}
+
+// typing reports whether keys go to a text input, the runner's fields and prompts included.
+// What is typed there, such as secrets and header values, is kept out of the debug log
+func (m Model) typing() bool {
+	return m.commandMode || m.searchMode || m.runner != nil || m.specSwitcher != nil || m.tagPicker != nil
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
+		debugLog.Debug("window resized", "width", msg.Width, "height", msg.Height)
 		m.width = msg.Width
 		m.height = msg.Height
+		m.ensureCursorVisible()
+
+	case specCheckMsg:
+		return m, m.checkSpec()
+
+	case specRefreshMsg:
+		return m, refreshSpec(m.specPath, m.patch, m.specHash, m.specContent)
+
+	case specReloadedMsg:
+		// The workspace switched to another spec while this one was read
+		if msg.path != m.specPath {
+			return m, nil
+		}
+		// Periodic refreshes keep going whether or not this one changed anything
+		var next tea.Cmd
+		if msg.refreshed {
+			next = refreshSpecLater(m.refreshEvery)
+		}
+		if msg.err != nil {
+			debugLog.Warn("reloading spec failed", "error", msg.err)
+			m.reloadErr = msg.err
+			return m, next
+		}
+		if msg.unchanged {
+			m.reloadErr = nil
+			return m, next
+		}
+		if msg.refreshed {
+			m.specRefreshedAt = time.Now()
+		}
+		debugLog.Debug("spec reloaded", "hash", msg.hash)
+		m.replaceDocument(msg.doc)
+		m.specHash = msg.hash
+		m.specModTime = msg.modTime
+		m.specChanged = false
+		m.reloadErr = nil
+		m.specSize = msg.size
+		m.specContent = msg.content
+		m.patchBadges = msg.patchBadges
+		m.problems = msg.problems
+		m.updateBudgetWarnings()
+		m.reloadBadges = msg.changes
+		if len(msg.changes) == 0 {
+			return m, next
+		}
+		m.setStatus("Reloaded: "+patchSummary(msg.changes), false)
+		return m, tea.Batch(next, reloadHighlightDone(msg.hash))
+
+	case reloadHighlightDoneMsg:
+		// A later reload has its own highlight
+		if msg.hash == m.specHash {
+			m.reloadBadges = nil
+		}
+		return m, nil
+
+	case runResultMsg:
+		m.handleRunResult(msg)
+		return m, nil
+
+	case oauthClientMsg:
+		return m, m.handleOAuthClient(msg)
+
+	case footerClockMsg:
+		return m, tickFooterClock()
+
+	case spinner.TickMsg:
+		if m.runner != nil && m.runner.sending {
+			return m, m.runner.loading.update(msg)
+		}
+		return m, nil
 
 	case tea.KeyMsg:
+		if msg.Type != tea.KeyRunes && msg.Type != tea.KeySpace || !m.typing() {
+			debugLog.Debug("key", "key", msg.String(), "mode", m.mode, "search", m.searchMode, "cursor", m.cursor)
+		}
+
+		m.statusMessage = ""
+
+		// Handle command prompt input
+		if m.commandMode {
+			switch msg.String() {
+			case "esc":
+				m.commandMode = false
+				m.commandInput.Blur()
+				return m, nil
+			case "ctrl+c":
+				return m, tea.Quit
+			case "enter":
+				m.commandMode = false
+				m.commandInput.Blur()
+				m.runCommand(m.commandInput.Value())
+				return m, nil
+			default:
+				var cmd tea.Cmd
+				m.commandInput, cmd = m.commandInput.Update(msg)
+				return m, cmd
+			}
+		}
+
 		// Handle search mode input
 		if m.searchMode {
 			switch msg.String() {
 			case "esc":
 				// Esc clears search and exits search mode
-				m.searchMode = false
+				m.searchMode, m.searchAll = false, false
 				m.searchInput.SetValue("")
 				m.filterItems()
 				m.cursor = 0
@@ -508,7 +836,33 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "ctrl+c":
 				// Ctrl+C quits the application
 				return m, tea.Quit
+			case "ctrl+g":
+				m.toggleSearchScope()
+				return m, nil
+			case "tab", "shift+tab":
+				if !m.searchAll {
+					step := 1
+					if msg.String() == "shift+tab" {
+						step = -1
+					}
+					m.switchSearchView(step)
+				}
+				return m, nil
+			case "up", "ctrl+p", "down", "ctrl+n":
+				if m.searchAll {
+					if msg.String() == "up" || msg.String() == "ctrl+p" {
+						m.moveSearchCursor(-1)
+					} else {
+						m.moveSearchCursor(1)
+					}
+				}
+				return m, nil
 			case "enter":
+				if m.searchAll {
+					// Enter in a search across all views opens the selected result
+					m.openSearchResult()
+					return m, nil
+				}
 				// Enter keeps the filter and exits search mode
 				m.searchMode = false
 				m.searchInput.Blur()
@@ -517,13 +871,157 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				var cmd tea.Cmd
 				m.searchInput, cmd = m.searchInput.Update(msg)
 				m.filterItems()
+				m.searchCursor = 0
 				m.cursor = 0
 				m.scrollOffset = 0
 				return m, cmd
 			}
 		}
 
-		switch msg.String() {
+		// Handle the request runner
+		if m.runner != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			return m, m.updateRunner(msg)
+		}
+
+		// Handle the spec switcher
+		if m.specSwitcher != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			return m, m.updateSpecSwitcher(msg)
+		}
+
+		// Configured bindings apply to the lists and panes, not to text being typed
+		key := m.bindKey(msg.String())
+		if key == "" {
+			return m, nil
+		}
+		if key == "gg" {
+			m.lastKey, m.lastKeyAt, key = "g", time.Now(), "g"
+		}
+
+		// Handle the schema usages pane
+		if m.usages != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			m.updateUsages(key)
+			return m, nil
+		}
+
+		// Handle the schema tree
+		if m.schemaTree != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			m.updateSchemaTree(key)
+			return m, nil
+		}
+
+		// Handle the response examples
+		if m.responses != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			m.updateResponses(key)
+			return m, nil
+		}
+
+		// Handle the scope matrix
+		if m.scopes != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			m.updateScopes(key)
+			return m, nil
+		}
+
+		// Handle the media-type matrix
+		if m.mediaTypes != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			m.updateMediaTypes(key)
+			return m, nil
+		}
+
+		// Handle the servers pane
+		if m.servers != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			m.updateServers(key)
+			return m, nil
+		}
+
+		if m.overview != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			m.updateOverview(key)
+			return m, nil
+		}
+
+		// Handle the PII findings
+		if m.pii != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			m.updatePII(key)
+			return m, nil
+		}
+
+		// Handle the ruleset issues
+		if m.issues != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			m.updateIssues(key)
+			return m, nil
+		}
+
+		// Handle the tag picker
+		if m.tagPicker != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			return m, m.updateTagPicker(msg)
+		}
+
+		// Handle the operationId review
+		if m.operationIDs != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			return m, m.updateOperationIDReview(msg)
+		}
+
+		// Handle the sections to jump to
+		if m.outline != nil {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			return m, m.updateOutline(msg)
+		}
+
+		// The key after F picks a method filter
+		if m.methodPrefix {
+			m.methodPrefix = false
+			if !m.showHelp && msg.String() != "ctrl+c" {
+				m.quickMethodFilter(key)
+				return m, nil
+			}
+		}
+
+		// Help and snippets shown full-screen on short terminals scroll
+		if m.scrollModal(key) {
+			return m, nil
+		}
+
+		switch key {
 		case "q", "ctrl+c":
 			if m.showHelp {
 				m.showHelp = false
@@ -533,6 +1031,150 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "?":
 			m.showHelp = !m.showHelp
+			m.modalScroll = 0
+
+		case "e":
+			if !m.showHelp {
+				m.filters.missingExamples = !m.filters.missingExamples
+				m.refilter()
+			}
+
+		case "X":
+			if !m.showHelp {
+				m.cycleDeprecatedFilter()
+			}
+
+		case "F":
+			if !m.showHelp && m.mode != viewComponents {
+				m.methodPrefix = true
+				m.setStatus(quickMethodHint(), false)
+			}
+
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			if !m.showHelp {
+				m.removeFilterChip(int(key[0] - '0'))
+			}
+
+		case "u":
+			if !m.showHelp && m.mode == viewComponents {
+				m.openUsages()
+			}
+
+		case "O":
+			if !m.showHelp && m.mode == viewEndpoints {
+				m.exportOperation("")
+			}
+
+		case "T":
+			if !m.showHelp && m.mode == viewEndpoints {
+				m.openTagPicker()
+			}
+
+		case "A":
+			if !m.showHelp && m.mode == viewEndpoints {
+				m.openScopeMatrix()
+			}
+
+		case "M":
+			if !m.showHelp && m.mode == viewEndpoints {
+				m.openMediaMatrix()
+			}
+
+		case "t":
+			if !m.showHelp && m.mode == viewEndpoints {
+				m.toggleGrouping()
+			}
+
+		case "P":
+			if !m.showHelp && m.mode != viewWebhooks {
+				m.openPIIFindings()
+			}
+
+		case "y":
+			if !m.showHelp {
+				m.yank()
+			}
+
+		case "Y":
+			if !m.showHelp {
+				m.yankPointer()
+			}
+
+		case "b":
+			if !m.showHelp {
+				m.togglePin()
+			}
+
+		case "E":
+			if !m.showHelp {
+				m.openResponses()
+			}
+
+		case "I":
+			if !m.showHelp {
+				m.openIssues()
+			}
+
+		case "W":
+			if !m.showHelp {
+				m.openSpecSwitcher()
+			}
+
+		case "x":
+			if !m.showHelp && m.mode == viewEndpoints {
+				return m, m.openRunner()
+			}
+
+		case "v":
+			if !m.showHelp {
+				m.splitView = !m.splitView
+				m.ensureCursorVisible()
+			}
+
+		case "S":
+			if !m.showHelp {
+				m.openServers()
+			}
+
+		case "p":
+			if !m.showHelp {
+				m.togglePinnedDetails()
+			}
+
+		case "o":
+			if !m.showHelp {
+				m.openOutline()
+			}
+
+		case "D":
+			if !m.showHelp {
+				m.openOverview()
+			}
+
+		case "J":
+			if !m.showHelp && m.useSplitView() {
+				m.scrollDetails(1)
+			}
+
+		case "K":
+			if !m.showHelp && m.useSplitView() {
+				m.scrollDetails(-1)
+			}
+
+		case "ctrl+f":
+			if !m.showHelp && m.useSplitView() {
+				m.scrollDetails(m.detailHeight() / 2)
+			}
+
+		case "ctrl+b":
+			if !m.showHelp && m.useSplitView() {
+				m.scrollDetails(-m.detailHeight() / 2)
+			}
+
+		case "R":
+			if !m.showHelp && m.specPath != "" {
+				return m, reloadSpec(m.specPath, m.patch, m.specContent)
+			}
 
 		case "/":
 			if !m.showHelp {
@@ -543,6 +1185,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
+		case ":":
+			if !m.showHelp {
+				m.commandMode = true
+				m.commandInput.SetValue("")
+				return m, m.commandInput.Focus()
+			}
+
 		case "esc":
 			if m.showHelp {
 				m.showHelp = false
@@ -556,25 +1205,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.showCurl = false
 			}
 
+		case "left", "h", "right", "l":
+			if m.showCurl && !m.showHelp {
+				if key == "left" || key == "h" {
+					m.cycleSnippet(-1)
+				} else {
+					m.cycleSnippet(1)
+				}
+			}
+
 		case "r":
 			if !m.showHelp && !m.searchMode {
 				if m.mode == viewEndpoints {
-					eps := m.getActiveEndpoints()
-					if m.cursor < len(eps) {
-						m.curlCommand = generateCurl(eps[m.cursor], m.doc)
-						m.showCurl = true
+					if ep, ok := m.selectedEndpoint(); ok {
+						m.openSnippet(ep, "")
 					}
 				} else if m.mode == viewWebhooks {
 					hooks := m.getActiveWebhooks()
-					if m.cursor < len(hooks) {
+					if m.cursor >= 0 && m.cursor < len(hooks) {
 						// Create a temporary endpoint for webhook
 						tempEp := endpoint{
 							path:   hooks[m.cursor].name,
 							method: hooks[m.cursor].method,
 							op:     hooks[m.cursor].op,
 						}
-						m.curlCommand = generateCurl(tempEp, m.doc)
-						m.showCurl = true
+						var suffix string
+						if sig := hooks[m.cursor].signature; sig != nil {
+							suffix = sig.verificationSnippet()
+						}
+						m.openSnippet(tempEp, suffix)
 					}
 				}
 			}
@@ -592,8 +1251,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				case viewWebhooks:
 					m.mode = viewComponents
 				case viewComponents:
+					m.mode = viewTags
+				case viewTags:
 					m.mode = viewEndpoints
 				}
+				if m.mode == viewComponents {
+					m.loadComponents()
+				}
 				m.cursor = 0
 				m.scrollOffset = 0
 			}
@@ -603,6 +1267,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Cycle backwards through available views
 				switch m.mode {
 				case viewEndpoints:
+					m.mode = viewTags
+				case viewTags:
 					m.mode = viewComponents
 				case viewWebhooks:
 					m.mode = viewEndpoints
@@ -613,6 +1279,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.mode = viewEndpoints
 					}
 				}
+				if m.mode == viewComponents {
+					m.loadComponents()
+				}
 				m.cursor = 0
 				m.scrollOffset = 0
 			}
@@ -637,7 +1306,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				newCursorPos := m.cursor + scrollHalfScreenLines
 
 				if newCursorPos > maxItems {
-					m.cursor = maxItems
+					// An empty list has no last item, the cursor stays on the first row
+					m.cursor = max(0, maxItems)
 				} else {
 					m.cursor += scrollHalfScreenLines
 				}
@@ -647,7 +1317,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "ctrl+u":
 			if !m.showHelp {
-				halfLines := max(1, calculateContentHeight(m.height)/2)
+				halfLines := max(1, m.contentHeight()/2)
 				if m.cursor < halfLines {
 					m.cursor = 0
 				} else {
@@ -684,14 +1354,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "enter", " ":
-			if !m.showHelp && !m.searchMode {
+			if !m.showHelp && !m.searchMode && m.mode == viewTags {
+				// Enter drills down into the tag's endpoints, space shows its details
+				if key == "enter" {
+					m.showTagEndpoints()
+				} else if !m.useSplitView() {
+					m.toggleTagDetails()
+				}
+			} else if !m.showHelp && !m.searchMode && m.mode == viewComponents && key == "enter" && m.openSchemaTree() {
+				// Enter browses a schema as a tree, space still shows its details inline
+			} else if !m.showHelp && !m.searchMode && !m.useSplitView() {
 				if m.mode == viewEndpoints {
-					eps := m.getActiveEndpoints()
-					if m.cursor < len(eps) {
+					if group, ok := m.selectedTagGroup(); ok {
+						m.toggleTagGroup(group.tag)
+					} else if ep, ok := m.selectedEndpoint(); ok {
 						// Toggle the folded state in the source list
 						for i := range m.endpoints {
-							if m.endpoints[i].path == eps[m.cursor].path && m.endpoints[i].method == eps[m.cursor].method {
+							if m.endpoints[i].path == ep.path && m.endpoints[i].method == ep.method {
 								m.endpoints[i].folded = !m.endpoints[i].folded
+								// Unfolded again from the top, not the section jumped to
+								m.detailScroll = 0
 								m.filterItems() // Refresh filtered list
 								break
 							}
@@ -699,7 +1381,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				} else if m.mode == viewComponents {
 					comps := m.getActiveComponents()
-					if m.cursor < len(comps) {
+					if m.cursor >= 0 && m.cursor < len(comps) {
 						for i := range m.components {
 							if m.components[i].name == comps[m.cursor].name {
 								m.components[i].folded = !m.components[i].folded
@@ -710,7 +1392,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				} else if m.mode == viewWebhooks {
 					hooks := m.getActiveWebhooks()
-					if m.cursor < len(hooks) {
+					if m.cursor >= 0 && m.cursor < len(hooks) {
 						for i := range m.webhooks {
 							if m.webhooks[i].name == hooks[m.cursor].name && m.webhooks[i].method == hooks[m.cursor].method {
 								m.webhooks[i].folded = !m.webhooks[i].folded
@@ -765,6 +1447,16 @@ func (m Model) View() string {
 		content = m.renderComponents()
 	case viewWebhooks:
 		content = m.renderWebhooks()
+	case viewTags:
+		content = m.renderTags()
+	}
+
+	if m.searchMode && m.searchAll {
+		content = m.renderSearchResults(availableContentLines)
+	} else if m.searchInput.Value() != "" && m.viewMatches(m.mode) == 0 {
+		content = m.renderSearchEmpty()
+	} else if m.useSplitView() {
+		content = m.renderSplitView(content, availableContentLines)
 	}
 
 	// Truncate content if it's too long
@@ -786,11 +1478,66 @@ func (m Model) View() string {
 	baseView := s.String()
 
 	if m.showHelp {
-		return m.renderHelpModal()
+		return m.renderModal(m.helpModal())
 	}
 
 	if m.showCurl {
-		return m.renderCurlModal()
+		return m.renderModal(m.snippetModal())
+	}
+
+	if m.usages != nil {
+		return m.renderUsagesPane()
+	}
+
+	if m.schemaTree != nil {
+		return m.renderSchemaTree()
+	}
+
+	if m.responses != nil {
+		return m.renderResponsesPane()
+	}
+
+	if m.tagPicker != nil {
+		return m.renderTagPicker()
+	}
+
+	if m.operationIDs != nil {
+		return m.renderOperationIDReview()
+	}
+
+	if m.outline != nil {
+		return m.renderOutline()
+	}
+
+	if m.scopes != nil {
+		return m.renderScopePane()
+	}
+
+	if m.mediaTypes != nil {
+		return m.renderMediaPane()
+	}
+
+	if m.servers != nil {
+		return m.renderServersPane()
+	}
+
+	if m.overview != nil {
+		return m.renderOverview()
+	}
+
+	if m.pii != nil {
+		return m.renderPIIPane()
+	}
+	if m.issues != nil {
+		return m.renderIssuesPane()
+	}
+
+	if m.runner != nil {
+		return m.renderRunner()
+	}
+
+	if m.specSwitcher != nil {
+		return m.renderSpecSwitcher()
 	}
 
 	return baseView
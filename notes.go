@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// specNotes holds team-internal annotations loaded from a sidecar YAML file, keyed by
+// operationId, "METHOD /path" or "/path". Values are a string or a list of strings
+type specNotes map[string][]string
+
+// sidecarNotesPath returns the default notes file for a spec, e.g. openapi.notes.yaml
+// next to openapi.yaml
+func sidecarNotesPath(specPath string) string {
+	ext := filepath.Ext(specPath)
+	return strings.TrimSuffix(specPath, ext) + ".notes.yaml"
+}
+
+// loadNotes reads a notes file. A missing file is only an error when required is set,
+// so the sidecar next to a spec stays optional
+func loadNotes(path string, required bool) (specNotes, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading notes: %w", err)
+	}
+
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("Error parsing notes %s: %w", path, err)
+	}
+	notes, err := parseNotes(raw, path)
+	if err != nil {
+		return nil, err
+	}
+
+	debugLog.Debug("loaded notes", "path", path, "keys", len(notes))
+	return notes, nil
+}
+
+// parseNotes reads the notes of a notes file, or of the notes key of a team file
+func parseNotes(raw map[string]yaml.Node, path string) (specNotes, error) {
+	notes := specNotes{}
+	for key, node := range raw {
+		switch node.Kind {
+		case yaml.ScalarNode:
+			notes[key] = []string{node.Value}
+		case yaml.SequenceNode:
+			for _, item := range node.Content {
+				if item.Kind != yaml.ScalarNode {
+					return nil, fmt.Errorf("Error parsing notes %s: %q must be a string or a list of strings", path, key)
+				}
+				notes[key] = append(notes[key], item.Value)
+			}
+		default:
+			return nil, fmt.Errorf("Error parsing notes %s: %q must be a string or a list of strings", path, key)
+		}
+	}
+	return notes, nil
+}
+
+// loadSpecNotes loads the notes file given with --notes, falling back to the optional sidecar next to the spec
+func loadSpecNotes(specPath, notesPath string) (specNotes, error) {
+	if notesPath != "" {
+		return loadNotes(notesPath, true)
+	}
+	if specPath == "" || isRemoteSpec(specPath) {
+		return nil, nil
+	}
+	return loadNotes(sidecarNotesPath(specPath), false)
+}
+
+// forEndpoint collects the notes matching an endpoint, most specific key first
+func (n specNotes) forEndpoint(ep endpoint) []string {
+	if n == nil {
+		return nil
+	}
+
+	var notes []string
+	for _, key := range endpointKeys(ep) {
+		notes = append(notes, n[key]...)
+	}
+	return notes
+}
+
+// attachNotes copies the matching notes onto each endpoint so details can render them
+func attachNotes(eps []endpoint, notes specNotes) {
+	for i := range eps {
+		eps[i].notes = notes.forEndpoint(eps[i])
+	}
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/pb33f/libopenapi/datamodel/high/base"
 	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"go.yaml.in/yaml/v4"
 )
 
 // sortResponseCodes sorts HTTP response codes with stable ordering:
@@ -48,29 +53,15 @@ func extractEndpoints(doc *v3.Document) []endpoint {
 		path := pair.Key()
 		pathItem := pair.Value()
 
-		if pathItem.Get != nil {
-			endpoints = append(endpoints, endpoint{path: path, method: "GET", op: pathItem.Get, folded: true})
-		}
-		if pathItem.Post != nil {
-			endpoints = append(endpoints, endpoint{path: path, method: "POST", op: pathItem.Post, folded: true})
-		}
-		if pathItem.Put != nil {
-			endpoints = append(endpoints, endpoint{path: path, method: "PUT", op: pathItem.Put, folded: true})
-		}
-		if pathItem.Delete != nil {
-			endpoints = append(endpoints, endpoint{path: path, method: "DELETE", op: pathItem.Delete, folded: true})
-		}
-		if pathItem.Patch != nil {
-			endpoints = append(endpoints, endpoint{path: path, method: "PATCH", op: pathItem.Patch, folded: true})
-		}
-		if pathItem.Head != nil {
-			endpoints = append(endpoints, endpoint{path: path, method: "HEAD", op: pathItem.Head, folded: true})
-		}
-		if pathItem.Options != nil {
-			endpoints = append(endpoints, endpoint{path: path, method: "OPTIONS", op: pathItem.Options, folded: true})
-		}
-		if pathItem.Trace != nil {
-			endpoints = append(endpoints, endpoint{path: path, method: "TRACE", op: pathItem.Trace, folded: true})
+		for _, mo := range pathItemOperations(pathItem) {
+			endpoints = append(endpoints, endpoint{
+				path:    path,
+				method:  mo.method,
+				op:      mo.op,
+				folded:  true,
+				pointer: "#/paths/" + escapePointer(path) + "/" + mo.key,
+				shared:  pathItem.Parameters,
+			})
 		}
 	}
 
@@ -92,31 +83,12 @@ func extractWebhooks(doc *v3.Document) []webhook {
 		for pair := doc.Webhooks.First(); pair != nil; pair = pair.Next() {
 			name := pair.Key()
 			hook := pair.Value()
-			if hook != nil {
-				if hook.Get != nil {
-					webhooks = append(webhooks, webhook{name: name, method: "GET", op: hook.Get, folded: true})
-				}
-				if hook.Post != nil {
-					webhooks = append(webhooks, webhook{name: name, method: "POST", op: hook.Post, folded: true})
-				}
-				if hook.Put != nil {
-					webhooks = append(webhooks, webhook{name: name, method: "PUT", op: hook.Put, folded: true})
-				}
-				if hook.Delete != nil {
-					webhooks = append(webhooks, webhook{name: name, method: "DELETE", op: hook.Delete, folded: true})
-				}
-				if hook.Patch != nil {
-					webhooks = append(webhooks, webhook{name: name, method: "PATCH", op: hook.Patch, folded: true})
-				}
-				if hook.Head != nil {
-					webhooks = append(webhooks, webhook{name: name, method: "HEAD", op: hook.Head, folded: true})
-				}
-				if hook.Options != nil {
-					webhooks = append(webhooks, webhook{name: name, method: "OPTIONS", op: hook.Options, folded: true})
-				}
-				if hook.Trace != nil {
-					webhooks = append(webhooks, webhook{name: name, method: "TRACE", op: hook.Trace, folded: true})
+			for _, mo := range pathItemOperations(hook) {
+				wh := webhook{name: name, method: mo.method, op: mo.op, folded: true, pointer: "#/webhooks/" + escapePointer(name) + "/" + mo.key}
+				if sig, ok := findWebhookSignature(mo.op, doc); ok {
+					wh.signature = &sig
 				}
+				webhooks = append(webhooks, wh)
 			}
 		}
 	}
@@ -132,15 +104,30 @@ func extractWebhooks(doc *v3.Document) []webhook {
 	return webhooks
 }
 
+// countComponents is the number of components declared in the spec, without building their
+// details as extractComponents does
+func countComponents(doc *v3.Document) int {
+	if doc == nil || doc.Components == nil {
+		return 0
+	}
+	c := doc.Components
+	return orderedmap.Len(c.Schemas) + orderedmap.Len(c.RequestBodies) + orderedmap.Len(c.Responses) +
+		orderedmap.Len(c.Parameters) + orderedmap.Len(c.Headers) + orderedmap.Len(c.SecuritySchemes)
+}
+
 func extractComponents(doc *v3.Document) []component {
 	var components []component
 
 	if doc.Components != nil {
 		if doc.Components.Schemas != nil {
+			cycles := schemaCycles(doc)
 			for pair := doc.Components.Schemas.First(); pair != nil; pair = pair.Next() {
 				name := pair.Key()
 				schema := pair.Value()
 				details := formatSchemaDetails(schema)
+				if cycle, ok := cycles[name]; ok {
+					details = fmt.Sprintf("Recursive: %s\n", cycle) + details
+				}
 				description := ""
 				if schema != nil && schema.Schema() != nil && schema.Schema().Description != "" {
 					description = schema.Schema().Description
@@ -151,6 +138,8 @@ func extractComponents(doc *v3.Document) []component {
 					description: description,
 					details:     details,
 					folded:      true,
+					cycle:       cycles[name],
+					deprecated:  schema != nil && schema.Schema() != nil && schema.Schema().Deprecated != nil && *schema.Schema().Deprecated,
 				})
 			}
 		}
@@ -205,6 +194,7 @@ func extractComponents(doc *v3.Document) []component {
 					description: description,
 					details:     details,
 					folded:      true,
+					deprecated:  param != nil && param.Deprecated,
 				})
 			}
 		}
@@ -223,6 +213,7 @@ func extractComponents(doc *v3.Document) []component {
 					description: description,
 					details:     details,
 					folded:      true,
+					deprecated:  header != nil && header.Deprecated,
 				})
 			}
 		}
@@ -241,11 +232,23 @@ func extractComponents(doc *v3.Document) []component {
 					description: description,
 					details:     details,
 					folded:      true,
+					deprecated:  secScheme != nil && secScheme.Deprecated,
 				})
 			}
 		}
 	}
 
+	for i := range components {
+		if !strings.HasSuffix(components[i].details, "\n") && components[i].details != "" {
+			components[i].details += "\n"
+		}
+		components[i].details += fmt.Sprintf("Pointer: %s\n", componentPointer(components[i]))
+	}
+
+	if resolveRefs {
+		components = append(components, externalSchemaComponents(doc)...)
+	}
+
 	// Sort components for stable ordering: first by type, then by name
 	sort.Slice(components, func(i, j int) bool {
 		if components[i].compType != components[j].compType {
@@ -268,16 +271,49 @@ func formatEndpointDetails(ep endpoint) string {
 		details.WriteString(fmt.Sprintf("Description: %s\n", ep.op.Description))
 	}
 
-	if len(ep.op.Parameters) > 0 {
-		details.WriteString("Parameters:\n")
-		for _, param := range ep.op.Parameters {
-			if param != nil {
-				details.WriteString(fmt.Sprintf("  - %s (%s): %s\n",
-					param.Name, param.In, param.Description))
-			}
+	if origin, ok := findCodeOrigin(ep.op); ok {
+		details.WriteString(fmt.Sprintf("Handler: %s\n", origin))
+	}
+
+	if changes := findChangelog(ep.op); !changes.empty() {
+		details.WriteString(fmt.Sprintf("Changelog: %s\n", changes))
+	}
+	for _, header := range findLifecycleHeaders(ep.op) {
+		details.WriteString(fmt.Sprintf("Lifecycle header: %s\n", header))
+	}
+
+	if budget := findLatencyBudget(ep.op); !budget.empty() {
+		details.WriteString(fmt.Sprintf("Latency budget: %s\n", budget))
+	}
+
+	// The examples come from the resolved schemas, so no document is needed
+	if size := estimatePayloadSize(nil, ep.op); size.largest() > 0 {
+		details.WriteString(fmt.Sprintf("Payload size: %s\n", size))
+	}
+
+	retries := findRetryHints(ep.op)
+	if retries.String() != "" {
+		details.WriteString(fmt.Sprintf("Retries: %s\n", retries))
+	}
+	if retries.idempotencyKey != "" {
+		details.WriteString(fmt.Sprintf("Idempotency: send a unique %s header to retry safely\n", retries.idempotencyKey))
+	}
+
+	if len(ep.notes) > 0 {
+		details.WriteString("Annotations:\n")
+		for _, note := range ep.notes {
+			details.WriteString(fmt.Sprintf("  - %s\n", strings.ReplaceAll(strings.TrimSpace(note), "\n", "\n    ")))
 		}
 	}
 
+	if params := mergeParameters(ep.op.Parameters, ep.shared); len(params) > 0 {
+		details.WriteString("Parameters:\n")
+		details.WriteString(formatParameterTable(params, ep.shared))
+	}
+
+	// Inline body schemas are shown under names such as CreateUserRequestBody
+	name := operationName(ep.method, ep.path, ep.op.OperationId)
+
 	if ep.op.RequestBody != nil {
 		details.WriteString("Request Body:\n")
 
@@ -291,10 +327,20 @@ func formatEndpointDetails(ep endpoint) string {
 		sort.Strings(mediaTypes)
 
 		for _, mediaType := range mediaTypes {
-			details.WriteString(fmt.Sprintf("  - %s\n", mediaType))
+			line := "  - " + mediaType
+			if media := ep.op.RequestBody.Content.GetOrZero(mediaType); media != nil && media.Schema != nil {
+				line += " → " + schemaDisplayName(media.Schema, name+"RequestBody")
+			}
+			details.WriteString(line + "\n")
 		}
 	}
 
+	if examples := countExamples(ep.op); examples.missing() {
+		details.WriteString("Examples: none (missing)\n")
+	} else {
+		details.WriteString(fmt.Sprintf("Examples: %s\n", examples))
+	}
+
 	if ep.op.Responses != nil {
 		details.WriteString("Responses:\n")
 
@@ -312,15 +358,125 @@ func formatEndpointDetails(ep endpoint) string {
 		for _, code := range codes {
 			if resp, ok := ep.op.Responses.Codes.Get(code); ok && resp != nil {
 				if resp.Description != "" {
-					details.WriteString(fmt.Sprintf("  - %s: %s\n", code, resp.Description))
+					line := fmt.Sprintf("  - %s: %s", code, resp.Description)
+					if schema := mediaSchema(resp.Content); schema != nil {
+						line += " → " + schemaDisplayName(schema, name+"Response"+pascalCase(code))
+					}
+					details.WriteString(line + "\n")
 				}
 			}
 		}
 	}
 
+	// Only requirements of the operation itself, the ones of the spec apply otherwise
+	if ep.op.Security != nil {
+		writeSecurityDetails(&details, ep.op.Security)
+	}
+
+	if ep.op.Callbacks != nil && ep.op.Callbacks.Len() > 0 {
+		details.WriteString("Callbacks:\n")
+		for pair := ep.op.Callbacks.First(); pair != nil; pair = pair.Next() {
+			if pair.Value() == nil || pair.Value().Expression == nil {
+				continue
+			}
+			for expr := pair.Value().Expression.First(); expr != nil; expr = expr.Next() {
+				for _, op := range pathItemOperations(expr.Value()) {
+					details.WriteString(fmt.Sprintf("  - %s: %s %s\n", pair.Key(), op.method, expr.Key()))
+				}
+			}
+		}
+	}
+
+	if ep.pointer != "" {
+		details.WriteString(fmt.Sprintf("Pointer: %s\n", ep.pointer))
+	}
+
 	return details.String()
 }
 
+// writeSecurityDetails lists security requirements, which are alternatives, with the
+// schemes of each one joined by +
+func writeSecurityDetails(details *strings.Builder, requirements []*base.SecurityRequirement) {
+	if len(requirements) == 0 {
+		details.WriteString("Security: none\n")
+		return
+	}
+	details.WriteString("Security:\n")
+	for _, requirement := range requirements {
+		if requirement == nil || requirement.Requirements == nil || requirement.Requirements.Len() == 0 {
+			details.WriteString("  - anonymous\n")
+			continue
+		}
+		var schemes []string
+		for pair := requirement.Requirements.First(); pair != nil; pair = pair.Next() {
+			scheme := pair.Key()
+			if len(pair.Value()) > 0 {
+				scheme += " (" + strings.Join(pair.Value(), ", ") + ")"
+			}
+			schemes = append(schemes, scheme)
+		}
+		details.WriteString(fmt.Sprintf("  - %s\n", strings.Join(schemes, " + ")))
+	}
+}
+
+// parameterLocations orders the rows of the parameter table
+var parameterLocations = []string{"path", "query", "header", "cookie"}
+
+// formatParameterTable lists parameters as aligned columns of name, location, type, whether
+// they are required, default and description. Rows start with "  - " like the other lists
+// of the details, parameters inherited from the path item are marked as such
+func formatParameterTable(params, shared []*v3.Parameter) string {
+	params = slices.Clone(params)
+	sort.SliceStable(params, func(i, j int) bool {
+		return slices.Index(parameterLocations, params[i].In) < slices.Index(parameterLocations, params[j].In)
+	})
+
+	rows := [][]string{{"name", "in", "type", "required", "default", "description"}}
+	for _, p := range params {
+		typ, def := "any", ""
+		if p.Schema != nil {
+			typ = schemaType(p.Schema)
+			if s := p.Schema.Schema(); s != nil {
+				def = schemaValueJSON(s.Default)
+			}
+		} else if p.Content != nil && p.Content.Len() > 0 {
+			typ = p.Content.First().Key()
+		}
+		required := ""
+		if p.Required != nil && *p.Required {
+			required = "yes"
+		}
+		description, _, _ := strings.Cut(strings.TrimSpace(p.Description), "\n")
+		if slices.Contains(shared, p) {
+			description = strings.TrimSpace(description + " (path-level)")
+		}
+		rows = append(rows, []string{p.Name, p.In, typ, required, def, description})
+	}
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+	}
+	var table strings.Builder
+	for i, row := range rows {
+		prefix := "  - "
+		if i == 0 {
+			prefix = "    "
+		}
+		var line strings.Builder
+		for j, cell := range row {
+			line.WriteString(cell)
+			if j < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[j]-lipgloss.Width(cell)+2))
+			}
+		}
+		table.WriteString(prefix + strings.TrimRight(line.String(), " ") + "\n")
+	}
+	return table.String()
+}
+
 func formatSchemaDetails(schema *base.SchemaProxy) string {
 	var details strings.Builder
 
@@ -343,6 +499,24 @@ func formatSchemaDetails(schema *base.SchemaProxy) string {
 		details.WriteString(fmt.Sprintf("Format: %s\n", s.Format))
 	}
 
+	if bounds := schemaRange(s); bounds != "" {
+		details.WriteString(fmt.Sprintf("Range: %s\n", bounds))
+	}
+
+	if value := schemaValueJSON(s.Const); value != "" {
+		details.WriteString(fmt.Sprintf("Const: %s\n", value))
+	}
+
+	if len(s.Examples) > 0 {
+		var examples []string
+		for _, example := range s.Examples {
+			if value := schemaValueJSON(example); value != "" {
+				examples = append(examples, value)
+			}
+		}
+		details.WriteString(fmt.Sprintf("Examples: %s\n", strings.Join(examples, ", ")))
+	}
+
 	if len(s.Required) > 0 {
 		details.WriteString(fmt.Sprintf("Required: %v\n", s.Required))
 	}
@@ -381,9 +555,60 @@ func formatSchemaDetails(schema *base.SchemaProxy) string {
 		}
 	}
 
+	// OpenAPI 3.1 tuples type their leading items one by one
+	if len(s.PrefixItems) > 0 {
+		var types []string
+		for _, proxy := range s.PrefixItems {
+			types = append(types, schemaType(proxy))
+		}
+		details.WriteString(fmt.Sprintf("Prefix Items: %s\n", strings.Join(types, ", ")))
+	}
+
+	if s.If != nil {
+		details.WriteString(fmt.Sprintf("If: %s\n", conditionSummary(s.If)))
+		if s.Then != nil {
+			details.WriteString(fmt.Sprintf("Then: %s\n", conditionSummary(s.Then)))
+		}
+		if s.Else != nil {
+			details.WriteString(fmt.Sprintf("Else: %s\n", conditionSummary(s.Else)))
+		}
+	}
+
 	return details.String()
 }
 
+// schemaValueJSON renders a const or example value as compact JSON
+func schemaValueJSON(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+	var value bytes.Buffer
+	if err := writeJSONNode(&value, node); err != nil {
+		return ""
+	}
+	return value.String()
+}
+
+// conditionSummary describes an if, then or else schema by the properties it constrains,
+// e.g. "kind = \"card\", number"
+func conditionSummary(proxy *base.SchemaProxy) string {
+	s := proxy.Schema()
+	if s == nil || s.Properties == nil || s.Properties.Len() == 0 {
+		return schemaType(proxy)
+	}
+	var parts []string
+	for pair := s.Properties.First(); pair != nil; pair = pair.Next() {
+		part := pair.Key()
+		if prop := pair.Value().Schema(); prop != nil {
+			if value := schemaValueJSON(prop.Const); value != "" {
+				part += " = " + value
+			}
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
 func formatRequestBodyDetails(reqBody *v3.RequestBody) string {
 	var details strings.Builder
 
@@ -498,10 +723,13 @@ func formatParameterDetails(param *v3.Parameter) string {
 		if param.Schema.Schema().Format != "" {
 			details.WriteString(fmt.Sprintf("Format: %s\n", param.Schema.Schema().Format))
 		}
+		if bounds := schemaRange(param.Schema.Schema()); bounds != "" {
+			details.WriteString(fmt.Sprintf("Range: %s\n", bounds))
+		}
 	}
 
-	if param.Example != nil {
-		details.WriteString(fmt.Sprintf("Example: %v\n", param.Example))
+	if value := schemaValueJSON(param.Example); value != "" {
+		details.WriteString(fmt.Sprintf("Example: %s\n", value))
 	}
 
 	return details.String()
@@ -576,5 +804,17 @@ func formatWebhookDetails(hook webhook) string {
 		details.WriteString(fmt.Sprintf("Operation ID: %s\n", hook.op.OperationId))
 	}
 
+	if origin, ok := findCodeOrigin(hook.op); ok {
+		details.WriteString(fmt.Sprintf("Handler: %s\n", origin))
+	}
+
+	if hook.signature != nil {
+		details.WriteString(fmt.Sprintf("Signature: %s, press r for a verification snippet\n", hook.signature))
+	}
+
+	if hook.pointer != "" {
+		details.WriteString(fmt.Sprintf("Pointer: %s\n", hook.pointer))
+	}
+
 	return details.String()
 }
@@ -0,0 +1,513 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// oauthGrant is an OAuth2 flow the runner can get a token with by itself: client credentials,
+// or the device flow of OpenAPI 3.2 where the user approves the request in a browser
+type oauthGrant struct {
+	scheme    string
+	flow      string
+	tokenURL  string
+	deviceURL string
+	scopes    []string
+}
+
+// oauthToken is a granted access token, cached in the credential store as <scheme>.token
+type oauthToken struct {
+	AccessToken string    `json:"access_token"`
+	Expires     time.Time `json:"expires"`
+}
+
+// credentialValue returns the credential stored for a security scheme, or else the token oq
+// was granted for it, as long as it hasn't expired
+func credentialValue(store credentialStore, scheme string) (string, error) {
+	if value, err := store.get(scheme); err == nil {
+		return value, nil
+	}
+	cached, err := store.get(scheme + ".token")
+	if err != nil {
+		return "", err
+	}
+	var token oauthToken
+	if err := json.Unmarshal([]byte(cached), &token); err != nil || token.AccessToken == "" || time.Now().After(token.Expires) {
+		return "", errCredentialNotFound
+	}
+	return token.AccessToken, nil
+}
+
+// pendingOAuthGrant returns the flow to run before sending a request for op: the first OAuth2
+// scheme without a credential in a requirement, unless another requirement is already met
+func pendingOAuthGrant(doc *v3.Document, op *v3.Operation, store credentialStore) (oauthGrant, bool) {
+	if doc.Components == nil || doc.Components.SecuritySchemes == nil {
+		return oauthGrant{}, false
+	}
+	var pending *oauthGrant
+	for _, requirement := range effectiveSecurity(doc, op) {
+		if requirement == nil || requirement.Requirements == nil || requirement.Requirements.Len() == 0 {
+			return oauthGrant{}, false
+		}
+		met := true
+		for pair := requirement.Requirements.First(); pair != nil; pair = pair.Next() {
+			if _, err := credentialValue(store, pair.Key()); err == nil {
+				continue
+			}
+			met = false
+			if pending != nil {
+				continue
+			}
+			if grant, ok := oauthGrantFor(pair.Key(), doc.Components.SecuritySchemes.GetOrZero(pair.Key()), pair.Value()); ok {
+				pending = &grant
+			}
+		}
+		if met {
+			return oauthGrant{}, false
+		}
+	}
+	if pending == nil {
+		return oauthGrant{}, false
+	}
+	return *pending, true
+}
+
+// oauthGrantFor picks the flow of an OAuth2 scheme oq can run, client credentials first
+func oauthGrantFor(name string, scheme *v3.SecurityScheme, scopes []string) (oauthGrant, bool) {
+	if scheme == nil || !strings.EqualFold(scheme.Type, "oauth2") || scheme.Flows == nil {
+		return oauthGrant{}, false
+	}
+	if flow := scheme.Flows.ClientCredentials; flow != nil && flow.TokenUrl != "" {
+		return oauthGrant{scheme: name, flow: "client credentials", tokenURL: flow.TokenUrl, scopes: scopes}, true
+	}
+	if tokenURL, deviceURL := deviceFlow(scheme.Flows); tokenURL != "" && deviceURL != "" {
+		return oauthGrant{scheme: name, flow: "device code", tokenURL: tokenURL, deviceURL: deviceURL, scopes: scopes}, true
+	}
+	return oauthGrant{}, false
+}
+
+// deviceFlow returns the token and device authorization URLs of the device flow, declared as
+// deviceAuthorization in OpenAPI 3.2. The parsed model only knows it as device, without its
+// deviceAuthorizationUrl, so both are read from the YAML
+func deviceFlow(flows *v3.OAuthFlows) (string, string) {
+	low := flows.GoLow()
+	if low == nil {
+		return "", ""
+	}
+	for _, name := range []string{"deviceAuthorization", "device"} {
+		flow := mappingValue(low.RootNode, name)
+		if tokenURL := mappingValue(flow, "tokenUrl"); tokenURL != nil {
+			if deviceURL := mappingValue(flow, "deviceAuthorizationUrl"); deviceURL != nil {
+				return tokenURL.Value, deviceURL.Value
+			}
+		}
+	}
+	return "", ""
+}
+
+// oauthClientEnv is the prefix of the environment variables holding the client of a scheme,
+// such as OQ_PETSTORE_AUTH for petstore_auth
+func oauthClientEnv(scheme string) string {
+	return "OQ_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, scheme)
+}
+
+// oauthClient returns the client id and secret of a scheme from the environment, or else
+// from the credential store where they are kept once a token was granted with them
+func oauthClient(store credentialStore, scheme string) (string, string) {
+	prefix := oauthClientEnv(scheme)
+	if id := os.Getenv(prefix + "_CLIENT_ID"); id != "" {
+		return id, os.Getenv(prefix + "_CLIENT_SECRET")
+	}
+	id, err := store.get(scheme + ".client_id")
+	if err != nil {
+		return "", ""
+	}
+	secret, _ := store.get(scheme + ".client_secret")
+	return id, secret
+}
+
+// oauthPrompt asks for the client of a scheme in the runner, before a token is requested
+type oauthPrompt struct {
+	grant  oauthGrant
+	inputs []textinput.Model
+	focus  int
+}
+
+func newOAuthPrompt(grant oauthGrant) *oauthPrompt {
+	prompt := &oauthPrompt{grant: grant}
+	for _, placeholder := range []string{"client id", "client secret"} {
+		input := textinput.New()
+		input.Prompt = ""
+		input.Placeholder = placeholder
+		prompt.inputs = append(prompt.inputs, input)
+	}
+	prompt.inputs[1].EchoMode = textinput.EchoPassword
+	if grant.deviceURL != "" {
+		prompt.inputs[1].Placeholder = "client secret, if any"
+	}
+	return prompt
+}
+
+func (p *oauthPrompt) focusInput(i int) tea.Cmd {
+	p.focus = (i + len(p.inputs)) % len(p.inputs)
+	for j := range p.inputs {
+		p.inputs[j].Blur()
+	}
+	return p.inputs[p.focus].Focus()
+}
+
+// oauthClientMsg carries the client a token was requested with, stored once it is granted
+type oauthClientMsg struct {
+	store          credentialStore
+	grant          oauthGrant
+	id, secret     string
+	fromPrompt     bool
+	token          oauthToken
+	device         *deviceAuthorization
+	err            error
+	ctx            context.Context
+	cancel         context.CancelFunc
+	awaitingDevice bool
+}
+
+// startOAuth gets a token for grant before the request is sent, asking for the client when
+// neither the environment nor the store has one
+func (m *Model) startOAuth(store credentialStore, grant oauthGrant) tea.Cmd {
+	id, secret := oauthClient(store, grant.scheme)
+	if id == "" {
+		m.runner.oauth = newOAuthPrompt(grant)
+		return m.runner.oauth.focusInput(0)
+	}
+	return m.requestOAuthToken(oauthClientMsg{store: store, grant: grant, id: id, secret: secret})
+}
+
+// requestOAuthToken requests the token in the background, starting with the device
+// authorization for the device flow
+func (m *Model) requestOAuthToken(client oauthClientMsg) tea.Cmd {
+	runner := m.runner
+	runner.oauth = nil
+	client.ctx, client.cancel = context.WithCancel(context.Background())
+	runner.sending, runner.cancel = true, client.cancel
+	runner.loading = newLoadingIndicator("Requesting a token for " + client.grant.scheme + "...")
+	runner.result, runner.err = nil, nil
+	return tea.Batch(runner.loading.tick(), func() tea.Msg {
+		if client.grant.deviceURL != "" {
+			client.device, client.err = authorizeDevice(client.ctx, client.grant, client.id, client.secret)
+			client.awaitingDevice = client.err == nil
+			return client
+		}
+		client.token, client.err = clientCredentialsToken(client.ctx, client.grant, client.id, client.secret)
+		return client
+	})
+}
+
+// handleOAuthClient waits on the user for the device flow, then stores the token and sends
+// the request it was requested for
+func (m *Model) handleOAuthClient(msg oauthClientMsg) tea.Cmd {
+	if m.runner == nil || !m.runner.sending || msg.ctx.Err() != nil {
+		return nil
+	}
+	runner := m.runner
+	if msg.err != nil {
+		msg.cancel()
+		runner.sending = false
+		runner.err = fmt.Errorf("Error getting a token for %s: %w", msg.grant.scheme, msg.err)
+		return nil
+	}
+	if msg.awaitingDevice {
+		msg.awaitingDevice = false
+		runner.loading = newLoadingIndicator(fmt.Sprintf("Open %s and enter %s, waiting for approval...", msg.device.verificationURL(), msg.device.UserCode))
+		return tea.Batch(runner.loading.tick(), func() tea.Msg {
+			msg.token, msg.err = pollDeviceToken(msg.ctx, msg.grant, msg.device, msg.id, msg.secret)
+			return msg
+		})
+	}
+
+	msg.cancel()
+	cached, _ := json.Marshal(msg.token)
+	if err := msg.store.set(msg.grant.scheme+".token", string(cached)); err != nil {
+		debugLog.Warn("caching the OAuth2 token failed", "error", err)
+	}
+	if msg.fromPrompt {
+		if err := msg.store.set(msg.grant.scheme+".client_id", msg.id); err != nil {
+			debugLog.Warn("storing the OAuth2 client failed", "error", err)
+		}
+		if msg.secret != "" {
+			if err := msg.store.set(msg.grant.scheme+".client_secret", msg.secret); err != nil {
+				debugLog.Warn("storing the OAuth2 client failed", "error", err)
+			}
+		}
+	}
+	runner.sending = false
+	return m.dispatchRequest()
+}
+
+// updateOAuthPrompt edits the client fields, Enter on the last one requests the token
+func (m *Model) updateOAuthPrompt(msg tea.KeyMsg) tea.Cmd {
+	prompt := m.runner.oauth
+	switch msg.String() {
+	case "esc":
+		m.runner.oauth = nil
+		return m.runner.focusField(m.runner.focus)
+	case "tab", "down":
+		return prompt.focusInput(prompt.focus + 1)
+	case "shift+tab", "up":
+		return prompt.focusInput(prompt.focus - 1)
+	case "enter", "ctrl+s":
+		if prompt.focus == 0 && msg.String() == "enter" {
+			return prompt.focusInput(1)
+		}
+		id := strings.TrimSpace(prompt.inputs[0].Value())
+		if id == "" {
+			return prompt.focusInput(0)
+		}
+		store, err := m.openCredentials()
+		if err != nil {
+			m.runner.err = fmt.Errorf("Error opening credential store: %w", err)
+			m.runner.oauth = nil
+			return nil
+		}
+		return m.requestOAuthToken(oauthClientMsg{store: store, grant: prompt.grant, id: id, secret: prompt.inputs[1].Value(), fromPrompt: true})
+	}
+	var cmd tea.Cmd
+	prompt.inputs[prompt.focus], cmd = prompt.inputs[prompt.focus].Update(msg)
+	return cmd
+}
+
+// renderOAuthPrompt renders the client fields in place of the request form
+func (m Model) renderOAuthPrompt(innerWidth int) string {
+	prompt := m.runner.oauth
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorBlue))
+	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray))
+
+	lines := []string{
+		fmt.Sprintf("%s needs an OAuth2 token, requested with the %s flow from", prompt.grant.scheme, prompt.grant.flow),
+		grayStyle.Render(prompt.grant.tokenURL),
+		"",
+	}
+	for i, label := range []string{"Client ID", "Client secret"} {
+		marker := "  "
+		if i == prompt.focus {
+			marker = "> "
+		}
+		prompt.inputs[i].Width = max(10, innerWidth-18)
+		lines = append(lines, marker+labelStyle.Width(14).Render(label)+" "+prompt.inputs[i].View())
+	}
+	env := oauthClientEnv(prompt.grant.scheme)
+	lines = append(lines, "", grayStyle.Render(fmt.Sprintf("Kept in the credential store once a token is granted, or set %s_CLIENT_ID and %s_CLIENT_SECRET", env, env)))
+	return lipgloss.NewStyle().Width(innerWidth).Render(strings.Join(lines, "\n"))
+}
+
+// clientCredentialsToken requests a token for the client itself
+func clientCredentialsToken(ctx context.Context, grant oauthGrant, id, secret string) (oauthToken, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(grant.scopes) > 0 {
+		form.Set("scope", strings.Join(grant.scopes, " "))
+	}
+	token, _, err := postTokenForm(ctx, grant.tokenURL, form, id, secret)
+	return token, err
+}
+
+// deviceAuthorization is the answer to a device authorization request, which the user
+// approves at the verification URI with the user code
+type deviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+	// wait is the time between polls, the interval or 5 seconds
+	wait time.Duration
+}
+
+func (d *deviceAuthorization) verificationURL() string {
+	if d.VerificationURIComplete != "" {
+		return d.VerificationURIComplete
+	}
+	return d.VerificationURI
+}
+
+// authorizeDevice starts the device flow, returning the code to show the user
+func authorizeDevice(ctx context.Context, grant oauthGrant, id, secret string) (*deviceAuthorization, error) {
+	form := url.Values{}
+	if len(grant.scopes) > 0 {
+		form.Set("scope", strings.Join(grant.scopes, " "))
+	}
+	body, err := postForm(ctx, grant.deviceURL, form, id, secret)
+	if err != nil {
+		return nil, err
+	}
+	var device deviceAuthorization
+	if err := json.Unmarshal(body, &device); err != nil {
+		return nil, err
+	}
+	if device.DeviceCode == "" || device.UserCode == "" || device.verificationURL() == "" {
+		return nil, errors.New("incomplete device authorization response")
+	}
+	device.wait = 5 * time.Second
+	if device.Interval > 0 {
+		device.wait = time.Duration(device.Interval) * time.Second
+	}
+	return &device, nil
+}
+
+// pollDeviceToken polls the token URL until the user approved or denied the request, or the
+// device code expired
+func pollDeviceToken(ctx context.Context, grant oauthGrant, device *deviceAuthorization, id, secret string) (oauthToken, error) {
+	expiresIn := time.Duration(device.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 10 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, expiresIn)
+	defer cancel()
+	form := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {device.DeviceCode},
+	}
+	wait := device.wait
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return oauthToken{}, errors.New("the device code expired before it was approved")
+			}
+			return oauthToken{}, ctx.Err()
+		case <-time.After(wait):
+		}
+		token, code, err := postTokenForm(ctx, grant.tokenURL, form, id, secret)
+		switch code {
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			wait += 5 * time.Second
+			continue
+		}
+		return token, err
+	}
+}
+
+// postTokenForm sends a token request, authenticating the client with HTTP Basic when it has
+// a secret. It returns the OAuth2 error code of a rejected request, such as
+// authorization_pending
+func postTokenForm(ctx context.Context, tokenURL string, form url.Values, id, secret string) (oauthToken, string, error) {
+	body, err := postForm(ctx, tokenURL, form, id, secret)
+	var rejected *oauthError
+	if errors.As(err, &rejected) {
+		return oauthToken{}, rejected.Code, err
+	}
+	if err != nil {
+		return oauthToken{}, "", err
+	}
+	var granted struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &granted); err != nil {
+		return oauthToken{}, "", err
+	}
+	if granted.AccessToken == "" {
+		return oauthToken{}, "", errors.New("no access_token in the response")
+	}
+	expiresIn := time.Duration(granted.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = time.Hour
+	}
+	// Renewed a little early, so a request doesn't go out with a token about to expire
+	if expiresIn > 2*time.Minute {
+		expiresIn -= time.Minute
+	}
+	return oauthToken{AccessToken: granted.AccessToken, Expires: time.Now().Add(expiresIn)}, "", nil
+}
+
+// oauthError is an error response of an authorization server
+type oauthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	status      string
+}
+
+func (e *oauthError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s (%s)", e.status, e.Code, e.Description)
+	}
+	return e.status + ": " + e.Code
+}
+
+// postForm posts a form to an authorization server. Clients with a secret authenticate with
+// HTTP Basic, public ones send their id in the form
+func postForm(ctx context.Context, target string, form url.Values, id, secret string) ([]byte, error) {
+	if id != "" && secret == "" {
+		form.Set("client_id", id)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if secret != "" {
+		req.SetBasicAuth(url.QueryEscape(id), url.QueryEscape(secret))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		rejected := &oauthError{status: resp.Status}
+		if json.Unmarshal(body, rejected) == nil && rejected.Code != "" {
+			return nil, rejected
+		}
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// snippetAuthorization is the Authorization header snippets show for op: a granted or stored
+// token of its OAuth2 schemes, if there is one
+func snippetAuthorization(doc *v3.Document, op *v3.Operation, store credentialStore) (string, bool) {
+	if doc.Components == nil || doc.Components.SecuritySchemes == nil {
+		return "", false
+	}
+	for _, requirement := range effectiveSecurity(doc, op) {
+		if requirement == nil || requirement.Requirements == nil {
+			continue
+		}
+		for pair := requirement.Requirements.First(); pair != nil; pair = pair.Next() {
+			scheme := doc.Components.SecuritySchemes.GetOrZero(pair.Key())
+			if scheme == nil || !strings.EqualFold(scheme.Type, "oauth2") {
+				continue
+			}
+			if token, err := credentialValue(store, pair.Key()); err == nil {
+				return "Bearer " + token, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ociManifestTypes are the manifests and indexes accepted from a registry, OCI and Docker
+var ociManifestTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+// ociTitleAnnotation names the file a layer holds, as set by oras push
+const ociTitleAnnotation = "org.opencontainers.image.title"
+
+// challengeParam matches a key="value" parameter of a WWW-Authenticate challenge
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// ociReference is an image or artifact in a registry, e.g. oci://ghcr.io/acme/api-spec:1.2
+// or oci://ghcr.io/acme/api@sha256:…
+type ociReference struct {
+	registry   string
+	repository string
+	// reference is a tag or digest
+	reference string
+}
+
+// parseOCIReference parses an oci:// source the way docker names images: without a registry
+// the image is on Docker Hub, and without a tag it is latest
+func parseOCIReference(source string) (ociReference, error) {
+	artifact, _ := splitArtifactPath(source)
+	name := strings.TrimPrefix(artifact, "oci://")
+	ref := ociReference{reference: "latest"}
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.reference = name[:i], name[i+1:]
+	}
+
+	registry, repository, ok := strings.Cut(name, "/")
+	if !ok || (!strings.ContainsAny(registry, ".:") && registry != "localhost") {
+		registry, repository = "docker.io", name
+	}
+	if registry == "docker.io" {
+		registry = "registry-1.docker.io"
+		if !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+	}
+	if repository == "" || ref.reference == "" {
+		return ociReference{}, fmt.Errorf("Error reading %s: expected oci://registry/repository:tag", source)
+	}
+	ref.registry, ref.repository = registry, repository
+	return ref, nil
+}
+
+// url is the address of a manifest or blob. Registries on the local machine are spoken to
+// over plain HTTP, as registries started for testing don't have certificates
+func (r ociReference) url(kind, reference string) string {
+	scheme := "https"
+	host := r.registry
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" || net.ParseIP(host).IsLoopback() {
+		scheme = "http"
+	}
+	return scheme + "://" + r.registry + "/v2/" + r.repository + "/" + kind + "/" + reference
+}
+
+// ociDescriptor points to a manifest of an index or a layer of a manifest
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *struct {
+		OS string `json:"os"`
+	} `json:"platform"`
+}
+
+// ociManifest is an image manifest, or an index of manifests per platform
+type ociManifest struct {
+	Manifests []ociDescriptor `json:"manifests"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+// ociClient reads from a registry, getting a token the first time the registry asks for one
+type ociClient struct {
+	ref           ociReference
+	authorization string
+}
+
+// fetchOCISpec reads a spec out of an artifact pushed with oras, where each layer is a file
+// named by its title, or out of the files of a container image. The spec is found by its
+// content unless picked with #path/to/openapi.yaml
+func fetchOCISpec(ctx context.Context, source string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, remote.timeout)
+	defer cancel()
+
+	ref, err := parseOCIReference(source)
+	if err != nil {
+		return nil, err
+	}
+	_, file := splitArtifactPath(source)
+	client := &ociClient{ref: ref}
+	manifest, err := client.manifest(ctx, ref.reference, 0)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %w", source, err)
+	}
+
+	var specs []tarballFile
+	for _, layer := range manifest.Layers {
+		title := layer.Annotations[ociTitleAnnotation]
+		if !slices.Contains([]string{".yaml", ".yml", ".json"}, strings.ToLower(path.Ext(title))) || (file != "" && title != file) {
+			continue
+		}
+		blob, err := client.blob(ctx, layer)
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching %s: %w", source, err)
+		}
+		if file != "" || specFilePattern.Match(blob) {
+			specs = append(specs, tarballFile{name: title, content: blob})
+		}
+	}
+
+	// Files of container images are in tar layers, a later layer replacing the files of
+	// earlier ones
+	if len(specs) == 0 {
+		for _, layer := range manifest.Layers {
+			if !strings.Contains(layer.MediaType, "tar") || layer.Annotations[ociTitleAnnotation] != "" && !isTarballSpec(layer.Annotations[ociTitleAnnotation]) {
+				continue
+			}
+			blob, err := client.blob(ctx, layer)
+			if err != nil {
+				return nil, fmt.Errorf("Error fetching %s: %w", source, err)
+			}
+			files, err := tarballSpecs(blob, file)
+			if err != nil {
+				return nil, fmt.Errorf("Error reading %s: layer %s: %w", source, layer.Digest, err)
+			}
+			for _, f := range files {
+				specs = slices.DeleteFunc(specs, func(spec tarballFile) bool { return spec.name == f.name })
+				specs = append(specs, f)
+			}
+		}
+	}
+	return pickSpec(specs, file, source)
+}
+
+// manifest fetches the manifest of a tag or digest. For an index, the manifest of the first
+// platform is used, as specs don't differ between platforms
+func (c *ociClient) manifest(ctx context.Context, reference string, depth int) (ociManifest, error) {
+	body, err := c.get(ctx, c.ref.url("manifests", reference), strings.Join(ociManifestTypes, ", "))
+	if err != nil {
+		return ociManifest{}, err
+	}
+	var manifest ociManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return ociManifest{}, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if len(manifest.Manifests) == 0 || depth > 0 {
+		return manifest, nil
+	}
+	for _, m := range manifest.Manifests {
+		// Attestations are listed for the unknown platform
+		if m.Platform == nil || m.Platform.OS != "unknown" {
+			return c.manifest(ctx, m.Digest, depth+1)
+		}
+	}
+	return ociManifest{}, errors.New("the index lists no image")
+}
+
+// blob fetches a layer, checking it against its digest
+func (c *ociClient) blob(ctx context.Context, layer ociDescriptor) ([]byte, error) {
+	body, err := c.get(ctx, c.ref.url("blobs", layer.Digest), "")
+	if err != nil {
+		return nil, err
+	}
+	if hexDigest, ok := strings.CutPrefix(layer.Digest, "sha256:"); ok {
+		if sum := sha256.Sum256(body); hex.EncodeToString(sum[:]) != hexDigest {
+			return nil, fmt.Errorf("layer %s doesn't match its digest", layer.Digest)
+		}
+	}
+	return body, nil
+}
+
+func (c *ociClient) get(ctx context.Context, target, accept string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if c.authorization != "" {
+			req.Header.Set("Authorization", c.authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			resp.Body.Close()
+			if err := c.authenticate(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("%s: %s", target, resp.Status)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSpecSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > maxRemoteSpecSize {
+			return nil, fmt.Errorf("%s is larger than %s", target, formatBytes(maxRemoteSpecSize))
+		}
+		return body, nil
+	}
+}
+
+// authenticate answers the challenge of a registry, with the credentials docker login saved
+// for it or anonymously. Bearer challenges are exchanged for a pull token
+func (c *ociClient) authenticate(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	username, password := registryCredentials(c.ref.registry)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if username == "" {
+			return fmt.Errorf("%s needs credentials, log in with docker login", c.ref.registry)
+		}
+		c.authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("%s asks for unsupported authentication %q", c.ref.registry, scheme)
+	}
+
+	fields := map[string]string{}
+	for _, match := range challengeParam.FindAllStringSubmatch(params, -1) {
+		fields[match[1]] = match[2]
+	}
+	realm, err := url.Parse(fields["realm"])
+	if err != nil || fields["realm"] == "" {
+		return fmt.Errorf("%s sent an invalid challenge %q", c.ref.registry, challenge)
+	}
+	query := realm.Query()
+	if fields["service"] != "" {
+		query.Set("service", fields["service"])
+	}
+	scope := fields["scope"]
+	if scope == "" {
+		scope = "repository:" + c.ref.repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("getting a token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("getting a token: %s", resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("getting a token: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return errors.New("getting a token: the registry sent none")
+	}
+	c.authorization = "Bearer " + token.Token
+	return nil
+}
+
+// registryCredentials returns the credentials docker login saved for a registry in
+// ~/.docker/config.json, or $DOCKER_CONFIG/config.json. Credential helpers aren't run
+func registryCredentials(registry string) (string, string) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return "", ""
+	}
+	var config struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(content, &config); err != nil {
+		return "", ""
+	}
+
+	names := []string{registry, "https://" + registry, "http://" + registry}
+	if registry == "registry-1.docker.io" {
+		names = append(names, "https://index.docker.io/v1/", "index.docker.io", "docker.io")
+	}
+	for _, name := range names {
+		entry, ok := config.Auths[name]
+		if !ok {
+			continue
+		}
+		if entry.Auth != "" {
+			if decoded, err := base64.StdEncoding.DecodeString(entry.Auth); err == nil {
+				username, password, _ := strings.Cut(string(decoded), ":")
+				return username, password
+			}
+		}
+		if entry.Username != "" {
+			return entry.Username, entry.Password
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.yaml.in/yaml/v4"
+)
+
+// operationIDPattern is the naming convention operationIds are held to: camelCase, as in
+// getUserById
+var operationIDPattern = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+
+// versionSegment matches path prefixes such as /v1, left out of proposed names
+var versionSegment = regexp.MustCompile(`^v[0-9]+$`)
+
+// operationIDRename is a proposed operationId for an operation missing one or not following
+// the convention
+type operationIDRename struct {
+	ep       endpoint
+	from, to string
+	accepted bool
+}
+
+// operationIDReview lists the proposed renames to accept or skip before they are written
+type operationIDReview struct {
+	renames []operationIDRename
+	cursor  int
+}
+
+// proposeOperationIDs proposes an operationId for every operation missing one or not
+// following the convention. Ids that only break the convention are converted, e.g.
+// get_user → getUser, missing ones are named after the method and path. Proposals never
+// clash with each other or with the ids that are kept, a number is added when they would
+func proposeOperationIDs(eps []endpoint) []operationIDRename {
+	taken := map[string]bool{}
+	for _, ep := range eps {
+		if operationIDPattern.MatchString(ep.op.OperationId) {
+			taken[ep.op.OperationId] = true
+		}
+	}
+
+	var renames []operationIDRename
+	for _, ep := range eps {
+		from := ep.op.OperationId
+		if operationIDPattern.MatchString(from) {
+			continue
+		}
+		base := camelCase(from)
+		if base == "" || !operationIDPattern.MatchString(base) {
+			base = operationIDFor(ep.method, ep.path)
+		}
+		to := base
+		for n := 2; taken[to]; n++ {
+			to = base + strconv.Itoa(n)
+		}
+		taken[to] = true
+		renames = append(renames, operationIDRename{ep: ep, from: from, to: to, accepted: true})
+	}
+	return renames
+}
+
+// operationIDFor names an operation after its method and path: GET /users/{id} is
+// getUserById, GET /users listUsers, POST /users createUser and GET /users/{userId}/orders
+// listUserOrders
+func operationIDFor(method, path string) string {
+	var words []string
+	last := ""
+	for _, segment := range strings.Split(path, "/") {
+		switch {
+		case segment == "" || versionSegment.MatchString(segment):
+			continue
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			// The resource a parameter picks from is named in the singular
+			if len(words) > 0 {
+				words[len(words)-1] = singular(words[len(words)-1])
+			}
+			last = strings.Trim(segment, "{}")
+		default:
+			if word := pascalCase(segment); word != "" {
+				words = append(words, word)
+				last = ""
+			}
+		}
+	}
+	item := last != ""
+	collection := !item && len(words) > 0 && singular(words[len(words)-1]) != words[len(words)-1]
+
+	verb := strings.ToLower(method)
+	switch verb {
+	case "get":
+		if collection {
+			verb = "list"
+		}
+	case "post":
+		verb = "create"
+		if collection {
+			words[len(words)-1] = singular(words[len(words)-1])
+		}
+	case "put":
+		verb = "replace"
+	case "patch":
+		verb = "update"
+	}
+	if len(words) == 0 {
+		words = []string{"Root"}
+	}
+
+	id := camelCase(verb) + strings.Join(words, "")
+	if item {
+		// {id} and {userId} under /users both read as ById
+		by := pascalCase(last)
+		if resource := words[len(words)-1]; strings.EqualFold(last, "id") || strings.EqualFold(last, resource+"id") {
+			by = "Id"
+		}
+		id += "By" + by
+	}
+	return id
+}
+
+// camelCase turns "get_user" or "GetUser" into getUser
+func camelCase(s string) string {
+	word := pascalCase(s)
+	if word == "" {
+		return ""
+	}
+	runes := []rune(word)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// singular makes the English plural of a resource name singular, leaving other words alone
+func singular(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"), strings.HasSuffix(lower, "xes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(lower, "ss"), strings.HasSuffix(lower, "us"), strings.HasSuffix(lower, "is"):
+		return word
+	case strings.HasSuffix(lower, "s") && len(word) > 1:
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// openOperationIDReview proposes operationIds following the convention, for review before
+// they are written to the spec
+func (m *Model) openOperationIDReview() {
+	if !m.requireWriteMode() {
+		return
+	}
+	renames := proposeOperationIDs(m.endpoints)
+	if len(renames) == 0 {
+		m.setStatus("Every operationId follows the naming convention", false)
+		return
+	}
+	m.operationIDs = &operationIDReview{renames: renames}
+}
+
+// updateOperationIDReview handles keys while the proposed renames are reviewed
+func (m *Model) updateOperationIDReview(msg tea.KeyMsg) tea.Cmd {
+	r := m.operationIDs
+	switch msg.String() {
+	case "esc", "q":
+		m.operationIDs = nil
+	case "up", "k":
+		r.cursor = max(0, r.cursor-1)
+	case "down", "j":
+		r.cursor = min(len(r.renames)-1, r.cursor+1)
+	case "y", "n":
+		// Deciding on a rename moves on to the next one
+		r.renames[r.cursor].accepted = msg.String() == "y"
+		r.cursor = min(len(r.renames)-1, r.cursor+1)
+	case " ", "enter":
+		r.renames[r.cursor].accepted = !r.renames[r.cursor].accepted
+	case "a":
+		all := !slices.ContainsFunc(r.renames, func(rename operationIDRename) bool { return !rename.accepted })
+		for i := range r.renames {
+			r.renames[i].accepted = !all
+		}
+	case "ctrl+s":
+		m.operationIDs = nil
+		return m.saveOperationIDs(r.renames)
+	}
+	return nil
+}
+
+// saveOperationIDs writes the accepted renames to the spec file, along with the links that
+// name the operations by their old ids, and reloads it
+func (m *Model) saveOperationIDs(renames []operationIDRename) tea.Cmd {
+	var accepted []operationIDRename
+	for _, rename := range renames {
+		if rename.accepted {
+			accepted = append(accepted, rename)
+		}
+	}
+	if len(accepted) == 0 {
+		m.setStatus("No operationIds renamed", false)
+		return nil
+	}
+
+	_, err := editSpecFile(m.specPath, func(root *yaml.Node) error {
+		links := map[string]string{}
+		for _, rename := range accepted {
+			op := operationNode(root, rename.ep.path, rename.ep.method)
+			if op == nil || op.Kind != yaml.MappingNode {
+				return fmt.Errorf("%s %s not found in %s", rename.ep.method, rename.ep.path, m.specPath)
+			}
+			setOperationID(op, rename.to)
+			if rename.from != "" {
+				links[rename.from] = rename.to
+			}
+		}
+		renameLinkedOperations(root, links)
+		return nil
+	})
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Error renaming operationIds: %v", err), true)
+		return nil
+	}
+
+	m.setStatus(fmt.Sprintf("Renamed %d %s", len(accepted), plural(len(accepted), "operationId", "operationIds")), false)
+	return reloadSpec(m.specPath, m.patch, nil)
+}
+
+// setOperationID sets the operationId of an operation node, adding a missing one after the
+// keys that conventionally come before it
+func setOperationID(op *yaml.Node, id string) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: id}
+	if mappingValue(op, "operationId") != nil {
+		setMapping(op, "operationId", value)
+		return
+	}
+	before := objectKeyOrders["operation"][:slices.Index(objectKeyOrders["operation"], "operationId")]
+	at := 0
+	for i := 0; i+1 < len(op.Content); i += 2 {
+		if slices.Contains(before, op.Content[i].Value) {
+			at = i + 2
+		}
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "operationId"}
+	op.Content = slices.Insert(op.Content, at, key, value)
+}
+
+// renameLinkedOperations updates the operationId of links to renamed operations, in
+// responses and under components
+func renameLinkedOperations(node *yaml.Node, renamed map[string]string) {
+	if len(renamed) == 0 {
+		return
+	}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			renameLinkedOperations(item, renamed)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			if key == "links" && value.Kind == yaml.MappingNode {
+				for j := 1; j < len(value.Content); j += 2 {
+					if id := mappingValue(value.Content[j], "operationId"); id != nil {
+						if to, ok := renamed[id.Value]; ok {
+							id.Value = to
+						}
+					}
+				}
+				continue
+			}
+			renameLinkedOperations(value, renamed)
+		}
+	}
+}
+
+func (m Model) renderOperationIDReview() string {
+	r := m.operationIDs
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(colorThemePurple)).
+		Padding(1, 2).
+		Width(min(m.width-4, 100))
+
+	var endpointWidth int
+	for _, rename := range r.renames {
+		endpointWidth = max(endpointWidth, lipgloss.Width(rename.ep.method+" "+rename.ep.path))
+	}
+	endpointWidth = min(endpointWidth, 50)
+
+	// Keep the list within the screen, scrolled to the cursor
+	rowsHeight := max(3, m.height-14)
+	start := max(0, min(r.cursor-rowsHeight+1, len(r.renames)-rowsHeight))
+
+	var rows []string
+	accepted := 0
+	for _, rename := range r.renames {
+		if rename.accepted {
+			accepted++
+		}
+	}
+	for i := start; i < len(r.renames) && i < start+rowsHeight; i++ {
+		rename := r.renames[i]
+		box := "[ ]"
+		if rename.accepted {
+			box = "[x]"
+		}
+		was := "missing"
+		if rename.from != "" {
+			was = "was " + rename.from
+		}
+		line := box + " " + lipgloss.NewStyle().Width(endpointWidth).MaxWidth(endpointWidth).Render(rename.ep.method+" "+rename.ep.path) +
+			"  " + rename.to + " " + instructionStyle.Render("("+was+")")
+		if i == r.cursor {
+			line = highlight(lipgloss.NewStyle()).Bold(true).Render(line)
+		}
+		rows = append(rows, line)
+	}
+
+	title := titleStyle.Render(fmt.Sprintf("operationIds: %d of %d %s accepted", accepted, len(r.renames), plural(len(r.renames), "rename", "renames")))
+	instruction := instructionStyle.Render("↑/↓ move • y accept • n skip • Space toggle • a all • Ctrl+S write • Esc cancel")
+	modal := modalStyle.Render(title + "\n\n" + strings.Join(rows, "\n") + "\n\n" + instruction)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
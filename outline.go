@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// outlineSections are the sections of an operation's details that can be jumped to, by the
+// key jumping to them
+var outlineSections = []struct{ key, name string }{
+	{"p", "Parameters"},
+	{"b", "Request Body"},
+	{"r", "Responses"},
+	{"s", "Security"},
+	{"c", "Callbacks"},
+}
+
+// detailSection is a section found in the details, with the number of entries listed in it
+type detailSection struct {
+	key, name string
+	entries   int
+}
+
+// outlineMenu lists the sections of the selected operation's details to jump to
+type outlineMenu struct {
+	sections []detailSection
+	cursor   int
+}
+
+// isSectionHeader reports whether a details line starts a section, like "Parameters:" or
+// "Security: none"
+func isSectionHeader(line, name string) bool {
+	return line == name+":" || strings.HasPrefix(line, name+": ")
+}
+
+// detailSections returns the sections present in details, in the order they are shown
+func detailSections(details string) []detailSection {
+	var sections []detailSection
+	lines := strings.Split(details, "\n")
+	for i, line := range lines {
+		for _, section := range outlineSections {
+			if !isSectionHeader(line, section.name) {
+				continue
+			}
+			entries := 0
+			for _, entry := range lines[i+1:] {
+				if !strings.HasPrefix(entry, "  - ") {
+					if strings.HasPrefix(entry, "  ") {
+						continue
+					}
+					break
+				}
+				entries++
+			}
+			sections = append(sections, detailSection{key: section.key, name: section.name, entries: entries})
+		}
+	}
+	return sections
+}
+
+// openOutline lists the sections of the selected operation to jump to
+func (m *Model) openOutline() {
+	ep, ok := m.selectedEndpoint()
+	if m.mode != viewEndpoints || !ok {
+		m.setStatus("Select an operation to jump to a section of its details", true)
+		return
+	}
+	sections := detailSections(m.endpointDetails(ep))
+	if len(sections) == 0 {
+		m.setStatus(fmt.Sprintf("%s %s has no sections to jump to", ep.method, ep.path), false)
+		return
+	}
+	m.outline = &outlineMenu{sections: sections}
+}
+
+// updateOutline handles keys while the sections to jump to are listed
+func (m *Model) updateOutline(msg tea.KeyMsg) tea.Cmd {
+	o := m.outline
+	switch key := msg.String(); key {
+	case "esc", "q", "o":
+		m.outline = nil
+	case "up", "k":
+		o.cursor = max(0, o.cursor-1)
+	case "down", "j":
+		o.cursor = min(len(o.sections)-1, o.cursor+1)
+	case "enter":
+		m.outline = nil
+		m.jumpToSection(o.sections[o.cursor].name)
+	default:
+		for _, section := range o.sections {
+			if section.key == key {
+				m.outline = nil
+				m.jumpToSection(section.name)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// jumpToSection scrolls the details of the selected operation to a section. Inline, the
+// operation is unfolded and its details are shown from the section on
+func (m *Model) jumpToSection(name string) {
+	ep, ok := m.selectedEndpoint()
+	if !ok {
+		return
+	}
+	if !m.useSplitView() && ep.folded {
+		for i := range m.endpoints {
+			if m.endpoints[i].path == ep.path && m.endpoints[i].method == ep.method {
+				m.endpoints[i].folded = false
+				m.filterItems()
+				break
+			}
+		}
+	}
+
+	key, _, details := m.selectedDetails()
+	lines := strings.Split(details, "\n")
+	if m.useSplitView() {
+		lines = m.detailLines()
+	}
+	for i, line := range lines {
+		// Wrapped lines are padded to the width of the pane
+		if isSectionHeader(strings.TrimRight(line, " "), name) {
+			m.detailKey, m.detailScroll = key, i
+			break
+		}
+	}
+	if m.useSplitView() {
+		// Clamps the scroll position, the last sections can't be scrolled to the top
+		m.scrollDetails(0)
+	} else {
+		m.ensureCursorVisible()
+	}
+}
+
+// inlineDetails returns the details of an unfolded operation as shown under it in the list,
+// from the section jumped to when it is selected, and the number of lines left out above
+func (m *Model) inlineDetails(ep endpoint, selected bool) (string, int) {
+	details := m.endpointDetails(ep)
+	if !selected || m.detailScroll == 0 || m.detailKey != "endpoint "+ep.method+" "+ep.path {
+		return details, 0
+	}
+	lines := strings.SplitAfter(details, "\n")
+	skipped := min(m.detailScroll, len(lines)-1)
+	return strings.Join(lines[skipped:], ""), skipped
+}
+
+func (m Model) renderOutline() string {
+	o := m.outline
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	keyStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorGreen))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(colorThemePurple)).
+		Padding(1, 2)
+
+	var rows []string
+	for i, section := range o.sections {
+		count := ""
+		if section.entries > 0 {
+			count = instructionStyle.Render(fmt.Sprintf(" (%d)", section.entries))
+		}
+		line := keyStyle.Render(section.key) + "  " + section.name + count
+		if i == o.cursor {
+			line = highlight(lipgloss.NewStyle()).Render(line)
+		}
+		rows = append(rows, line)
+	}
+
+	ep, _ := m.selectedEndpoint()
+	title := titleStyle.Render("Jump to: " + ep.method + " " + ep.path)
+	instruction := instructionStyle.Render("↑/↓ move • Enter or key jump • Esc cancel")
+	modal := modalStyle.Render(title + "\n\n" + strings.Join(rows, "\n") + "\n\n" + instruction)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
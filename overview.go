@@ -0,0 +1,313 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// overviewBarWidth is the width of the longest bar in the overview's counts
+const overviewBarWidth = 24
+
+// overviewPane summarizes the spec: its metadata, what its operations and components are made
+// of, the security schemes they use and the servers they run on
+type overviewPane struct {
+	lines  []string
+	scroll int
+}
+
+// openOverview opens the overview of the spec, on D or at startup with default_view overview
+func (m *Model) openOverview() {
+	m.loadComponents()
+	m.overview = &overviewPane{lines: m.overviewLines()}
+}
+
+// updateOverview scrolls the overview
+func (m *Model) updateOverview(key string) {
+	pane := m.overview
+	last := max(0, len(pane.lines)-m.overviewHeight())
+	page := max(1, m.height/2)
+	switch key {
+	case "esc", "q", "D":
+		m.overview = nil
+	case "up", "k":
+		pane.scroll = max(0, pane.scroll-1)
+	case "down", "j":
+		pane.scroll = min(last, pane.scroll+1)
+	case "ctrl+u":
+		pane.scroll = max(0, pane.scroll-page)
+	case "ctrl+d":
+		pane.scroll = min(last, pane.scroll+page)
+	case "g":
+		pane.scroll = 0
+	case "G":
+		pane.scroll = last
+	}
+}
+
+func (m Model) overviewHeight() int {
+	return max(1, m.height-6)
+}
+
+// countRow is a name with how many items it has
+type countRow struct {
+	name  string
+	count int
+}
+
+// sortedCounts orders counts from the largest, then by name
+func sortedCounts(counts map[string]int) []countRow {
+	rows := make([]countRow, 0, len(counts))
+	for _, name := range slices.Sorted(maps.Keys(counts)) {
+		rows = append(rows, countRow{name, counts[name]})
+	}
+	slices.SortStableFunc(rows, func(a, b countRow) int { return cmp.Compare(b.count, a.count) })
+	return rows
+}
+
+// overviewLines renders the sections of the overview
+func (m Model) overviewLines() []string {
+	headingStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(colorBlue))
+	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray))
+	barStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorThemePurple))
+
+	stats := computeStats(m.doc, m.specContent)
+	var lines []string
+	section := func(title string) {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, headingStyle.Render(title))
+	}
+	field := func(name, value string) {
+		if value != "" {
+			lines = append(lines, "  "+grayStyle.Render(fmt.Sprintf("%-10s", name))+" "+value)
+		}
+	}
+	// bars renders a row per name with a bar scaled to the largest count
+	bars := func(rows []countRow, label func(string) string) {
+		nameWidth, largest := 0, 1
+		for _, row := range rows {
+			nameWidth = max(nameWidth, lipgloss.Width(label(row.name)))
+			largest = max(largest, row.count)
+		}
+		for _, row := range rows {
+			name := label(row.name)
+			bar := strings.Repeat("█", max(1, row.count*overviewBarWidth/largest))
+			lines = append(lines, fmt.Sprintf("  %s%s %4d %s", name, strings.Repeat(" ", nameWidth-lipgloss.Width(name)), row.count, barStyle.Render(bar)))
+		}
+	}
+
+	section("Info")
+	if info := m.doc.Info; info != nil {
+		field("Title", info.Title)
+		field("Version", info.Version)
+		field("Summary", info.Summary)
+		if info.Description != "" {
+			first, _, _ := strings.Cut(strings.TrimSpace(info.Description), "\n")
+			field("About", first)
+		}
+		if contact := info.Contact; contact != nil {
+			var parts []string
+			for _, part := range []string{contact.Name, contact.Email, contact.URL} {
+				if part != "" {
+					parts = append(parts, part)
+				}
+			}
+			field("Contact", strings.Join(parts, " · "))
+		}
+		if license := info.License; license != nil {
+			name := license.Name
+			switch {
+			case license.Identifier != "":
+				name += " (" + license.Identifier + ")"
+			case license.URL != "":
+				name += " · " + license.URL
+			}
+			field("License", name)
+		}
+		field("Terms", info.TermsOfService)
+	}
+	field("OpenAPI", stats.OpenAPI)
+	field("Spec", "#"+stats.Fingerprint)
+
+	section(fmt.Sprintf("Operations (%d on %d %s, %d deprecated)", stats.Operations, stats.Paths, plural(stats.Paths, "path", "paths"), stats.Deprecated))
+	bars(sortedCounts(stats.Methods), m.methodLabel)
+	if stats.Webhooks > 0 {
+		lines = append(lines, "  "+grayStyle.Render(fmt.Sprintf("and %d %s", stats.Webhooks, plural(stats.Webhooks, "webhook", "webhooks"))))
+	}
+
+	if len(stats.Tags) > 0 {
+		section(fmt.Sprintf("Tags (%d)", len(stats.Tags)))
+		untagged := stats.Operations - stats.Coverage.Tagged.Count
+		bars(sortedCounts(stats.Tags), func(name string) string { return name })
+		if untagged > 0 {
+			lines = append(lines, "  "+grayStyle.Render(fmt.Sprintf("and %d untagged", untagged)))
+		}
+	}
+
+	if deprecated, components := deprecatedItems(m.endpoints, m.components); len(deprecated) > 0 || len(components) > 0 {
+		section(fmt.Sprintf("Deprecation (%d %s, %d %s)", len(deprecated), plural(len(deprecated), "operation", "operations"), len(components), plural(len(components), "component", "components")))
+		methodWidth := m.methodWidth(endpointMethods(deprecated))
+		for _, ep := range deprecated {
+			line := "  " + lipgloss.NewStyle().Width(methodWidth).Render(m.methodLabel(ep.method)) + " " + ep.path
+			if badge := findChangelog(ep.op).deprecationBadge(); badge != "" {
+				line += grayStyle.Render(" · " + badge)
+			}
+			lines = append(lines, line)
+		}
+		for _, comp := range components {
+			lines = append(lines, "  "+grayStyle.Render(comp.compType)+" "+comp.name)
+		}
+	}
+
+	if schemes := securitySchemeUsage(m.doc, m.endpoints); len(schemes) > 0 {
+		section(fmt.Sprintf("Security schemes (%d)", len(schemes)))
+		nameWidth := 0
+		for _, scheme := range schemes {
+			nameWidth = max(nameWidth, lipgloss.Width(scheme.name))
+		}
+		for _, scheme := range schemes {
+			lines = append(lines, fmt.Sprintf("  %-*s  %s", nameWidth, scheme.name, scheme.kind)+
+				grayStyle.Render(fmt.Sprintf(" · %d %s", scheme.operations, plural(scheme.operations, "operation", "operations"))))
+		}
+	}
+
+	if servers := collectServers(m.doc, m.endpoints); len(servers) > 0 {
+		section(fmt.Sprintf("Servers (%d)", len(servers)))
+		for _, server := range servers {
+			line := "  " + server.server.URL
+			if server.server.Description != "" {
+				line += grayStyle.Render(" - " + server.server.Description)
+			}
+			if server.scope != "Document" {
+				line += grayStyle.Render(" (" + server.scope + ")")
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	if len(stats.Components) > 0 {
+		total := 0
+		for _, count := range stats.Components {
+			total += count
+		}
+		section(fmt.Sprintf("Components (%d)", total))
+		bars(sortedCounts(stats.Components), func(name string) string { return name })
+	}
+	return lines
+}
+
+// deprecatedItems lists the deprecated operations, the ones with the nearest sunset first,
+// and components
+func deprecatedItems(eps []endpoint, components []component) ([]endpoint, []component) {
+	var deprecated []endpoint
+	for _, ep := range eps {
+		if isDeprecated(ep.op) {
+			deprecated = append(deprecated, ep)
+		}
+	}
+	slices.SortStableFunc(deprecated, func(a, b endpoint) int {
+		sunsetA, sunsetB := findChangelog(a.op).sunset, findChangelog(b.op).sunset
+		switch {
+		case sunsetA == sunsetB:
+			return 0
+		case sunsetA == "":
+			return 1
+		case sunsetB == "":
+			return -1
+		}
+		return cmp.Compare(sunsetA, sunsetB)
+	})
+	var deprecatedComponents []component
+	for _, comp := range components {
+		if comp.deprecated {
+			deprecatedComponents = append(deprecatedComponents, comp)
+		}
+	}
+	return deprecated, deprecatedComponents
+}
+
+// schemeUsage is a security scheme with how many operations require it
+type schemeUsage struct {
+	name       string
+	kind       string
+	operations int
+}
+
+// securitySchemeUsage lists the declared security schemes in order, described by type,
+// with the operations whose effective security names them
+func securitySchemeUsage(doc *v3.Document, eps []endpoint) []schemeUsage {
+	if doc.Components == nil || doc.Components.SecuritySchemes == nil {
+		return nil
+	}
+	var schemes []schemeUsage
+	for pair := doc.Components.SecuritySchemes.First(); pair != nil; pair = pair.Next() {
+		usage := schemeUsage{name: pair.Key(), kind: securitySchemeKind(pair.Value())}
+		for _, ep := range eps {
+			if slices.ContainsFunc(effectiveSecurity(doc, ep.op), func(requirement *base.SecurityRequirement) bool {
+				if requirement == nil || requirement.Requirements == nil {
+					return false
+				}
+				_, ok := requirement.Requirements.Get(pair.Key())
+				return ok
+			}) {
+				usage.operations++
+			}
+		}
+		schemes = append(schemes, usage)
+	}
+	return schemes
+}
+
+// securitySchemeKind describes a scheme in a few words, such as "apiKey in header X-API-Key"
+func securitySchemeKind(scheme *v3.SecurityScheme) string {
+	if scheme == nil {
+		return ""
+	}
+	switch strings.ToLower(scheme.Type) {
+	case "http":
+		return "http " + scheme.Scheme
+	case "apikey":
+		return fmt.Sprintf("apiKey in %s %s", scheme.In, scheme.Name)
+	case "oauth2":
+		var flows []string
+		if scheme.Flows != nil {
+			for name, flow := range map[string]*v3.OAuthFlow{"implicit": scheme.Flows.Implicit, "password": scheme.Flows.Password, "clientCredentials": scheme.Flows.ClientCredentials, "authorizationCode": scheme.Flows.AuthorizationCode, "device": scheme.Flows.Device} {
+				if flow != nil {
+					flows = append(flows, name)
+				}
+			}
+		}
+		slices.Sort(flows)
+		return strings.TrimSpace("oauth2 " + strings.Join(flows, ", "))
+	}
+	return scheme.Type
+}
+
+func (m Model) renderOverview() string {
+	pane := m.overview
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	height := m.overviewHeight()
+	scroll := min(pane.scroll, max(0, len(pane.lines)-height))
+	visible := slices.Clone(pane.lines[scroll:min(len(pane.lines), scroll+height)])
+	for i, line := range visible {
+		visible[i] = lipgloss.NewStyle().MaxWidth(m.width).Render(line)
+	}
+	title := titleStyle.Render("Overview")
+	instruction := instructionStyle.Render("j/k scroll · Esc close")
+	return lipgloss.NewStyle().MaxHeight(m.height).Render(title + "\n\n" + strings.Join(visible, "\n") + "\n\n" + instruction)
+}
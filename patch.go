@@ -0,0 +1,426 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"go.yaml.in/yaml/v4"
+)
+
+// specPatch is a JSON Patch (RFC 6902) or a JSON Merge Patch (RFC 7386), in JSON or YAML,
+// applied to the spec in memory with --patch to preview proposed changes
+type specPatch struct {
+	file  string
+	ops   []patchOperation
+	merge *yaml.Node
+}
+
+type patchOperation struct {
+	op    string
+	path  []string
+	from  []string
+	value *yaml.Node
+}
+
+func (op patchOperation) String() string {
+	return op.op + " " + jsonPointer(op.path)
+}
+
+// loadPatch reads a patch file. A list is a JSON Patch, a mapping a merge patch
+func loadPatch(path string) (*specPatch, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading patch: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("Error: patch %s is empty", path)
+	}
+
+	patch := &specPatch{file: path}
+	root := doc.Content[0]
+	// Values written as JSON are inserted in the block style of the spec
+	clearStyle(root)
+	switch root.Kind {
+	case yaml.MappingNode:
+		patch.merge = root
+		return patch, nil
+	case yaml.SequenceNode:
+	default:
+		return nil, fmt.Errorf("Error parsing %s: expected a JSON Patch list or a merge patch object", path)
+	}
+
+	for i, item := range root.Content {
+		op := patchOperation{op: scalarValue(item, "op"), value: mappingValue(item, "value")}
+		var err error
+		if op.path, err = parsePointer(scalarValue(item, "path")); err != nil {
+			return nil, fmt.Errorf("Error in %s, operation %d: %w", path, i+1, err)
+		}
+		switch op.op {
+		case "add", "replace", "test":
+			if op.value == nil {
+				return nil, fmt.Errorf("Error in %s, operation %d: %s needs a value", path, i+1, op.op)
+			}
+		case "move", "copy":
+			if mappingValue(item, "from") == nil {
+				return nil, fmt.Errorf("Error in %s, operation %d: %s needs a from pointer", path, i+1, op.op)
+			}
+			if op.from, err = parsePointer(scalarValue(item, "from")); err != nil {
+				return nil, fmt.Errorf("Error in %s, operation %d: from: %w", path, i+1, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("Error in %s, operation %d: unknown op %q", path, i+1, op.op)
+		}
+		patch.ops = append(patch.ops, op)
+	}
+	return patch, nil
+}
+
+// kind describes the patch for the banner, e.g. "JSON Patch, 3 operations"
+func (p *specPatch) kind() string {
+	if p.merge != nil {
+		return "merge patch"
+	}
+	return fmt.Sprintf("JSON Patch, %d %s", len(p.ops), plural(len(p.ops), "operation", "operations"))
+}
+
+// parsePointer splits an RFC 6901 pointer into its unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// apply patches the spec and returns the result as YAML, with the badges of the endpoints,
+// components and webhooks the patch added or changed, keyed like selectedDetails
+func (p *specPatch) apply(content []byte) ([]byte, map[string]string, error) {
+	var original, patched yaml.Node
+	if err := yaml.Unmarshal(content, &original); err != nil {
+		return nil, nil, fmt.Errorf("Error parsing spec: %w", err)
+	}
+	if err := yaml.Unmarshal(content, &patched); err != nil {
+		return nil, nil, fmt.Errorf("Error parsing spec: %w", err)
+	}
+	if len(patched.Content) == 0 {
+		return nil, nil, fmt.Errorf("Error: spec is empty")
+	}
+
+	var touched [][]string
+	if p.merge != nil {
+		patched.Content[0] = mergePatch(patched.Content[0], p.merge, nil, &touched)
+	}
+	for i, op := range p.ops {
+		paths, err := applyPatchOperation(patched.Content[0], op)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error applying %s, operation %d (%s): %w", p.file, i+1, op, err)
+		}
+		touched = append(touched, paths...)
+	}
+
+	out, err := marshalFragment(&patched)
+	if err != nil {
+		return nil, nil, err
+	}
+	var before *yaml.Node
+	if len(original.Content) > 0 {
+		before = original.Content[0]
+	}
+	return out, patchBadges(before, patched.Content[0], touched), nil
+}
+
+// mergePatch applies a merge patch: null removes a key, mappings merge and anything else
+// replaces the target
+func mergePatch(target, patch *yaml.Node, path []string, touched *[][]string) *yaml.Node {
+	if patch.Kind != yaml.MappingNode {
+		*touched = append(*touched, path)
+		return patch
+	}
+	if target == nil || target.Kind != yaml.MappingNode {
+		*touched = append(*touched, path)
+		target = newMapping()
+	}
+	for i := 0; i+1 < len(patch.Content); i += 2 {
+		key, value := patch.Content[i].Value, patch.Content[i+1]
+		childPath := append(slices.Clip(path), key)
+		if isNullNode(value) {
+			if mappingValue(target, key) != nil {
+				removeMapping(target, key)
+				*touched = append(*touched, childPath)
+			}
+			continue
+		}
+		setMapping(target, key, mergePatch(mappingValue(target, key), value, childPath, touched))
+	}
+	return target
+}
+
+func isNullNode(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
+func removeMapping(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = slices.Delete(node.Content, i, i+2)
+			return
+		}
+	}
+}
+
+// applyPatchOperation applies one JSON Patch operation and returns the paths it changed
+func applyPatchOperation(root *yaml.Node, op patchOperation) ([][]string, error) {
+	switch op.op {
+	case "add":
+		return [][]string{op.path}, patchAdd(root, op.path, op.value)
+	case "remove":
+		_, err := patchRemove(root, op.path)
+		return [][]string{op.path}, err
+	case "replace":
+		if len(op.path) == 0 {
+			*root = *op.value
+			return [][]string{op.path}, nil
+		}
+		if resolvePointer(root, op.path) == nil {
+			return nil, fmt.Errorf("%s does not exist", jsonPointer(op.path))
+		}
+		// Replaced in place, keeping the position of the key
+		parent := resolvePointer(root, op.path[:len(op.path)-1])
+		if i, err := strconv.Atoi(op.path[len(op.path)-1]); err == nil && parent.Kind == yaml.SequenceNode {
+			parent.Content[i] = op.value
+		} else {
+			setMapping(parent, op.path[len(op.path)-1], op.value)
+		}
+		return [][]string{op.path}, nil
+	case "move":
+		value, err := patchRemove(root, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return [][]string{op.from, op.path}, patchAdd(root, op.path, value)
+	case "copy":
+		value := resolvePointer(root, op.from)
+		if value == nil {
+			return nil, fmt.Errorf("%s does not exist", jsonPointer(op.from))
+		}
+		return [][]string{op.path}, patchAdd(root, op.path, copyNode(value))
+	case "test":
+		value := resolvePointer(root, op.path)
+		if value == nil || canonicalNode(value) != canonicalNode(op.value) {
+			return nil, fmt.Errorf("test failed")
+		}
+	}
+	return nil, nil
+}
+
+func resolvePointer(root *yaml.Node, path []string) *yaml.Node {
+	node := root
+	for _, token := range path {
+		switch node.Kind {
+		case yaml.MappingNode:
+			node = mappingValue(node, token)
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[i]
+		default:
+			return nil
+		}
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// patchAdd sets a mapping key, or inserts into a list where "-" appends
+func patchAdd(root *yaml.Node, path []string, value *yaml.Node) error {
+	if len(path) == 0 {
+		*root = *value
+		return nil
+	}
+	parent := resolvePointer(root, path[:len(path)-1])
+	if parent == nil {
+		return fmt.Errorf("%s does not exist", jsonPointer(path[:len(path)-1]))
+	}
+	key := path[len(path)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		setMapping(parent, key, value)
+		return nil
+	case yaml.SequenceNode:
+		if key == "-" {
+			parent.Content = append(parent.Content, value)
+			return nil
+		}
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i > len(parent.Content) {
+			return fmt.Errorf("invalid index %q", key)
+		}
+		parent.Content = slices.Insert(parent.Content, i, value)
+		return nil
+	}
+	return fmt.Errorf("%s is not an object or a list", jsonPointer(path[:len(path)-1]))
+}
+
+// patchRemove removes the value at path and returns it
+func patchRemove(root *yaml.Node, path []string) (*yaml.Node, error) {
+	value := resolvePointer(root, path)
+	if value == nil || len(path) == 0 {
+		return nil, fmt.Errorf("%s does not exist", jsonPointer(path))
+	}
+	parent := resolvePointer(root, path[:len(path)-1])
+	key := path[len(path)-1]
+	if parent.Kind == yaml.SequenceNode {
+		i, _ := strconv.Atoi(key)
+		parent.Content = slices.Delete(parent.Content, i, i+1)
+	} else {
+		removeMapping(parent, key)
+	}
+	return value, nil
+}
+
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+func copyNode(node *yaml.Node) *yaml.Node {
+	clone := *node
+	clone.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		clone.Content[i] = copyNode(child)
+	}
+	return &clone
+}
+
+// patchBadges maps the changed paths to the items listed in the TUI and whether the patch
+// added, changed or removed them. Removed items only count towards the banner
+func patchBadges(before, after *yaml.Node, touched [][]string) map[string]string {
+	badges := map[string]string{}
+	// mark compares item in both versions. scope is what the touched path changed, such as
+	// the parameters of a path item shared by its operations
+	mark := func(key string, item, scope []string) {
+		inBefore, inAfter := nodeAt(before, item), nodeAt(after, item)
+		switch {
+		case inBefore != nil && inAfter != nil:
+			if scopeBefore, scopeAfter := nodeAt(before, scope), nodeAt(after, scope); scopeBefore == nil || scopeAfter == nil || canonicalNode(scopeBefore) != canonicalNode(scopeAfter) {
+				badges[key] = "changed"
+			}
+		case inAfter != nil:
+			badges[key] = "added"
+		case inBefore != nil:
+			badges[key] = "removed"
+		}
+	}
+	children := func(path []string) []string {
+		var keys []string
+		for _, node := range []*yaml.Node{nodeAt(before, path), nodeAt(after, path)} {
+			if node == nil || node.Kind != yaml.MappingNode {
+				continue
+			}
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if !slices.Contains(keys, node.Content[i].Value) {
+					keys = append(keys, node.Content[i].Value)
+				}
+			}
+		}
+		return keys
+	}
+
+	for _, path := range touched {
+		if len(path) < 2 {
+			continue
+		}
+		switch path[0] {
+		case "paths", "webhooks":
+			kind := "endpoint"
+			if path[0] == "webhooks" {
+				kind = "webhook"
+			}
+			for _, method := range children(path[:2]) {
+				item := []string{path[0], path[1], method}
+				scope := path[:2]
+				if len(path) > 2 && standardOperationKeys[path[2]] {
+					if path[2] != method {
+						continue
+					}
+					scope = item
+				}
+				if standardOperationKeys[method] {
+					mark(kind+" "+strings.ToUpper(method)+" "+path[1], item, scope)
+				}
+			}
+		case "components":
+			compType, ok := componentTypes[path[1]]
+			if !ok {
+				continue
+			}
+			names := children(path[:2])
+			if len(path) > 2 {
+				names = path[2:3]
+			}
+			for _, name := range names {
+				item := []string{"components", path[1], name}
+				mark("component "+compType+" "+name, item, item)
+			}
+		}
+	}
+	return badges
+}
+
+// patchSummary counts the badges for the banner, e.g. "2 changed, 1 added"
+func patchSummary(badges map[string]string) string {
+	var parts []string
+	for _, badge := range []string{"changed", "added", "removed"} {
+		n := 0
+		for _, b := range badges {
+			if b == badge {
+				n++
+			}
+		}
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, badge))
+		}
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// renderPatchBadge renders the badge of an item the previewed patch added or changed, or
+// that the last reload added or changed for a while after it
+func (m Model) renderPatchBadge(key string, style lipgloss.Style) string {
+	badge := m.patchBadges[key]
+	if badge == "" {
+		badge = m.reloadBadges[key]
+	}
+	if badge == "" {
+		return ""
+	}
+	color := colorYellow
+	if badge == "added" {
+		color = colorGreen
+	}
+	return style.Render(" ") + style.Foreground(lipgloss.Color(color)).Render("["+badge+"]")
+}
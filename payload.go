@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// payloadSize is the estimated size of an operation's JSON request body and largest success
+// response, serialized without whitespace. The examples are fully populated, with every
+// property set and one item per array. Zero means there is no JSON body
+type payloadSize struct {
+	request, response int
+}
+
+func estimatePayloadSize(doc *v3.Document, op *v3.Operation) payloadSize {
+	var size payloadSize
+	if mediaType, body, ok := exampleRequestBody(doc, op); ok && isJSONMediaType(mediaType) && body != "" {
+		size.request = compactSize(body)
+	}
+	for _, example := range exampleResponses(doc, op) {
+		if strings.HasPrefix(example.Code, "2") {
+			size.response = max(size.response, compactSize(example.Body))
+		}
+	}
+	return size
+}
+
+// compactSize is the length of a JSON body without whitespace
+func compactSize(body string) int {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(body)); err != nil {
+		return len(body)
+	}
+	return compact.Len()
+}
+
+func (s payloadSize) largest() int {
+	return max(s.request, s.response)
+}
+
+// String describes the sizes, e.g. "request ~212 B, response ~1.4 KiB"
+func (s payloadSize) String() string {
+	var parts []string
+	if s.request > 0 {
+		parts = append(parts, "request ~"+formatBytes(uint64(s.request)))
+	}
+	if s.response > 0 {
+		parts = append(parts, "response ~"+formatBytes(uint64(s.response)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// oversizedPayloads returns the operations whose request or response payload is estimated
+// larger than limit, keyed like the session items
+func oversizedPayloads(doc *v3.Document, limit uint64) map[string]payloadSize {
+	if limit == 0 {
+		return nil
+	}
+	oversized := map[string]payloadSize{}
+	for _, ep := range extractEndpoints(doc) {
+		if size := estimatePayloadSize(doc, ep.op); uint64(size.largest()) > limit {
+			oversized["endpoint "+ep.method+" "+ep.path] = size
+		}
+	}
+	return oversized
+}
+
+// payloadWarning summarizes the oversized operations for the budget warnings
+func payloadWarning(doc *v3.Document, oversized map[string]payloadSize, limit uint64) string {
+	var largest string
+	var largestSize int
+	for _, ep := range extractEndpoints(doc) {
+		if size, ok := oversized["endpoint "+ep.method+" "+ep.path]; ok && size.largest() > largestSize {
+			largest, largestSize = ep.method+" "+ep.path, size.largest()
+		}
+	}
+	return fmt.Sprintf("%d %s with payloads over %s (largest %s, ~%s)", len(oversized), plural(len(oversized), "operation", "operations"),
+		formatBytes(limit), largest, formatBytes(uint64(largestSize)))
+}
+
+// renderPayloadBadge flags endpoints whose payloads exceed the configured max_payload_size
+func (m Model) renderPayloadBadge(ep endpoint, style lipgloss.Style) string {
+	size, ok := m.oversizedPayloads["endpoint "+ep.method+" "+ep.path]
+	if !ok {
+		return ""
+	}
+	return style.Render(" ") + style.Foreground(lipgloss.Color(colorYellow)).Render("[payload ~"+formatBytes(uint64(size.largest()))+"]")
+}
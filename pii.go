@@ -0,0 +1,543 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// piiTerm flags property names containing term. Terms of three letters or less, such as ssn,
+// must be a whole word of the name so dob doesn't match adobe
+type piiTerm struct {
+	term     string
+	category string
+}
+
+// defaultPIITerms are used unless the pii_terms setting replaces them
+var defaultPIITerms = []piiTerm{
+	{"email", "contact"}, {"phone", "contact"}, {"mobile", "contact"}, {"fax", "contact"},
+	{"firstname", "name"}, {"lastname", "name"}, {"fullname", "name"}, {"surname", "name"}, {"middlename", "name"},
+	{"address", "location"}, {"street", "location"}, {"postcode", "location"}, {"postalcode", "location"},
+	{"zipcode", "location"}, {"latitude", "location"}, {"longitude", "location"},
+	{"dob", "birth date"}, {"dateofbirth", "birth date"}, {"birthdate", "birth date"}, {"birthday", "birth date"},
+	{"ssn", "government id"}, {"socialsecurity", "government id"}, {"passport", "government id"},
+	{"nationalid", "government id"}, {"taxid", "government id"}, {"driverslicense", "government id"},
+	{"creditcard", "financial"}, {"cardnumber", "financial"}, {"cvv", "financial"}, {"cvc", "financial"},
+	{"iban", "financial"}, {"sortcode", "financial"},
+	{"accountnumber", "financial"},
+	{"password", "secret"}, {"passwd", "secret"}, {"secret", "secret"}, {"apikey", "secret"},
+	{"accesstoken", "secret"}, {"refreshtoken", "secret"}, {"pin", "secret"},
+	{"ipaddress", "network"},
+	{"gender", "sensitive"}, {"ethnicity", "sensitive"}, {"religion", "sensitive"}, {"diagnosis", "sensitive"},
+}
+
+// piiFormats are string formats that mark a property as PII whatever it is called
+var piiFormats = map[string]string{
+	"email":     "contact",
+	"idn-email": "contact",
+	"password":  "secret",
+	"ipv4":      "network",
+	"ipv6":      "network",
+}
+
+// piiTermsFromConfig returns the configured terms, keeping the category of the default ones
+func piiTermsFromConfig(cfg *Config) []piiTerm {
+	if len(cfg.PIITerms) == 0 {
+		return defaultPIITerms
+	}
+	var terms []piiTerm
+	for _, term := range cfg.PIITerms {
+		term = normalizePIIName(term)
+		category := "custom"
+		for _, known := range defaultPIITerms {
+			if known.term == term {
+				category = known.category
+			}
+		}
+		terms = append(terms, piiTerm{term: term, category: category})
+	}
+	return terms
+}
+
+// piiFinding is a property or parameter that looks like personal or sensitive data
+type piiFinding struct {
+	schema    string
+	property  string
+	category  string
+	reason    string
+	writeOnly bool
+	requests  []string
+	responses []string
+}
+
+// severity rates a finding for review: secrets returned in responses and identifiers that
+// are regulated everywhere are high, secrets only ever sent are low
+func (f *piiFinding) severity() string {
+	switch f.category {
+	case "secret":
+		if len(f.responses) > 0 && !f.writeOnly {
+			return "high"
+		}
+		return "low"
+	case "government id", "financial", "sensitive":
+		return "high"
+	}
+	return "medium"
+}
+
+// note explains the severity of a finding
+func (f *piiFinding) note() string {
+	if f.category == "secret" && f.severity() == "high" {
+		return "returned in responses"
+	}
+	return ""
+}
+
+var piiSeverityOrder = map[string]int{"high": 0, "medium": 1, "low": 2}
+
+// piiScanner collects findings keyed by schema and property, so a component used by many
+// operations is reported once with every operation that sends or returns it
+type piiScanner struct {
+	doc      *v3.Document
+	terms    []piiTerm
+	findings map[string]*piiFinding
+	order    []string
+}
+
+// scanPII looks for likely PII in the component schemas and in the parameters, request
+// bodies and responses of eps
+func scanPII(doc *v3.Document, eps []endpoint, terms []piiTerm) []*piiFinding {
+	scanner := &piiScanner{doc: doc, terms: terms, findings: map[string]*piiFinding{}}
+
+	if doc.Components != nil && doc.Components.Schemas != nil {
+		for pair := doc.Components.Schemas.First(); pair != nil; pair = pair.Next() {
+			scanner.walk(pair.Key(), "", pair.Value(), "", "", map[string]bool{}, 0)
+		}
+	}
+
+	for _, ep := range eps {
+		operation := ep.method + " " + ep.path
+		for _, param := range ep.op.Parameters {
+			if param == nil {
+				continue
+			}
+			var schema *base.Schema
+			if param.Schema != nil {
+				schema = param.Schema.Schema()
+			}
+			if finding := scanner.record(operation, param.Name+" ("+param.In+")", param.Name, schema); finding != nil {
+				finding.requests = appendOnce(finding.requests, operation)
+			}
+		}
+		if ep.op.RequestBody != nil && ep.op.RequestBody.Content != nil {
+			for pair := ep.op.RequestBody.Content.First(); pair != nil; pair = pair.Next() {
+				if pair.Value() != nil {
+					scanner.walk(operation+" request", "", pair.Value().Schema, operation, "request", map[string]bool{}, 0)
+				}
+			}
+		}
+		if ep.op.Responses != nil {
+			for _, code := range responseCodes(ep.op) {
+				resp := ep.op.Responses.Codes.GetOrZero(code)
+				if code == "default" {
+					resp = ep.op.Responses.Default
+				}
+				if resp == nil || resp.Content == nil {
+					continue
+				}
+				for pair := resp.Content.First(); pair != nil; pair = pair.Next() {
+					if pair.Value() != nil {
+						scanner.walk(operation+" "+code, "", pair.Value().Schema, operation, "response", map[string]bool{}, 0)
+					}
+				}
+			}
+		}
+	}
+
+	findings := make([]*piiFinding, 0, len(scanner.order))
+	for _, key := range scanner.order {
+		findings = append(findings, scanner.findings[key])
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return piiSeverityOrder[findings[i].severity()] < piiSeverityOrder[findings[j].severity()]
+	})
+	return findings
+}
+
+// responseCodes returns the documented status codes of op in order, with default last
+func responseCodes(op *v3.Operation) []string {
+	if op.Responses == nil {
+		return nil
+	}
+	var codes []string
+	if op.Responses.Codes != nil {
+		for pair := op.Responses.Codes.First(); pair != nil; pair = pair.Next() {
+			codes = append(codes, pair.Key())
+		}
+	}
+	sortResponseCodes(codes)
+	if op.Responses.Default != nil {
+		codes = append(codes, "default")
+	}
+	return codes
+}
+
+// walk visits the properties of proxy, owned by schema at path. Referenced component schemas
+// become the owner of their properties. operation and direction record who uses them
+func (s *piiScanner) walk(schema, path string, proxy *base.SchemaProxy, operation, direction string, seen map[string]bool, depth int) {
+	if proxy == nil || depth > maxSchemaDepth {
+		return
+	}
+	if ref := proxy.GetReference(); strings.HasPrefix(ref, "#/components/schemas/") {
+		if depth > 0 || operation != "" {
+			if seen[ref] {
+				return
+			}
+			seen[ref] = true
+			schema, path = strings.TrimPrefix(ref, "#/components/schemas/"), ""
+		}
+	}
+	sch := proxy.Schema()
+	if sch == nil {
+		return
+	}
+
+	for _, child := range append(append(append([]*base.SchemaProxy{}, sch.AllOf...), sch.OneOf...), sch.AnyOf...) {
+		s.walk(schema, path, child, operation, direction, seen, depth+1)
+	}
+	if sch.Items != nil && sch.Items.IsA() {
+		s.walk(schema, path+"[]", sch.Items.A, operation, direction, seen, depth+1)
+	}
+	if sch.AdditionalProperties != nil && sch.AdditionalProperties.IsA() {
+		s.walk(schema, path+".*", sch.AdditionalProperties.A, operation, direction, seen, depth+1)
+	}
+	if sch.Properties == nil {
+		return
+	}
+	for pair := sch.Properties.First(); pair != nil; pair = pair.Next() {
+		property := pair.Key()
+		if path != "" {
+			property = path + "." + property
+		}
+		if finding := s.record(schema, property, pair.Key(), pair.Value().Schema()); finding != nil && operation != "" {
+			if direction == "request" {
+				finding.requests = appendOnce(finding.requests, operation)
+			} else {
+				finding.responses = appendOnce(finding.responses, operation)
+			}
+		}
+		s.walk(schema, property, pair.Value(), operation, direction, seen, depth+1)
+	}
+}
+
+// record returns the finding for a property or parameter, or nil when it doesn't look like PII
+func (s *piiScanner) record(schema, property, name string, sch *base.Schema) *piiFinding {
+	key := schema + "\x00" + property
+	if finding, ok := s.findings[key]; ok {
+		return finding
+	}
+	category, reason := s.classify(name, sch)
+	if category == "" {
+		return nil
+	}
+	finding := &piiFinding{schema: schema, property: property, category: category, reason: reason}
+	if sch != nil && sch.WriteOnly != nil && *sch.WriteOnly {
+		finding.writeOnly = true
+	}
+	s.findings[key] = finding
+	s.order = append(s.order, key)
+	return finding
+}
+
+// classify matches a property name against the terms, preferring the longest term so
+// ipAddress is network data rather than a location. Flags such as emailVerified are skipped
+func (s *piiScanner) classify(name string, sch *base.Schema) (string, string) {
+	if sch != nil && len(sch.Type) > 0 && sch.Type[0] == "boolean" {
+		return "", ""
+	}
+	if sch != nil {
+		if category, ok := piiFormats[sch.Format]; ok {
+			return category, "format: " + sch.Format
+		}
+	}
+
+	normalized := normalizePIIName(name)
+	words := piiWords(name)
+	var best piiTerm
+	for _, term := range s.terms {
+		matches := strings.Contains(normalized, term.term)
+		if len(term.term) <= 3 {
+			matches = false
+			for _, word := range words {
+				matches = matches || word == term.term
+			}
+		}
+		if matches && len(term.term) > len(best.term) {
+			best = term
+		}
+	}
+	if best.term == "" {
+		return "", ""
+	}
+	return best.category, fmt.Sprintf("name matches %q", best.term)
+}
+
+// normalizePIIName lowercases a name and drops separators, so date_of_birth, dateOfBirth
+// and DateOfBirth compare equal
+func normalizePIIName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// piiWords splits a camelCase, snake_case or kebab-case name into lower-case words
+func piiWords(name string) []string {
+	var words []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))):
+			flush()
+		}
+		word.WriteRune(unicode.ToLower(r))
+	}
+	flush()
+	return words
+}
+
+func appendOnce(list []string, value string) []string {
+	if slices.Contains(list, value) {
+		return list
+	}
+	return append(list, value)
+}
+
+// writePIICSV writes one row per finding with the operations using it
+func writePIICSV(w io.Writer, findings []*piiFinding) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"severity", "schema", "property", "category", "reason", "note", "requests", "responses"}); err != nil {
+		return err
+	}
+	for _, f := range findings {
+		record := []string{f.severity(), f.schema, f.property, f.category, f.reason, f.note(),
+			strings.Join(f.requests, "; "), strings.Join(f.responses, "; ")}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writePIITable(w io.Writer, findings []*piiFinding) {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEVERITY\tSCHEMA\tPROPERTY\tCATEGORY\tREASON\tUSED BY")
+	for _, f := range findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", f.severity(), f.schema, f.property, f.category, f.reason, f.usage())
+	}
+	tw.Flush()
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line != "" {
+			fmt.Fprintln(w, strings.TrimRight(line, " \n"))
+		}
+	}
+}
+
+// usage summarizes the operations sending and returning a finding
+func (f *piiFinding) usage() string {
+	var parts []string
+	if n := len(f.requests); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", n, plural(n, "request", "requests")))
+	}
+	if n := len(f.responses); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", n, plural(n, "response", "responses")))
+	}
+	if note := f.note(); note != "" {
+		parts = append(parts, note)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// runPII implements `oq pii spec.yaml`, printing the findings as a table or CSV
+func runPII(ctx context.Context, cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("pii", flag.ContinueOnError)
+	format := fs.String("format", "table", "output format: table or csv")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq pii [--format table|csv] [spec]\n\n")
+		fs.PrintDefaults()
+	}
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) > 1 || (*format != "table" && *format != "csv") {
+		fs.Usage()
+		return 2
+	}
+
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	}
+	_, doc, err := loadSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+
+	findings := scanPII(doc, extractEndpoints(doc), piiTermsFromConfig(cfg))
+	if *format == "csv" {
+		if err := writePIICSV(os.Stdout, findings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if len(findings) == 0 {
+		fmt.Println("No likely PII found")
+		return 0
+	}
+	writePIITable(os.Stdout, findings)
+	return 0
+}
+
+// piiPane lists the findings of the PII scan with the operations behind the selected one
+type piiPane struct {
+	findings []*piiFinding
+	cursor   int
+}
+
+// openPIIFindings scans the listed endpoints and the component schemas
+func (m *Model) openPIIFindings() {
+	findings := scanPII(m.doc, m.getActiveEndpoints(), m.piiTerms)
+	if len(findings) == 0 {
+		m.setStatus("No likely PII found", false)
+		return
+	}
+	m.pii = &piiPane{findings: findings}
+}
+
+// updatePII handles keys while the PII findings are open
+func (m *Model) updatePII(key string) {
+	pane := m.pii
+	page := max(1, m.height/2)
+	switch key {
+	case "esc", "q", "P":
+		m.pii = nil
+	case "up", "k":
+		pane.cursor = max(0, pane.cursor-1)
+	case "down", "j":
+		pane.cursor = min(len(pane.findings)-1, pane.cursor+1)
+	case "ctrl+u":
+		pane.cursor = max(0, pane.cursor-page)
+	case "ctrl+d":
+		pane.cursor = min(len(pane.findings)-1, pane.cursor+page)
+	case "g":
+		pane.cursor = 0
+	case "G":
+		pane.cursor = len(pane.findings) - 1
+	case "w":
+		path := m.exportPath("pii.csv")
+		f, err := os.Create(path)
+		if err == nil {
+			err = writePIICSV(f, pane.findings)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			m.setStatus(fmt.Sprintf("Error exporting PII findings: %v", err), true)
+			return
+		}
+		m.setStatus(fmt.Sprintf("Exported the PII findings to %s", path), false)
+	}
+}
+
+func (m Model) renderPIIPane() string {
+	pane := m.pii
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	detailStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorDetailGray))
+
+	severityColors := map[string]string{"high": colorRed, "medium": colorYellow, "low": colorGray}
+
+	// The operations of the selected finding are listed below the findings
+	selected := pane.findings[pane.cursor]
+	var usage []string
+	for _, op := range selected.requests {
+		usage = append(usage, "  sent by "+op)
+	}
+	for _, op := range selected.responses {
+		usage = append(usage, "  returned by "+op)
+	}
+	usage = usage[:min(len(usage), 6)]
+	if hidden := len(selected.requests) + len(selected.responses) - len(usage); hidden > 0 {
+		usage = append(usage, fmt.Sprintf("  and %d more", hidden))
+	}
+
+	schemaWidth := min(max(16, m.width/4), 40)
+	propertyWidth := min(max(16, m.width/4), 40)
+	bodyHeight := max(1, m.height-8-len(usage))
+	start := max(0, min(pane.cursor-bodyHeight+1, len(pane.findings)-bodyHeight))
+	var lines []string
+	for i := start; i < len(pane.findings) && i < start+bodyHeight; i++ {
+		f := pane.findings[i]
+		background := lipgloss.NewStyle()
+		if i == pane.cursor {
+			background = highlight(background)
+		}
+		line := background.Foreground(lipgloss.Color(severityColors[f.severity()])).Bold(true).Width(8).Render(f.severity())
+		line += background.Width(schemaWidth).MaxWidth(schemaWidth).Render(f.schema) + background.Render("  ")
+		line += background.Width(propertyWidth).MaxWidth(propertyWidth).Render(f.property) + background.Render("  ")
+		line += background.Foreground(lipgloss.Color(colorGray)).Render(f.category + " · " + f.reason)
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(m.width).Render(line))
+	}
+
+	title := titleStyle.Render(fmt.Sprintf("Likely PII (%d findings)", len(pane.findings)))
+	details := detailStyle.Render(strings.Join(usage, "\n"))
+	instruction := instructionStyle.Render("j/k move · w export CSV · Esc close")
+
+	return lipgloss.NewStyle().MaxHeight(m.height).Render(title + "\n\n" + strings.Join(lines, "\n") + "\n\n" + details + "\n\n" + instruction)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// componentPointer returns the JSON Pointer of a component, e.g. #/components/schemas/User.
+// Schemas of other files are named by their $ref, which points into that file
+func componentPointer(comp component) string {
+	if strings.Contains(comp.name, "#") {
+		return comp.name
+	}
+	for section, compType := range componentTypes {
+		if compType == comp.compType {
+			return "#/components/" + section + "/" + escapePointer(comp.name)
+		}
+	}
+	return ""
+}
+
+// tagPointer returns the JSON Pointer of a tag declared at the top level, "" for tags only
+// used on operations
+func (m *Model) tagPointer(name string) string {
+	for i, tag := range m.doc.Tags {
+		if tag != nil && tag.Name == name {
+			return "#/tags/" + strconv.Itoa(i)
+		}
+	}
+	return ""
+}
+
+// selectedPointer returns the JSON Pointer of the item under the cursor and what it is, for
+// overlays, patches and ruleset overrides that target it
+func (m *Model) selectedPointer() (string, string) {
+	switch m.mode {
+	case viewEndpoints:
+		if group, ok := m.selectedTagGroup(); ok {
+			return m.tagPointer(group.tag), "tag " + group.tag
+		}
+		if ep, ok := m.selectedEndpoint(); ok {
+			return ep.pointer, ep.method + " " + ep.path
+		}
+	case viewComponents:
+		if comps := m.getActiveComponents(); m.cursor >= 0 && m.cursor < len(comps) {
+			return componentPointer(comps[m.cursor]), comps[m.cursor].name
+		}
+	case viewWebhooks:
+		if hooks := m.getActiveWebhooks(); m.cursor >= 0 && m.cursor < len(hooks) {
+			return hooks[m.cursor].pointer, "webhook " + hooks[m.cursor].name
+		}
+	case viewTags:
+		if tags := m.getActiveTags(); m.cursor >= 0 && m.cursor < len(tags) {
+			return m.tagPointer(tags[m.cursor].name), "tag " + tags[m.cursor].name
+		}
+	}
+	return "", ""
+}
+
+// yankPointer copies the JSON Pointer of the item under the cursor
+func (m *Model) yankPointer() {
+	pointer, what := m.selectedPointer()
+	if what == "" {
+		return
+	}
+	if pointer == "" {
+		m.setStatus(fmt.Sprintf("%s isn't declared in the spec, so it has no pointer", what), true)
+		return
+	}
+	if err := m.copyText(pointer); err != nil {
+		m.setStatus(fmt.Sprintf("Error copying to the clipboard: %v", err), true)
+		return
+	}
+	m.setStatus("Copied "+pointer, false)
+}
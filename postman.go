@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// postmanCollection is a Postman v2.1 collection: a folder per tag holding its requests,
+// with the server and credentials as collection variables
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// postmanItem is a folder when it has items, a request otherwise
+type postmanItem struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Item        []postmanItem   `json:"item,omitempty"`
+	Request     *postmanRequest `json:"request,omitempty"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	Header []postmanKeyValue `json:"header"`
+	URL    postmanURL        `json:"url"`
+	Body   *postmanBody      `json:"body,omitempty"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path,omitempty"`
+	Query    []postmanKeyValue `json:"query,omitempty"`
+	Variable []postmanKeyValue `json:"variable,omitempty"`
+}
+
+type postmanKeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+type postmanBody struct {
+	Mode    string              `json:"mode"`
+	Raw     string              `json:"raw"`
+	Options *postmanBodyOptions `json:"options,omitempty"`
+}
+
+type postmanBodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+// buildPostmanCollection converts every operation into a request. Requests go to
+// {{baseUrl}}, the first server of the spec, unless their path or operation declares its
+// own servers. Credentials are sent as the security schemes require, from a variable named
+// after the scheme
+func buildPostmanCollection(doc *v3.Document, specPath string) postmanCollection {
+	collection := postmanCollection{Info: postmanInfo{Name: "API", Schema: postmanSchema}}
+	if doc.Info != nil {
+		if doc.Info.Title != "" {
+			collection.Info.Name = doc.Info.Title
+		}
+		collection.Info.Description = strings.TrimSpace(doc.Info.Description)
+	}
+
+	baseURL := "https://api.example.com"
+	if server := defaultServerURL(doc, specPath); server != "" {
+		baseURL = strings.TrimSuffix(server, "/")
+	}
+	collection.Variable = append(collection.Variable, postmanKeyValue{Key: "baseUrl", Value: baseURL})
+
+	eps := extractEndpoints(doc)
+	folders := map[string]*postmanItem{}
+	var untagged []postmanItem
+	schemes := map[string]bool{}
+	for _, ep := range eps {
+		item := postmanItem{Name: ep.method + " " + ep.path, Description: strings.TrimSpace(ep.op.Description)}
+		if ep.op.Summary != "" {
+			item.Name = ep.op.Summary
+		}
+		item.Request = buildPostmanRequest(doc, ep, specPath, schemes)
+
+		// Operations with several tags are listed under the first, so each is exported once
+		if len(ep.op.Tags) == 0 {
+			untagged = append(untagged, item)
+			continue
+		}
+		folder := folders[ep.op.Tags[0]]
+		if folder == nil {
+			folder = &postmanItem{Name: ep.op.Tags[0]}
+			folders[ep.op.Tags[0]] = folder
+		}
+		folder.Item = append(folder.Item, item)
+	}
+
+	tagDescriptions := map[string]string{}
+	for _, tag := range doc.Tags {
+		if tag != nil {
+			tagDescriptions[tag.Name] = strings.TrimSpace(tag.Description)
+		}
+	}
+	for _, tag := range specTags(doc, eps) {
+		if folder := folders[tag]; folder != nil {
+			folder.Description = tagDescriptions[tag]
+			collection.Item = append(collection.Item, *folder)
+		}
+	}
+	collection.Item = append(collection.Item, untagged...)
+
+	for _, name := range slices.Sorted(maps.Keys(schemes)) {
+		collection.Variable = append(collection.Variable, postmanKeyValue{Key: name, Value: "", Description: "Credential for the " + name + " security scheme"})
+	}
+	return collection
+}
+
+// buildPostmanRequest describes an operation as a Postman request, adding the security
+// schemes it uses to schemes
+func buildPostmanRequest(doc *v3.Document, ep endpoint, specPath string, schemes map[string]bool) *postmanRequest {
+	req := &postmanRequest{Method: ep.method, Header: []postmanKeyValue{}}
+
+	host := "{{baseUrl}}"
+	if server := operationServer(doc, ep); server != nil && !slices.Contains(doc.Servers, server) {
+		host = strings.TrimSuffix(resolveServerURL(server, nil, specPath), "/")
+	}
+	req.URL.Host = []string{host}
+
+	// Postman writes path parameters as :name
+	path := pathTemplateParam.ReplaceAllString(ep.path, ":$1")
+	req.URL.Path = slices.DeleteFunc(strings.Split(path, "/"), func(segment string) bool { return segment == "" })
+
+	for _, p := range declaredParameters(doc, ep) {
+		param := postmanKeyValue{Key: p.Name, Value: parameterExample(p), Description: strings.TrimSpace(p.Description), Disabled: p.Required == nil || !*p.Required}
+		switch p.In {
+		case "path":
+			param.Disabled = false
+			req.URL.Variable = append(req.URL.Variable, param)
+		case "query":
+			req.URL.Query = append(req.URL.Query, param)
+		case "header":
+			req.Header = append(req.Header, param)
+		}
+	}
+
+	if mediaType, body, ok := exampleRequestBody(doc, ep.op); ok {
+		req.Header = append(req.Header, postmanKeyValue{Key: "Content-Type", Value: mediaType})
+		req.Body = &postmanBody{Mode: "raw", Raw: body}
+		if isJSONMediaType(mediaType) {
+			req.Body.Options = &postmanBodyOptions{}
+			req.Body.Options.Raw.Language = "json"
+		}
+	}
+
+	for _, use := range operationCredentials(doc, ep.op) {
+		value := use.prefix + "{{" + use.scheme + "}}"
+		switch use.in {
+		case "header":
+			req.Header = append(req.Header, postmanKeyValue{Key: use.name, Value: value})
+		case "query":
+			req.URL.Query = append(req.URL.Query, postmanKeyValue{Key: use.name, Value: value})
+		case "cookie":
+			req.Header = append(req.Header, postmanKeyValue{Key: "Cookie", Value: use.name + "=" + value})
+		}
+		schemes[use.scheme] = true
+	}
+
+	req.URL.Raw = host + "/" + strings.Join(req.URL.Path, "/")
+	var query []string
+	for _, q := range req.URL.Query {
+		if !q.Disabled {
+			query = append(query, q.Key+"="+q.Value)
+		}
+	}
+	if len(query) > 0 {
+		req.URL.Raw += "?" + strings.Join(query, "&")
+	}
+	return req
+}
+
+// postmanSecurity returns the schemes of the first security requirement, none when the
+// operation doesn't require any
+func postmanSecurity(requirements []*base.SecurityRequirement) []string {
+	for _, requirement := range requirements {
+		if requirement == nil || requirement.Requirements == nil {
+			continue
+		}
+		var names []string
+		for pair := requirement.Requirements.First(); pair != nil; pair = pair.Next() {
+			names = append(names, pair.Key())
+		}
+		return names
+	}
+	return nil
+}
+
+// postmanEnvironment is a Postman environment, the values a collection's variables take
+type postmanEnvironment struct {
+	Name   string            `json:"name"`
+	Values []postmanEnvValue `json:"values"`
+	Scope  string            `json:"_postman_variable_scope"`
+}
+
+type postmanEnvValue struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+// buildPostmanEnvironment derives an environment from the collection's variables, so it runs
+// as soon as both are imported: the base URL of the first server and an empty secret per
+// security scheme to fill in
+func buildPostmanEnvironment(collection postmanCollection) postmanEnvironment {
+	env := postmanEnvironment{Name: collection.Info.Name, Values: []postmanEnvValue{}, Scope: "environment"}
+	for _, variable := range collection.Variable {
+		value := postmanEnvValue{Key: variable.Key, Value: variable.Value, Type: "default", Enabled: true}
+		if variable.Key != "baseUrl" {
+			value.Type = "secret"
+		}
+		env.Values = append(env.Values, value)
+	}
+	return env
+}
+
+// writePostmanJSON writes a collection or environment as indented JSON
+func writePostmanJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// querySegment is one step of a query path: a mapping key, a sequence index or [*] for
+// every child. A recursive segment also applies to everything below its input, as in ..name,
+// and a union such as [get,put] selects several keys
+type querySegment struct {
+	key       string
+	keys      []string
+	index     int
+	isIndex   bool
+	wildcard  bool
+	recursive bool
+}
+
+// parseQuery parses a jq-style path such as `paths[*].get`, `.info.title` or
+// `paths["/pets/{id}"].get.parameters[0]`. Keys may contain anything but dots and
+// brackets, others need quoting in brackets
+func parseQuery(query string) ([]querySegment, error) {
+	return parsePath(strings.TrimSpace(query), false)
+}
+
+// parseJSONPath parses the JSONPath subset rulesets use: the query syntax with a leading $,
+// .. for recursive descent and unions of keys, as in `$..parameters[*]` or `$.paths[*][get,put]`
+func parseJSONPath(path string) ([]querySegment, error) {
+	return parsePath(strings.TrimPrefix(strings.TrimSpace(path), "$"), true)
+}
+
+func parsePath(query string, allowRecursive bool) ([]querySegment, error) {
+	var segments []querySegment
+	recursive := false
+	add := func(segment querySegment) {
+		segment.recursive = recursive
+		recursive = false
+		segments = append(segments, segment)
+	}
+	for i := 0; i < len(query); {
+		switch query[i] {
+		case '.':
+			i++
+			if i < len(query) && query[i] == '.' {
+				if !allowRecursive || recursive {
+					return nil, fmt.Errorf("unexpected .. at %d", i)
+				}
+				recursive = true
+				i++
+			}
+		case '[':
+			end := strings.IndexByte(query[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] after %q", query[i:])
+			}
+			inner := strings.TrimSpace(query[i+1 : i+end])
+			if allowRecursive && strings.Contains(inner, ",") {
+				var keys []string
+				for _, key := range strings.Split(inner, ",") {
+					keys = append(keys, strings.Trim(strings.TrimSpace(key), `"'`))
+				}
+				add(querySegment{keys: keys})
+				i += end + 1
+				continue
+			}
+			if quote := inner[:min(1, len(inner))]; quote == `"` || quote == "'" {
+				// A quoted key may contain ], find the closing quote instead
+				closing := strings.Index(query[i+2:], quote+"]")
+				if closing < 0 {
+					return nil, fmt.Errorf("missing %s] after %q", quote, query[i:])
+				}
+				add(querySegment{key: query[i+2 : i+2+closing]})
+				i += 2 + closing + 2
+				continue
+			}
+			segment, err := bracketSegment(inner)
+			if err != nil {
+				return nil, err
+			}
+			add(segment)
+			i += end + 1
+		default:
+			end := strings.IndexAny(query[i:], ".[")
+			if end < 0 {
+				end = len(query) - i
+			}
+			key := query[i : i+end]
+			if key == "*" {
+				add(querySegment{wildcard: true})
+			} else {
+				add(querySegment{key: key})
+			}
+			i += end
+		}
+	}
+	if recursive {
+		return nil, fmt.Errorf("missing key after ..")
+	}
+	return segments, nil
+}
+
+func bracketSegment(inner string) (querySegment, error) {
+	if inner == "*" || inner == "" {
+		return querySegment{wildcard: true}, nil
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return querySegment{}, fmt.Errorf("expected [*], [number] or [\"key\"], got [%s]", inner)
+	}
+	return querySegment{index: index, isIndex: true}, nil
+}
+
+// queryMatch is a node selected by a query with the keys and indexes leading to it. keyNode
+// is set when the node is a mapping value
+type queryMatch struct {
+	node    *yaml.Node
+	keyNode *yaml.Node
+	path    []string
+}
+
+// key is the mapping key or sequence index the node was reached by
+func (qm queryMatch) key() string {
+	if len(qm.path) == 0 {
+		return ""
+	}
+	return qm.path[len(qm.path)-1]
+}
+
+// child returns the match for a node below qm, copying the path so siblings don't share it
+func (qm queryMatch) child(node *yaml.Node, key string) queryMatch {
+	path := make([]string, len(qm.path), len(qm.path)+1)
+	copy(path, qm.path)
+	return queryMatch{node: node, path: append(path, key)}
+}
+
+// value returns the match for the mapping value at index i of node
+func (qm queryMatch) value(node *yaml.Node, i int) queryMatch {
+	match := qm.child(node.Content[i], node.Content[i-1].Value)
+	match.keyNode = node.Content[i-1]
+	return match
+}
+
+// evalQuery returns the nodes the query path selects below root. Missing keys select nothing
+func evalQuery(root *yaml.Node, segments []querySegment) []*yaml.Node {
+	var nodes []*yaml.Node
+	for _, match := range evalQueryMatches(root, segments) {
+		nodes = append(nodes, match.node)
+	}
+	return nodes
+}
+
+// evalQueryMatches is evalQuery keeping the path of every selected node
+func evalQueryMatches(root *yaml.Node, segments []querySegment) []queryMatch {
+	matches := []queryMatch{{node: root}}
+	for _, segment := range segments {
+		if segment.recursive {
+			var expanded []queryMatch
+			for _, match := range matches {
+				expanded = appendDescendants(expanded, match)
+			}
+			matches = expanded
+		}
+		var next []queryMatch
+		for _, match := range matches {
+			next = append(next, applySegment(match, segment)...)
+		}
+		matches = next
+	}
+	return matches
+}
+
+func applySegment(match queryMatch, segment querySegment) []queryMatch {
+	node := match.node
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	var next []queryMatch
+	switch {
+	case segment.wildcard && node.Kind == yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			next = append(next, match.value(node, i))
+		}
+	case segment.wildcard && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			next = append(next, match.child(item, strconv.Itoa(i)))
+		}
+	case segment.keys != nil:
+		for _, key := range segment.keys {
+			next = append(next, applySegment(match, querySegment{key: key})...)
+		}
+	case node.Kind == yaml.MappingNode && !segment.isIndex:
+		for i := 1; i < len(node.Content); i += 2 {
+			if node.Content[i-1].Value == segment.key {
+				next = append(next, match.value(node, i))
+				break
+			}
+		}
+	case node.Kind == yaml.SequenceNode:
+		index, isIndex := segment.index, segment.isIndex
+		if !isIndex {
+			// tags.0.name works like tags[0].name
+			n, err := strconv.Atoi(segment.key)
+			index, isIndex = n, err == nil
+		}
+		if index < 0 {
+			index += len(node.Content)
+		}
+		if isIndex && index >= 0 && index < len(node.Content) {
+			next = append(next, match.child(node.Content[index], strconv.Itoa(index)))
+		}
+	}
+	return next
+}
+
+// appendDescendants appends match and every node below it, depth first
+func appendDescendants(matches []queryMatch, match queryMatch) []queryMatch {
+	matches = append(matches, match)
+	node := match.node
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			matches = appendDescendants(matches, match.value(node, i))
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			matches = appendDescendants(matches, match.child(item, strconv.Itoa(i)))
+		}
+	}
+	return matches
+}
+
+// writeQueryResult prints scalars as plain text, so they can be used in scripts directly,
+// and everything else as indented JSON
+func writeQueryResult(w io.Writer, node *yaml.Node) error {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yaml.ScalarNode {
+		_, err := fmt.Fprintln(w, node.Value)
+		return err
+	}
+	out, err := marshalFragmentJSON(node)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// runQuery implements `oq spec.yaml --query <path>`. The path is applied to the spec as
+// written, before Swagger 2.0 conversion or reference resolution
+func runQuery(ctx context.Context, path, query string) int {
+	segments, err := parseQuery(query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --query: %v\n", err)
+		return 2
+	}
+
+	content, err := readSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing spec: %v\n", err)
+		return 1
+	}
+	if len(doc.Content) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: spec is empty\n")
+		return 1
+	}
+
+	results := evalQuery(doc.Content[0], segments)
+	if len(results) == 0 {
+		fmt.Fprintf(os.Stderr, "No matches for %s\n", query)
+		return 1
+	}
+	for _, node := range results {
+		if err := writeQueryResult(os.Stdout, node); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing result: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// listKinds are the values --list accepts
+var listKinds = []string{"endpoints", "components", "webhooks", "tags"}
+
+// runListKind implements `oq spec.yaml --list <kind>`
+func runListKind(ctx context.Context, path, kind string) int {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if !slices.Contains(listKinds, kind) {
+		fmt.Fprintf(os.Stderr, "Invalid --list: expected one of %s\n", strings.Join(listKinds, ", "))
+		return 2
+	}
+
+	_, doc, err := loadSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+	writeListKind(os.Stdout, doc, kind)
+	return 0
+}
+
+func writeListKind(w io.Writer, doc *v3.Document, kind string) {
+	switch kind {
+	case "endpoints":
+		writeEndpointList(w, extractEndpoints(doc))
+	case "components":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, c := range extractComponents(doc) {
+			fmt.Fprintf(tw, "%s\t%s\n", c.compType, c.name)
+		}
+		tw.Flush()
+	case "webhooks":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, hook := range extractWebhooks(doc) {
+			fmt.Fprintf(tw, "%s\t%s\n", hook.method, hook.name)
+		}
+		tw.Flush()
+	case "tags":
+		for _, tag := range specTags(doc, extractEndpoints(doc)) {
+			fmt.Fprintln(w, tag)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"go.yaml.in/yaml/v4"
+)
+
+// rateLimitExtensions document how many requests an operation, or the whole API, accepts
+var rateLimitExtensions = []string{"x-ratelimit", "x-rate-limit", "x-ratelimit-limit", "x-rate-limits"}
+
+// defaultRetries is how many times `oq compare` retries a request answered with 429
+const defaultRetries = 3
+
+// maxRetryAfter is the longest Retry-After waited for, longer ones fail the request
+const maxRetryAfter = 5 * time.Minute
+
+// rateLimit is a number of requests per window, such as 100 per minute
+type rateLimit struct {
+	requests int
+	window   time.Duration
+}
+
+func (r rateLimit) empty() bool {
+	return r.requests <= 0 || r.window <= 0
+}
+
+// interval is the time between two requests that keeps within the limit
+func (r rateLimit) interval() time.Duration {
+	return r.window / time.Duration(r.requests)
+}
+
+// String renders the limit as in 100 per minute or 3 per 30s
+func (r rateLimit) String() string {
+	for _, unit := range []string{"second", "minute", "hour", "day"} {
+		if rateWindows[unit] == r.window {
+			return fmt.Sprintf("%d per %s", r.requests, unit)
+		}
+	}
+	return fmt.Sprintf("%d per %s", r.requests, r.window)
+}
+
+// rateWindows are the units a rate can be given per, as in 100/min
+var rateWindows = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hour": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour,
+}
+
+// parseRate reads a rate such as 10/s, 100/min, 5000/hour or 100/30s
+func parseRate(s string) (rateLimit, bool) {
+	count, per, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return rateLimit{}, false
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil {
+		return rateLimit{}, false
+	}
+	window, ok := parseRateWindow(per)
+	limit := rateLimit{requests: requests, window: window}
+	return limit, ok && !limit.empty()
+}
+
+// parseRateWindow reads a window as a unit, a duration such as 30s or a number of seconds
+func parseRateWindow(s string) (time.Duration, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if window, ok := rateWindows[strings.TrimSuffix(s, "s")]; ok {
+		return window, true
+	}
+	if window, ok := rateWindows[s]; ok {
+		return window, true
+	}
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), seconds > 0
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil && d > 0
+}
+
+// parseRateLimit reads a rate such as 100/min, or a mapping of limit and window keys as in
+// {limit: 100, window: 1m}. A window without a unit is in seconds
+func parseRateLimit(node *yaml.Node) rateLimit {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		limit, _ := parseRate(node.Value)
+		return limit
+	case yaml.MappingNode:
+		var limit rateLimit
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := strings.ToLower(node.Content[i].Value), node.Content[i+1].Value
+			switch key {
+			case "limit", "requests", "max", "rate", "count":
+				if rate, ok := parseRate(value); ok {
+					return rate
+				}
+				limit.requests, _ = strconv.Atoi(value)
+			case "window", "period", "interval", "per", "duration", "unit":
+				limit.window, _ = parseRateWindow(value)
+			}
+		}
+		if limit.window == 0 && limit.requests > 0 {
+			limit.window = time.Second
+		}
+		return limit
+	}
+	return rateLimit{}
+}
+
+// findRateLimit returns the first rate limit documented in the extensions
+func findRateLimit(extensions *orderedmap.Map[string, *yaml.Node]) rateLimit {
+	if extensions == nil {
+		return rateLimit{}
+	}
+	for _, name := range rateLimitExtensions {
+		if node := extensions.GetOrZero(name); node != nil {
+			if limit := parseRateLimit(node); !limit.empty() {
+				return limit
+			}
+		}
+	}
+	return rateLimit{}
+}
+
+// ratePacer spaces the requests of a batch run so they keep within the documented rate
+// limits, and pauses a server that answers 429 Too Many Requests for as long as it asks
+type ratePacer struct {
+	mu      sync.Mutex
+	global  rateLimit
+	retries int
+	// next is when the next request may go out, by server for the limit of the whole API and
+	// by server and operation for their own. paused is when a server that answered 429 may
+	// be sent to again
+	next   map[string]time.Time
+	paused map[string]time.Time
+}
+
+// documentRateLimit is the limit documented for the whole spec, at the top level or in info
+func documentRateLimit(doc *v3.Document) rateLimit {
+	rate := findRateLimit(doc.Extensions)
+	if rate.empty() && doc.Info != nil {
+		rate = findRateLimit(doc.Info.Extensions)
+	}
+	return rate
+}
+
+// newRatePacer paces by the limit documented for the whole spec, or rate when it is set
+func newRatePacer(doc *v3.Document, rate rateLimit, retries int) *ratePacer {
+	if rate.empty() {
+		rate = documentRateLimit(doc)
+	}
+	return &ratePacer{global: rate, retries: retries, next: map[string]time.Time{}, paused: map[string]time.Time{}}
+}
+
+// wait blocks until ep may be sent to server, booking the slot it is sent in. A nil pacer
+// doesn't wait
+func (p *ratePacer) wait(ctx context.Context, server string, ep endpoint) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	at := time.Now()
+	if paused := p.paused[server]; paused.After(at) {
+		at = paused
+	}
+	limits := map[string]rateLimit{server: p.global, server + " " + ep.method + " " + ep.path: findRateLimit(ep.op.Extensions)}
+	for key, limit := range limits {
+		if next := p.next[key]; !limit.empty() && next.After(at) {
+			at = next
+		}
+	}
+	for key, limit := range limits {
+		if !limit.empty() {
+			p.next[key] = at.Add(limit.interval())
+		}
+	}
+	p.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backoff pauses a server that answered 429, or 503 with Retry-After, for as long as
+// Retry-After says or else 1, 2, 4... seconds. It reports whether the request is retried
+func (p *ratePacer) backoff(server string, attempt int, result *runResult, now time.Time) bool {
+	if p == nil || attempt >= p.retries {
+		return false
+	}
+	delay, ok := retryAfter(result.headers.Get("Retry-After"), now)
+	switch {
+	case result.code == http.StatusServiceUnavailable && !ok:
+		return false
+	case result.code != http.StatusTooManyRequests && result.code != http.StatusServiceUnavailable:
+		return false
+	case !ok:
+		delay = time.Second << attempt
+	case delay > maxRetryAfter:
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if until := now.Add(delay); until.After(p.paused[server]) {
+		p.paused[server] = until
+	}
+	debugLog.Info("backing off", "server", server, "status", result.code, "delay", delay)
+	return true
+}
+
+// retryAfter reads a Retry-After header, in seconds or as an HTTP date
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(0, at.Sub(now)), true
+	}
+	return 0, false
+}
+
+// retriesLabel is how often a request was retried, e.g. ", retried twice"
+func retriesLabel(retries int) string {
+	switch retries {
+	case 0:
+		return ""
+	case 1:
+		return ", retried once"
+	case 2:
+		return ", retried twice"
+	}
+	return fmt.Sprintf(", retried %d times", retries)
+}
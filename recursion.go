@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// schemaEdge is a $ref from a component schema to another, with the path within the schema
+// where it is made, e.g. "children[]"
+type schemaEdge struct {
+	to, via string
+}
+
+// schemaCycles finds the component schemas that refer back to themselves, directly or through
+// other schemas, and describes the shortest cycle of each, e.g. "Node.children[] → Node".
+// Many code generators can't handle such schemas
+func schemaCycles(doc *v3.Document) map[string]string {
+	if doc.Components == nil || doc.Components.Schemas == nil {
+		return nil
+	}
+	edges := map[string][]schemaEdge{}
+	for pair := doc.Components.Schemas.First(); pair != nil; pair = pair.Next() {
+		var out []schemaEdge
+		if pair.Value() != nil && pair.Value().IsReference() {
+			if name, ok := componentSchemaName(pair.Value().GetReference()); ok {
+				out = append(out, schemaEdge{to: name})
+			}
+		} else {
+			collectSchemaEdges(pair.Value(), "", &out, 0)
+		}
+		edges[pair.Key()] = out
+	}
+
+	cycles := map[string]string{}
+	for pair := doc.Components.Schemas.First(); pair != nil; pair = pair.Next() {
+		if cycle := shortestCycle(pair.Key(), edges); cycle != "" {
+			cycles[pair.Key()] = cycle
+		}
+	}
+	return cycles
+}
+
+// collectSchemaEdges records the component schemas proxy refers to, without following the
+// refs themselves
+func collectSchemaEdges(proxy *base.SchemaProxy, via string, edges *[]schemaEdge, depth int) {
+	if proxy == nil || depth > maxSchemaDepth {
+		return
+	}
+	s := proxy.Schema()
+	if s == nil {
+		return
+	}
+
+	labels := map[*base.SchemaProxy]string{}
+	if s.Properties != nil {
+		for pair := s.Properties.First(); pair != nil; pair = pair.Next() {
+			labels[pair.Value()] = pair.Key()
+		}
+	}
+	if s.Items != nil && s.Items.IsA() {
+		labels[s.Items.A] = "[]"
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.IsA() {
+		labels[s.AdditionalProperties.A] = "{}"
+	}
+
+	for _, child := range schemaChildren(s) {
+		if child == nil {
+			continue
+		}
+		path := via
+		switch label := labels[child]; {
+		case label == "[]" || label == "{}":
+			path += label
+		case label != "" && path != "":
+			path += "." + label
+		case label != "":
+			path = label
+		}
+		if child.IsReference() {
+			if name, ok := componentSchemaName(child.GetReference()); ok {
+				*edges = append(*edges, schemaEdge{to: name, via: path})
+				continue
+			}
+		}
+		collectSchemaEdges(child, path, edges, depth+1)
+	}
+}
+
+// shortestCycle searches breadth first for the shortest way from start back to itself
+func shortestCycle(start string, edges map[string][]schemaEdge) string {
+	type step struct {
+		from string
+		edge schemaEdge
+	}
+	reached := map[string]step{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, edge := range edges[from] {
+			if edge.to == start {
+				hops := []step{{from, edge}}
+				for node := from; node != start; node = reached[node].from {
+					hops = append([]step{reached[node]}, hops...)
+				}
+				var cycle strings.Builder
+				for _, hop := range hops {
+					cycle.WriteString(hop.from)
+					switch via := hop.edge.via; {
+					case strings.HasPrefix(via, "[]"), strings.HasPrefix(via, "{}"):
+						cycle.WriteString(via)
+					case via != "":
+						cycle.WriteString("." + via)
+					}
+					cycle.WriteString(" → ")
+				}
+				cycle.WriteString(start)
+				return cycle.String()
+			}
+			if _, seen := reached[edge.to]; !seen && edge.to != start {
+				reached[edge.to] = step{from, edge}
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+	return ""
+}
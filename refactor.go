@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// Keywords below which a schema holds sub-schemas, by how they are nested
+var (
+	schemaMapKeywords    = []string{"properties", "patternProperties", "dependentSchemas", "$defs", "definitions"}
+	schemaListKeywords   = []string{"allOf", "oneOf", "anyOf", "prefixItems"}
+	schemaSingleKeywords = []string{
+		"items", "additionalItems", "additionalProperties", "unevaluatedItems", "unevaluatedProperties",
+		"not", "contains", "if", "then", "else", "propertyNames", "contentSchema",
+	}
+)
+
+// extractableKeywords make an inline schema worth its own component. Plain `type: string`
+// schemas repeat everywhere and are left alone
+var extractableKeywords = []string{"properties", "allOf", "oneOf", "anyOf", "enum"}
+
+// inlineSchema is an inline schema found while walking the spec
+type inlineSchema struct {
+	node    *yaml.Node
+	pointer string
+	hint    string
+}
+
+// extraction is one change made by extractInlineSchemas
+type extraction struct {
+	name     string
+	reused   bool
+	pointers []string
+}
+
+func (e extraction) String() string {
+	if e.reused {
+		copies := "copies"
+		if len(e.pointers) == 1 {
+			copies = "copy"
+		}
+		return fmt.Sprintf("Replaced %d inline %s of %s with a $ref", len(e.pointers), copies, e.name)
+	}
+	return fmt.Sprintf("Extracted %s from %d inline schemas", e.name, len(e.pointers))
+}
+
+// extractInlineSchemas promotes inline schemas that appear more than once to components and
+// replaces inline copies of existing components with $refs. root is modified in place
+func extractInlineSchemas(root *yaml.Node) []extraction {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var changes []extraction
+	for {
+		schemas := mappingValue(mappingValue(root, "components"), "schemas")
+		named := map[string]string{}
+		if schemas != nil {
+			for i := 0; i+1 < len(schemas.Content); i += 2 {
+				key := canonicalNode(schemas.Content[i+1])
+				if _, ok := named[key]; !ok {
+					named[key] = schemas.Content[i].Value
+				}
+			}
+		}
+
+		groups := map[string][]inlineSchema{}
+		walkSpec(root, "", "", func(s inlineSchema) {
+			if isExtractable(s.node) {
+				key := canonicalNode(s.node)
+				groups[key] = append(groups[key], s)
+			}
+		})
+
+		// The largest schema goes first, so an outer schema is extracted before the
+		// schemas nested in it
+		best := ""
+		for key, occurrences := range groups {
+			_, exists := named[key]
+			if (exists || len(occurrences) > 1) && (len(key) > len(best) || (len(key) == len(best) && key < best)) {
+				best = key
+			}
+		}
+		if best == "" {
+			return changes
+		}
+
+		occurrences := groups[best]
+		change := extraction{}
+		if name, ok := named[best]; ok {
+			change.name, change.reused = name, true
+		} else {
+			if schemas == nil {
+				components := mappingValue(root, "components")
+				if components == nil {
+					components = newMapping()
+					setMapping(root, "components", components)
+				}
+				schemas = newMapping()
+				setMapping(components, "schemas", schemas)
+			}
+			change.name = uniqueSchemaName(schemas, occurrences)
+			component := *occurrences[0].node
+			setMapping(schemas, change.name, &component)
+		}
+
+		for _, s := range occurrences {
+			*s.node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "$ref"},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: componentSchemaPrefix + change.name},
+			}}
+			change.pointers = append(change.pointers, s.pointer)
+		}
+		changes = append(changes, change)
+	}
+}
+
+// operationMethods are the keys of the operations of a path item
+var operationMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace", "query"}
+
+// walkSpec visits the inline schemas of a spec: under `schema` keys of parameters, media
+// types and headers, and below the named component schemas. hint is a name suggestion
+// taken from the operation, parameter or property the schema belongs to, so bodies are
+// named as the details show them, e.g. CreateUserRequestBody
+func walkSpec(node *yaml.Node, pointer, hint string, visit func(inlineSchema)) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			walkSpec(item, pointer+"/"+strconv.Itoa(i), hint, visit)
+		}
+	case yaml.MappingNode:
+		if id := mappingValue(node, "operationId"); id != nil && id.Kind == yaml.ScalarNode {
+			hint = id.Value
+		}
+		if name, in := mappingValue(node, "name"), mappingValue(node, "in"); name != nil && in != nil {
+			hint = name.Value
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			child := pointer + "/" + escapePointer(key)
+			switch {
+			case strings.HasPrefix(key, "x-") || key == "example" || key == "examples":
+				continue
+			case pointer == "/components" && key == "schemas" && value.Kind == yaml.MappingNode:
+				for j := 0; j+1 < len(value.Content); j += 2 {
+					name := value.Content[j].Value
+					walkSchemaChildren(value.Content[j+1], child+"/"+escapePointer(name), name, visit)
+				}
+			case key == "schema":
+				walkSchema(value, child, hint, visit)
+			case pointer == "/paths":
+				// The path names the operations below it that have no operationId
+				walkSpec(value, child, key, visit)
+			case strings.HasPrefix(pointer, "/paths/") && strings.Count(pointer, "/") == 2 && slices.Contains(operationMethods, key):
+				walkSpec(value, child, operationIDFor(key, hint), visit)
+			case key == "requestBody":
+				walkSpec(value, child, hint+"RequestBody", visit)
+			case strings.HasSuffix(pointer, "/responses"):
+				walkSpec(value, child, hint+"Response"+pascalCase(key), visit)
+			default:
+				walkSpec(value, child, hint, visit)
+			}
+		}
+	}
+}
+
+func walkSchema(node *yaml.Node, pointer, hint string, visit func(inlineSchema)) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	visit(inlineSchema{node: node, pointer: pointer, hint: hint})
+	walkSchemaChildren(node, pointer, hint, visit)
+}
+
+func walkSchemaChildren(node *yaml.Node, pointer, hint string, visit func(inlineSchema)) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		child := pointer + "/" + escapePointer(key)
+		switch {
+		case slices.Contains(schemaMapKeywords, key) && value.Kind == yaml.MappingNode:
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				name := value.Content[j].Value
+				walkSchema(value.Content[j+1], child+"/"+escapePointer(name), name, visit)
+			}
+		case slices.Contains(schemaListKeywords, key) && value.Kind == yaml.SequenceNode:
+			for j, item := range value.Content {
+				walkSchema(item, child+"/"+strconv.Itoa(j), hint, visit)
+			}
+		case key == "items":
+			walkSchema(value, child, hint+"Item", visit)
+		case slices.Contains(schemaSingleKeywords, key):
+			walkSchema(value, child, hint, visit)
+		}
+	}
+}
+
+func isExtractable(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode || mappingValue(node, "$ref") != nil {
+		return false
+	}
+	return slices.ContainsFunc(extractableKeywords, func(key string) bool { return mappingValue(node, key) != nil })
+}
+
+// canonicalNode serializes node with sorted keys, so schemas that only differ in key order
+// or formatting compare equal
+func canonicalNode(node *yaml.Node) string {
+	var b strings.Builder
+	writeCanonical(&b, node)
+	return b.String()
+}
+
+func writeCanonical(b *strings.Builder, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			writeCanonical(b, child)
+		}
+	case yaml.AliasNode:
+		writeCanonical(b, node.Alias)
+	case yaml.ScalarNode:
+		b.WriteString(node.ShortTag())
+		b.WriteString(strconv.Quote(node.Value))
+	case yaml.SequenceNode:
+		b.WriteByte('[')
+		for _, child := range node.Content {
+			writeCanonical(b, child)
+			b.WriteByte(',')
+		}
+		b.WriteByte(']')
+	case yaml.MappingNode:
+		type pair struct{ key, value string }
+		pairs := make([]pair, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			var value strings.Builder
+			writeCanonical(&value, node.Content[i+1])
+			pairs = append(pairs, pair{node.Content[i].Value, value.String()})
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i].key < pairs[j].key })
+		b.WriteByte('{')
+		for _, p := range pairs {
+			b.WriteString(strconv.Quote(p.key))
+			b.WriteByte(':')
+			b.WriteString(p.value)
+			b.WriteByte(',')
+		}
+		b.WriteByte('}')
+	}
+}
+
+// uniqueSchemaName names a new component after the schema title or the first usable hint,
+// adding a number when the name is taken
+func uniqueSchemaName(schemas *yaml.Node, occurrences []inlineSchema) string {
+	base := ""
+	if title := mappingValue(occurrences[0].node, "title"); title != nil {
+		base = pascalCase(title.Value)
+	}
+	for _, s := range occurrences {
+		if base != "" {
+			break
+		}
+		base = pascalCase(s.hint)
+	}
+	if base == "" {
+		base = "InlineSchema"
+	}
+
+	name := base
+	for n := 2; mappingValue(schemas, name) != nil; n++ {
+		name = base + strconv.Itoa(n)
+	}
+	return name
+}
+
+// pascalCase turns "shipping_address" or "get-user" into ShippingAddress and GetUser
+func pascalCase(s string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	return b.String()
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// runRefactor implements `oq refactor <refactoring> [flags] [spec]`
+func runRefactor(ctx context.Context, args []string) int {
+	if len(args) == 0 || args[0] != "extract-inline-schemas" {
+		fmt.Fprintf(os.Stderr, "Usage: oq refactor extract-inline-schemas [flags] [spec]\n")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("extract-inline-schemas", flag.ContinueOnError)
+	output := fs.String("o", "", "file to write the rewritten spec to (default stdout)")
+	inPlace := fs.Bool("w", false, "rewrite the spec file in place")
+	dryRun := fs.Bool("dry-run", false, "only report what would change")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq refactor extract-inline-schemas [flags] [spec]\n\n")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		return 2
+	}
+	if len(positional) > 1 || (*inPlace && (len(positional) == 0 || *output != "")) {
+		fs.Usage()
+		return 2
+	}
+
+	var path string
+	if len(positional) > 0 {
+		path = positional[0]
+	}
+	// Loading the model first reports specs that aren't OpenAPI at all
+	content, _, err := loadSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing spec: %v\n", err)
+		return 1
+	}
+
+	changes := extractInlineSchemas(&root)
+	for _, change := range changes {
+		fmt.Fprintln(os.Stderr, change)
+		for _, pointer := range change.pointers {
+			fmt.Fprintf(os.Stderr, "  %s\n", pointer)
+		}
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(os.Stderr, "No repeated inline schemas found")
+	}
+	if *dryRun || (*inPlace && len(changes) == 0) {
+		return 0
+	}
+
+	var out []byte
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		out, err = marshalFragmentJSON(&root)
+	} else {
+		out, err = marshalFragment(&root)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing spec: %v\n", err)
+		return 1
+	}
+
+	target := *output
+	if *inPlace {
+		target = path
+	}
+	if target == "" {
+		os.Stdout.Write(out)
+		return 0
+	}
+	if err := os.WriteFile(target, out, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", target, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	pathpkg "path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pb33f/libopenapi/datamodel"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// resolveRefs is set by --resolve-refs. $refs to other files and URLs are then followed,
+// relative to the spec they appear in
+var resolveRefs bool
+
+// configureReferences enables external references on cfg when --resolve-refs is set. Relative
+// references resolve against the directory of the spec at path, the working directory for stdin
+func configureReferences(cfg *datamodel.DocumentConfiguration, path string) {
+	if !resolveRefs {
+		return
+	}
+	cfg.AllowFileReferences = true
+	cfg.AllowRemoteReferences = true
+
+	specHost := ""
+	if isSpecURL(path) {
+		if base, err := url.Parse(path); err == nil {
+			specHost = base.Host
+			base.Path = pathpkg.Dir(base.Path)
+			base.RawQuery, base.Fragment = "", ""
+			cfg.BaseURL = base
+		}
+	}
+	cfg.RemoteURLHandler = func(ref string) (*http.Response, error) {
+		return fetchReference(ref, specHost)
+	}
+	if cfg.BaseURL != nil {
+		return
+	}
+
+	base := "."
+	if path != "" {
+		base = filepath.Dir(path)
+		cfg.SpecFilePath = filepath.Base(path)
+	}
+	if abs, err := filepath.Abs(base); err == nil {
+		base = abs
+	}
+	cfg.BasePath = base
+}
+
+// fetchReference downloads a referenced document. The --header values and --auth credentials
+// are only sent to the host the spec came from, other hosts never see them
+func fetchReference(ref, specHost string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, ref, nil)
+	if err != nil {
+		return nil, err
+	}
+	if specHost != "" && req.URL.Host == specHost {
+		for name, values := range remote.headers {
+			req.Header[name] = values
+		}
+		if err := authorizeRequest(req); err != nil {
+			return nil, err
+		}
+	}
+	client := &http.Client{Timeout: remote.timeout}
+	return client.Do(req)
+}
+
+// errRefSkipped is what fetching a referenced document returns once the user skipped the rest
+var errRefSkipped = errors.New("skipped")
+
+// refProgress reports on the loading screen which referenced document is being fetched, out
+// of the ones the spec references, and lets the user skip the rest to continue with their
+// $refs unresolved
+type refProgress struct {
+	control *loadingControl
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu      sync.Mutex
+	total   int
+	fetched map[string]bool
+	skipped map[string]bool
+	// rebuilt is set when the model had to be built without following references
+	rebuilt bool
+}
+
+// newRefProgress returns the progress of resolving the references of content, or nil when
+// they aren't followed or there is no loading screen to report in
+func newRefProgress(ctx context.Context, content []byte, path string) *refProgress {
+	control := loadingFrom(ctx)
+	if !resolveRefs || control == nil {
+		return nil
+	}
+	p := &refProgress{control: control, fetched: map[string]bool{}, skipped: map[string]bool{}}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	var root yaml.Node
+	if yaml.Unmarshal(content, &root) == nil {
+		documents := map[string]bool{}
+		for _, ref := range externalRefs(&root) {
+			// Relative references of a spec fetched from a URL are fetched too
+			file, _, _ := strings.Cut(ref, "#")
+			if isSpecURL(file) || (isSpecURL(path) && !filepath.IsAbs(file)) {
+				documents[file] = true
+			}
+		}
+		p.total = len(documents)
+	}
+	return p
+}
+
+// watch reports the documents cfg fetches. Once skipped, fetches in flight are dropped and
+// the ones still to come fail with errRefSkipped
+func (p *refProgress) watch(cfg *datamodel.DocumentConfiguration) {
+	fetch := cfg.RemoteURLHandler
+	if p == nil || fetch == nil {
+		return
+	}
+	cfg.RemoteURLHandler = func(ref string) (*http.Response, error) {
+		document, _, _ := strings.Cut(ref, "#")
+		p.mu.Lock()
+		if p.ctx.Err() != nil {
+			p.skipped[document] = true
+			p.mu.Unlock()
+			return nil, errRefSkipped
+		}
+		if !p.fetched[document] {
+			p.fetched[document] = true
+			// Documents referenced from fetched ones only become known as they are fetched
+			p.control.step(fmt.Sprintf("Resolving references %d/%d: %s", len(p.fetched), max(p.total, len(p.fetched)), document))
+			if len(p.fetched) == 1 {
+				p.control.offer("to continue without the remaining references", p.skip)
+			}
+		}
+		p.mu.Unlock()
+
+		resp, err := runWithContext(p.ctx, func() (*http.Response, error) { return fetch(ref) })
+		if errors.Is(err, context.Canceled) && p.ctx.Err() != nil {
+			p.mu.Lock()
+			p.skipped[document] = true
+			p.mu.Unlock()
+			return nil, errRefSkipped
+		}
+		return resp, err
+	}
+}
+
+// skip stops fetching referenced documents, for the ones fetched so far to be used
+func (p *refProgress) skip() {
+	p.cancel()
+}
+
+// skippedAny reports whether a referenced document was skipped
+func (p *refProgress) skippedAny() bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.skipped) > 0
+}
+
+// done withdraws the offer to skip once the model is built, and adds the documents that were
+// skipped to err. The errors of their unresolved $refs follow
+func (p *refProgress) done(err error) error {
+	if p == nil {
+		return err
+	}
+	p.control.offer("", nil)
+	p.cancel()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.skipped) == 0 {
+		return err
+	}
+	debugLog.Info("skipped referenced documents", "skipped", len(p.skipped), "rebuilt", p.rebuilt)
+	skipped := fmt.Errorf("Error resolving references: %d referenced %s skipped, their $refs are left unresolved", len(p.skipped), plural(len(p.skipped), "document", "documents"))
+	if p.rebuilt {
+		skipped = errors.New("Error resolving references: referenced documents skipped, $refs to other files and URLs are left unresolved")
+	}
+	return errors.Join(skipped, err)
+}
+
+// isExternalRef reports whether ref points outside the spec document
+func isExternalRef(ref string) bool {
+	return ref != "" && !strings.HasPrefix(ref, "#")
+}
+
+// externalSchemas returns the schemas the spec references in other files or URLs, keyed by
+// their location relative to the spec
+func externalSchemas(doc *v3.Document) map[string]*base.SchemaProxy {
+	schemas := map[string]*base.SchemaProxy{}
+	// dir is the directory of the file proxy appears in, relative refs resolve against it
+	var walk func(proxy *base.SchemaProxy, dir string, depth int)
+	walk = func(proxy *base.SchemaProxy, dir string, depth int) {
+		if proxy == nil || depth > maxSchemaDepth {
+			return
+		}
+		if ref := proxy.GetReference(); isExternalRef(ref) {
+			location := externalRefLocation(ref, dir)
+			if _, seen := schemas[location]; seen {
+				return
+			}
+			schemas[location] = proxy
+			file, _, _ := strings.Cut(location, "#")
+			if i := strings.LastIndex(file, "/"); i >= 0 {
+				dir = file[:i]
+			} else {
+				dir = "."
+			}
+		}
+		s := proxy.Schema()
+		if s == nil {
+			return
+		}
+		for _, child := range schemaChildren(s) {
+			walk(child, dir, depth+1)
+		}
+	}
+
+	if doc.Components != nil && doc.Components.Schemas != nil {
+		for pair := doc.Components.Schemas.First(); pair != nil; pair = pair.Next() {
+			walk(pair.Value(), ".", 0)
+		}
+	}
+	for _, ep := range extractEndpoints(doc) {
+		for _, proxy := range operationSchemas(ep.op) {
+			walk(proxy, ".", 0)
+		}
+	}
+	return schemas
+}
+
+// externalRefLocation resolves a relative reference against dir, so the same file referenced
+// from different directories gets one name. URLs and absolute paths are kept
+func externalRefLocation(ref, dir string) string {
+	file, fragment, hasFragment := strings.Cut(ref, "#")
+	switch {
+	case file == "" || isRemoteSpec(file) || pathpkg.IsAbs(file):
+	case isRemoteSpec(dir):
+		if base, err := url.Parse(dir + "/"); err == nil {
+			if rel, err := url.Parse(file); err == nil {
+				file = base.ResolveReference(rel).String()
+			}
+		}
+	default:
+		file = pathpkg.Join(dir, file)
+	}
+	if hasFragment {
+		return file + "#" + fragment
+	}
+	return file
+}
+
+// externalSchemaComponents lists the resolved external schemas for the Components view
+func externalSchemaComponents(doc *v3.Document) []component {
+	schemas := externalSchemas(doc)
+	refs := make([]string, 0, len(schemas))
+	for ref := range schemas {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	var components []component
+	for _, ref := range refs {
+		s := schemas[ref].Schema()
+		if s == nil {
+			continue
+		}
+		components = append(components, component{
+			name:        ref,
+			compType:    "Schema",
+			description: s.Description,
+			details:     formatSchemaDetails(schemas[ref]),
+			folded:      true,
+		})
+	}
+	return components
+}
+
+// refFetchWorkers bounds how many referenced documents are fetched at once
+var refFetchWorkers = 8
+
+// refFetchAttempts is how often a referenced document is requested before giving up, after
+// failing to connect or an answer of 429 or 5xx
+const refFetchAttempts = 3
+
+// refRetryDelay is the first pause before fetching a document again, doubled on every retry
+// unless the server sent Retry-After
+var refRetryDelay = 250 * time.Millisecond
+
+// maxRefRetryAfter caps the Retry-After waited for, the build shouldn't stall on one document
+const maxRefRetryAfter = 10 * time.Second
+
+// fetchedRef is a referenced document as the server returned it
+type fetchedRef struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// refPrefetch fetches the remote documents a spec references ahead of libopenapi, which
+// follows them one by one, and serves them from memory once it asks
+type refPrefetch struct {
+	fetch func(string) (*http.Response, error)
+	mu    sync.Mutex
+	seen  map[string]bool
+	docs  map[string]*fetchedRef
+}
+
+// prefetchReferences fetches the remote documents content references, and the ones those
+// reference in turn, with up to refFetchWorkers at a time. It returns nil unless cfg follows
+// remote references. Documents that fail are left to libopenapi to report
+func prefetchReferences(ctx context.Context, cfg *datamodel.DocumentConfiguration, content []byte, path string) *refPrefetch {
+	if !cfg.AllowRemoteReferences || cfg.RemoteURLHandler == nil {
+		return nil
+	}
+	p := &refPrefetch{fetch: cfg.RemoteURLHandler, seen: map[string]bool{}, docs: map[string]*fetchedRef{}}
+	start := time.Now()
+	workers := make(chan struct{}, refFetchWorkers)
+	var wg sync.WaitGroup
+	var queue func(document string)
+	queue = func(document string) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.seen[document] {
+			return
+		}
+		p.seen[document] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case workers <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			doc, err := fetchWithRetries(ctx, p.fetch, document)
+			<-workers
+			if err != nil {
+				debugLog.Warn("prefetching a referenced document failed", "url", document, "error", err)
+				return
+			}
+			p.mu.Lock()
+			p.docs[document] = doc
+			p.mu.Unlock()
+			for _, ref := range remoteDocuments(doc.body, document) {
+				queue(ref)
+			}
+		}()
+	}
+	base := ""
+	if isSpecURL(path) {
+		base = path
+	}
+	for _, document := range remoteDocuments(content, base) {
+		queue(document)
+	}
+	wg.Wait()
+	debugLog.Debug("prefetched referenced documents", "documents", len(p.docs), "took", time.Since(start))
+	return p
+}
+
+// serve answers the fetches of cfg with the prefetched documents, fetching the others
+func (p *refPrefetch) serve(cfg *datamodel.DocumentConfiguration) {
+	if p == nil {
+		return
+	}
+	cfg.RemoteURLHandler = func(ref string) (*http.Response, error) {
+		document, _, _ := strings.Cut(ref, "#")
+		p.mu.Lock()
+		doc := p.docs[document]
+		p.mu.Unlock()
+		if doc == nil {
+			return p.fetch(ref)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", doc.status, http.StatusText(doc.status)),
+			StatusCode:    doc.status,
+			Header:        doc.header,
+			Body:          io.NopCloser(bytes.NewReader(doc.body)),
+			ContentLength: int64(len(doc.body)),
+		}, nil
+	}
+}
+
+// remoteDocuments lists the URLs of the documents content references, relative ones resolved
+// against base, the URL content came from. Relative references of a local spec are files
+func remoteDocuments(content []byte, base string) []string {
+	var root yaml.Node
+	if yaml.Unmarshal(content, &root) != nil {
+		return nil
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil
+	}
+	var documents []string
+	for _, ref := range externalRefs(&root) {
+		file, _, _ := strings.Cut(ref, "#")
+		if !isSpecURL(file) && base == "" {
+			continue
+		}
+		rel, err := url.Parse(file)
+		if err != nil {
+			continue
+		}
+		if document := baseURL.ResolveReference(rel).String(); !slices.Contains(documents, document) {
+			documents = append(documents, document)
+		}
+	}
+	return documents
+}
+
+// fetchWithRetries fetches a referenced document, retrying after failing to connect or an
+// answer of 429 or 5xx. A skipped document isn't retried
+func fetchWithRetries(ctx context.Context, fetch func(string) (*http.Response, error), document string) (*fetchedRef, error) {
+	delay := refRetryDelay
+	for attempt := 1; ; attempt++ {
+		resp, err := fetch(document)
+		if err == nil {
+			body, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+			if readErr == nil && !retryable {
+				return &fetchedRef{status: resp.StatusCode, header: resp.Header, body: body}, nil
+			}
+			err = readErr
+			if err == nil {
+				err = fmt.Errorf("%s answered %s", document, resp.Status)
+			}
+			if wait, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				delay = min(wait, maxRefRetryAfter)
+			}
+		}
+		if errors.Is(err, errRefSkipped) || attempt == refFetchAttempts {
+			return nil, err
+		}
+		debugLog.Debug("retrying a referenced document", "url", document, "attempt", attempt, "error", err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultRemoteTimeout bounds fetching a spec given as a URL
+const defaultRemoteTimeout = 30 * time.Second
+
+// maxRemoteSpecSize guards against endpoints that stream without end
+const maxRemoteSpecSize = 256 << 20
+
+// remoteOptions configure how specs given as URLs are fetched
+type remoteOptions struct {
+	timeout time.Duration
+	headers http.Header
+	// auth names the helper adding cloud credentials, see authHelpers
+	auth string
+}
+
+// remote is set from the command line and config before any spec is read
+var remote = remoteOptions{timeout: defaultRemoteTimeout}
+
+// isRemoteSpec reports whether the spec is fetched instead of read from the disk: a URL,
+// or an artifact in a registry or bucket
+func isRemoteSpec(path string) bool {
+	return isSpecURL(path) || isArtifactSpec(path)
+}
+
+// isSpecURL reports whether the spec is fetched over HTTP, so relative addresses resolve
+// against its URL
+func isSpecURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// fetchSpec downloads a spec, sending the configured headers, e.g. for gateways that need
+// authentication
+func fetchSpec(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, remote.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %w", url, err)
+	}
+	req.Header.Set("Accept", "application/json, application/yaml, text/yaml, */*")
+	for name, values := range remote.headers {
+		req.Header[name] = values
+	}
+	if err := authorizeRequest(req); err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %w", url, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("Error fetching %s: %s", url, resp.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSpecSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %w", url, err)
+	}
+	if len(content) > maxRemoteSpecSize {
+		return nil, fmt.Errorf("Error fetching %s: spec is larger than %s", url, formatBytes(maxRemoteSpecSize))
+	}
+	return content, nil
+}
+
+// headerFlags collects repeated --header "Name: value" flags. Values are expanded from the
+// environment, so tokens can stay out of the shell history
+type headerFlags http.Header
+
+func (h headerFlags) String() string {
+	var pairs []string
+	for name, values := range h {
+		for _, value := range values {
+			pairs = append(pairs, name+": "+value)
+		}
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (h headerFlags) Set(value string) error {
+	name, v, ok := strings.Cut(value, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("expected \"Name: value\", got %q", value)
+	}
+	http.Header(h).Add(name, os.ExpandEnv(strings.TrimSpace(v)))
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxHexDumpSize is how much of a binary body is shown as a hex dump
+const maxHexDumpSize = 512
+
+// isJSONMediaType reports whether mediaType is application/json or a +json type such as
+// application/problem+json
+func isJSONMediaType(mediaType string) bool {
+	mediaType = baseMediaType(mediaType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func isXMLMediaType(mediaType string) bool {
+	mediaType = baseMediaType(mediaType)
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// baseMediaType strips parameters such as charset from a Content-Type
+func baseMediaType(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+func jsonIndent(w *bytes.Buffer, data []byte) error {
+	return json.Indent(w, bytes.TrimSpace(data), "", "  ")
+}
+
+// formatResponseBody renders a response body for display based on its Content-Type: JSON
+// and XML are indented, text is shown as is, images are summarized and anything else is
+// hex dumped. Control characters are stripped, see stripControl
+func formatResponseBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return "(empty body)"
+	}
+	mediaType := baseMediaType(contentType)
+
+	switch {
+	case isJSONMediaType(mediaType):
+		var out bytes.Buffer
+		if err := jsonIndent(&out, body); err == nil {
+			return stripControl(out.String())
+		}
+	case isXMLMediaType(mediaType):
+		if out, err := indentXML(body); err == nil {
+			return stripControl(out)
+		}
+	case strings.HasPrefix(mediaType, "image/"):
+		if config, format, err := image.DecodeConfig(bytes.NewReader(body)); err == nil {
+			return fmt.Sprintf("%s image, %d×%d, %s", strings.ToUpper(format), config.Width, config.Height, formatBytes(uint64(len(body))))
+		}
+		return stripControl(fmt.Sprintf("%s image, %s", mediaType, formatBytes(uint64(len(body)))))
+	}
+
+	if isText(body) {
+		return stripControl(strings.TrimRight(string(body), "\n"))
+	}
+	dump := strings.TrimRight(hex.Dump(body[:min(len(body), maxHexDumpSize)]), "\n")
+	if len(body) > maxHexDumpSize {
+		dump += fmt.Sprintf("\n... %s more", formatBytes(uint64(len(body)-maxHexDumpSize)))
+	}
+	return dump
+}
+
+// stripControl drops the C0 and C1 control characters of s but newlines and tabs. A server
+// could otherwise send escape sequences that redraw the TUI or set the clipboard
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// isText reports whether body reads as text: valid UTF-8 without NUL bytes
+func isText(body []byte) bool {
+	return utf8.Valid(body) && !bytes.ContainsRune(body, 0)
+}
+
+// isBinaryBody reports whether a body of mediaType isn't meant to be read as text, going by
+// its media type, or else its content
+func isBinaryBody(mediaType string, body []byte) bool {
+	switch {
+	case isJSONMediaType(mediaType), isXMLMediaType(mediaType), strings.HasPrefix(mediaType, "text/"):
+		return false
+	case mediaType == "application/octet-stream", strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"), strings.HasPrefix(mediaType, "video/"):
+		return true
+	}
+	return !isText(body)
+}
+
+// indentXML re-indents an XML document token by token, keeping its content
+func indentXML(body []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	var out bytes.Buffer
+	encoder := xml.NewEncoder(&out)
+	encoder.Indent("", "  ")
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		// Whitespace between elements is replaced by the encoder's indentation
+		if data, ok := token.(xml.CharData); ok && len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	pngpkg "image/png"
+	"testing"
+)
+
+func TestFormatResponseBody(t *testing.T) {
+	var png bytes.Buffer
+	if err := pngpkg.Encode(&png, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{"application/problem+json; charset=utf-8", []byte(`{"a":[1]}`), "{\n  \"a\": [\n    1\n  ]\n}"},
+		{"application/xml", []byte(`<a><b>x</b></a>`), "<a>\n  <b>x</b>\n</a>"},
+		{"text/plain", []byte("hello\n"), "hello"},
+		{"text/plain", []byte("\x1b[31mred\x1b[0m\tx\r\n"), "[31mred[0m\tx"},
+		{"application/json", []byte("{\"a\":\"\x1b]52;c;aGk=\x07\"}"), `{"a":"]52;c;aGk="}`},
+		{"image/png", png.Bytes(), fmt.Sprintf("PNG image, 3×2, %d B", png.Len())},
+		{"application/octet-stream", []byte{0, 1, 2}, "00000000  00 01 02                                          |...|"},
+		{"application/json", nil, "(empty body)"},
+	}
+	for _, tt := range tests {
+		if got := formatResponseBody(tt.contentType, tt.body); got != tt.want {
+			t.Errorf("formatResponseBody(%q) = %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// responseExamples are the example bodies of one response status code, per media type
+type responseExamples struct {
+	code        string
+	description string
+	media       []mediaExamples
+}
+
+type mediaExamples struct {
+	mediaType string
+	examples  []namedExample
+}
+
+// namedExample is a declared example, or one generated from the schema when none is declared
+type namedExample struct {
+	label string
+	body  string
+}
+
+// responsesPane cycles through the status codes and media types of an operation's responses
+type responsesPane struct {
+	title     string
+	responses []responseExamples
+	code      int
+	media     int
+	scroll    int
+}
+
+// buildResponseExamples collects the declared examples of every response, in status code
+// order. A media type without declared examples gets one generated from its schema
+func buildResponseExamples(doc *v3.Document, op *v3.Operation) []responseExamples {
+	var responses []responseExamples
+	for _, code := range responseCodes(op) {
+		resp := op.Responses.Default
+		if code != "default" {
+			resp = op.Responses.Codes.GetOrZero(code)
+		}
+		if resp == nil {
+			continue
+		}
+		entry := responseExamples{code: code, description: strings.TrimSpace(resp.Description)}
+		if resp.Content != nil {
+			for pair := resp.Content.First(); pair != nil; pair = pair.Next() {
+				entry.media = append(entry.media, mediaExamples{
+					mediaType: pair.Key(),
+					examples:  declaredExamples(doc, pair.Key(), pair.Value()),
+				})
+			}
+		}
+		responses = append(responses, entry)
+	}
+	return responses
+}
+
+func declaredExamples(doc *v3.Document, mediaType string, media *v3.MediaType) []namedExample {
+	if media == nil {
+		return nil
+	}
+	var examples []namedExample
+	if media.Example != nil {
+		examples = append(examples, namedExample{label: "Example", body: formatExampleNode(mediaType, media.Example)})
+	}
+	if media.Examples != nil {
+		for pair := media.Examples.First(); pair != nil; pair = pair.Next() {
+			example := pair.Value()
+			if example == nil {
+				continue
+			}
+			label := "Example " + pair.Key()
+			if example.Summary != "" {
+				label += ": " + example.Summary
+			}
+			switch {
+			case example.Value != nil:
+				examples = append(examples, namedExample{label: label, body: formatExampleNode(mediaType, example.Value)})
+			case example.ExternalValue != "":
+				examples = append(examples, namedExample{label: label, body: "See " + example.ExternalValue})
+			}
+		}
+	}
+	if len(examples) > 0 || media.Schema == nil || media.Schema.Schema() == nil {
+		return examples
+	}
+
+	schema := media.Schema.Schema()
+	if schema.Example != nil {
+		return []namedExample{{label: "Schema example", body: formatExampleNode(mediaType, schema.Example)}}
+	}
+	body := generateExampleJSON(schema, doc, 0)
+	var indented bytes.Buffer
+	if err := jsonIndent(&indented, []byte(body)); err == nil {
+		body = indented.String()
+	}
+	return []namedExample{{label: "Generated from schema", body: body}}
+}
+
+// formatExampleNode renders a declared example as indented JSON for JSON media types, as is
+// for text and as YAML otherwise
+func formatExampleNode(mediaType string, node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode && !isJSONMediaType(mediaType) {
+		return node.Value
+	}
+	if isJSONMediaType(mediaType) {
+		var compact, indented bytes.Buffer
+		if err := writeJSONNode(&compact, node); err == nil {
+			if err := jsonIndent(&indented, compact.Bytes()); err == nil {
+				return indented.String()
+			}
+			return compact.String()
+		}
+	}
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return node.Value
+	}
+	return strings.TrimRight(string(out), "\n")
+}
+
+// openResponses opens the response examples of the endpoint or webhook under the cursor
+func (m *Model) openResponses() {
+	var title string
+	var op *v3.Operation
+	switch m.mode {
+	case viewEndpoints:
+		ep, ok := m.selectedEndpoint()
+		if !ok {
+			return
+		}
+		title, op = ep.method+" "+ep.path, ep.op
+	case viewWebhooks:
+		hooks := m.getActiveWebhooks()
+		if m.cursor < 0 || m.cursor >= len(hooks) {
+			return
+		}
+		title, op = hooks[m.cursor].method+" "+hooks[m.cursor].name, hooks[m.cursor].op
+	default:
+		return
+	}
+
+	responses := buildResponseExamples(m.doc, op)
+	if len(responses) == 0 {
+		m.setStatus(fmt.Sprintf("%s declares no responses", title), false)
+		return
+	}
+	m.responses = &responsesPane{title: title, responses: responses}
+}
+
+// updateResponses handles keys while the response examples are open
+func (m *Model) updateResponses(key string) {
+	pane := m.responses
+	page := max(1, m.height/2)
+	switch key {
+	case "esc", "q", "E":
+		m.responses = nil
+	case "left", "h":
+		pane.code = (pane.code - 1 + len(pane.responses)) % len(pane.responses)
+		pane.media, pane.scroll = 0, 0
+	case "right", "l":
+		pane.code = (pane.code + 1) % len(pane.responses)
+		pane.media, pane.scroll = 0, 0
+	case "tab", "shift+tab":
+		if n := len(pane.responses[pane.code].media); n > 0 {
+			step := 1
+			if key == "shift+tab" {
+				step = n - 1
+			}
+			pane.media = (pane.media + step) % n
+			pane.scroll = 0
+		}
+	case "up", "k":
+		pane.scroll = max(0, pane.scroll-1)
+	case "down", "j":
+		pane.scroll++
+	case "ctrl+u":
+		pane.scroll = max(0, pane.scroll-page)
+	case "ctrl+d":
+		pane.scroll += page
+	case "g":
+		pane.scroll = 0
+	}
+}
+
+func (m Model) renderResponsesPane() string {
+	pane := m.responses
+	resp := pane.responses[pane.code]
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+	activeStyle := highlight(lipgloss.NewStyle()).
+		Foreground(lipgloss.Color(colorWhite)).
+		Bold(true)
+	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorBlue))
+	instructionStyle := grayStyle.Italic(true)
+
+	codes := make([]string, len(pane.responses))
+	for i, r := range pane.responses {
+		codes[i] = grayStyle.Render(" " + r.code + " ")
+		if i == pane.code {
+			codes[i] = activeStyle.Render(" " + r.code + " ")
+		}
+	}
+	header := []string{strings.Join(codes, " ")}
+	if resp.description != "" {
+		header = append(header, resp.description)
+	}
+
+	var lines []string
+	if len(resp.media) == 0 {
+		lines = append(lines, grayStyle.Render("No response body"))
+	} else {
+		types := make([]string, len(resp.media))
+		for i, media := range resp.media {
+			types[i] = grayStyle.Render(media.mediaType)
+			if i == pane.media {
+				types[i] = labelStyle.Bold(true).Render(media.mediaType)
+			}
+		}
+		header = append(header, strings.Join(types, grayStyle.Render(" · ")))
+
+		media := resp.media[pane.media]
+		if len(media.examples) == 0 {
+			lines = append(lines, grayStyle.Render("No example or schema"))
+		}
+		for i, example := range media.examples {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, labelStyle.Render(example.label))
+			lines = append(lines, strings.Split(example.body, "\n")...)
+		}
+	}
+
+	height := max(3, m.height-len(header)-5)
+	scroll := min(pane.scroll, max(0, len(lines)-height))
+	pane.scroll = scroll
+	visible := lines[scroll:min(len(lines), scroll+height)]
+	for i, line := range visible {
+		visible[i] = lipgloss.NewStyle().MaxWidth(m.width).Render(line)
+	}
+
+	title := titleStyle.Render(fmt.Sprintf("Responses of %s (%d/%d)", pane.title, pane.code+1, len(pane.responses)))
+	instruction := instructionStyle.Render("←/→ status code · Tab media type · j/k scroll · Esc close")
+	return lipgloss.NewStyle().MaxHeight(m.height).Render(title + "\n\n" + strings.Join(header, "\n") + "\n\n" + strings.Join(visible, "\n") + "\n\n" + instruction)
+}
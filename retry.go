@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// defaultIdempotencyHeader is used in curls for write operations that don't document their own
+const defaultIdempotencyHeader = "Idempotency-Key"
+
+// writeMethods are the methods that get an idempotency key placeholder in generated curls
+var writeMethods = map[string]bool{"POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+// retryHints describes how clients are expected to retry an operation
+type retryHints struct {
+	rateLimited    bool
+	retryAfter     []string
+	idempotencyKey string
+	// rateLimit is the limit documented in an x-ratelimit extension
+	rateLimit rateLimit
+}
+
+// findRetryHints looks for 429 responses, Retry-After response headers and an
+// idempotency key header parameter
+func findRetryHints(op *v3.Operation) retryHints {
+	var hints retryHints
+	if op == nil {
+		return hints
+	}
+
+	hints.rateLimited = hasResponseCode(op, "429")
+	hints.rateLimit = findRateLimit(op.Extensions)
+
+	if op.Responses != nil && op.Responses.Codes != nil {
+		var codes []string
+		for pair := op.Responses.Codes.First(); pair != nil; pair = pair.Next() {
+			codes = append(codes, pair.Key())
+		}
+		sortResponseCodes(codes)
+
+		for _, code := range codes {
+			resp := op.Responses.Codes.GetOrZero(code)
+			if resp == nil || resp.Headers == nil {
+				continue
+			}
+			for pair := resp.Headers.First(); pair != nil; pair = pair.Next() {
+				if strings.EqualFold(pair.Key(), "Retry-After") {
+					hints.retryAfter = append(hints.retryAfter, code)
+				}
+			}
+		}
+	}
+
+	for _, param := range op.Parameters {
+		if param != nil && strings.EqualFold(param.In, "header") && strings.Contains(strings.ToLower(param.Name), "idempotency") {
+			hints.idempotencyKey = param.Name
+			break
+		}
+	}
+
+	return hints
+}
+
+// String renders the retry line of endpoint details, or an empty string when the operation
+// documents nothing about retries
+func (h retryHints) String() string {
+	var parts []string
+	if h.rateLimited {
+		parts = append(parts, "rate limited (429)")
+	}
+	if len(h.retryAfter) > 0 {
+		parts = append(parts, fmt.Sprintf("Retry-After on %s", strings.Join(h.retryAfter, ", ")))
+	}
+	if !h.rateLimit.empty() {
+		parts = append(parts, "limited to "+h.rateLimit.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// idempotencyHeader returns the idempotency key header to send with ep in a curl, if any
+func idempotencyHeader(ep endpoint) string {
+	if hints := findRetryHints(ep.op); hints.idempotencyKey != "" {
+		return hints.idempotencyKey
+	}
+	if writeMethods[ep.method] {
+		return defaultIdempotencyHeader
+	}
+	return ""
+}
@@ -0,0 +1,1051 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// maxResponseSize caps how much of a response body is read for display
+const maxResponseSize = 10 << 20
+
+// runParam is a parameter value entered in the request form
+type runParam struct {
+	name     string
+	in       string
+	required bool
+	value    string
+	schema   *base.Schema
+}
+
+// runField is one line of the request form: the server URL or a parameter
+type runField struct {
+	label string
+	param *runParam
+	input textinput.Model
+}
+
+// runResult is the response to a sent request
+type runResult struct {
+	status   string
+	code     int
+	duration time.Duration
+	headers  http.Header
+	body     []byte
+	size     int64
+	auth     []string
+}
+
+type runResultMsg struct {
+	result *runResult
+	err    error
+}
+
+// requestRunner is the try-it-out modal. It starts as a form prefilled from the spec, and shows
+// the response once the request was sent
+type requestRunner struct {
+	ep        endpoint
+	fields    []runField
+	body      textarea.Model
+	mediaType string
+	focus     int
+	sending   bool
+	loading   loadingIndicator
+	cancel    context.CancelFunc
+	result    *runResult
+	err       error
+	scroll    int
+	// JSON object bodies are edited in form unless rawBody is set. invalid lists what the
+	// body gets wrong against bodySchema, sending it again sends it anyway
+	bodySchema *base.Schema
+	form       *bodyForm
+	rawBody    bool
+	invalid    []string
+	// oauth asks for the OAuth2 client a token is requested with before sending, apiKey for
+	// the key of an apiKey scheme
+	oauth  *oauthPrompt
+	apiKey *apiKeyPrompt
+	// save asks where to save the response body, saved says where it went
+	save  *savePrompt
+	saved string
+}
+
+// openRunner opens the request form for the endpoint under the cursor
+func (m *Model) openRunner() tea.Cmd {
+	ep, ok := m.selectedEndpoint()
+	if !ok {
+		return nil
+	}
+	runner := &requestRunner{ep: ep}
+
+	server := textinput.New()
+	server.Prompt = ""
+	server.Placeholder = "https://api.example.com"
+	server.SetValue(m.serverURL(ep))
+	runner.fields = append(runner.fields, runField{label: "Server", input: server})
+
+	for _, param := range operationParameters(m.doc, ep) {
+		runner.fields = append(runner.fields, newParamField(param))
+	}
+
+	if mediaType, body, ok := exampleRequestBody(m.doc, ep.op); ok {
+		runner.mediaType = mediaType
+		runner.body = textarea.New()
+		runner.body.ShowLineNumbers = false
+		runner.body.MaxHeight = 0
+		runner.body.SetValue(body)
+		if isJSONMediaType(mediaType) {
+			runner.bodySchema = requestBodySchema(ep.op, mediaType)
+			runner.form = newBodyForm(runner.bodySchema)
+			if runner.form != nil {
+				if err := runner.form.setBody(body); err != nil {
+					debugLog.Warn("example body doesn't fit the form", "error", err)
+				}
+			}
+		}
+	}
+
+	m.runner = runner
+	return runner.focusField(0)
+}
+
+// newParamField is the form field of a parameter, prefilled with its example or default
+func newParamField(param *runParam) runField {
+	input := textinput.New()
+	input.Prompt = ""
+	input.Placeholder = param.name
+	input.SetValue(param.value)
+	return runField{label: param.in + " " + param.name, param: param, input: input}
+}
+
+// closeRunner closes the runner, cancelling a request or token grant still pending
+func (m *Model) closeRunner() {
+	if m.runner != nil && m.runner.cancel != nil {
+		m.runner.cancel()
+	}
+	m.runner = nil
+}
+
+// reloadRunner points the open runner at its operation in a reloaded document, keeping what
+// was typed in the form and a request in flight. The runner is closed when the operation is
+// gone
+func (m *Model) reloadRunner() {
+	runner := m.runner
+	if runner == nil {
+		return
+	}
+	i := slices.IndexFunc(m.endpoints, func(ep endpoint) bool {
+		return ep.method == runner.ep.method && ep.path == runner.ep.path
+	})
+	if i < 0 {
+		m.closeRunner()
+		return
+	}
+	runner.ep = m.endpoints[i]
+
+	// Parameters keep their values, new ones start from their examples
+	params := operationParameters(m.doc, runner.ep)
+	fields := make([]runField, 1, len(params)+1)
+	fields[0] = runner.fields[0]
+	for _, param := range params {
+		j := slices.IndexFunc(runner.fields[1:], func(field runField) bool {
+			return field.param.name == param.name && field.param.in == param.in
+		})
+		if j < 0 {
+			fields = append(fields, newParamField(param))
+			continue
+		}
+		field := runner.fields[j+1]
+		field.param = param
+		fields = append(fields, field)
+	}
+	runner.fields = fields
+	if runner.mediaType != "" && isJSONMediaType(runner.mediaType) {
+		runner.bodySchema = requestBodySchema(runner.ep.op, runner.mediaType)
+	}
+	if !runner.sending && runner.result == nil && runner.err == nil && runner.oauth == nil && runner.apiKey == nil {
+		runner.focusField(min(runner.focus, len(runner.fields)+runner.bodyStops()-1))
+	}
+}
+
+func (r *requestRunner) hasBody() bool {
+	return r.mediaType != ""
+}
+
+// useForm reports whether the body is edited in the form rather than as raw JSON
+func (r *requestRunner) useForm() bool {
+	return r.form != nil && !r.rawBody
+}
+
+// bodyStops is how many fields the body adds to the form: one per property, or the editor
+func (r *requestRunner) bodyStops() int {
+	switch {
+	case r.useForm():
+		return len(r.form.fields)
+	case r.hasBody():
+		return 1
+	}
+	return 0
+}
+
+// bodyField returns the focused property of the body form, if any
+func (r *requestRunner) bodyField() *bodyField {
+	if !r.useForm() || r.focus < len(r.fields) {
+		return nil
+	}
+	return &r.form.fields[r.focus-len(r.fields)]
+}
+
+// focusField moves the focus to field i, where the indexes after the last field are the body
+func (r *requestRunner) focusField(i int) tea.Cmd {
+	count := len(r.fields) + r.bodyStops()
+	r.focus = (i + count) % count
+	for j := range r.fields {
+		r.fields[j].input.Blur()
+	}
+	if r.form != nil {
+		for j := range r.form.fields {
+			r.form.fields[j].input.Blur()
+		}
+	}
+	if r.hasBody() {
+		r.body.Blur()
+	}
+	switch field := r.bodyField(); {
+	case field != nil:
+		return field.input.Focus()
+	case r.focus == len(r.fields):
+		return r.body.Focus()
+	}
+	return r.fields[r.focus].input.Focus()
+}
+
+// toggleBodyEditor switches the body between the form and raw JSON, carrying the values over
+func (r *requestRunner) toggleBodyEditor() tea.Cmd {
+	if r.form == nil {
+		return nil
+	}
+	if r.rawBody {
+		if err := r.form.setBody(r.body.Value()); err != nil {
+			r.invalid = []string{"body must be a JSON object to edit it in the form: " + err.Error()}
+			return nil
+		}
+	} else {
+		body, _ := r.form.body()
+		r.body.SetValue(body)
+	}
+	r.rawBody = !r.rawBody
+	r.invalid = nil
+	return r.focusField(len(r.fields))
+}
+
+// requestBody returns the body to send and what it gets wrong against the schema
+func (r *requestRunner) requestBody() (string, []string) {
+	if !r.hasBody() {
+		return "", nil
+	}
+	if r.useForm() {
+		body, problems := r.form.body()
+		if len(problems) == 0 {
+			problems = validateJSONBody(r.bodySchema, body)
+		}
+		return body, problems
+	}
+	body := r.body.Value()
+	if r.bodySchema == nil {
+		return body, nil
+	}
+	return body, validateJSONBody(r.bodySchema, body)
+}
+
+func (m *Model) updateRunner(msg tea.KeyMsg) tea.Cmd {
+	runner := m.runner
+	if runner.save != nil {
+		return m.updateSavePrompt(msg)
+	}
+	if runner.result != nil || runner.err != nil {
+		switch msg.String() {
+		case "esc", "q":
+			m.runner = nil
+		case "s":
+			if runner.result != nil {
+				return runner.openSavePrompt()
+			}
+		case "e":
+			runner.result, runner.err = nil, nil
+			return runner.focusField(runner.focus)
+		case "ctrl+s", "x":
+			return m.sendRequest()
+		case "up", "k":
+			runner.scroll = max(0, runner.scroll-1)
+		case "down", "j":
+			runner.scroll++
+		case "ctrl+u":
+			runner.scroll = max(0, runner.scroll-m.height/2)
+		case "ctrl+d":
+			runner.scroll += m.height / 2
+		case "g":
+			runner.scroll = 0
+		}
+		return nil
+	}
+
+	if runner.sending {
+		if msg.String() == "esc" {
+			runner.cancel()
+			m.runner = nil
+		}
+		return nil
+	}
+
+	if runner.oauth != nil {
+		return m.updateOAuthPrompt(msg)
+	}
+	if runner.apiKey != nil {
+		return m.updateAPIKeyPrompt(msg)
+	}
+
+	field := runner.bodyField()
+	switch msg.String() {
+	case "esc":
+		m.runner = nil
+		return nil
+	case "ctrl+s":
+		return m.sendRequest()
+	case "ctrl+e":
+		return runner.toggleBodyEditor()
+	case "tab", "down":
+		if msg.String() == "tab" || runner.focus < len(runner.fields) || field != nil {
+			return runner.focusField(runner.focus + 1)
+		}
+	case "shift+tab", "up":
+		if msg.String() == "shift+tab" || runner.focus < len(runner.fields) || field != nil {
+			return runner.focusField(runner.focus - 1)
+		}
+	}
+	runner.invalid = nil
+
+	var cmd tea.Cmd
+	switch {
+	case field != nil && field.kind == formChoice:
+		switch msg.String() {
+		case "left", "h":
+			field.cycle(-1)
+		case "right", "l", " ", "space":
+			field.cycle(1)
+		}
+	case field != nil:
+		field.input, cmd = field.input.Update(msg)
+	case runner.focus == len(runner.fields):
+		runner.body, cmd = runner.body.Update(msg)
+	default:
+		runner.fields[runner.focus].input, cmd = runner.fields[runner.focus].input.Update(msg)
+	}
+	return cmd
+}
+
+// sendRequest builds the request from the form and sends it in the background
+func (m *Model) sendRequest() tea.Cmd {
+	runner := m.runner
+	// A request the schemas reject is shown with its problems first, sending again sends it as is
+	var problems []string
+	for _, field := range runner.fields[1:] {
+		if problem := field.problem(); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	_, bodyProblems := runner.requestBody()
+	problems = append(problems, bodyProblems...)
+	if len(problems) > 0 && !slices.Equal(problems, runner.invalid) {
+		runner.invalid = problems
+		runner.result, runner.err = nil, nil
+		return nil
+	}
+	runner.invalid = nil
+	return m.dispatchRequest()
+}
+
+// dispatchRequest sends the request of the form once it was checked, getting an OAuth2 token
+// first when the operation needs one that isn't cached, or asking for a missing API key
+func (m *Model) dispatchRequest() tea.Cmd {
+	runner := m.runner
+	body, _ := runner.requestBody()
+	req, err := newRunRequest(runner.ep.method, runner.fields[0].input.Value(), runner.ep.path, runner.params(), runner.mediaType, body)
+	if err != nil {
+		runner.err = err
+		return nil
+	}
+	var auth []string
+	if m.openCredentials != nil {
+		if store, err := m.openCredentials(); err == nil {
+			store = promptedCredentials{credentialStore: store, keys: m.promptedKeys}
+			if grant, ok := pendingOAuthGrant(m.doc, runner.ep.op, store); ok {
+				return m.startOAuth(store, grant)
+			}
+			if name, scheme, ok := pendingAPIKey(req, m.doc, runner.ep.op, store); ok {
+				runner.apiKey = newAPIKeyPrompt(name, scheme, store.name())
+				return runner.apiKey.input.Focus()
+			}
+			auth = applyCredentials(req, m.doc, runner.ep.op, store)
+		} else {
+			debugLog.Warn("opening credential store failed", "error", err)
+		}
+	}
+
+	// Closing the form while the request is in flight cancels it
+	ctx, cancel := context.WithCancel(req.Context())
+	req = req.WithContext(ctx)
+	runner.sending, runner.cancel = true, cancel
+	runner.loading = newLoadingIndicator("Sending request...")
+	runner.result, runner.err = nil, nil
+	runner.scroll = 0
+	timeout := remote.timeout
+	return tea.Batch(runner.loading.tick(), func() tea.Msg {
+		defer cancel()
+		result, err := doRunRequest(req, timeout)
+		if result != nil {
+			result.auth = auth
+		}
+		return runResultMsg{result: result, err: err}
+	})
+}
+
+// params returns the parameters with the values typed in the form
+func (r *requestRunner) params() []runParam {
+	var params []runParam
+	for _, field := range r.fields[1:] {
+		param := *field.param
+		param.value = field.input.Value()
+		params = append(params, param)
+	}
+	return params
+}
+
+// previewURL is the URL the request goes to with the values typed so far, or why there is none
+func (r *requestRunner) previewURL() (string, error) {
+	req, err := newRunRequest(r.ep.method, r.fields[0].input.Value(), r.ep.path, r.params(), "", "")
+	if err != nil {
+		return "", err
+	}
+	return req.URL.String(), nil
+}
+
+// problem checks the value typed in a parameter field against the parameter's schema
+func (f runField) problem() string {
+	if f.param == nil {
+		return ""
+	}
+	value := f.input.Value()
+	if value == "" {
+		if f.param.required {
+			return f.param.name + " is required"
+		}
+		return ""
+	}
+	if f.param.schema == nil || exampleType(f.param.schema) == "object" {
+		return ""
+	}
+	var problems []string
+	validateValue(f.param.schema, parameterValue(f.param.schema, value), f.param.name, &problems, 0)
+	return strings.Join(problems, ", ")
+}
+
+// parameterValue reads a parameter typed in the form as its schema's type, so it can be
+// validated. Arrays are comma separated, values that don't parse stay strings and fail the
+// type check
+func parameterValue(schema *base.Schema, value string) any {
+	switch exampleType(schema) {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil && json.Valid([]byte(value)) {
+			return json.Number(value)
+		}
+	case "boolean":
+		if value == "true" || value == "false" {
+			return value == "true"
+		}
+	case "array":
+		var items []any
+		var itemSchema *base.Schema
+		if schema.Items != nil && schema.Items.IsA() && schema.Items.A != nil {
+			itemSchema = schema.Items.A.Schema()
+		}
+		for _, item := range strings.Split(value, ",") {
+			if itemSchema == nil {
+				items = append(items, item)
+			} else {
+				items = append(items, parameterValue(itemSchema, item))
+			}
+		}
+		return items
+	}
+	return value
+}
+
+// handleRunResult shows a response in the runner, unless it was closed in the meantime
+func (m *Model) handleRunResult(msg runResultMsg) {
+	if m.runner == nil || !m.runner.sending {
+		return
+	}
+	m.runner.sending = false
+	m.runner.result, m.runner.err = msg.result, msg.err
+	m.runner.saved = ""
+	m.lastRequest = &lastRequest{ep: m.runner.ep, err: msg.err}
+	if msg.result != nil {
+		m.recordLatency(m.runner.ep, msg.result.duration)
+		m.lastRequest.status, m.lastRequest.duration = msg.result.status, msg.result.duration
+	}
+}
+
+// newRunRequest builds the HTTP request for an operation. Empty optional parameters are left
+// out, empty path parameters are an error
+func newRunRequest(method, server, path string, params []runParam, mediaType, body string) (*http.Request, error) {
+	server = strings.TrimRight(strings.TrimSpace(server), "/")
+	if server == "" {
+		return nil, fmt.Errorf("no server URL")
+	}
+
+	query := url.Values{}
+	header := http.Header{}
+	var cookies []*http.Cookie
+	for _, param := range params {
+		if param.value == "" {
+			if param.in == "path" {
+				return nil, fmt.Errorf("missing path parameter %s", param.name)
+			}
+			continue
+		}
+		switch param.in {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+param.name+"}", url.PathEscape(param.value))
+		case "query":
+			query.Add(param.name, param.value)
+		case "header":
+			header.Set(param.name, param.value)
+		case "cookie":
+			cookies = append(cookies, &http.Cookie{Name: param.name, Value: param.value})
+		}
+	}
+
+	target, err := url.Parse(server + path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("server URL must start with http:// or https://")
+	}
+	if len(query) > 0 {
+		values := target.Query()
+		for name, vs := range query {
+			values[name] = append(values[name], vs...)
+		}
+		target.RawQuery = values.Encode()
+	}
+
+	var reader io.Reader
+	if mediaType != "" && body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, target.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+	if reader != nil {
+		req.Header.Set("Content-Type", mediaType)
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	return req, nil
+}
+
+// applyCredentials authenticates req with credentials named after the operation's security
+// schemes. The first requirement with a stored credential or a granted token for every scheme
+// is used, headers already set from the form are kept. It returns the schemes used
+func applyCredentials(req *http.Request, doc *v3.Document, op *v3.Operation, store credentialStore) []string {
+	if doc.Components == nil || doc.Components.SecuritySchemes == nil {
+		return nil
+	}
+	for _, requirement := range effectiveSecurity(doc, op) {
+		if requirement == nil || requirement.Requirements == nil || requirement.Requirements.Len() == 0 {
+			continue
+		}
+		values := map[string]string{}
+		var names []string
+		for pair := requirement.Requirements.First(); pair != nil; pair = pair.Next() {
+			value, err := credentialValue(store, pair.Key())
+			if err != nil {
+				values = nil
+				break
+			}
+			values[pair.Key()] = value
+			names = append(names, pair.Key())
+		}
+		if values == nil {
+			continue
+		}
+
+		for _, name := range names {
+			scheme := doc.Components.SecuritySchemes.GetOrZero(name)
+			if scheme == nil {
+				continue
+			}
+			value := values[name]
+			switch strings.ToLower(scheme.Type) {
+			case "http":
+				switch strings.ToLower(scheme.Scheme) {
+				case "basic":
+					// Stored as user:password, or already encoded
+					if strings.Contains(value, ":") {
+						value = base64.StdEncoding.EncodeToString([]byte(value))
+					}
+					setMissingHeader(req, "Authorization", "Basic "+value)
+				default:
+					setMissingHeader(req, "Authorization", "Bearer "+value)
+				}
+			case "oauth2", "openidconnect":
+				setMissingHeader(req, "Authorization", "Bearer "+value)
+			case "apikey":
+				switch scheme.In {
+				case "header":
+					setMissingHeader(req, scheme.Name, value)
+				case "query":
+					query := req.URL.Query()
+					if !query.Has(scheme.Name) {
+						query.Set(scheme.Name, value)
+						req.URL.RawQuery = query.Encode()
+					}
+				case "cookie":
+					if _, err := req.Cookie(scheme.Name); err != nil {
+						req.AddCookie(&http.Cookie{Name: scheme.Name, Value: value})
+					}
+				}
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+func setMissingHeader(req *http.Request, name, value string) {
+	if req.Header.Get(name) == "" {
+		req.Header.Set(name, value)
+	}
+}
+
+// redactedURL is u as written to the debug log: query values are masked like passwords, as
+// an apiKey in: query, added from the store or typed as a parameter, travels there
+func redactedURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	query := u.Query()
+	for name, values := range query {
+		for i := range values {
+			values[i] = "xxxxx"
+		}
+		query[name] = values
+	}
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.Redacted()
+}
+
+// doRunRequest sends req and reads up to maxResponseSize of the response
+func doRunRequest(req *http.Request, timeout time.Duration) (*runResult, error) {
+	if timeout <= 0 {
+		timeout = defaultRemoteTimeout
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	size := int64(len(body))
+	if len(body) > maxResponseSize {
+		body = body[:maxResponseSize]
+		if resp.ContentLength > size {
+			size = resp.ContentLength
+		}
+	}
+	debugLog.Debug("sent request", "method", req.Method, "url", redactedURL(req.URL), "status", resp.StatusCode, "took", time.Since(start))
+	return &runResult{
+		status:   resp.Status,
+		code:     resp.StatusCode,
+		duration: time.Since(start),
+		headers:  resp.Header,
+		body:     body,
+		size:     size,
+	}, nil
+}
+
+// defaultServerURL returns the first server with its variables set to their defaults. Relative
+// server URLs are resolved against the spec URL
+func defaultServerURL(doc *v3.Document, specPath string) string {
+	if len(doc.Servers) == 0 || doc.Servers[0] == nil {
+		return ""
+	}
+	return resolveServerURL(doc.Servers[0], nil, specPath)
+}
+
+// operationParameters returns the parameters of an operation, including those declared on its
+// path item, prefilled with their examples or defaults
+func operationParameters(doc *v3.Document, ep endpoint) []*runParam {
+	var params []*runParam
+	for _, p := range declaredParameters(doc, ep) {
+		param := &runParam{
+			name:     p.Name,
+			in:       p.In,
+			required: p.Required != nil && *p.Required,
+			value:    parameterExample(p),
+		}
+		if p.Schema != nil {
+			param.schema = p.Schema.Schema()
+		}
+		params = append(params, param)
+	}
+	// Path parameters come first, in the order they appear in the path
+	sort.SliceStable(params, func(i, j int) bool {
+		return runParamOrder(params[i], ep.path) < runParamOrder(params[j], ep.path)
+	})
+	return params
+}
+
+// declaredParameters returns the parameters of an operation followed by those of its path
+// item that the operation doesn't override
+func declaredParameters(doc *v3.Document, ep endpoint) []*v3.Parameter {
+	var shared []*v3.Parameter
+	if doc.Paths != nil && doc.Paths.PathItems != nil {
+		if item := doc.Paths.PathItems.GetOrZero(ep.path); item != nil {
+			shared = item.Parameters
+		}
+	}
+	return mergeParameters(ep.op.Parameters, shared)
+}
+
+// mergeParameters returns the parameters of an operation followed by the shared ones of its
+// path item, the operation's overriding those with the same name and location
+func mergeParameters(own, shared []*v3.Parameter) []*v3.Parameter {
+	var declared []*v3.Parameter
+	for _, p := range own {
+		if p != nil {
+			declared = append(declared, p)
+		}
+	}
+	for _, p := range shared {
+		overridden := false
+		for _, op := range own {
+			overridden = overridden || (op != nil && p != nil && op.Name == p.Name && op.In == p.In)
+		}
+		if p != nil && !overridden {
+			declared = append(declared, p)
+		}
+	}
+	return declared
+}
+
+func runParamOrder(p *runParam, path string) int {
+	if p.in == "path" {
+		if i := strings.Index(path, "{"+p.name+"}"); i >= 0 {
+			return i - len(path)
+		}
+	}
+	return 0
+}
+
+func parameterExample(p *v3.Parameter) string {
+	if value := scalarExample(p.Example); value != "" {
+		return value
+	}
+	if p.Examples != nil {
+		for pair := p.Examples.First(); pair != nil; pair = pair.Next() {
+			if ex := pair.Value(); ex != nil {
+				if value := scalarExample(ex.Value); value != "" {
+					return value
+				}
+			}
+		}
+	}
+	if p.Schema == nil || p.Schema.Schema() == nil {
+		return ""
+	}
+	s := p.Schema.Schema()
+	for _, node := range append([]*yaml.Node{s.Const, s.Example}, s.Examples...) {
+		if value := scalarExample(node); value != "" {
+			return value
+		}
+	}
+	if value := scalarExample(s.Default); value != "" {
+		return value
+	}
+	if len(s.Enum) > 0 {
+		return scalarExample(s.Enum[0])
+	}
+	return ""
+}
+
+func scalarExample(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+// exampleRequestBody returns the media type and an example body for the request form. JSON
+// is preferred, other media types start empty
+func exampleRequestBody(doc *v3.Document, op *v3.Operation) (string, string, bool) {
+	if op.RequestBody == nil || op.RequestBody.Content == nil || op.RequestBody.Content.Len() == 0 {
+		return "", "", false
+	}
+	mediaType := op.RequestBody.Content.First().Key()
+	for pair := op.RequestBody.Content.First(); pair != nil; pair = pair.Next() {
+		if isJSONMediaType(pair.Key()) {
+			mediaType = pair.Key()
+			break
+		}
+	}
+	content := op.RequestBody.Content.GetOrZero(mediaType)
+	if !isJSONMediaType(mediaType) || content == nil {
+		return mediaType, "", true
+	}
+	if content.Schema == nil || content.Schema.Schema() == nil {
+		return mediaType, "{}", true
+	}
+	body := generateExampleJSON(content.Schema.Schema(), doc, 0)
+	var indented bytes.Buffer
+	if err := jsonIndent(&indented, []byte(body)); err == nil {
+		body = indented.String()
+	}
+	return mediaType, body, true
+}
+
+// requestBodySchema returns the schema of an operation's request body for a media type
+func requestBodySchema(op *v3.Operation, mediaType string) *base.Schema {
+	if op.RequestBody == nil || op.RequestBody.Content == nil {
+		return nil
+	}
+	media := op.RequestBody.Content.GetOrZero(mediaType)
+	if media == nil || media.Schema == nil {
+		return nil
+	}
+	return media.Schema.Schema()
+}
+
+func (m Model) renderRunner() string {
+	runner := m.runner
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorBlue))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	errorStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorRed)).
+		Bold(true)
+
+	invalidStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorRed))
+
+	width := min(m.width-4, 100)
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(colorThemePurple)).
+		Padding(1, 2).
+		Width(width)
+	innerWidth := max(20, width-6)
+
+	title := titleStyle.Render(m.methodLabel(runner.ep.method) + " " + runner.ep.path)
+	var body string
+
+	switch {
+	case runner.result != nil:
+		body = m.renderRunResult(runner.result, innerWidth)
+		switch {
+		case runner.save != nil:
+			runner.save.input.Width = max(10, innerWidth-10)
+			body += "\n\n" + labelStyle.Render("Save as") + " " + runner.save.input.View()
+			if runner.save.exists {
+				body += "\n" + invalidStyle.Render("The file exists, Enter again to overwrite it")
+			}
+			body += "\n\n" + instructionStyle.Render("Enter save · Esc back")
+		default:
+			if runner.saved != "" {
+				body += "\n\n" + labelStyle.Render(runner.saved)
+			}
+			body += "\n\n" + instructionStyle.Render("j/k scroll · s save body · e edit · x resend · Esc close")
+		}
+	case runner.err != nil:
+		body = errorStyle.Render("Request failed: "+runner.err.Error()) +
+			"\n\n" + instructionStyle.Render("e edit · x resend · Esc close")
+	case runner.sending:
+		body = runner.loading.view("Esc cancel")
+	case runner.oauth != nil:
+		body = m.renderOAuthPrompt(innerWidth) + "\n\n" + instructionStyle.Render("Tab next field · Enter get a token and send · Esc back")
+	case runner.apiKey != nil:
+		body = m.renderAPIKeyPrompt(innerWidth) + "\n\n" + instructionStyle.Render("Tab save or not · Space toggle · Enter send · Esc back")
+	default:
+		labelWidth := 0
+		for _, field := range runner.fields {
+			labelWidth = max(labelWidth, lipgloss.Width(field.label))
+		}
+		var lines []string
+		if warning := deprecationWarning(runner.ep.op); warning != "" {
+			lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color(colorYellow)).Bold(true).Render("⚠ "+warning), "")
+		}
+		for i, field := range runner.fields {
+			field.input.Width = max(10, innerWidth-labelWidth-4)
+			label := field.label
+			if field.param != nil && field.param.required {
+				label += "*"
+			}
+			marker := "  "
+			if i == runner.focus {
+				marker = "> "
+			}
+			problem := field.problem()
+			if problem == "" {
+				lines = append(lines, marker+labelStyle.Width(labelWidth+1).Render(label)+" "+field.input.View())
+				continue
+			}
+			lines = append(lines, marker+invalidStyle.Width(labelWidth+1).Render(label)+" "+field.input.View())
+			lines = append(lines, strings.Repeat(" ", labelWidth+4)+invalidStyle.Render("↳ "+problem))
+		}
+		// The URL is rebuilt as the fields are typed in, so what is sent can be checked first
+		if preview, err := runner.previewURL(); err != nil {
+			lines = append(lines, "", "  "+labelStyle.Width(labelWidth+1).Render("URL")+" "+invalidStyle.Render(err.Error()))
+		} else {
+			lines = append(lines, "", "  "+labelStyle.Width(labelWidth+1).Render("URL")+" "+lipgloss.NewStyle().Width(innerWidth-labelWidth-3).Render(preview))
+		}
+		available := max(3, min(12, m.height-len(lines)-14-len(runner.invalid)))
+		switch {
+		case runner.useForm():
+			lines = append(lines, "", "  "+labelStyle.Render("Body ("+runner.mediaType+")"))
+			lines = append(lines, m.renderBodyForm(innerWidth, available)...)
+		case runner.hasBody():
+			marker := "  "
+			if runner.focus == len(runner.fields) {
+				marker = "> "
+			}
+			runner.body.SetWidth(innerWidth - 2)
+			runner.body.SetHeight(available)
+			lines = append(lines, "", marker+labelStyle.Render("Body ("+runner.mediaType+")"), runner.body.View())
+		}
+		if len(runner.invalid) > 0 {
+			lines = append(lines, "", errorStyle.Render("The request doesn't match the schema, Ctrl+S again to send it anyway:"))
+			for _, problem := range runner.invalid {
+				lines = append(lines, invalidStyle.Render("  • "+problem))
+			}
+		}
+		instruction := "Tab next field · Ctrl+S send · Esc cancel · credentials come from `oq credentials`"
+		switch {
+		case runner.useForm():
+			instruction = "Tab/↑/↓ next field · ←/→ choose · Ctrl+E edit as JSON · Ctrl+S send · Esc cancel"
+		case runner.form != nil:
+			instruction = "Tab next field · Ctrl+E edit in a form · Ctrl+S send · Esc cancel"
+		}
+		body = strings.Join(lines, "\n") + "\n\n" + instructionStyle.Render(instruction)
+	}
+
+	modal := modalStyle.Render(title + "\n\n" + body)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
+
+// renderRunResult renders the status line, headers and body of a response, scrolled by the
+// runner
+func (m Model) renderRunResult(result *runResult, width int) string {
+	statusColor := colorGreen
+	switch {
+	case result.code >= 500:
+		statusColor = colorRed
+	case result.code >= 400:
+		statusColor = colorYellow
+	case result.code >= 300:
+		statusColor = colorBlue
+	}
+	statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(statusColor)).Bold(true)
+	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray))
+
+	summary := fmt.Sprintf("  %s · %s", result.duration.Round(time.Millisecond), formatBytes(uint64(result.size)))
+	if result.truncated() {
+		summary += fmt.Sprintf(", showing the first %s", formatBytes(uint64(len(result.body))))
+	}
+	if len(result.auth) > 0 {
+		summary += " · credentials: " + strings.Join(result.auth, ", ")
+	}
+	status := statusStyle.Render(stripControl(result.status)) + grayStyle.Render(summary)
+	if budget := findLatencyBudget(m.runner.ep.op); !budget.empty() {
+		verdictColor := colorGreen
+		if budget.exceededBy(result.duration) {
+			verdictColor = colorRed
+		}
+		status += grayStyle.Render(" · ") + lipgloss.NewStyle().Foreground(lipgloss.Color(verdictColor)).Render(latencyVerdict(budget, result.duration))
+	}
+
+	names := make([]string, 0, len(result.headers))
+	for name := range result.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var lines []string
+	for _, name := range names {
+		for _, value := range result.headers[name] {
+			lines = append(lines, grayStyle.Render(stripControl(name)+": ")+stripControl(value))
+		}
+	}
+	lines = append(lines, "")
+	if result.shownInline() {
+		lines = append(lines, strings.Split(formatResponseBody(result.headers.Get("Content-Type"), result.body), "\n")...)
+	} else {
+		kind := "Binary"
+		if !isBinaryBody(baseMediaType(result.headers.Get("Content-Type")), result.body) {
+			kind = "Large"
+		}
+		note := fmt.Sprintf("%s body not shown, press s to save it as %s", kind, responseFileName(result, m.runner.ep.path))
+		if result.truncated() {
+			note = fmt.Sprintf("Body over the %s oq reads, download it with the curl command from r", formatBytes(maxResponseSize))
+		}
+		lines = append(lines, grayStyle.Render(note))
+	}
+
+	height := max(3, m.height-12)
+	scroll := min(m.runner.scroll, max(0, len(lines)-height))
+	m.runner.scroll = scroll
+	visible := lines[scroll:min(len(lines), scroll+height)]
+	for i, line := range visible {
+		visible[i] = lipgloss.NewStyle().MaxWidth(width).Render(line)
+	}
+	return status + "\n\n" + strings.Join(visible, "\n")
+}
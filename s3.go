@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty body, signed for GET requests
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// awsCredentials sign requests to AWS with Signature Version 4
+type awsCredentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+// loadAWSCredentials returns the credentials of the environment, or else of the AWS_PROFILE
+// or default profile in ~/.aws/credentials, the first places the AWS CLI looks
+func loadAWSCredentials() (awsCredentials, bool) {
+	creds := awsCredentials{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.accessKeyID != "" && creds.secretAccessKey != "" {
+		return creds, true
+	}
+	file := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if file == "" {
+		file = awsConfigPath("credentials")
+	}
+	profile := awsINISection(file, awsProfile())
+	creds = awsCredentials{
+		accessKeyID:     profile["aws_access_key_id"],
+		secretAccessKey: profile["aws_secret_access_key"],
+		sessionToken:    profile["aws_session_token"],
+	}
+	return creds, creds.accessKeyID != "" && creds.secretAccessKey != ""
+}
+
+// awsRegion returns the region of the environment or of the profile in ~/.aws/config,
+// us-east-1 when none is set
+func awsRegion() string {
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(name); region != "" {
+			return region
+		}
+	}
+	file := os.Getenv("AWS_CONFIG_FILE")
+	if file == "" {
+		file = awsConfigPath("config")
+	}
+	section := "profile " + awsProfile()
+	if awsProfile() == "default" {
+		section = "default"
+	}
+	if region := awsINISection(file, section)["region"]; region != "" {
+		return region
+	}
+	return "us-east-1"
+}
+
+func awsProfile() string {
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
+
+func awsConfigPath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".aws", name)
+}
+
+// awsINISection returns the keys of a section of an AWS config or credentials file
+func awsINISection(file, section string) map[string]string {
+	values := map[string]string{}
+	f, err := os.Open(file)
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+
+	current := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			current = strings.TrimSpace(strings.Trim(line, "[]"))
+		case current == section:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return values
+}
+
+// signV4 signs a request with AWS Signature Version 4, covering the host and every header
+// set on the request. payloadHash is the hex SHA-256 of the body
+func signV4(req *http.Request, creds awsCredentials, region, service, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		var trimmed []string
+		for _, value := range values {
+			trimmed = append(trimmed, strings.Join(strings.Fields(value), " "))
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := slices.Sorted(maps.Keys(headers))
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	var params []string
+	for _, key := range slices.Sorted(maps.Keys(query)) {
+		values := slices.Clone(query[key])
+		slices.Sort(values)
+		for _, value := range values {
+			params = append(params, awsURIEncode(key, true)+"="+awsURIEncode(value, true))
+		}
+	}
+	canonicalPath := req.URL.EscapedPath()
+	if canonicalPath == "" {
+		canonicalPath = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method, canonicalPath, strings.Join(params, "&"), canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + creds.secretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// awsURIEncode percent-encodes everything but unreserved characters, as signing expects.
+// Slashes are kept in paths
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3ObjectURL is the address of an object, on AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL for
+// S3-compatible stores such as MinIO
+func s3ObjectURL(bucket, key, region string) string {
+	path := "/" + awsURIEncode(key, false)
+	for _, name := range []string{"AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"} {
+		if endpoint := os.Getenv(name); endpoint != "" {
+			return strings.TrimSuffix(endpoint, "/") + "/" + awsURIEncode(bucket, true) + path
+		}
+	}
+	// Buckets with dots don't match the certificate of virtual-hosted addresses
+	if strings.Contains(bucket, ".") {
+		return "https://s3." + region + ".amazonaws.com/" + bucket + path
+	}
+	return "https://" + bucket + ".s3." + region + ".amazonaws.com" + path
+}
+
+// fetchS3Spec downloads an object from S3, signed with the AWS credentials when there are
+// some and unsigned for public buckets. Objects that are tarballs are extracted
+func fetchS3Spec(ctx context.Context, source string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, remote.timeout)
+	defer cancel()
+
+	artifact, file := splitArtifactPath(source)
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(artifact, "s3://"), "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("Error reading %s: expected s3://bucket/key", source)
+	}
+	region := awsRegion()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s3ObjectURL(bucket, key, region), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %w", source, err)
+	}
+	creds, signed := loadAWSCredentials()
+	if signed {
+		signV4(req, creds, region, "s3", emptyPayloadHash, time.Now())
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if !signed && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusUnauthorized) {
+			return nil, fmt.Errorf("Error fetching %s: %s, set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or AWS_PROFILE for private buckets", source, resp.Status)
+		}
+		return nil, fmt.Errorf("Error fetching %s: %s", source, resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSpecSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching %s: %w", source, err)
+	}
+	if len(content) > maxRemoteSpecSize {
+		return nil, fmt.Errorf("Error fetching %s: spec is larger than %s", source, formatBytes(maxRemoteSpecSize))
+	}
+	if isTarballSpec(artifact) || file != "" {
+		return extractSpec(content, file, source)
+	}
+	return content, nil
+}
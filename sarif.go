@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// SARIF 2.1.0, the format GitHub code scanning and most CI tools ingest. Only the parts
+// oq fills in are modelled
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     *sarifMessage      `json:"shortDescription,omitempty"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	LogicalLocations []sarifLogical        `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           sarifRegion   `json:"region"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type sarifLogical struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// sarifLevel maps severities to SARIF levels, which have no separate level for hints
+func sarifLevel(severity lintSeverity) string {
+	switch severity {
+	case severityError:
+		return "error"
+	case severityWarn:
+		return "warning"
+	}
+	return "note"
+}
+
+// sarifURI is the artifact the results point into: the spec path as given, so it resolves
+// against the repository root when oq runs there, or the URL of a remote spec
+func sarifURI(specPath string) string {
+	switch {
+	case specPath == "":
+		return "stdin"
+	case isRemoteSpec(specPath):
+		return specPath
+	}
+	return filepath.ToSlash(filepath.Clean(specPath))
+}
+
+// jsonPointer writes a path as an RFC 6901 pointer such as /paths/~1pets/get
+func jsonPointer(path []string) string {
+	var b strings.Builder
+	for _, key := range path {
+		b.WriteString("/" + escapePointer(key))
+	}
+	return b.String()
+}
+
+// writeLintSARIF writes the issues as a SARIF log with one run, listing every rule of the
+// ruleset so tools can show rules without results too, and then the rules of oq's own issues
+func writeLintSARIF(w io.Writer, issues []lintIssue, ruleset *spectralRuleset, specPath string) error {
+	driver := sarifDriver{Name: "oq", Version: buildVersion(), InformationURI: "https://github.com/plutov/oq", Rules: []sarifRule{}}
+	ruleIndex := map[string]int{}
+	for _, rule := range ruleset.rules {
+		ruleIndex[rule.name] = len(driver.Rules)
+		sr := sarifRule{ID: rule.name, DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.severity)}}
+		if rule.description != "" {
+			sr.ShortDescription = &sarifMessage{Text: rule.description}
+		}
+		driver.Rules = append(driver.Rules, sr)
+	}
+	// Issues from oq itself, such as the budgets, have no rule in the ruleset
+	for _, issue := range issues {
+		if _, ok := ruleIndex[issue.rule]; !ok {
+			ruleIndex[issue.rule] = len(driver.Rules)
+			driver.Rules = append(driver.Rules, sarifRule{ID: issue.rule, DefaultConfiguration: sarifConfiguration{Level: sarifLevel(issue.severity)}})
+		}
+	}
+
+	uri := sarifURI(specPath)
+	results := make([]sarifResult, 0, len(issues))
+	for _, issue := range issues {
+		location := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifact{URI: uri},
+				Region:           sarifRegion{StartLine: max(1, issue.line), StartColumn: issue.column},
+			},
+		}
+		if len(issue.path) > 0 {
+			location.LogicalLocations = []sarifLogical{{FullyQualifiedName: jsonPointer(issue.path)}}
+		}
+		results = append(results, sarifResult{
+			RuleID:    issue.rule,
+			RuleIndex: ruleIndex[issue.rule],
+			Level:     sarifLevel(issue.severity),
+			Message:   sarifMessage{Text: issue.message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+const componentSchemaPrefix = "#/components/schemas/"
+
+// maxSchemaDepth bounds schema traversal for refs that can't be tracked by name
+const maxSchemaDepth = 64
+
+// componentSchemaName returns the component name a local schema $ref points to
+func componentSchemaName(ref string) (string, bool) {
+	if !strings.HasPrefix(ref, componentSchemaPrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(ref, componentSchemaPrefix)
+	if name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return name, true
+}
+
+// schemaChildren returns every sub-schema of s, following the JSON Schema applicators
+func schemaChildren(s *base.Schema) []*base.SchemaProxy {
+	var children []*base.SchemaProxy
+	children = append(children, s.AllOf...)
+	children = append(children, s.OneOf...)
+	children = append(children, s.AnyOf...)
+	children = append(children, s.PrefixItems...)
+	children = append(children, s.Not, s.Contains, s.If, s.Then, s.Else, s.PropertyNames, s.UnevaluatedItems)
+
+	for _, dv := range []*base.DynamicValue[*base.SchemaProxy, bool]{s.Items, s.AdditionalProperties, s.UnevaluatedProperties} {
+		if dv != nil && dv.IsA() {
+			children = append(children, dv.A)
+		}
+	}
+
+	if s.Properties != nil {
+		for pair := s.Properties.First(); pair != nil; pair = pair.Next() {
+			children = append(children, pair.Value())
+		}
+	}
+	if s.PatternProperties != nil {
+		for pair := s.PatternProperties.First(); pair != nil; pair = pair.Next() {
+			children = append(children, pair.Value())
+		}
+	}
+	if s.DependentSchemas != nil {
+		for pair := s.DependentSchemas.First(); pair != nil; pair = pair.Next() {
+			children = append(children, pair.Value())
+		}
+	}
+
+	return children
+}
+
+// operationSchemas returns the top-level schemas used by an operation's parameters,
+// request body, responses and response headers
+func operationSchemas(op *v3.Operation) []*base.SchemaProxy {
+	var schemas []*base.SchemaProxy
+	if op == nil {
+		return schemas
+	}
+
+	for _, param := range op.Parameters {
+		if param != nil {
+			schemas = append(schemas, param.Schema)
+		}
+	}
+
+	if op.RequestBody != nil && op.RequestBody.Content != nil {
+		for pair := op.RequestBody.Content.First(); pair != nil; pair = pair.Next() {
+			if pair.Value() != nil {
+				schemas = append(schemas, pair.Value().Schema)
+			}
+		}
+	}
+
+	if op.Responses != nil {
+		var responses []*v3.Response
+		if op.Responses.Codes != nil {
+			for pair := op.Responses.Codes.First(); pair != nil; pair = pair.Next() {
+				responses = append(responses, pair.Value())
+			}
+		}
+		responses = append(responses, op.Responses.Default)
+
+		for _, resp := range responses {
+			if resp == nil {
+				continue
+			}
+			if resp.Content != nil {
+				for pair := resp.Content.First(); pair != nil; pair = pair.Next() {
+					if pair.Value() != nil {
+						schemas = append(schemas, pair.Value().Schema)
+					}
+				}
+			}
+			if resp.Headers != nil {
+				for pair := resp.Headers.First(); pair != nil; pair = pair.Next() {
+					if pair.Value() != nil {
+						schemas = append(schemas, pair.Value().Schema)
+					}
+				}
+			}
+		}
+	}
+
+	return schemas
+}
+
+// collectSchemaRefs records the names of all component schemas reachable from proxy,
+// directly or through other schemas
+func collectSchemaRefs(proxy *base.SchemaProxy, refs map[string]bool) {
+	collectSchemaRefsDepth(proxy, refs, 0)
+}
+
+func collectSchemaRefsDepth(proxy *base.SchemaProxy, refs map[string]bool, depth int) {
+	if proxy == nil || depth > maxSchemaDepth {
+		return
+	}
+
+	if proxy.IsReference() {
+		if name, ok := componentSchemaName(proxy.GetReference()); ok {
+			if refs[name] {
+				return
+			}
+			refs[name] = true
+		}
+	}
+
+	s := proxy.Schema()
+	if s == nil {
+		return
+	}
+	for _, child := range schemaChildren(s) {
+		collectSchemaRefsDepth(child, refs, depth+1)
+	}
+}
+
+// operationSchemaRefs returns the component schemas an operation uses, transitively
+func operationSchemaRefs(op *v3.Operation) map[string]bool {
+	refs := map[string]bool{}
+	for _, proxy := range operationSchemas(op) {
+		collectSchemaRefs(proxy, refs)
+	}
+	return refs
+}
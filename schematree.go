@@ -0,0 +1,393 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+)
+
+// schemaNode is a schema in the tree browser: a component, a property, array items or one
+// of the schemas of allOf, oneOf and anyOf. Children are built when a node is expanded
+type schemaNode struct {
+	label    string
+	proxy    *base.SchemaProxy
+	required bool
+	depth    int
+	// refs are the component schemas expanded on the way to this node, to stop at cycles
+	refs     []string
+	children []*schemaNode
+	loaded   bool
+	expanded bool
+	// level is set on the groups of the inheritance view to the schema they come from
+	level string
+}
+
+// ref returns the component schema the node references, if any
+func (n *schemaNode) ref() string {
+	if n.proxy == nil || !n.proxy.IsReference() {
+		return ""
+	}
+	name, _ := componentSchemaName(n.proxy.GetReference())
+	return name
+}
+
+// circular reports whether the node references a schema it is already part of
+func (n *schemaNode) circular() bool {
+	ref := n.ref()
+	return ref != "" && slices.Contains(n.refs, ref)
+}
+
+func (n *schemaNode) schema() *base.Schema {
+	if n.proxy == nil {
+		return nil
+	}
+	return n.proxy.Schema()
+}
+
+func (n *schemaNode) expandable() bool {
+	if n.circular() {
+		return false
+	}
+	return len(n.loadChildren()) > 0
+}
+
+// loadChildren builds the properties, prefixItems, items, additionalProperties, composed
+// schemas and if/then/else
+func (n *schemaNode) loadChildren() []*schemaNode {
+	if n.loaded {
+		return n.children
+	}
+	n.loaded = true
+	s := n.schema()
+	if s == nil || n.circular() {
+		return nil
+	}
+	refs := n.refs
+	if ref := n.ref(); ref != "" {
+		refs = append(slices.Clip(refs), ref)
+	}
+	add := func(label string, proxy *base.SchemaProxy, required bool) {
+		if proxy != nil {
+			n.children = append(n.children, &schemaNode{label: label, proxy: proxy, required: required, depth: n.depth + 1, refs: refs})
+		}
+	}
+
+	if s.Properties != nil {
+		for pair := s.Properties.First(); pair != nil; pair = pair.Next() {
+			add(pair.Key(), pair.Value(), slices.Contains(s.Required, pair.Key()))
+		}
+	}
+	for i, proxy := range s.PrefixItems {
+		add(fmt.Sprintf("[%d]", i), proxy, false)
+	}
+	if s.Items != nil && s.Items.IsA() {
+		add("[items]", s.Items.A, false)
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.IsA() {
+		add("{additional}", s.AdditionalProperties.A, false)
+	}
+	for _, group := range []struct {
+		name    string
+		schemas []*base.SchemaProxy
+	}{{"allOf", s.AllOf}, {"oneOf", s.OneOf}, {"anyOf", s.AnyOf}} {
+		for i, proxy := range group.schemas {
+			add(fmt.Sprintf("%s[%d]", group.name, i), proxy, false)
+		}
+	}
+	add("not", s.Not, false)
+	add("if", s.If, false)
+	add("then", s.Then, false)
+	add("else", s.Else, false)
+	return n.children
+}
+
+// annotations describes a node as shown after its label, e.g. "string date-time required"
+func (n *schemaNode) annotations() []string {
+	if n.level != "" {
+		return []string{n.levelSummary()}
+	}
+	s := n.schema()
+	if s == nil {
+		return []string{"unresolved"}
+	}
+	var notes []string
+	types := slices.DeleteFunc(slices.Clone(s.Type), func(t string) bool { return t == "null" })
+	if len(types) > 0 {
+		notes = append(notes, strings.Join(types, "|"))
+	}
+	if s.Format != "" {
+		notes = append(notes, s.Format)
+	}
+	if n.required {
+		notes = append(notes, "required")
+	}
+	if (s.Nullable != nil && *s.Nullable) || slices.Contains(s.Type, "null") {
+		notes = append(notes, "nullable")
+	}
+	if s.ReadOnly != nil && *s.ReadOnly {
+		notes = append(notes, "read-only")
+	}
+	if s.WriteOnly != nil && *s.WriteOnly {
+		notes = append(notes, "write-only")
+	}
+	if s.Deprecated != nil && *s.Deprecated {
+		notes = append(notes, "deprecated")
+	}
+	if len(s.Enum) > 0 {
+		notes = append(notes, fmt.Sprintf("enum(%d)", len(s.Enum)))
+	}
+	if value := schemaValueJSON(s.Const); value != "" {
+		notes = append(notes, "const "+value)
+	}
+	return notes
+}
+
+// schemaTree browses a component schema. history holds the schemas left by following a
+// $ref, so backspace can return to them. Schemas extending others through allOf are shown
+// by level of their inheritance chain, or as written when inherited is off
+type schemaTree struct {
+	root      *schemaNode
+	levels    *schemaNode
+	inherited bool
+	chain     []string
+	cursor    int
+	history   []*schemaTree
+}
+
+// newSchemaTree opens the component schema name. chain is the inheritance chain to show,
+// or nil to follow the schema's own
+func newSchemaTree(schemas schemaMap, name string, chain []string) *schemaTree {
+	if !slices.Contains(chain, name) {
+		chain = inheritanceChain(schemas, name)
+	}
+	proxy := schemas.GetOrZero(name)
+	root := &schemaNode{label: name, proxy: proxy, refs: []string{name}}
+	root.expanded = root.expandable()
+	tree := &schemaTree{root: root, chain: chain}
+	if level := slices.Index(chain, name); level > 0 {
+		tree.levels = &schemaNode{label: name, proxy: proxy, loaded: true, expanded: true, children: levelNodes(schemas, chain[:level+1])}
+		tree.inherited = true
+	}
+	return tree
+}
+
+// active is the root of the view shown: by inheritance level or as written
+func (t *schemaTree) active() *schemaNode {
+	if t.inherited && t.levels != nil {
+		return t.levels
+	}
+	return t.root
+}
+
+// visible lists the nodes shown, depth first through expanded nodes
+func (t *schemaTree) visible() []*schemaNode {
+	var nodes []*schemaNode
+	var walk func(n *schemaNode)
+	walk = func(n *schemaNode) {
+		nodes = append(nodes, n)
+		if n.expanded {
+			for _, child := range n.loadChildren() {
+				walk(child)
+			}
+		}
+	}
+	walk(t.active())
+	return nodes
+}
+
+// openSchemaTree opens the tree browser for the schema component under the cursor
+func (m *Model) openSchemaTree() bool {
+	comps := m.getActiveComponents()
+	if m.cursor < 0 || m.cursor >= len(comps) || comps[m.cursor].compType != "Schema" || m.doc.Components == nil || m.doc.Components.Schemas == nil {
+		return false
+	}
+	m.schemaTree = newSchemaTree(m.doc.Components.Schemas, comps[m.cursor].name, nil)
+	return true
+}
+
+// updateSchemaTree handles keys while the schema tree is open
+func (m *Model) updateSchemaTree(key string) {
+	tree := m.schemaTree
+	nodes := tree.visible()
+	tree.cursor = min(tree.cursor, len(nodes)-1)
+	node := nodes[tree.cursor]
+	page := max(1, m.height/2)
+
+	switch key {
+	case "esc", "q":
+		m.schemaTree = nil
+	case "up", "k":
+		tree.cursor = max(0, tree.cursor-1)
+	case "down", "j":
+		tree.cursor = min(len(nodes)-1, tree.cursor+1)
+	case "ctrl+u":
+		tree.cursor = max(0, tree.cursor-page)
+	case "ctrl+d":
+		tree.cursor = min(len(nodes)-1, tree.cursor+page)
+	case "g":
+		tree.cursor = 0
+	case "G":
+		tree.cursor = len(nodes) - 1
+	case "right", "l":
+		if node.expandable() {
+			node.expanded = true
+		}
+	case " ":
+		if node.expandable() {
+			node.expanded = !node.expanded
+		}
+	case "left", "h":
+		// Collapse the node, or move to its parent when it is collapsed already
+		if node.expanded {
+			node.expanded = false
+			return
+		}
+		for i := tree.cursor - 1; i >= 0; i-- {
+			if nodes[i].depth < node.depth {
+				tree.cursor = i
+				break
+			}
+		}
+	case "enter":
+		ref := node.ref()
+		if ref == "" {
+			if node.expandable() {
+				node.expanded = !node.expanded
+			}
+			return
+		}
+		if m.doc.Components.Schemas.GetOrZero(ref) == nil {
+			m.setStatus(fmt.Sprintf("%s is not a component schema", ref), true)
+			return
+		}
+		m.openInTree(ref, nil)
+	case "[", "]":
+		// Move up or down the inheritance chain
+		level := slices.Index(tree.chain, tree.root.label)
+		if key == "[" {
+			if level == 0 {
+				m.setStatus(tree.root.label+" doesn't extend another schema", false)
+				return
+			}
+			level--
+		} else {
+			if level == len(tree.chain)-1 {
+				m.setStatus("No schema extends "+tree.root.label, false)
+				return
+			}
+			level++
+		}
+		m.openInTree(tree.chain[level], tree.chain)
+		if others := schemaExtensions(m.doc.Components.Schemas, tree.root.label); key == "]" && len(others) > 1 {
+			m.setStatus(fmt.Sprintf("%s is also extended by %s", tree.root.label, strings.Join(slices.DeleteFunc(others, func(name string) bool { return name == tree.chain[level] }), ", ")), false)
+		}
+	case "i":
+		if tree.levels == nil {
+			m.setStatus(tree.root.label+" doesn't extend another schema", false)
+			return
+		}
+		tree.inherited = !tree.inherited
+		tree.cursor = 0
+	case "backspace":
+		if n := len(tree.history); n > 0 {
+			m.schemaTree = tree.history[n-1]
+		}
+	}
+}
+
+// openInTree opens another schema in the tree, keeping the current one for backspace
+func (m *Model) openInTree(name string, chain []string) {
+	next := newSchemaTree(m.doc.Components.Schemas, name, chain)
+	next.history = append(slices.Clip(m.schemaTree.history), m.schemaTree)
+	m.schemaTree = next
+}
+
+func (m Model) renderSchemaTree() string {
+	tree := m.schemaTree
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray))
+
+	nodes := tree.visible()
+	cursor := min(tree.cursor, len(nodes)-1)
+	bodyHeight := max(1, m.height-7)
+	if len(tree.chain) > 1 {
+		bodyHeight = max(1, bodyHeight-1)
+	}
+	start := max(0, min(cursor-bodyHeight+1, len(nodes)-bodyHeight))
+
+	var lines []string
+	for i := start; i < len(nodes) && i < start+bodyHeight; i++ {
+		node := nodes[i]
+		style := lipgloss.NewStyle()
+		if i == cursor {
+			style = highlight(style)
+		}
+
+		icon := "  "
+		switch {
+		case node.expanded:
+			icon = "▼ "
+		case node.expandable():
+			icon = "▶ "
+		}
+		labelStyle := style.Bold(true)
+		if node.level != "" {
+			labelStyle = labelStyle.Foreground(lipgloss.Color(colorThemePurple))
+		}
+		line := style.Render(strings.Repeat("  ", node.depth)+icon) + labelStyle.Render(node.label)
+		if notes := node.annotations(); len(notes) > 0 {
+			line += style.Foreground(lipgloss.Color(colorGreen)).Render("  " + strings.Join(notes, " "))
+		}
+		if ref := node.ref(); ref != "" && node.depth > 0 {
+			line += style.Foreground(lipgloss.Color(colorBlue)).Render("  → " + ref)
+			if node.circular() {
+				line += style.Foreground(lipgloss.Color(colorYellow)).Render(" (circular)")
+			}
+		}
+		if s := node.schema(); s != nil && s.Description != "" {
+			line += style.Foreground(lipgloss.Color(colorGray)).Render("  " + strings.SplitN(strings.TrimSpace(s.Description), "\n", 2)[0])
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(m.width).Render(line))
+	}
+
+	var trail []string
+	for _, previous := range tree.history {
+		trail = append(trail, previous.root.label)
+	}
+	title := titleStyle.Render("Schema " + tree.root.label)
+	if len(trail) > 0 {
+		title = grayStyle.Render(strings.Join(trail, " › ")+" › ") + title
+	}
+	if len(tree.chain) > 1 {
+		var levels []string
+		for _, name := range tree.chain {
+			if name == tree.root.label {
+				name = titleStyle.Render(name)
+			}
+			levels = append(levels, name)
+		}
+		title += "\n" + grayStyle.Render("Inheritance: ") + strings.Join(levels, grayStyle.Render(" → "))
+	}
+
+	instruction := "j/k move · l/h expand/collapse · Enter follow $ref"
+	if len(tree.chain) > 1 {
+		instruction += " · [/] parent/child"
+	}
+	if tree.levels != nil {
+		instruction += " · i as written/by level"
+	}
+	if len(tree.history) > 0 {
+		instruction += " · Backspace back"
+	}
+	instruction += " · Esc close"
+	return lipgloss.NewStyle().MaxHeight(m.height).Render(title + "\n\n" + strings.Join(lines, "\n") + "\n\n" + instructionStyle.Render(instruction))
+}
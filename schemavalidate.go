@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	"go.yaml.in/yaml/v4"
+)
+
+// validateJSONBody checks a request body against its schema before it is sent, returning a
+// problem per violation such as "pet.name is required". The keywords a hand-written body
+// usually gets wrong are checked: types, required properties, enums, lengths, ranges,
+// patterns and item counts
+func validateJSONBody(schema *base.Schema, body string) []string {
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []string{"body is not valid JSON: " + err.Error()}
+	}
+	var problems []string
+	validateValue(schema, value, "", &problems, 0)
+	return problems
+}
+
+func validateValue(s *base.Schema, value any, path string, problems *[]string, depth int) {
+	if s == nil || depth > maxSchemaDepth {
+		return
+	}
+	name := path
+	if name == "" {
+		name = "body"
+	}
+	report := func(format string, args ...any) {
+		*problems = append(*problems, name+" "+fmt.Sprintf(format, args...))
+	}
+
+	for _, proxy := range s.AllOf {
+		if proxy != nil {
+			validateValue(proxy.Schema(), value, path, problems, depth+1)
+		}
+	}
+
+	if value == nil {
+		if len(s.Type) > 0 && !slices.Contains(s.Type, "null") && (s.Nullable == nil || !*s.Nullable) {
+			report("must not be null")
+		}
+		return
+	}
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return jsonTypeMatches(t, value) }) {
+		report("must be %s", strings.Join(s.Type, " or "))
+		return
+	}
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		report("must be one of %s", strings.Join(enumJSONValues(s.Enum), ", "))
+	}
+
+	switch v := value.(type) {
+	case string:
+		n := int64(utf8.RuneCountInString(v))
+		if s.MinLength != nil && n < *s.MinLength {
+			report("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			report("must be at most %d characters", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(v) {
+				report("must match %s", s.Pattern)
+			}
+		}
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return
+		}
+		// exclusiveMinimum is a flag on minimum in OpenAPI 3.0 and a bound of its own in 3.1
+		if limit, ok := exclusiveLimit(s.ExclusiveMinimum, s.Minimum); ok && f <= limit {
+			report("must be more than %s", formatNumber(limit, -1))
+		} else if s.Minimum != nil && f < *s.Minimum {
+			report("must be at least %s", formatNumber(*s.Minimum, -1))
+		}
+		if limit, ok := exclusiveLimit(s.ExclusiveMaximum, s.Maximum); ok && f >= limit {
+			report("must be less than %s", formatNumber(limit, -1))
+		} else if s.Maximum != nil && f > *s.Maximum {
+			report("must be at most %s", formatNumber(*s.Maximum, -1))
+		}
+	case []any:
+		n := int64(len(v))
+		if s.MinItems != nil && n < *s.MinItems {
+			report("must have at least %d %s", *s.MinItems, plural(int(*s.MinItems), "item", "items"))
+		}
+		if s.MaxItems != nil && n > *s.MaxItems {
+			report("must have at most %d %s", *s.MaxItems, plural(int(*s.MaxItems), "item", "items"))
+		}
+		if s.Items != nil && s.Items.IsA() && s.Items.A != nil {
+			for i, item := range v {
+				validateValue(s.Items.A.Schema(), item, fmt.Sprintf("%s[%d]", path, i), problems, depth+1)
+			}
+		}
+	case map[string]any:
+		for _, required := range s.Required {
+			if _, ok := v[required]; !ok {
+				*problems = append(*problems, joinBodyPath(path, required)+" is required")
+			}
+		}
+		if s.Properties != nil {
+			for pair := s.Properties.First(); pair != nil; pair = pair.Next() {
+				if child, ok := v[pair.Key()]; ok && pair.Value() != nil {
+					validateValue(pair.Value().Schema(), child, joinBodyPath(path, pair.Key()), problems, depth+1)
+				}
+			}
+		}
+	}
+}
+
+func joinBodyPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// jsonTypeMatches reports whether a decoded JSON value is of a JSON Schema type
+func jsonTypeMatches(t string, value any) bool {
+	switch v := value.(type) {
+	case string:
+		return t == "string"
+	case bool:
+		return t == "boolean"
+	case json.Number:
+		return t == "number" || (t == "integer" && !strings.ContainsAny(v.String(), ".eE"))
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	}
+	return false
+}
+
+func exclusiveLimit(exclusive *base.DynamicValue[bool, float64], bound *float64) (float64, bool) {
+	switch {
+	case exclusive == nil:
+		return 0, false
+	case exclusive.IsB():
+		return exclusive.B, true
+	case exclusive.A && bound != nil:
+		return *bound, true
+	}
+	return 0, false
+}
+
+func enumContains(enum []*yaml.Node, value any) bool {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	return slices.Contains(enumJSONValues(enum), string(encoded))
+}
+
+// enumJSONValues renders the values of an enum as compact JSON
+func enumJSONValues(enum []*yaml.Node) []string {
+	var values []string
+	for _, node := range enum {
+		if node == nil {
+			continue
+		}
+		var buf, compact bytes.Buffer
+		if err := writeJSONNode(&buf, node); err != nil {
+			continue
+		}
+		if err := json.Compact(&compact, buf.Bytes()); err != nil {
+			continue
+		}
+		values = append(values, compact.String())
+	}
+	return values
+}
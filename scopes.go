@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// roleExtensions list the roles or permissions an operation needs, for APIs that authorize
+// by role rather than OAuth scope
+var roleExtensions = []string{"x-roles", "x-required-roles", "x-permissions"}
+
+// scopeColumn is a security scheme, one of its scopes, or a role
+type scopeColumn struct {
+	scheme string
+	scope  string
+	role   bool
+}
+
+func (c scopeColumn) label() string {
+	switch {
+	case c.role:
+		return "role:" + c.scope
+	case c.scope == "":
+		return c.scheme
+	default:
+		return c.scheme + ":" + c.scope
+	}
+}
+
+// scopeRow holds what one operation requires. Security requirements are alternatives, any
+// one of them grants access, so cells record which alternatives need the column
+type scopeRow struct {
+	ep           endpoint
+	alternatives int
+	public       bool
+	cells        map[int][]int
+}
+
+// cell renders the requirement of the row for column i: mark when the only alternative
+// needs it, the 1-based numbers of the alternatives needing it otherwise
+func (r scopeRow) cell(i int, mark string) string {
+	alternatives := r.cells[i]
+	if len(alternatives) == 0 {
+		return ""
+	}
+	if r.alternatives == 1 {
+		return mark
+	}
+	numbers := make([]string, len(alternatives))
+	for j, n := range alternatives {
+		numbers[j] = strconv.Itoa(n)
+	}
+	return strings.Join(numbers, "|")
+}
+
+// access summarizes the row for the first column
+func (r scopeRow) access() string {
+	switch {
+	case r.alternatives == 0 && len(r.cells) == 0:
+		return "public"
+	case r.public:
+		return "optional"
+	default:
+		return ""
+	}
+}
+
+type scopeMatrix struct {
+	columns []scopeColumn
+	rows    []scopeRow
+}
+
+// effectiveSecurity returns the security requirements of op, falling back to the ones
+// declared for the whole spec. An explicit empty list makes the operation public
+func effectiveSecurity(doc *v3.Document, op *v3.Operation) []*base.SecurityRequirement {
+	if op.Security != nil {
+		return op.Security
+	}
+	return doc.Security
+}
+
+// operationRoles returns the roles listed in the role extensions of op
+func operationRoles(op *v3.Operation) []string {
+	if op.Extensions == nil {
+		return nil
+	}
+	var roles []string
+	for _, name := range roleExtensions {
+		node := op.Extensions.GetOrZero(name)
+		if node == nil {
+			continue
+		}
+		values := []string{node.Value}
+		if len(node.Content) > 0 {
+			values = nil
+			for _, item := range node.Content {
+				values = append(values, item.Value)
+			}
+		}
+		for _, role := range values {
+			if role = strings.TrimSpace(role); role != "" && !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	return roles
+}
+
+// buildScopeMatrix lays out the security schemes, scopes and roles every operation needs.
+// Columns follow the order of components.securitySchemes and of the scopes they declare,
+// anything only found on operations comes after
+func buildScopeMatrix(doc *v3.Document, eps []endpoint) scopeMatrix {
+	var matrix scopeMatrix
+	index := map[scopeColumn]int{}
+	column := func(c scopeColumn) int {
+		i, ok := index[c]
+		if !ok {
+			i = len(matrix.columns)
+			index[c] = i
+			matrix.columns = append(matrix.columns, c)
+		}
+		return i
+	}
+
+	// Preallocate the declared order so the first operation using a scope doesn't decide it
+	used := map[scopeColumn]bool{}
+	for _, ep := range eps {
+		for _, req := range effectiveSecurity(doc, ep.op) {
+			if req == nil || req.Requirements == nil {
+				continue
+			}
+			for pair := req.Requirements.First(); pair != nil; pair = pair.Next() {
+				if len(pair.Value()) == 0 {
+					used[scopeColumn{scheme: pair.Key()}] = true
+				}
+				for _, scope := range pair.Value() {
+					used[scopeColumn{scheme: pair.Key(), scope: scope}] = true
+				}
+			}
+		}
+	}
+	if doc.Components != nil && doc.Components.SecuritySchemes != nil {
+		for pair := doc.Components.SecuritySchemes.First(); pair != nil; pair = pair.Next() {
+			if used[scopeColumn{scheme: pair.Key()}] {
+				column(scopeColumn{scheme: pair.Key()})
+			}
+			for _, scope := range declaredScopes(pair.Value()) {
+				if c := (scopeColumn{scheme: pair.Key(), scope: scope}); used[c] {
+					column(c)
+				}
+			}
+		}
+	}
+
+	for _, ep := range eps {
+		row := scopeRow{ep: ep, cells: map[int][]int{}}
+		for _, req := range effectiveSecurity(doc, ep.op) {
+			if req == nil {
+				continue
+			}
+			if req.ContainsEmptyRequirement || req.Requirements == nil || req.Requirements.Len() == 0 {
+				row.public = true
+				continue
+			}
+			row.alternatives++
+			for pair := req.Requirements.First(); pair != nil; pair = pair.Next() {
+				cols := []scopeColumn{{scheme: pair.Key()}}
+				if len(pair.Value()) > 0 {
+					cols = nil
+					for _, scope := range pair.Value() {
+						cols = append(cols, scopeColumn{scheme: pair.Key(), scope: scope})
+					}
+				}
+				for _, c := range cols {
+					i := column(c)
+					row.cells[i] = append(row.cells[i], row.alternatives)
+				}
+			}
+		}
+		if roles := operationRoles(ep.op); len(roles) > 0 {
+			// Roles apply whichever security requirement is used
+			row.alternatives = max(1, row.alternatives)
+			for _, role := range roles {
+				i := column(scopeColumn{scope: role, role: true})
+				for n := 1; n <= row.alternatives; n++ {
+					row.cells[i] = append(row.cells[i], n)
+				}
+			}
+		}
+		matrix.rows = append(matrix.rows, row)
+	}
+	return matrix
+}
+
+// declaredScopes returns the scopes of every OAuth2 flow of a scheme, in declaration order
+func declaredScopes(scheme *v3.SecurityScheme) []string {
+	if scheme == nil || scheme.Flows == nil {
+		return nil
+	}
+	var scopes []string
+	for _, flow := range []*v3.OAuthFlow{scheme.Flows.Implicit, scheme.Flows.Password, scheme.Flows.ClientCredentials, scheme.Flows.AuthorizationCode, scheme.Flows.Device} {
+		if flow == nil || flow.Scopes == nil {
+			continue
+		}
+		for pair := flow.Scopes.First(); pair != nil; pair = pair.Next() {
+			if !slices.Contains(scopes, pair.Key()) {
+				scopes = append(scopes, pair.Key())
+			}
+		}
+	}
+	return scopes
+}
+
+// writeScopeCSV writes the matrix with one row per operation and one column per scope
+func writeScopeCSV(w io.Writer, matrix scopeMatrix) error {
+	cw := csv.NewWriter(w)
+	header := []string{"method", "path", "operationId", "access"}
+	for _, c := range matrix.columns {
+		header = append(header, c.label())
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, row := range matrix.rows {
+		record := []string{row.ep.method, row.ep.path, row.ep.op.OperationId, row.access()}
+		for i := range matrix.columns {
+			record = append(record, row.cell(i, "x"))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeScopeTable(w io.Writer, matrix scopeMatrix) {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	header := []string{"METHOD", "PATH", "ACCESS"}
+	for _, c := range matrix.columns {
+		header = append(header, c.label())
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range matrix.rows {
+		record := []string{row.ep.method, row.ep.path, row.access()}
+		for i := range matrix.columns {
+			record = append(record, row.cell(i, "x"))
+		}
+		fmt.Fprintln(tw, strings.Join(record, "\t"))
+	}
+	tw.Flush()
+
+	// Empty cells at the end of a row would leave trailing padding
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line != "" {
+			fmt.Fprintln(w, strings.TrimRight(line, " \n"))
+		}
+	}
+}
+
+// runScopes implements `oq scopes spec.yaml`, printing the scope matrix as a table or CSV
+func runScopes(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("scopes", flag.ContinueOnError)
+	format := fs.String("format", "table", "output format: table or csv")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq scopes [--format table|csv] [spec]\n\n")
+		fs.PrintDefaults()
+	}
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) > 1 || (*format != "table" && *format != "csv") {
+		fs.Usage()
+		return 2
+	}
+
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	}
+	_, doc, err := loadSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+
+	matrix := buildScopeMatrix(doc, extractEndpoints(doc))
+	if *format == "csv" {
+		if err := writeScopeCSV(os.Stdout, matrix); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	writeScopeTable(os.Stdout, matrix)
+	return 0
+}
+
+// scopePane shows the scope matrix of the listed endpoints, scrolling in both directions
+type scopePane struct {
+	matrix scopeMatrix
+	row    int
+	column int
+}
+
+// openScopeMatrix opens the scope matrix for the endpoints currently listed
+func (m *Model) openScopeMatrix() {
+	eps := m.getActiveEndpoints()
+	if len(eps) == 0 {
+		m.setStatus("No endpoints to show", true)
+		return
+	}
+	m.scopes = &scopePane{matrix: buildScopeMatrix(m.doc, eps)}
+}
+
+// exportPath names an export such as scopes.csv after the spec, e.g. petstore-scopes.csv
+func (m *Model) exportPath(name string) string {
+	if m.specPath == "" || isRemoteSpec(m.specPath) {
+		return name
+	}
+	base := strings.TrimSuffix(filepath.Base(m.specPath), filepath.Ext(m.specPath))
+	return base + "-" + name
+}
+
+// updateScopes handles keys while the scope matrix is open
+func (m *Model) updateScopes(key string) {
+	pane := m.scopes
+	rows, columns := len(pane.matrix.rows), len(pane.matrix.columns)
+	page := max(1, m.height/2)
+	switch key {
+	case "esc", "q", "A":
+		m.scopes = nil
+	case "up", "k":
+		pane.row = max(0, pane.row-1)
+	case "down", "j":
+		pane.row = min(rows-1, pane.row+1)
+	case "ctrl+u":
+		pane.row = max(0, pane.row-page)
+	case "ctrl+d":
+		pane.row = min(rows-1, pane.row+page)
+	case "g":
+		pane.row = 0
+	case "G":
+		pane.row = rows - 1
+	case "left", "h":
+		pane.column = max(0, pane.column-1)
+	case "right", "l":
+		pane.column = min(max(0, columns-1), pane.column+1)
+	case "w":
+		path := m.exportPath("scopes.csv")
+		f, err := os.Create(path)
+		if err == nil {
+			err = writeScopeCSV(f, pane.matrix)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			m.setStatus(fmt.Sprintf("Error exporting scopes: %v", err), true)
+			return
+		}
+		m.setStatus(fmt.Sprintf("Exported the scope matrix to %s", path), false)
+	}
+}
+
+func (m Model) renderScopePane() string {
+	pane := m.scopes
+	matrix := pane.matrix
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	headerStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorBlue)).
+		Bold(true)
+
+	// The operation column stays put while scope columns scroll horizontally
+	methodWidth := m.methodWidth(endpointMethods(matrix.endpoints()))
+	opWidth := min(max(20, m.width/3), 50)
+	var columnWidths []int
+	for _, c := range matrix.columns {
+		columnWidths = append(columnWidths, min(max(lipgloss.Width(c.label()), 3), 24))
+	}
+	visibleColumns := 0
+	used := opWidth + 10
+	for i := pane.column; i < len(matrix.columns) && used+columnWidths[i]+2 <= m.width; i++ {
+		used += columnWidths[i] + 2
+		visibleColumns++
+	}
+
+	cellStyle := func(width int) lipgloss.Style {
+		return lipgloss.NewStyle().Width(width).MaxWidth(width).Align(lipgloss.Center)
+	}
+
+	header := lipgloss.NewStyle().Width(opWidth).Render("") + headerStyle.Width(8).Render("access") + "  "
+	for i := pane.column; i < pane.column+visibleColumns; i++ {
+		header += headerStyle.Inherit(cellStyle(columnWidths[i])).Render(matrix.columns[i].label()) + "  "
+	}
+
+	bodyHeight := max(1, m.height-6)
+	start := max(0, min(pane.row-bodyHeight+1, len(matrix.rows)-bodyHeight))
+	var lines []string
+	for r := start; r < len(matrix.rows) && r < start+bodyHeight; r++ {
+		row := matrix.rows[r]
+		background := lipgloss.NewStyle()
+		if r == pane.row {
+			background = highlight(background)
+		}
+		methodStyle := background.
+			Foreground(m.methodColor(row.ep.method)).
+			Bold(true).
+			Width(methodWidth)
+		op := methodStyle.Render(m.methodLabel(row.ep.method)) + background.Render(" "+row.ep.path)
+		line := background.Width(opWidth).MaxWidth(opWidth).Render(op)
+		line += background.Foreground(lipgloss.Color(colorGray)).Width(8).Render(row.access()) + background.Render("  ")
+		for i := pane.column; i < pane.column+visibleColumns; i++ {
+			line += background.Foreground(lipgloss.Color(colorGreen)).Inherit(cellStyle(columnWidths[i])).Render(row.cell(i, "●")) + background.Render("  ")
+		}
+		lines = append(lines, line)
+	}
+	if len(matrix.columns) == 0 {
+		lines = append(lines, instructionStyle.Render("No operation requires a security scheme or role"))
+	}
+
+	more := ""
+	if hidden := len(matrix.columns) - pane.column - visibleColumns; hidden == 1 {
+		more = " · 1 more column →"
+	} else if hidden > 1 {
+		more = fmt.Sprintf(" · %d more columns →", hidden)
+	}
+	title := titleStyle.Render(fmt.Sprintf("Scope matrix (%d operations, %d scopes)", len(matrix.rows), len(matrix.columns)))
+	instruction := instructionStyle.Render("j/k rows · h/l columns · numbers are alternatives · w export CSV · Esc close" + more)
+
+	return lipgloss.NewStyle().MaxHeight(m.height).Render(title + "\n\n" + header + "\n" + strings.Join(lines, "\n") + "\n\n" + instruction)
+}
+
+func (matrix scopeMatrix) endpoints() []endpoint {
+	eps := make([]endpoint, len(matrix.rows))
+	for i, row := range matrix.rows {
+		eps[i] = row.ep
+	}
+	return eps
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// searchResult is one match of a search across all views
+type searchResult struct {
+	mode  viewMode
+	index int
+}
+
+// searchResults lists the filtered endpoints, webhooks and components in that order. They
+// are filtered together, so the query already applies to every view
+func (m *Model) searchResults() []searchResult {
+	if m.searchInput.Value() == "" {
+		return nil
+	}
+	var results []searchResult
+	for i := range m.filteredEndpoints {
+		results = append(results, searchResult{mode: viewEndpoints, index: i})
+	}
+	for i := range m.filteredWebhooks {
+		results = append(results, searchResult{mode: viewWebhooks, index: i})
+	}
+	for i := range m.filteredComponents {
+		results = append(results, searchResult{mode: viewComponents, index: i})
+	}
+	return results
+}
+
+// toggleSearchScope switches the search between the active view and all views
+func (m *Model) toggleSearchScope() {
+	m.searchAll = !m.searchAll
+	m.searchCursor = 0
+}
+
+func (m *Model) moveSearchCursor(step int) {
+	if n := len(m.searchResults()); n > 0 {
+		m.searchCursor = (m.searchCursor + step + n) % n
+	}
+}
+
+// openSearchResult ends the search and shows the selected result expanded in its view
+func (m *Model) openSearchResult() {
+	results := m.searchResults()
+	m.searchMode, m.searchAll = false, false
+	m.searchInput.Blur()
+	if m.searchCursor >= len(results) {
+		return
+	}
+	result := results[m.searchCursor]
+	m.searchCursor = 0
+
+	switch result.mode {
+	case viewEndpoints:
+		ep := m.filteredEndpoints[result.index]
+		m.searchInput.SetValue("")
+		m.jumpToEndpoint(ep)
+	case viewWebhooks:
+		hook := m.filteredWebhooks[result.index]
+		m.searchInput.SetValue("")
+		m.jumpToWebhook(hook.method, hook.name)
+	case viewComponents:
+		comp := m.filteredComponents[result.index]
+		m.searchInput.SetValue("")
+		m.filterItems()
+		m.jumpToComponent(comp.compType, comp.name)
+	}
+}
+
+// jumpToWebhook shows a webhook expanded in the webhooks view, clearing filters that hide it
+func (m *Model) jumpToWebhook(method, name string) {
+	m.mode = viewWebhooks
+	m.filterItems()
+
+	find := func() int {
+		for i, hook := range m.getActiveWebhooks() {
+			if hook.method == method && hook.name == name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	if find() < 0 {
+		m.filters = listFilters{}
+		m.searchInput.SetValue("")
+		m.filterItems()
+	}
+	for i := range m.webhooks {
+		if m.webhooks[i].method == method && m.webhooks[i].name == name {
+			m.webhooks[i].folded = false
+			break
+		}
+	}
+	m.filterItems()
+
+	m.cursor = max(0, find())
+	m.ensureCursorVisible()
+}
+
+// renderSearchResults renders the matches of every view in groups, keeping the selected
+// result within height lines
+func (m Model) renderSearchResults(height int) string {
+	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray))
+	groupStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorThemePurple)).Bold(true)
+
+	results := m.searchResults()
+	if m.searchInput.Value() == "" {
+		return grayStyle.Render("Type to search endpoints, webhooks and components at once") + "\n"
+	}
+	if len(results) == 0 {
+		return grayStyle.Render(fmt.Sprintf("Nothing matches %q in any view", m.searchInput.Value())) + "\n"
+	}
+
+	groupNames := map[viewMode]string{viewEndpoints: "Endpoints", viewWebhooks: "Webhooks", viewComponents: "Components"}
+	counts := map[viewMode]int{
+		viewEndpoints:  len(m.filteredEndpoints),
+		viewWebhooks:   len(m.filteredWebhooks),
+		viewComponents: len(m.filteredComponents),
+	}
+
+	var lines []string
+	selectedLine := 0
+	for i, result := range results {
+		if i == 0 || results[i-1].mode != result.mode {
+			lines = append(lines, groupStyle.Render(fmt.Sprintf("%s (%d)", groupNames[result.mode], counts[result.mode])))
+		}
+
+		style := lipgloss.NewStyle()
+		if i == m.searchCursor {
+			style = highlight(style)
+			selectedLine = len(lines)
+		}
+		var line string
+		switch result.mode {
+		case viewEndpoints:
+			ep := m.filteredEndpoints[result.index]
+			line = style.Render("  ") + style.Foreground(m.methodColor(ep.method)).Bold(true).Render(m.methodLabel(ep.method)) + style.Render(" "+ep.path+summarySuffix(ep.op.Summary))
+		case viewWebhooks:
+			hook := m.filteredWebhooks[result.index]
+			line = style.Render("  ") + style.Foreground(m.methodColor(hook.method)).Bold(true).Render(m.methodLabel(hook.method)) + style.Render(" "+hook.name+summarySuffix(hook.op.Summary))
+		case viewComponents:
+			comp := m.filteredComponents[result.index]
+			line = style.Render("  ") + style.Foreground(lipgloss.Color(colorGreen)).Render(comp.compType) + style.Render(" "+comp.name)
+		}
+		lines = append(lines, style.Width(m.width).MaxWidth(m.width).Render(line))
+	}
+
+	start := 0
+	if selectedLine >= height {
+		start = selectedLine - height + 1
+	}
+	return strings.Join(lines[start:min(len(lines), start+height)], "\n") + "\n"
+}
+
+// viewMatches counts the items of a view that match the search
+func (m Model) viewMatches(mode viewMode) int {
+	switch mode {
+	case viewEndpoints:
+		return len(m.getActiveEndpoints())
+	case viewWebhooks:
+		return len(m.getActiveWebhooks())
+	case viewComponents:
+		return len(m.getActiveComponents())
+	case viewTags:
+		return len(m.getActiveTags())
+	}
+	return 0
+}
+
+// searchMatchCount is the number of matches shown for the query as it is typed
+func (m Model) searchMatchCount() int {
+	if m.searchAll {
+		return len(m.searchResults())
+	}
+	return m.viewMatches(m.mode)
+}
+
+// searchViews are the views in tab order
+func (m Model) searchViews() []viewMode {
+	if m.hasWebhooks() {
+		return []viewMode{viewEndpoints, viewWebhooks, viewComponents, viewTags}
+	}
+	return []viewMode{viewEndpoints, viewComponents, viewTags}
+}
+
+// nextSearchView is the view tab switches to while searching: the next one in tab order that
+// has matches, or simply the next one when no other view does
+func (m Model) nextSearchView(step int) viewMode {
+	views := m.searchViews()
+	current := max(0, slices.Index(views, m.mode))
+	for i := 1; i < len(views); i++ {
+		mode := views[(current+step*i+len(views))%len(views)]
+		if m.viewMatches(mode) > 0 {
+			return mode
+		}
+	}
+	return views[(current+step+len(views))%len(views)]
+}
+
+// switchSearchView keeps the query and shows its matches in another view
+func (m *Model) switchSearchView(step int) {
+	m.mode = m.nextSearchView(step)
+	m.cursor = 0
+	m.scrollOffset = 0
+}
+
+// renderSearchMatchCount renders "3 matches" for the search prompt
+func (m Model) renderSearchMatchCount() string {
+	if m.searchInput.Value() == "" {
+		return ""
+	}
+	n := m.searchMatchCount()
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(colorThemePurple)).Bold(true)
+	if n == 0 {
+		style = style.Foreground(lipgloss.Color(colorRed))
+	}
+	return style.Render(fmt.Sprintf("%d %s", n, plural(n, "match", "matches")))
+}
+
+// renderSearchEmpty explains what to do when the query matches nothing in the active view,
+// pointing to a view where it does match
+func (m Model) renderSearchEmpty() string {
+	names := viewModeNames
+	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray))
+
+	message := fmt.Sprintf("No %s match '%s'", names[m.mode], m.searchInput.Value())
+	if !m.searchMode {
+		return grayStyle.Render(message+" — press / to change the search") + "\n"
+	}
+	message += " — press esc to clear"
+	if next := m.nextSearchView(1); m.viewMatches(next) > 0 {
+		message += ", or tab to search " + names[next]
+	}
+	return grayStyle.Render(message) + "\n"
+}
+
+func summarySuffix(summary string) string {
+	if summary == "" {
+		return ""
+	}
+	return " · " + summary
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// searchFields are the prefixes scoping a search term to one field of an operation, as in
+// path:/users or status:500
+var searchFields = []string{"path", "method", "tag", "status"}
+
+// searchTerm is one word of a search, matching anywhere unless field scopes it. Terms
+// starting with re: are case-insensitive regular expressions
+type searchTerm struct {
+	field string
+	text  string
+	re    *regexp.Regexp
+}
+
+// searchQuery is a parsed search. Items match when every term does
+type searchQuery []searchTerm
+
+// parseSearchQuery splits a search on spaces into terms, such as method:post tag:billing or
+// re:^/users/\d+$. Unknown prefixes, and regular expressions that don't compile yet while
+// being typed, are searched as plain text
+func parseSearchQuery(query string) searchQuery {
+	var terms searchQuery
+	for _, word := range strings.Fields(query) {
+		term := searchTerm{text: strings.ToLower(word)}
+		if field, value, ok := strings.Cut(term.text, ":"); ok && value != "" && slices.Contains(searchFields, field) {
+			term.field, term.text = field, value
+			word = word[len(field)+1:]
+		}
+		// The prefix is matched in any case, like the fields, the pattern is kept as typed
+		if len(word) > 3 && strings.EqualFold(word[:3], "re:") {
+			if re, err := regexp.Compile("(?i)" + word[3:]); err == nil {
+				term.re = re
+			}
+		}
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// scoped reports whether a term of the query only applies to operations
+func (q searchQuery) scoped() bool {
+	return slices.ContainsFunc(q, func(term searchTerm) bool { return term.field != "" })
+}
+
+// matches reports whether value contains the term, or matches its regular expression.
+// Empty values, such as a missing summary, never match
+func (t searchTerm) matches(value string) bool {
+	if value == "" {
+		return false
+	}
+	if t.re != nil {
+		return t.re.MatchString(value)
+	}
+	return strings.Contains(strings.ToLower(value), t.text)
+}
+
+// matchesAny reports whether any of values matches the term
+func (t searchTerm) matchesAny(values ...string) bool {
+	return slices.ContainsFunc(values, t.matches)
+}
+
+// matchesMethod compares a whole method, so method:put doesn't match OPTIONS
+func (t searchTerm) matchesMethod(method string) bool {
+	if t.re != nil {
+		return t.re.MatchString(method)
+	}
+	return strings.EqualFold(method, t.text)
+}
+
+// matchesStatus reports whether a response code starts with the term, so status:4 matches
+// every 4xx code, or is the range of a code, so status:404 matches a 4XX response
+func (t searchTerm) matchesStatus(codes []string) bool {
+	return slices.ContainsFunc(codes, func(code string) bool {
+		code = strings.ToLower(code)
+		if t.re != nil {
+			return t.re.MatchString(code)
+		}
+		return strings.HasPrefix(code, t.text) ||
+			(len(code) == 3 && strings.HasSuffix(code, "xx") && len(t.text) == 3 && t.text[0] == code[0])
+	})
+}
+
+// matchesOperation reports whether every term matches the operation, unscoped terms in its
+// path, method, summary, description or schemaNames, the names of its inline body schemas
+// for endpoints, which are only listed when the rest doesn't match
+func (q searchQuery) matchesOperation(method, path string, op *v3.Operation, schemaNames func() []string) bool {
+	for _, term := range q {
+		var ok bool
+		switch term.field {
+		case "path":
+			ok = term.matches(path)
+		case "method":
+			ok = term.matchesMethod(method)
+		case "tag":
+			ok = term.matchesAny(op.Tags...)
+		case "status":
+			ok = term.matchesStatus(responseCodes(op))
+		default:
+			ok = term.matchesAny(path, method, op.Summary, op.Description) || (schemaNames != nil && term.matchesAny(schemaNames()...))
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesTag reports whether every term matches a tag of the tags view: tag: terms its
+// name and unscoped ones its name or description. Other fields only apply to operations
+func (q searchQuery) matchesTag(name, description string) bool {
+	for _, term := range q {
+		switch term.field {
+		case "tag":
+			if !term.matches(name) {
+				return false
+			}
+		case "":
+			if !term.matchesAny(name, description) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// matchesText reports whether every term matches one of values. Scoped terms only apply to
+// operations, so nothing else matches them
+func (q searchQuery) matchesText(values ...string) bool {
+	if q.scoped() {
+		return false
+	}
+	for _, term := range q {
+		if !term.matchesAny(values...) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// serverEntry is a server declared in the spec, with the values chosen for its variables.
+// scope tells where it is declared: the document, a path or an operation
+type serverEntry struct {
+	scope    string
+	server   *v3.Server
+	values   map[string]string
+	expanded bool
+}
+
+// variableNames lists the server's variables in the order they are declared
+func (s serverEntry) variableNames() []string {
+	var names []string
+	if s.server.Variables != nil {
+		for pair := s.server.Variables.First(); pair != nil; pair = pair.Next() {
+			if pair.Value() != nil {
+				names = append(names, pair.Key())
+			}
+		}
+	}
+	return names
+}
+
+func (s serverEntry) value(name string) string {
+	if v, ok := s.values[name]; ok {
+		return v
+	}
+	return s.server.Variables.GetOrZero(name).Default
+}
+
+// resolveServerURL substitutes the server's variables, using values where set and the
+// defaults otherwise. Relative server URLs are resolved against the spec URL
+func resolveServerURL(server *v3.Server, values map[string]string, specPath string) string {
+	entry := serverEntry{server: server, values: values}
+	serverURL := server.URL
+	for _, name := range entry.variableNames() {
+		serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", entry.value(name))
+	}
+	if strings.HasPrefix(serverURL, "/") && isSpecURL(specPath) {
+		if base, err := url.Parse(specPath); err == nil {
+			if rel, err := url.Parse(serverURL); err == nil {
+				serverURL = base.ResolveReference(rel).String()
+			}
+		}
+	}
+	return serverURL
+}
+
+// collectServers lists the document servers, then those of path items and operations
+func collectServers(doc *v3.Document, eps []endpoint) []serverEntry {
+	var entries []serverEntry
+	add := func(scope string, servers []*v3.Server) {
+		for _, server := range servers {
+			if server != nil {
+				entries = append(entries, serverEntry{scope: scope, server: server})
+			}
+		}
+	}
+	add("Document", doc.Servers)
+
+	seenPaths := map[string]bool{}
+	for _, ep := range eps {
+		if doc.Paths != nil && doc.Paths.PathItems != nil && !seenPaths[ep.path] {
+			seenPaths[ep.path] = true
+			if item := doc.Paths.PathItems.GetOrZero(ep.path); item != nil {
+				add("Path "+ep.path, item.Servers)
+			}
+		}
+		add(ep.method+" "+ep.path, ep.op.Servers)
+	}
+	return entries
+}
+
+// serverURL is where requests to an endpoint go: the server selected in the servers pane,
+// the configured default server, or the first server of the operation, its path or the
+// document, with variables set to their defaults
+func (m *Model) serverURL(ep endpoint) string {
+	if m.activeServer != "" {
+		return m.activeServer
+	}
+	if m.curl.server != "" {
+		return m.curl.server
+	}
+	if server := operationServer(m.doc, ep); server != nil {
+		return resolveServerURL(server, nil, m.specPath)
+	}
+	return ""
+}
+
+// operationServer returns the first server of the operation, its path or the document
+func operationServer(doc *v3.Document, ep endpoint) *v3.Server {
+	var servers []*v3.Server
+	if ep.op != nil {
+		servers = ep.op.Servers
+	}
+	if len(servers) == 0 && doc.Paths != nil && doc.Paths.PathItems != nil {
+		if item := doc.Paths.PathItems.GetOrZero(ep.path); item != nil {
+			servers = item.Servers
+		}
+	}
+	if len(servers) == 0 {
+		servers = doc.Servers
+	}
+	if len(servers) == 0 {
+		return nil
+	}
+	return servers[0]
+}
+
+// serverRow is a line of the servers pane: a server, or one of its variables when expanded
+type serverRow struct {
+	entry    int
+	variable string
+}
+
+// serversPane lists the servers of the spec to pick the one requests and snippets use
+type serversPane struct {
+	entries []serverEntry
+	cursor  int
+}
+
+func (p *serversPane) rows() []serverRow {
+	var rows []serverRow
+	for i, entry := range p.entries {
+		rows = append(rows, serverRow{entry: i})
+		if entry.expanded {
+			for _, name := range entry.variableNames() {
+				rows = append(rows, serverRow{entry: i, variable: name})
+			}
+		}
+	}
+	return rows
+}
+
+// openServers opens the servers pane, keeping the variable values chosen last time
+func (m *Model) openServers() {
+	entries := collectServers(m.doc, m.endpoints)
+	if len(entries) == 0 {
+		m.setStatus("The spec declares no servers", false)
+		return
+	}
+	for i := range entries {
+		if values, ok := m.serverValues[entries[i].server.URL]; ok {
+			entries[i].values = values
+		}
+	}
+	m.servers = &serversPane{entries: entries}
+}
+
+// updateServers handles keys while the servers pane is open
+func (m *Model) updateServers(key string) {
+	pane := m.servers
+	rows := pane.rows()
+	row := rows[pane.cursor]
+	entry := &pane.entries[row.entry]
+
+	switch key {
+	case "esc", "q", "S":
+		m.servers = nil
+	case "up", "k":
+		pane.cursor = max(0, pane.cursor-1)
+	case "down", "j":
+		pane.cursor = min(len(rows)-1, pane.cursor+1)
+	case "g":
+		pane.cursor = 0
+	case "G":
+		pane.cursor = len(rows) - 1
+	case " ", "tab":
+		if len(entry.variableNames()) == 0 {
+			m.setStatus(entry.server.URL+" has no variables", false)
+			return
+		}
+		entry.expanded = !entry.expanded
+		if !entry.expanded {
+			pane.cursor = slices.IndexFunc(pane.rows(), func(r serverRow) bool { return r.entry == row.entry })
+		}
+	case "left", "h", "right", "l":
+		if row.variable == "" {
+			return
+		}
+		variable := entry.server.Variables.GetOrZero(row.variable)
+		if len(variable.Enum) == 0 {
+			m.setStatus(fmt.Sprintf("%s has no enum, its default %q is used", row.variable, variable.Default), false)
+			return
+		}
+		step := 1
+		if key == "left" || key == "h" {
+			step = len(variable.Enum) - 1
+		}
+		current := max(0, slices.Index(variable.Enum, entry.value(row.variable)))
+		if entry.values == nil {
+			entry.values = map[string]string{}
+		}
+		entry.values[row.variable] = variable.Enum[(current+step)%len(variable.Enum)]
+		if m.serverValues == nil {
+			m.serverValues = map[string]map[string]string{}
+		}
+		m.serverValues[entry.server.URL] = entry.values
+	case "enter":
+		resolved := resolveServerURL(entry.server, entry.values, m.specPath)
+		if m.activeServer == resolved {
+			m.activeServer = ""
+			m.setStatus("Requests use the spec's servers again", false)
+			return
+		}
+		m.activeServer = resolved
+		m.setStatus("Requests and snippets now use "+resolved, false)
+	}
+}
+
+func (m Model) renderServersPane() string {
+	pane := m.servers
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray))
+
+	scopeWidth := 10
+	for _, entry := range pane.entries {
+		scopeWidth = max(scopeWidth, lipgloss.Width(entry.scope))
+	}
+	scopeWidth = min(scopeWidth, max(10, m.width/3))
+
+	rows := pane.rows()
+	bodyHeight := max(1, m.height-6)
+	start := max(0, min(pane.cursor-bodyHeight+1, len(rows)-bodyHeight))
+	var lines []string
+	for i := start; i < len(rows) && i < start+bodyHeight; i++ {
+		row := rows[i]
+		entry := pane.entries[row.entry]
+		background := lipgloss.NewStyle()
+		if i == pane.cursor {
+			background = highlight(background)
+		}
+
+		var line string
+		if row.variable == "" {
+			marker := "  "
+			resolved := resolveServerURL(entry.server, entry.values, m.specPath)
+			if resolved == m.activeServer {
+				marker = "● "
+			}
+			line = background.Foreground(lipgloss.Color(colorGreen)).Render(marker)
+			line += background.Foreground(lipgloss.Color(colorGray)).Width(scopeWidth).MaxWidth(scopeWidth).Render(entry.scope)
+			line += background.Render("  " + resolved)
+			if entry.server.Description != "" {
+				line += background.Foreground(lipgloss.Color(colorGray)).Render(" - " + entry.server.Description)
+			}
+			if n := len(entry.variableNames()); n > 0 && !entry.expanded {
+				line += background.Foreground(lipgloss.Color(colorGray)).Render(fmt.Sprintf("  (%d %s)", n, plural(n, "variable", "variables")))
+			}
+		} else {
+			variable := entry.server.Variables.GetOrZero(row.variable)
+			line = background.Render(strings.Repeat(" ", scopeWidth+4))
+			line += background.Foreground(lipgloss.Color(colorBlue)).Render(row.variable + " = ")
+			line += background.Bold(true).Render(entry.value(row.variable))
+			if len(variable.Enum) > 0 {
+				line += background.Foreground(lipgloss.Color(colorGray)).Render("  ←/→ " + strings.Join(variable.Enum, " | "))
+			}
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(m.width).Render(line))
+	}
+
+	active := grayStyle.Render("No server selected, requests use the first server of the operation, its path or the spec")
+	if m.activeServer != "" {
+		active = grayStyle.Render("Active: ") + m.activeServer
+	}
+	title := titleStyle.Render(fmt.Sprintf("Servers (%d)", len(pane.entries)))
+	instruction := instructionStyle.Render("j/k move · Space variables · ←/→ change value · Enter use server · Esc close")
+	return lipgloss.NewStyle().MaxHeight(m.height).Render(title + "\n\n" + strings.Join(lines, "\n") + "\n\n" + active + "\n\n" + instruction)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// sessionState is what oq remembers of a spec between runs: the view, the selected item,
+// the search and the fold state of the items
+type sessionState struct {
+	Spec          string   `yaml:"spec"`
+	View          string   `yaml:"view,omitempty"`
+	Selected      string   `yaml:"selected,omitempty"`
+	Cursor        int      `yaml:"cursor,omitempty"`
+	Search        string   `yaml:"search,omitempty"`
+	Unfolded      []string `yaml:"unfolded,omitempty"`
+	GroupedByTag  bool     `yaml:"grouped_by_tag,omitempty"`
+	CollapsedTags []string `yaml:"collapsed_tags,omitempty"`
+}
+
+// stateDir returns the oq directory inside $XDG_STATE_HOME, ~/.local/state by default
+func stateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "oq"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "oq"), nil
+}
+
+// sessionPath returns the state file of a spec, named after a hash of its absolute path or
+// URL. Specs read from stdin have none
+func sessionPath(specPath string) (string, error) {
+	if specPath == "" {
+		return "", nil
+	}
+	if !isRemoteSpec(specPath) {
+		abs, err := filepath.Abs(specPath)
+		if err != nil {
+			return "", err
+		}
+		specPath = abs
+	}
+	dir, err := stateDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(specPath))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".yaml"), nil
+}
+
+// loadSession reads the state saved for a spec, nil when there is none yet
+func loadSession(path string) (*sessionState, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading session state: %w", err)
+	}
+	state := &sessionState{}
+	if err := yaml.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("Error parsing session state %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// loadSpecSession returns the state saved for a spec, nil when there is none or it can't be
+// read. The session state only helps to pick up where the last run left off, so it never
+// stops oq from opening the spec
+func loadSpecSession(specPath string) *sessionState {
+	file, err := sessionPath(specPath)
+	if err != nil {
+		debugLog.Warn("no session state", "error", err)
+	}
+	if file == "" {
+		return nil
+	}
+	state, err := loadSession(file)
+	if err != nil {
+		debugLog.Warn("ignoring session state", "error", err)
+	}
+	return state
+}
+
+func (s sessionState) save(path string) error {
+	content, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o600)
+}
+
+// captureSession records where the user is in the spec
+func (m *Model) captureSession() sessionState {
+	state := sessionState{
+		Spec:          m.specPath,
+		View:          viewModeNames[m.mode],
+		Cursor:        m.cursor,
+		Search:        m.searchInput.Value(),
+		GroupedByTag:  m.groupedByTag,
+		CollapsedTags: slices.Sorted(maps.Keys(m.collapsedTags)),
+	}
+	if keys := m.itemKeys(); m.cursor >= 0 && m.cursor < len(keys) {
+		state.Selected = keys[m.cursor]
+	}
+	for _, ep := range m.endpoints {
+		if !ep.folded {
+			state.Unfolded = append(state.Unfolded, "endpoint "+ep.method+" "+ep.path)
+		}
+	}
+	for _, hook := range m.webhooks {
+		if !hook.folded {
+			state.Unfolded = append(state.Unfolded, "webhook "+hook.method+" "+hook.name)
+		}
+	}
+	for _, comp := range m.components {
+		if !comp.folded {
+			state.Unfolded = append(state.Unfolded, "component "+comp.compType+" "+comp.name)
+		}
+	}
+	for _, tag := range m.tags {
+		if !tag.folded {
+			state.Unfolded = append(state.Unfolded, "tag "+tag.name)
+		}
+	}
+	return state
+}
+
+// restoreSession puts the model back where the saved session left it. Items that are gone
+// from the spec are skipped, and the cursor falls back to its saved position when the
+// selected item can't be found
+func (m *Model) restoreSession(state sessionState) {
+	unfolded := map[string]bool{}
+	for _, key := range state.Unfolded {
+		unfolded[key] = true
+	}
+	for i, ep := range m.endpoints {
+		m.endpoints[i].folded = !unfolded["endpoint "+ep.method+" "+ep.path]
+	}
+	for i, hook := range m.webhooks {
+		m.webhooks[i].folded = !unfolded["webhook "+hook.method+" "+hook.name]
+	}
+	if mode, _ := parseViewMode(state.View); mode == viewComponents || slices.ContainsFunc(state.Unfolded, func(key string) bool {
+		return strings.HasPrefix(key, "component ")
+	}) {
+		m.loadComponents()
+	}
+	for i, comp := range m.components {
+		m.components[i].folded = !unfolded["component "+comp.compType+" "+comp.name]
+	}
+	for i, tag := range m.tags {
+		m.tags[i].folded = !unfolded["tag "+tag.name]
+	}
+
+	m.groupedByTag = state.GroupedByTag
+	m.collapsedTags = map[string]bool{}
+	for _, tag := range state.CollapsedTags {
+		m.collapsedTags[tag] = true
+	}
+	if mode, ok := parseViewMode(state.View); ok && (mode != viewWebhooks || m.hasWebhooks()) {
+		m.mode = mode
+	}
+	m.searchInput.SetValue(state.Search)
+	m.filterItems()
+
+	if i := slices.Index(m.itemKeys(), state.Selected); i >= 0 && state.Selected != "" {
+		m.cursor = i
+	} else {
+		m.cursor = max(0, min(state.Cursor, m.getMaxItems()))
+	}
+	m.ensureCursorVisible()
+}
+
+// itemKeys identifies the rows of the active view, with the keys of selectedDetails
+func (m *Model) itemKeys() []string {
+	var keys []string
+	switch m.mode {
+	case viewEndpoints:
+		eps := m.getActiveEndpoints()
+		for _, row := range m.endpointRows() {
+			if row.isHeader() {
+				keys = append(keys, "tag "+row.tag)
+			} else {
+				keys = append(keys, "endpoint "+eps[row.index].method+" "+eps[row.index].path)
+			}
+		}
+	case viewComponents:
+		for _, comp := range m.getActiveComponents() {
+			keys = append(keys, "component "+comp.compType+" "+comp.name)
+		}
+	case viewWebhooks:
+		for _, hook := range m.getActiveWebhooks() {
+			keys = append(keys, "webhook "+hook.method+" "+hook.name)
+		}
+	case viewTags:
+		for _, tag := range m.getActiveTags() {
+			keys = append(keys, "tag "+tag.name)
+		}
+	}
+	return keys
+}
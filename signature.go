@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// signatureExtensions document how a webhook is signed, either as the header name,
+// e.g. `x-signature: X-Hub-Signature-256`, or as a mapping with header and algorithm
+var signatureExtensions = []string{"x-signature", "x-webhook-signature"}
+
+// webhookSignature is how a webhook sender signs its payload
+type webhookSignature struct {
+	header    string
+	algorithm string
+}
+
+func (s webhookSignature) String() string {
+	return fmt.Sprintf("%s (HMAC-%s)", s.header, strings.ToUpper(s.algorithm))
+}
+
+// findWebhookSignature looks for a signing extension, a signature header parameter or an
+// apiKey security scheme sent in a signature header
+func findWebhookSignature(op *v3.Operation, doc *v3.Document) (webhookSignature, bool) {
+	if op == nil {
+		return webhookSignature{}, false
+	}
+
+	if op.Extensions != nil {
+		for _, ext := range signatureExtensions {
+			node, ok := op.Extensions.Get(ext)
+			if !ok || node == nil {
+				continue
+			}
+			switch node.Kind {
+			case yaml.ScalarNode:
+				return newWebhookSignature(node.Value, ""), true
+			case yaml.MappingNode:
+				var header, algorithm string
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					switch node.Content[i].Value {
+					case "header":
+						header = node.Content[i+1].Value
+					case "algorithm":
+						algorithm = node.Content[i+1].Value
+					}
+				}
+				return newWebhookSignature(header, algorithm), true
+			}
+		}
+	}
+
+	for _, param := range op.Parameters {
+		if param != nil && strings.EqualFold(param.In, "header") && isSignatureHeader(param.Name) {
+			return newWebhookSignature(param.Name, ""), true
+		}
+	}
+
+	if doc != nil && doc.Components != nil && doc.Components.SecuritySchemes != nil {
+		for _, req := range op.Security {
+			for pair := req.Requirements.First(); pair != nil; pair = pair.Next() {
+				scheme := doc.Components.SecuritySchemes.GetOrZero(pair.Key())
+				if scheme != nil && scheme.Type == "apiKey" && scheme.In == "header" && isSignatureHeader(scheme.Name) {
+					return newWebhookSignature(scheme.Name, ""), true
+				}
+			}
+		}
+	}
+
+	return webhookSignature{}, false
+}
+
+func isSignatureHeader(name string) bool {
+	return strings.Contains(strings.ToLower(name), "signature")
+}
+
+// newWebhookSignature fills in defaults, guessing the algorithm from headers such as
+// X-Hub-Signature-256 and falling back to SHA-256
+func newWebhookSignature(header, algorithm string) webhookSignature {
+	if header == "" {
+		header = "X-Signature"
+	}
+	algorithm = strings.ToLower(strings.ReplaceAll(algorithm, "-", ""))
+	algorithm = strings.TrimPrefix(algorithm, "hmac")
+	if algorithm == "" {
+		algorithm = "sha256"
+		lower := strings.ToLower(header)
+		for _, alg := range []string{"sha1", "sha512"} {
+			if strings.Contains(lower, alg) || strings.HasSuffix(lower, "-"+strings.TrimPrefix(alg, "sha")) {
+				algorithm = alg
+			}
+		}
+	}
+	return webhookSignature{header: header, algorithm: algorithm}
+}
+
+// goHashPackages maps supported algorithms to the Go package providing them
+var goHashPackages = map[string]string{"sha1": "crypto/sha1", "sha256": "crypto/sha256", "sha512": "crypto/sha512"}
+
+// verificationSnippet returns Go and JavaScript examples that check the HMAC of a webhook
+// payload against the signature header
+func (s webhookSignature) verificationSnippet() string {
+	pkg, ok := goHashPackages[s.algorithm]
+	if !ok {
+		return fmt.Sprintf("# Unsupported signature algorithm %q, verify %s manually", s.algorithm, s.header)
+	}
+	goName := pkg[strings.LastIndex(pkg, "/")+1:]
+
+	var snippet strings.Builder
+	snippet.WriteString(fmt.Sprintf("// Go: verify %s\n", s))
+	snippet.WriteString(fmt.Sprintf(`import (
+	"crypto/hmac"
+	"%s"
+	"encoding/hex"
+	"strings"
+)
+
+func verifySignature(payload []byte, header, secret string) bool {
+	mac := hmac.New(%s.New, []byte(secret))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	// Some senders prefix the digest, e.g. "%s=<hex>"
+	signature := strings.TrimPrefix(header, "%s=")
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+// signature: r.Header.Get("%s")
+`, pkg, goName, s.algorithm, s.algorithm, s.header))
+
+	snippet.WriteString(fmt.Sprintf(`
+// JavaScript (Node.js): verify %s
+const crypto = require("crypto");
+
+function verifySignature(payload, header, secret) {
+  const expected = crypto.createHmac("%s", secret).update(payload).digest("hex");
+  const signature = header.replace(/^%s=/, "");
+  return signature.length === expected.length &&
+    crypto.timingSafeEqual(Buffer.from(signature), Buffer.from(expected));
+}
+// header: req.headers["%s"], payload: the raw request body
+`, s, s.algorithm, s.algorithm, strings.ToLower(s.header)))
+
+	return snippet.String()
+}
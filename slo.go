@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// Extensions documenting how fast an operation should respond, either as a duration
+// (x-response-time: 300ms) or as a mapping of percentiles (x-slo: {p95: 200ms, p99: 1s}).
+// Durations without a unit are milliseconds. The first one present wins
+var sloExtensions = []string{"x-slo", "x-response-time"}
+
+var percentileKey = regexp.MustCompile(`^p\d+(\.\d+)?$`)
+
+// latencyTarget is one documented latency, e.g. p95 200ms
+type latencyTarget struct {
+	name     string
+	duration time.Duration
+}
+
+// latencyBudget is the response time documented for an operation
+type latencyBudget struct {
+	targets []latencyTarget
+}
+
+func (b latencyBudget) empty() bool {
+	return len(b.targets) == 0
+}
+
+// String renders e.g. "p95 200ms, p99 1s"
+func (b latencyBudget) String() string {
+	parts := make([]string, len(b.targets))
+	for i, target := range b.targets {
+		parts[i] = target.duration.String()
+		if target.name != "" {
+			parts[i] = target.name + " " + parts[i]
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// limit is the target a single measured request is checked against: a plain latency or max
+// when documented, otherwise the highest percentile, as one request says little about p50
+func (b latencyBudget) limit() (latencyTarget, bool) {
+	var best latencyTarget
+	bestRank := -1.0
+	for _, target := range b.targets {
+		rank := 0.0
+		switch {
+		case target.name == "" || target.name == "max":
+			return target, true
+		case percentileKey.MatchString(target.name):
+			rank, _ = strconv.ParseFloat(target.name[1:], 64)
+		}
+		if rank > bestRank {
+			best, bestRank = target, rank
+		}
+	}
+	return best, bestRank >= 0
+}
+
+// exceededBy reports whether a measured latency is over the budget
+func (b latencyBudget) exceededBy(measured time.Duration) bool {
+	target, ok := b.limit()
+	return ok && measured > target.duration
+}
+
+func findLatencyBudget(op *v3.Operation) latencyBudget {
+	if op == nil || op.Extensions == nil {
+		return latencyBudget{}
+	}
+	for _, name := range sloExtensions {
+		if node := op.Extensions.GetOrZero(name); node != nil {
+			if budget := parseLatencyBudget(node); !budget.empty() {
+				return budget
+			}
+		}
+	}
+	return latencyBudget{}
+}
+
+// parseLatencyBudget reads a duration, or a mapping of percentiles, max and latency keys.
+// A nested latency mapping is read too, so x-slo can also hold availability targets
+func parseLatencyBudget(node *yaml.Node) latencyBudget {
+	var budget latencyBudget
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if d, ok := parseLatency(node.Value); ok {
+			budget.targets = append(budget.targets, latencyTarget{duration: d})
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := strings.ToLower(node.Content[i].Value), node.Content[i+1]
+			switch {
+			case key == "latency" || key == "response_time" || key == "response-time":
+				if value.Kind == yaml.MappingNode {
+					budget.targets = append(budget.targets, parseLatencyBudget(value).targets...)
+				} else if d, ok := parseLatency(value.Value); ok {
+					budget.targets = append(budget.targets, latencyTarget{duration: d})
+				}
+			case key == "max" || percentileKey.MatchString(key):
+				if d, ok := parseLatency(value.Value); ok {
+					budget.targets = append(budget.targets, latencyTarget{name: key, duration: d})
+				}
+			}
+		}
+	}
+	return budget
+}
+
+// parseLatency reads 200ms, 1.5s or a bare number of milliseconds
+func parseLatency(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	if ms, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(ms * float64(time.Millisecond)), ms > 0
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil && d > 0
+}
+
+// recordLatency keeps the latency measured for an endpoint by the request runner, so the list
+// can flag operations that were slower than documented
+func (m *Model) recordLatency(ep endpoint, measured time.Duration) {
+	if m.latencies == nil {
+		m.latencies = map[string]time.Duration{}
+	}
+	m.latencies["endpoint "+ep.method+" "+ep.path] = measured
+}
+
+// renderLatencyBadge renders "[slow 350ms > p95 200ms]" after an endpoint whose last measured
+// latency exceeded its budget. style carries the row background
+func (m Model) renderLatencyBadge(ep endpoint, style lipgloss.Style) string {
+	measured, ok := m.latencies["endpoint "+ep.method+" "+ep.path]
+	if !ok {
+		return ""
+	}
+	budget := findLatencyBudget(ep.op)
+	if !budget.exceededBy(measured) {
+		return ""
+	}
+	return style.Render(" ") + style.Foreground(lipgloss.Color(colorRed)).Render("["+latencyVerdict(budget, measured)+"]")
+}
+
+// latencyVerdict describes a measured latency against the budget, e.g. "slow 350ms > p95 200ms"
+func latencyVerdict(budget latencyBudget, measured time.Duration) string {
+	target, _ := budget.limit()
+	limit := target.duration.String()
+	if target.name != "" {
+		limit = target.name + " " + limit
+	}
+	if budget.exceededBy(measured) {
+		return fmt.Sprintf("slow %s > %s", measured.Round(time.Millisecond), limit)
+	}
+	return fmt.Sprintf("within %s", limit)
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// snippetHeader is a header sent by a generated snippet
+type snippetHeader struct {
+	name  string
+	value string
+}
+
+// snippetRequest is the request a snippet sends, independent of the language it is written in
+type snippetRequest struct {
+	method      string
+	url         string
+	headers     []snippetHeader
+	body        string
+	conditional []snippetHeader
+}
+
+// setHeader sets a header, replacing the value built from the spec
+func (r *snippetRequest) setHeader(name, value string) {
+	for i, header := range r.headers {
+		if strings.EqualFold(header.name, name) {
+			r.headers[i].value = value
+			return
+		}
+	}
+	r.headers = append(r.headers, snippetHeader{name: name, value: value})
+	sort.Slice(r.headers, func(i, j int) bool { return r.headers[i].name < r.headers[j].name })
+}
+
+// snippetEmitter writes a request as code for one tool or language
+type snippetEmitter interface {
+	name() string
+	emit(req snippetRequest) string
+}
+
+// snippetEmitters are the targets the snippet modal cycles through, curl first
+func snippetEmitters(settings curlSettings) []snippetEmitter {
+	return []snippetEmitter{
+		curlEmitter{options: settings.options},
+		httpieEmitter{},
+		pythonEmitter{module: "requests"},
+		pythonEmitter{module: "httpx"},
+		goEmitter{},
+		fetchEmitter{},
+		axiosEmitter{},
+	}
+}
+
+// buildSnippetRequest describes the request for an operation: the configured or first server,
+// placeholder credentials for its security schemes and an example JSON body
+func buildSnippetRequest(ep endpoint, doc *v3.Document, server string) snippetRequest {
+	req := snippetRequest{method: ep.method}
+
+	// Use the configured server, then the first server if available, otherwise placeholder
+	baseURL := "https://api.example.com"
+	if server != "" {
+		baseURL = strings.TrimSuffix(server, "/")
+	} else if len(doc.Servers) > 0 {
+		baseURL = doc.Servers[0].URL
+	}
+	req.url = baseURL + ep.path
+
+	headers := make(map[string]string)
+
+	// Check if endpoint has request body (POST, PUT, PATCH typically)
+	if ep.op.RequestBody != nil {
+		headers["Content-Type"] = "application/json"
+	}
+
+	// Add security headers if defined
+	for _, secReq := range ep.op.Security {
+		for pair := secReq.Requirements.First(); pair != nil; pair = pair.Next() {
+			if doc.Components == nil || doc.Components.SecuritySchemes == nil {
+				continue
+			}
+			scheme := doc.Components.SecuritySchemes.GetOrZero(pair.Key())
+			if scheme == nil {
+				continue
+			}
+			switch scheme.Type {
+			case "http":
+				if scheme.Scheme == "bearer" {
+					headers["Authorization"] = "Bearer YOUR_TOKEN"
+				} else if scheme.Scheme == "basic" {
+					headers["Authorization"] = "Basic YOUR_CREDENTIALS"
+				}
+			case "apiKey":
+				if scheme.In == "header" {
+					headers[scheme.Name] = "YOUR_API_KEY"
+				}
+			case "oauth2":
+				headers["Authorization"] = "Bearer YOUR_TOKEN"
+			}
+		}
+	}
+
+	if name := idempotencyHeader(ep); name != "" {
+		headers[name] = "<unique key per request>"
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		req.headers = append(req.headers, snippetHeader{name: name, value: headers[name]})
+	}
+
+	// Add request body example if present
+	if ep.op.RequestBody != nil && ep.op.RequestBody.Content != nil {
+		if jsonContent := ep.op.RequestBody.Content.GetOrZero("application/json"); jsonContent != nil {
+			req.body = "{}"
+			if jsonContent.Schema != nil && jsonContent.Schema.Schema() != nil {
+				req.body = generateExampleJSON(jsonContent.Schema.Schema(), doc, 0)
+			}
+		}
+	}
+
+	req.conditional = conditionalHeaders(ep.op)
+	return req
+}
+
+// indentedBody returns the JSON body indented, with every line after the first prefixed so it
+// can be nested in code
+func (r snippetRequest) indentedBody(prefix string) string {
+	var indented bytes.Buffer
+	if err := jsonIndent(&indented, []byte(r.body)); err != nil {
+		return r.body
+	}
+	return strings.ReplaceAll(indented.String(), "\n", "\n"+prefix)
+}
+
+// conditionalComment renders the conditional headers as comments starting with marker
+func (r snippetRequest) conditionalComment(marker string, format func(h snippetHeader) string) string {
+	if len(r.conditional) == 0 {
+		return ""
+	}
+	lines := []string{marker + " " + conditionalHint}
+	for _, h := range r.conditional {
+		lines = append(lines, marker+" "+format(h))
+	}
+	return "\n\n" + strings.Join(lines, "\n")
+}
+
+type curlEmitter struct {
+	options string
+}
+
+func (curlEmitter) name() string { return "curl" }
+
+func (e curlEmitter) emit(req snippetRequest) string {
+	var curl strings.Builder
+	curl.WriteString("curl")
+	if e.options != "" {
+		curl.WriteString(" " + e.options)
+	}
+	curl.WriteString(" -X " + req.method + " '" + req.url + "'")
+	for _, h := range req.headers {
+		curl.WriteString(" \\\n  -H '" + h.name + ": " + h.value + "'")
+	}
+	if req.body != "" {
+		curl.WriteString(" \\\n  -d '" + req.body + "'")
+	}
+	curl.WriteString(req.conditionalComment("#", func(h snippetHeader) string {
+		return "-H '" + h.name + ": " + h.value + "'"
+	}))
+	return curl.String()
+}
+
+type httpieEmitter struct{}
+
+func (httpieEmitter) name() string { return "HTTPie" }
+
+func (httpieEmitter) emit(req snippetRequest) string {
+	var s strings.Builder
+	s.WriteString("http " + req.method + " '" + req.url + "'")
+	for _, h := range req.headers {
+		s.WriteString(" \\\n  '" + h.name + ":" + h.value + "'")
+	}
+	if req.body != "" {
+		s.WriteString(" \\\n  --raw '" + req.body + "'")
+	}
+	s.WriteString(req.conditionalComment("#", func(h snippetHeader) string {
+		return "'" + h.name + ":" + h.value + "'"
+	}))
+	return s.String()
+}
+
+// pythonMethods have a function of their own in requests and httpx
+var pythonMethods = map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "HEAD": true, "OPTIONS": true}
+
+// pythonEmitter writes a snippet for requests or httpx, which share their top-level API
+type pythonEmitter struct {
+	module string
+}
+
+func (e pythonEmitter) name() string { return e.module }
+
+func (e pythonEmitter) emit(req snippetRequest) string {
+	var s strings.Builder
+	s.WriteString("import " + e.module + "\n\n")
+	var args []string
+	if len(req.headers) > 0 {
+		s.WriteString("headers = {\n")
+		for _, h := range req.headers {
+			s.WriteString("    " + strconv.Quote(h.name) + ": " + strconv.Quote(h.value) + ",\n")
+		}
+		s.WriteString("}\n")
+		args = append(args, "headers=headers")
+	}
+	if req.body != "" {
+		s.WriteString("payload = " + pythonLiteral(req.body) + "\n")
+		args = append(args, "json=payload")
+	}
+	if len(args) > 0 {
+		s.WriteString("\n")
+	}
+
+	// Other methods, and bodies on methods that usually have none, which httpx only takes
+	// through request
+	call := e.module + "." + strings.ToLower(req.method) + "(" + strconv.Quote(req.url)
+	if !pythonMethods[req.method] || (req.body != "" && req.method != "POST" && req.method != "PUT" && req.method != "PATCH") {
+		call = e.module + ".request(" + strconv.Quote(req.method) + ", " + strconv.Quote(req.url)
+	}
+	for _, arg := range args {
+		call += ", " + arg
+	}
+	s.WriteString("response = " + call + ")\n")
+	s.WriteString("print(response.status_code, response.text)")
+	s.WriteString(req.conditionalComment("#", func(h snippetHeader) string {
+		return "headers[" + strconv.Quote(h.name) + "] = " + strconv.Quote(h.value)
+	}))
+	return s.String()
+}
+
+// pythonLiteral turns a JSON document into the equivalent Python literal, keeping key order
+func pythonLiteral(body string) string {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(body), &root); err != nil || len(root.Content) == 0 {
+		return "None"
+	}
+	var s strings.Builder
+	writePythonLiteral(&s, root.Content[0], "")
+	return s.String()
+}
+
+func writePythonLiteral(s *strings.Builder, node *yaml.Node, indent string) {
+	inner := indent + "    "
+	switch node.Kind {
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			s.WriteString("{}")
+			return
+		}
+		s.WriteString("{\n")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			s.WriteString(inner + strconv.Quote(node.Content[i].Value) + ": ")
+			writePythonLiteral(s, node.Content[i+1], inner)
+			s.WriteString(",\n")
+		}
+		s.WriteString(indent + "}")
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			s.WriteString("[]")
+			return
+		}
+		s.WriteString("[\n")
+		for _, item := range node.Content {
+			s.WriteString(inner)
+			writePythonLiteral(s, item, inner)
+			s.WriteString(",\n")
+		}
+		s.WriteString(indent + "]")
+	default:
+		switch node.Tag {
+		case "!!null":
+			s.WriteString("None")
+		case "!!bool":
+			if node.Value == "true" {
+				s.WriteString("True")
+			} else {
+				s.WriteString("False")
+			}
+		case "!!int", "!!float":
+			s.WriteString(node.Value)
+		default:
+			s.WriteString(strconv.Quote(node.Value))
+		}
+	}
+}
+
+type goEmitter struct{}
+
+func (goEmitter) name() string { return "Go" }
+
+func (goEmitter) emit(req snippetRequest) string {
+	var s strings.Builder
+	body := "nil"
+	if req.body != "" {
+		s.WriteString("body := strings.NewReader(" + goStringLiteral(req.indentedBody("")) + ")\n")
+		body = "body"
+	}
+	s.WriteString(fmt.Sprintf("req, err := http.NewRequest(%q, %q, %s)\n", req.method, req.url, body))
+	s.WriteString("if err != nil {\n\tlog.Fatal(err)\n}\n")
+	for _, h := range req.headers {
+		s.WriteString(fmt.Sprintf("req.Header.Set(%q, %q)\n", h.name, h.value))
+	}
+	s.WriteString("\nresp, err := http.DefaultClient.Do(req)\n")
+	s.WriteString("if err != nil {\n\tlog.Fatal(err)\n}\n")
+	s.WriteString("defer resp.Body.Close()")
+	s.WriteString(req.conditionalComment("//", func(h snippetHeader) string {
+		return fmt.Sprintf("req.Header.Set(%q, %q)", h.name, h.value)
+	}))
+	return s.String()
+}
+
+// goStringLiteral prefers a raw string, which keeps JSON readable
+func goStringLiteral(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+type fetchEmitter struct{}
+
+func (fetchEmitter) name() string { return "fetch" }
+
+func (fetchEmitter) emit(req snippetRequest) string {
+	var s strings.Builder
+	s.WriteString("const response = await fetch(" + strconv.Quote(req.url) + ", {\n")
+	s.WriteString("  method: " + strconv.Quote(req.method) + ",\n")
+	writeJSHeaders(&s, req.headers)
+	if req.body != "" {
+		s.WriteString("  body: JSON.stringify(" + req.indentedBody("  ") + "),\n")
+	}
+	s.WriteString("});\n")
+	s.WriteString("console.log(response.status, await response.text());")
+	s.WriteString(req.conditionalComment("//", func(h snippetHeader) string {
+		return strconv.Quote(h.name) + ": " + strconv.Quote(h.value) + ","
+	}))
+	return s.String()
+}
+
+type axiosEmitter struct{}
+
+func (axiosEmitter) name() string { return "axios" }
+
+func (axiosEmitter) emit(req snippetRequest) string {
+	var s strings.Builder
+	s.WriteString("import axios from \"axios\";\n\n")
+	s.WriteString("const response = await axios({\n")
+	s.WriteString("  method: " + strconv.Quote(strings.ToLower(req.method)) + ",\n")
+	s.WriteString("  url: " + strconv.Quote(req.url) + ",\n")
+	writeJSHeaders(&s, req.headers)
+	if req.body != "" {
+		s.WriteString("  data: " + req.indentedBody("  ") + ",\n")
+	}
+	s.WriteString("});\n")
+	s.WriteString("console.log(response.status, response.data);")
+	s.WriteString(req.conditionalComment("//", func(h snippetHeader) string {
+		return strconv.Quote(h.name) + ": " + strconv.Quote(h.value) + ","
+	}))
+	return s.String()
+}
+
+func writeJSHeaders(s *strings.Builder, headers []snippetHeader) {
+	if len(headers) == 0 {
+		return
+	}
+	s.WriteString("  headers: {\n")
+	for _, h := range headers {
+		s.WriteString("    " + strconv.Quote(h.name) + ": " + strconv.Quote(h.value) + ",\n")
+	}
+	s.WriteString("  },\n")
+}
+
+// openSnippet opens the snippet modal for an operation on the last used target. suffix is
+// shown after every snippet, such as the signature check of a webhook
+func (m *Model) openSnippet(ep endpoint, suffix string) {
+	m.snippetRequest = buildSnippetRequest(ep, m.doc, m.serverURL(ep))
+	// A token granted in the runner replaces the placeholder, so the snippet can be run as is
+	if m.openCredentials != nil {
+		if store, err := m.openCredentials(); err == nil {
+			if authorization, ok := snippetAuthorization(m.doc, ep.op, store); ok {
+				m.snippetRequest.setHeader("Authorization", authorization)
+			}
+		}
+	}
+	m.snippetSuffix = suffix
+	m.curlWarning = deprecationWarning(ep.op)
+	m.showCurl = true
+	m.renderSnippet()
+}
+
+// cycleSnippet switches the snippet modal to the next or previous target
+func (m *Model) cycleSnippet(step int) {
+	n := len(snippetEmitters(m.curl))
+	m.snippetTarget = (m.snippetTarget + step + n) % n
+	m.renderSnippet()
+}
+
+func (m *Model) renderSnippet() {
+	m.modalScroll = 0
+	m.curlCommand = snippetEmitters(m.curl)[m.snippetTarget].emit(m.snippetRequest)
+	if m.snippetSuffix != "" {
+		m.curlCommand += "\n\n" + m.snippetSuffix
+	}
+}
+
+// snippetTargetName returns the name of the target shown in the snippet modal
+func (m Model) snippetTargetName() string {
+	return snippetEmitters(m.curl)[m.snippetTarget].name()
+}
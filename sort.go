@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// sortKey is one level of a sort spec such as "tag,path" or "-method,path"
+type sortKey struct {
+	field      string
+	descending bool
+}
+
+// sortFields maps the fields accepted in a sort spec to the value compared for an endpoint
+var sortFields = map[string]func(ep endpoint) string{
+	"path":   func(ep endpoint) string { return ep.path },
+	"method": func(ep endpoint) string { return ep.method },
+	"tag": func(ep endpoint) string {
+		if len(ep.op.Tags) > 0 {
+			return ep.op.Tags[0]
+		}
+		return ""
+	},
+	"id":      func(ep endpoint) string { return ep.op.OperationId },
+	"summary": func(ep endpoint) string { return ep.op.Summary },
+}
+
+// defaultSortSpec is the order endpoints are extracted in
+var defaultSortSpec = []sortKey{{field: "path"}, {field: "method"}}
+
+// parseSortSpec parses a comma separated list of fields, each optionally prefixed
+// with "-" for descending order
+func parseSortSpec(spec string) ([]sortKey, error) {
+	var keys []sortKey
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key := sortKey{field: strings.ToLower(part)}
+		if strings.HasPrefix(key.field, "-") {
+			key.field = key.field[1:]
+			key.descending = true
+		}
+		if _, ok := sortFields[key.field]; !ok {
+			return nil, fmt.Errorf("unknown sort field %q, expected one of path, method, tag, id, summary", key.field)
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("empty sort spec")
+	}
+	return keys, nil
+}
+
+func formatSortSpec(keys []sortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.field
+		if key.descending {
+			parts[i] = "-" + key.field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// sortEndpoints orders endpoints by the given keys, breaking ties by path and method.
+// Empty values, such as untagged operations, sort last
+func sortEndpoints(eps []endpoint, keys []sortKey) {
+	keys = append(append([]sortKey{}, keys...), defaultSortSpec...)
+
+	sort.SliceStable(eps, func(i, j int) bool {
+		for _, key := range keys {
+			a, b := sortFields[key.field](eps[i]), sortFields[key.field](eps[j])
+			if a == b {
+				continue
+			}
+			if a == "" || b == "" {
+				return b == ""
+			}
+			if key.descending {
+				return a > b
+			}
+			return a < b
+		}
+		return false
+	})
+}
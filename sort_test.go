@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+func TestParseSortSpec(t *testing.T) {
+	keys, err := parseSortSpec("tag, -path")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := formatSortSpec(keys); got != "tag,-path" {
+		t.Errorf("Expected tag,-path, got %s", got)
+	}
+
+	for _, spec := range []string{"", "size", "tag,,nope"} {
+		if _, err := parseSortSpec(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestSortEndpointsByTagThenPath(t *testing.T) {
+	eps := []endpoint{
+		{path: "/b", method: "GET", op: &v3.Operation{Tags: []string{"users"}}},
+		{path: "/untagged", method: "GET", op: &v3.Operation{}},
+		{path: "/a", method: "POST", op: &v3.Operation{Tags: []string{"users"}}},
+		{path: "/z", method: "GET", op: &v3.Operation{Tags: []string{"admin"}}},
+		{path: "/a", method: "GET", op: &v3.Operation{Tags: []string{"users"}}},
+	}
+
+	keys, _ := parseSortSpec("tag,path")
+	sortEndpoints(eps, keys)
+
+	expected := []string{"GET /z", "GET /a", "POST /a", "GET /b", "GET /untagged"}
+	for i, ep := range eps {
+		if got := ep.method + " " + ep.path; got != expected[i] {
+			t.Errorf("Position %d: expected %s, got %s", i, expected[i], got)
+		}
+	}
+}
+
+func TestDefaultSortOrder(t *testing.T) {
+	model, err := buildModel(context.Background(), []byte(`openapi: 3.0.0
+info:
+  title: Unsorted
+  version: "1"
+paths:
+  /users:
+    post:
+      responses:
+        "201":
+          description: Created
+    get:
+      responses:
+        "200":
+          description: OK
+  /accounts:
+    get:
+      responses:
+        "200":
+          description: OK
+`), "")
+	if err != nil {
+		t.Fatalf("Failed to build model: %v", err)
+	}
+	m := NewModel(&model.Model)
+
+	// :sort alone reports the order the endpoints are in, not the order of the spec
+	m.runCommand("sort")
+	if m.statusMessage != "Sorted by path,method" {
+		t.Errorf("Unexpected status %q", m.statusMessage)
+	}
+	expected := []string{"GET /accounts", "GET /users", "POST /users"}
+	for i, ep := range m.getActiveEndpoints() {
+		if got := ep.method + " " + ep.path; got != expected[i] {
+			t.Errorf("Position %d: expected %s, got %s", i, expected[i], got)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// objectKeyOrders is the canonical key order per OpenAPI object, following the order of
+// the fields in the specification. Unknown keys follow in their original order, then
+// extensions
+var objectKeyOrders = map[string][]string{
+	"document":       {"openapi", "$self", "info", "jsonSchemaDialect", "servers", "security", "tags", "paths", "webhooks", "components", "externalDocs"},
+	"info":           {"title", "summary", "description", "termsOfService", "contact", "license", "version"},
+	"server":         {"url", "name", "description", "variables"},
+	"tag":            {"name", "summary", "description", "externalDocs", "parent", "kind"},
+	"pathItem":       {"$ref", "summary", "description", "servers", "parameters", "get", "put", "post", "delete", "options", "head", "patch", "trace", "query", "additionalOperations"},
+	"operation":      {"tags", "summary", "description", "externalDocs", "operationId", "parameters", "requestBody", "responses", "callbacks", "deprecated", "security", "servers"},
+	"parameter":      {"$ref", "name", "in", "summary", "description", "required", "deprecated", "allowEmptyValue", "style", "explode", "allowReserved", "schema", "example", "examples", "content"},
+	"header":         {"$ref", "summary", "description", "required", "deprecated", "style", "explode", "schema", "example", "examples", "content"},
+	"requestBody":    {"$ref", "summary", "description", "required", "content"},
+	"mediaType":      {"schema", "itemSchema", "example", "examples", "encoding", "prefixEncoding", "itemEncoding"},
+	"encoding":       {"contentType", "headers", "style", "explode", "allowReserved"},
+	"response":       {"$ref", "summary", "description", "headers", "content", "links"},
+	"securityScheme": {"$ref", "type", "description", "name", "in", "scheme", "bearerFormat", "flows", "openIdConnectUrl", "oauth2MetadataUrl", "deprecated"},
+	"components":     componentKinds,
+	"schema": {
+		"$ref", "$id", "$schema", "$anchor", "$dynamicAnchor", "$comment",
+		"title", "description", "type", "format", "enum", "const", "default", "nullable",
+		"readOnly", "writeOnly", "deprecated",
+		"multipleOf", "minimum", "exclusiveMinimum", "maximum", "exclusiveMaximum",
+		"minLength", "maxLength", "pattern", "contentEncoding", "contentMediaType", "contentSchema",
+		"minItems", "maxItems", "uniqueItems", "minContains", "maxContains",
+		"minProperties", "maxProperties", "required", "dependentRequired",
+		"properties", "patternProperties", "additionalProperties", "unevaluatedProperties", "propertyNames", "dependentSchemas",
+		"items", "prefixItems", "additionalItems", "unevaluatedItems", "contains",
+		"allOf", "oneOf", "anyOf", "not", "if", "then", "else", "discriminator",
+		"$defs", "definitions", "xml", "externalDocs", "example", "examples",
+	},
+}
+
+// objectChildren describes what the values below a key are. "kind" is a single object,
+// "list:kind" a sequence of them, "map:kind" a mapping by name keeping its order and
+// "sorted:kind" a mapping by name sorted alphabetically
+var objectChildren = map[string]map[string]string{
+	"document": {
+		"info": "info", "servers": "list:server", "tags": "list:tag",
+		"paths": "sorted:pathItem", "webhooks": "sorted:pathItem", "components": "components",
+	},
+	"pathItem": {
+		"servers": "list:server", "parameters": "list:parameter", "additionalOperations": "map:operation",
+		"get": "operation", "put": "operation", "post": "operation", "delete": "operation", "options": "operation",
+		"head": "operation", "patch": "operation", "trace": "operation", "query": "operation",
+	},
+	"operation": {
+		"parameters": "list:parameter", "requestBody": "requestBody", "responses": "responses",
+		"callbacks": "map:callback", "servers": "list:server",
+	},
+	"callback":    {"*": "pathItem"},
+	"responses":   {"*": "response"},
+	"parameter":   {"schema": "schema", "content": "map:mediaType"},
+	"header":      {"schema": "schema", "content": "map:mediaType"},
+	"requestBody": {"content": "map:mediaType"},
+	"mediaType":   {"schema": "schema", "itemSchema": "schema", "encoding": "map:encoding"},
+	"encoding":    {"headers": "map:header"},
+	"response":    {"headers": "map:header", "content": "map:mediaType"},
+	"components": {
+		"schemas": "sorted:schema", "responses": "sorted:response", "parameters": "sorted:parameter",
+		"examples": "sorted:", "requestBodies": "sorted:requestBody", "headers": "sorted:header",
+		"securitySchemes": "sorted:securityScheme", "links": "sorted:", "callbacks": "sorted:callback",
+		"pathItems": "sorted:pathItem", "mediaTypes": "sorted:mediaType",
+	},
+	"schema": {
+		"properties": "map:schema", "patternProperties": "map:schema", "dependentSchemas": "map:schema",
+		"$defs": "sorted:schema", "definitions": "sorted:schema",
+		"allOf": "list:schema", "oneOf": "list:schema", "anyOf": "list:schema", "prefixItems": "list:schema",
+		"items": "schema", "additionalItems": "schema", "additionalProperties": "schema",
+		"unevaluatedItems": "schema", "unevaluatedProperties": "schema", "contentSchema": "schema",
+		"not": "schema", "contains": "schema", "if": "schema", "then": "schema", "else": "schema", "propertyNames": "schema",
+	},
+}
+
+// formatSpec reorders a spec in place into the canonical order. Examples, extensions and
+// the order of schema properties are left as written
+func formatSpec(root *yaml.Node) {
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	formatObject(root, "document")
+	literalScalars(root)
+}
+
+// literalScalars rewrites folded scalars as literal ones. The encoder doesn't round-trip
+// folded text with more-indented lines, such as code samples, so formatting twice would
+// keep adding blank lines
+func literalScalars(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Style&yaml.FoldedStyle != 0 {
+		node.Style = node.Style&^yaml.FoldedStyle | yaml.LiteralStyle
+	}
+	for _, child := range node.Content {
+		literalScalars(child)
+	}
+}
+
+func formatObject(node *yaml.Node, kind string) {
+	if node == nil || node.Kind != yaml.MappingNode || kind == "" {
+		return
+	}
+
+	switch kind {
+	case "callback":
+		sortMapping(node, func(a, b string) bool { return a < b })
+	case "responses":
+		sortMapping(node, func(a, b string) bool { return responseCodeRank(a) < responseCodeRank(b) })
+	default:
+		order := objectKeyOrders[kind]
+		sortMapping(node, func(a, b string) bool { return keyRank(order, a) < keyRank(order, b) })
+	}
+
+	children := objectChildren[kind]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		if strings.HasPrefix(key, "x-") {
+			continue
+		}
+		child, ok := children[key]
+		if !ok {
+			child = children["*"]
+		}
+		formatChild(value, child)
+	}
+}
+
+func formatChild(node *yaml.Node, child string) {
+	shape, kind, found := strings.Cut(child, ":")
+	if !found {
+		formatObject(node, child)
+		return
+	}
+
+	switch shape {
+	case "list":
+		if node.Kind == yaml.SequenceNode {
+			for _, item := range node.Content {
+				formatObject(item, kind)
+			}
+		}
+	case "map", "sorted":
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		if shape == "sorted" {
+			sortMapping(node, func(a, b string) bool { return a < b })
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if !strings.HasPrefix(node.Content[i].Value, "x-") {
+				formatObject(node.Content[i+1], kind)
+			}
+		}
+	}
+}
+
+// sortMapping stably sorts the pairs of a mapping node, always keeping extensions last
+func sortMapping(node *yaml.Node, less func(a, b string) bool) {
+	type pair struct{ key, value *yaml.Node }
+	pairs := make([]pair, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, pair{node.Content[i], node.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		a, b := pairs[i].key.Value, pairs[j].key.Value
+		aExt, bExt := strings.HasPrefix(a, "x-"), strings.HasPrefix(b, "x-")
+		if aExt || bExt {
+			return !aExt && bExt
+		}
+		return less(a, b)
+	})
+	for i, p := range pairs {
+		node.Content[2*i], node.Content[2*i+1] = p.key, p.value
+	}
+}
+
+// keyRank is the position of key in order, with unknown keys after all known ones
+func keyRank(order []string, key string) int {
+	if i := slices.Index(order, key); i >= 0 {
+		return i
+	}
+	return len(order)
+}
+
+// responseCodeRank sorts status codes numerically, ranges such as 4XX after the codes they
+// cover and default last
+func responseCodeRank(code string) string {
+	if strings.EqualFold(code, "default") {
+		return "9"
+	}
+	return strings.ToUpper(code)
+}
+
+// runFmt implements `oq fmt [-w] [--check] [spec]`
+func runFmt(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	inPlace := fs.Bool("w", false, "rewrite the spec file in place")
+	check := fs.Bool("check", false, "exit with status 1 if the spec is not formatted, without writing it")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq fmt [flags] [spec]\n\n")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) > 1 || (*inPlace && len(positional) == 0) {
+		fs.Usage()
+		return 2
+	}
+
+	var path string
+	if len(positional) > 0 {
+		path = positional[0]
+	}
+	content, err := readSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing spec: %v\n", err)
+		return 1
+	}
+	if len(root.Content) == 0 || mappingValue(root.Content[0], "openapi") == nil {
+		fmt.Fprintf(os.Stderr, "Error: not an OpenAPI 3 document\n")
+		return 1
+	}
+
+	formatSpec(&root)
+	var out []byte
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		out, err = marshalFragmentJSON(&root)
+	} else {
+		out, err = marshalFragment(&root)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing spec: %v\n", err)
+		return 1
+	}
+
+	switch {
+	case *check:
+		if !bytes.Equal(content, out) {
+			name := path
+			if name == "" {
+				name = "stdin"
+			}
+			fmt.Fprintf(os.Stderr, "%s is not formatted\n", name)
+			return 1
+		}
+	case *inPlace:
+		if bytes.Equal(content, out) {
+			return 0
+		}
+		if err := os.WriteFile(path, out, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", path, err)
+			return 1
+		}
+	default:
+		os.Stdout.Write(out)
+	}
+	return 0
+}
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// defaultRulesetFiles are looked up in the working directory when --ruleset isn't given,
+// matching where Spectral looks for a ruleset
+var defaultRulesetFiles = []string{".spectral.yaml", ".spectral.yml", ".spectral.json"}
+
+// spectralFunctions are the Spectral core functions rules can use here
+var spectralFunctions = []string{"truthy", "falsy", "defined", "undefined", "pattern", "length"}
+
+// spectralRuleset is the supported subset of a Spectral ruleset: rules built from the core
+// functions in spectralFunctions, with given paths in the JSONPath subset parseJSONPath reads
+type spectralRuleset struct {
+	path  string
+	rules []*spectralRule
+	// skipped explains the rules and settings that are ignored
+	skipped []string
+}
+
+type spectralRule struct {
+	name        string
+	description string
+	message     string
+	severity    lintSeverity
+	given       [][]querySegment
+	then        []ruleCheck
+}
+
+// ruleCheck is one entry of a rule's then: the function applied to a field of every node
+// the rule is given
+type ruleCheck struct {
+	// field is empty for the given node itself, or a path below it. isKey selects the
+	// keys of the node, written @key
+	field    []querySegment
+	isKey    bool
+	function string
+	notMatch *regexp.Regexp
+	match    *regexp.Regexp
+	min, max *float64
+}
+
+type spectralRuleFile struct {
+	Extends yaml.Node            `yaml:"extends"`
+	Rules   map[string]yaml.Node `yaml:"rules"`
+}
+
+type spectralRuleSpec struct {
+	Description string    `yaml:"description"`
+	Message     string    `yaml:"message"`
+	Severity    yaml.Node `yaml:"severity"`
+	Given       yaml.Node `yaml:"given"`
+	Then        yaml.Node `yaml:"then"`
+}
+
+type spectralThenSpec struct {
+	Field           string         `yaml:"field"`
+	Function        string         `yaml:"function"`
+	FunctionOptions map[string]any `yaml:"functionOptions"`
+}
+
+// errUnsupportedRule marks rules that are valid Spectral but can't be evaluated here, they
+// are skipped instead of failing the whole ruleset
+var errUnsupportedRule = errors.New("unsupported")
+
+// findRuleset returns the ruleset given with --ruleset, or the first default ruleset file
+// in the working directory. It returns "" when there is none
+func findRuleset(flagPath string) (string, error) {
+	if flagPath != "" {
+		return flagPath, nil
+	}
+	for _, name := range defaultRulesetFiles {
+		if _, err := os.Stat(name); err == nil {
+			return name, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("Error reading ruleset: %w", err)
+		}
+	}
+	return "", nil
+}
+
+// loadRuleset reads a Spectral ruleset in YAML or JSON
+func loadRuleset(path string) (*spectralRuleset, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading ruleset: %w", err)
+	}
+	ruleset, err := parseRuleset(content)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing ruleset %s: %w", path, err)
+	}
+	ruleset.path = path
+	debugLog.Debug("loaded ruleset", "path", path, "rules", len(ruleset.rules), "skipped", len(ruleset.skipped))
+	return ruleset, nil
+}
+
+func parseRuleset(content []byte) (*spectralRuleset, error) {
+	var file spectralRuleFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+
+	ruleset := &spectralRuleset{}
+	if !file.Extends.IsZero() {
+		ruleset.skipped = append(ruleset.skipped, "extends is not supported, only the rules in the file are evaluated")
+	}
+
+	names := make([]string, 0, len(file.Rules))
+	for name := range file.Rules {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		node := file.Rules[name]
+		if node.Kind == yaml.ScalarNode {
+			// A severity or on/off alone changes a rule pulled in with extends
+			ruleset.skipped = append(ruleset.skipped, fmt.Sprintf("%s: overrides a rule from extends", name))
+			continue
+		}
+		rule, err := parseRule(name, &node)
+		if errors.Is(err, errUnsupportedRule) {
+			ruleset.skipped = append(ruleset.skipped, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		if rule != nil {
+			ruleset.rules = append(ruleset.rules, rule)
+		}
+	}
+	return ruleset, nil
+}
+
+// parseRule returns nil for rules that are turned off
+func parseRule(name string, node *yaml.Node) (*spectralRule, error) {
+	var spec spectralRuleSpec
+	if err := node.Decode(&spec); err != nil {
+		return nil, err
+	}
+
+	severity, off, err := parseRuleSeverity(&spec.Severity)
+	if err != nil || off {
+		return nil, err
+	}
+	rule := &spectralRule{name: name, description: spec.Description, message: spec.Message, severity: severity}
+
+	var givens []string
+	switch spec.Given.Kind {
+	case yaml.ScalarNode:
+		givens = []string{spec.Given.Value}
+	case yaml.SequenceNode:
+		if err := spec.Given.Decode(&givens); err != nil {
+			return nil, fmt.Errorf("given must be a path or a list of paths")
+		}
+	default:
+		return nil, fmt.Errorf("missing given")
+	}
+	for _, given := range givens {
+		if strings.HasPrefix(given, "#") {
+			return nil, fmt.Errorf("%w alias %s", errUnsupportedRule, given)
+		}
+		segments, err := parseJSONPath(given)
+		if err != nil {
+			return nil, fmt.Errorf("%w given %s: %v", errUnsupportedRule, given, err)
+		}
+		rule.given = append(rule.given, segments)
+	}
+
+	var thens []spectralThenSpec
+	switch spec.Then.Kind {
+	case yaml.MappingNode:
+		var then spectralThenSpec
+		if err := spec.Then.Decode(&then); err != nil {
+			return nil, err
+		}
+		thens = []spectralThenSpec{then}
+	case yaml.SequenceNode:
+		if err := spec.Then.Decode(&thens); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("missing then")
+	}
+	for _, then := range thens {
+		check, err := parseRuleCheck(then)
+		if err != nil {
+			return nil, err
+		}
+		rule.then = append(rule.then, check)
+	}
+	return rule, nil
+}
+
+// parseRuleSeverity reads error, warn, info and hint or their numbers 0 to 3. Rules warn
+// by default and off or false turns them off
+func parseRuleSeverity(node *yaml.Node) (lintSeverity, bool, error) {
+	if node.IsZero() {
+		return severityWarn, false, nil
+	}
+	switch value := strings.ToLower(node.Value); value {
+	case "off", "false":
+		return 0, true, nil
+	case "0", "1", "2", "3":
+		n, _ := strconv.Atoi(value)
+		return lintSeverity(n), false, nil
+	default:
+		severity, ok := parseLintSeverity(value)
+		if !ok {
+			return 0, false, fmt.Errorf("invalid severity %q", node.Value)
+		}
+		return severity, false, nil
+	}
+}
+
+func parseRuleCheck(then spectralThenSpec) (ruleCheck, error) {
+	check := ruleCheck{function: then.Function}
+	if !slices.Contains(spectralFunctions, then.Function) {
+		if then.Function == "" {
+			return check, fmt.Errorf("missing function")
+		}
+		return check, fmt.Errorf("%w function %s", errUnsupportedRule, then.Function)
+	}
+
+	switch field := then.Field; {
+	case field == "@key":
+		check.isKey = true
+	case field != "":
+		segments, err := parseJSONPath(field)
+		if err != nil {
+			return check, fmt.Errorf("%w field %s: %v", errUnsupportedRule, field, err)
+		}
+		check.field = segments
+	}
+
+	options := then.FunctionOptions
+	switch then.Function {
+	case "pattern":
+		var err error
+		if check.match, err = spectralRegexp(options["match"]); err != nil {
+			return check, fmt.Errorf("pattern match: %w", err)
+		}
+		if check.notMatch, err = spectralRegexp(options["notMatch"]); err != nil {
+			return check, fmt.Errorf("pattern notMatch: %w", err)
+		}
+		if check.match == nil && check.notMatch == nil {
+			return check, fmt.Errorf("pattern needs match or notMatch")
+		}
+	case "length":
+		var err error
+		if check.min, err = optionNumber(options, "min"); err != nil {
+			return check, err
+		}
+		if check.max, err = optionNumber(options, "max"); err != nil {
+			return check, err
+		}
+		if check.min == nil && check.max == nil {
+			return check, fmt.Errorf("length needs min or max")
+		}
+	}
+	return check, nil
+}
+
+// spectralRegexp compiles a pattern option, which is either a plain regular expression or
+// one written /like this/ with JavaScript flags
+func spectralRegexp(option any) (*regexp.Regexp, error) {
+	if option == nil {
+		return nil, nil
+	}
+	pattern, ok := option.(string)
+	if !ok {
+		return nil, fmt.Errorf("must be a string")
+	}
+	if end := strings.LastIndexByte(pattern, '/'); strings.HasPrefix(pattern, "/") && end > 0 {
+		flags := strings.ReplaceAll(pattern[end+1:], "g", "")
+		pattern = pattern[1:end]
+		if strings.Trim(flags, "ims") != "" {
+			return nil, fmt.Errorf("unsupported flags %q", flags)
+		}
+		if flags != "" {
+			pattern = "(?" + flags + ")" + pattern
+		}
+	}
+	return regexp.Compile(pattern)
+}
+
+func optionNumber(options map[string]any, name string) (*float64, error) {
+	var n float64
+	switch value := options[name].(type) {
+	case nil:
+		return nil, nil
+	case int:
+		n = float64(value)
+	case float64:
+		n = value
+	default:
+		return nil, fmt.Errorf("length %s must be a number", name)
+	}
+	return &n, nil
+}
+
+// evaluate runs the rules against the spec as written and returns the issues ordered by
+// position
+func (rs *spectralRuleset) evaluate(root *yaml.Node) []lintIssue {
+	var issues []lintIssue
+	for _, rule := range rs.rules {
+		for _, given := range rule.given {
+			for _, match := range evalQueryMatches(root, given) {
+				for _, check := range rule.then {
+					issues = append(issues, rule.check(match, check)...)
+				}
+			}
+		}
+	}
+	slices.SortStableFunc(issues, func(a, b lintIssue) int {
+		if a.line != b.line {
+			return a.line - b.line
+		}
+		return a.column - b.column
+	})
+	return issues
+}
+
+// check applies one function to the targets its field selects below match
+func (rule *spectralRule) check(match queryMatch, check ruleCheck) []lintIssue {
+	var targets []queryMatch
+	switch {
+	case check.isKey:
+		// @key checks the keys of the given object, as in the paths of $.paths
+		node := match.node
+		for node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			key := match.value(node, i)
+			targets = append(targets, queryMatch{node: key.keyNode, path: key.path})
+		}
+	case check.field != nil:
+		for _, target := range evalQueryMatches(match.node, check.field) {
+			targets = append(targets, queryMatch{node: target.node, keyNode: target.keyNode, path: append(slices.Clone(match.path), target.path...)})
+		}
+	default:
+		targets = []queryMatch{match}
+	}
+
+	if len(targets) == 0 && !check.isKey {
+		// A missing field only fails the functions about presence, reported on the given node
+		missing := queryMatch{path: match.path}
+		if len(check.field) > 0 && !check.field[len(check.field)-1].wildcard {
+			missing.path = append(slices.Clone(match.path), check.field[len(check.field)-1].key)
+		}
+		position := match.node
+		if match.keyNode != nil {
+			position = match.keyNode
+		}
+		switch check.function {
+		case "truthy", "defined":
+			return []lintIssue{rule.issue(missing, position, fmt.Sprintf("%q property must be %s", missing.key(), check.function))}
+		}
+		return nil
+	}
+
+	var issues []lintIssue
+	for _, target := range targets {
+		if message := check.apply(target.node); message != "" {
+			issues = append(issues, rule.issue(target, target.node, message))
+		}
+	}
+	return issues
+}
+
+// apply returns the error message of the function for node, or "" when it passes
+func (check ruleCheck) apply(node *yaml.Node) string {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch check.function {
+	case "truthy":
+		if !isTruthy(node) {
+			return "must be truthy"
+		}
+	case "falsy":
+		if isTruthy(node) {
+			return "must be falsy"
+		}
+	case "undefined":
+		return "must be undefined"
+	case "pattern":
+		if node.Kind != yaml.ScalarNode {
+			return ""
+		}
+		if check.match != nil && !check.match.MatchString(node.Value) {
+			return fmt.Sprintf("%q must match the pattern %q", node.Value, check.match.String())
+		}
+		if check.notMatch != nil && check.notMatch.MatchString(node.Value) {
+			return fmt.Sprintf("%q must not match the pattern %q", node.Value, check.notMatch.String())
+		}
+	case "length":
+		length, ok := nodeLength(node)
+		if !ok {
+			return ""
+		}
+		if check.min != nil && length < *check.min {
+			return fmt.Sprintf("must not be shorter than %s", strconv.FormatFloat(*check.min, 'f', -1, 64))
+		}
+		if check.max != nil && length > *check.max {
+			return fmt.Sprintf("must not be longer than %s", strconv.FormatFloat(*check.max, 'f', -1, 64))
+		}
+	}
+	return ""
+}
+
+// isTruthy follows JavaScript: null, false, 0 and "" are falsy, empty objects and arrays aren't
+func isTruthy(node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode {
+		return true
+	}
+	switch node.ShortTag() {
+	case "!!null":
+		return false
+	case "!!bool":
+		return node.Value == "true"
+	case "!!int", "!!float":
+		n, err := strconv.ParseFloat(node.Value, 64)
+		return err != nil || (n != 0 && !math.IsNaN(n))
+	}
+	return node.Value != ""
+}
+
+// nodeLength is the length the length function compares: characters of a string, items of
+// an array, keys of an object or the value of a number
+func nodeLength(node *yaml.Node) (float64, bool) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return float64(len(node.Content)), true
+	case yaml.MappingNode:
+		return float64(len(node.Content) / 2), true
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null", "!!bool":
+			return 0, false
+		case "!!int", "!!float":
+			n, err := strconv.ParseFloat(node.Value, 64)
+			return n, err == nil
+		}
+		return float64(utf8.RuneCountInString(node.Value)), true
+	}
+	return 0, false
+}
+
+// issue fills in the rule message, which defaults to the description or else the function's
+// error, and may use the {{error}}, {{description}}, {{property}}, {{path}} and {{value}}
+// placeholders
+func (rule *spectralRule) issue(target queryMatch, position *yaml.Node, message string) lintIssue {
+	template := rule.message
+	if template == "" {
+		template = rule.description
+	}
+	if template == "" {
+		template = "{{error}}"
+	}
+	value := ""
+	if target.node != nil && target.node.Kind == yaml.ScalarNode {
+		value = target.node.Value
+	}
+	text := strings.NewReplacer(
+		"{{error}}", message,
+		"{{description}}", rule.description,
+		"{{property}}", target.key(),
+		"{{path}}", strings.Join(target.path, "."),
+		"{{value}}", value,
+	).Replace(template)
+
+	return lintIssue{
+		rule:     rule.name,
+		severity: rule.severity,
+		message:  text,
+		path:     target.path,
+		line:     position.Line,
+		column:   position.Column,
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// untaggedGroup collects operations without tags when splitting by tag
+const untaggedGroup = "untagged"
+
+// groupByTag returns endpoints per tag in first-seen order. Operations with several tags
+// are part of every group they are tagged with
+func groupByTag(eps []endpoint) ([]string, map[string][]endpoint) {
+	var tags []string
+	groups := map[string][]endpoint{}
+	add := func(tag string, ep endpoint) {
+		if _, ok := groups[tag]; !ok {
+			tags = append(tags, tag)
+		}
+		groups[tag] = append(groups[tag], ep)
+	}
+
+	for _, ep := range eps {
+		if len(ep.op.Tags) == 0 {
+			add(untaggedGroup, ep)
+			continue
+		}
+		for _, tag := range ep.op.Tags {
+			add(tag, ep)
+		}
+	}
+	return tags, groups
+}
+
+// runSplit implements `oq split spec.yaml --by tag -o out/`, writing one spec per tag with
+// only the components its operations need
+func runSplit(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("split", flag.ContinueOnError)
+	by := fs.String("by", "tag", "how to group operations: tag")
+	outDir := fs.String("o", ".", "directory to write the specs to")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq split [flags] [spec]\n\n")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(positional) > 1 {
+		fs.Usage()
+		return 2
+	}
+	if *by != "tag" {
+		fmt.Fprintf(os.Stderr, "Invalid --by %q: only tag is supported\n", *by)
+		return 2
+	}
+
+	var path string
+	if len(positional) > 0 {
+		path = positional[0]
+	}
+	_, doc, err := loadSpec(ctx, path)
+	if err != nil {
+		return reportError(err)
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", *outDir, err)
+		return 1
+	}
+
+	ext := ".yaml"
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		ext = ".json"
+	}
+
+	tags, groups := groupByTag(extractEndpoints(doc))
+	written := map[string]string{}
+	for _, tag := range tags {
+		title := tag
+		if doc.Info != nil && doc.Info.Title != "" {
+			title = doc.Info.Title + " - " + tag
+		}
+		fragment, err := buildFragment(doc, groups[tag], title)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error splitting %s: %v\n", tag, err)
+			return 1
+		}
+
+		name := strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(tag), "-"), "-")
+		if name == "" {
+			name = "tag"
+		}
+		// Distinct tags can sanitize to the same name, e.g. "Pets" and "pets"
+		if other, ok := written[name]; ok {
+			fmt.Fprintf(os.Stderr, "Error: tags %q and %q would both be written to %s%s\n", other, tag, name, ext)
+			return 1
+		}
+		written[name] = tag
+
+		var content []byte
+		if ext == ".json" {
+			content, err = marshalFragmentJSON(fragment)
+		} else {
+			content, err = marshalFragment(fragment)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", tag, err)
+			return 1
+		}
+
+		file := filepath.Join(*outDir, name+ext)
+		if err := os.WriteFile(file, content, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", file, err)
+			return 1
+		}
+		fmt.Printf("%s\t%d operations\n", file, len(groups[tag]))
+	}
+	return 0
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// minSplitWidth is the narrowest terminal the split view is used in, below it items unfold
+// inline as usual
+const minSplitWidth = 60
+
+// minCompareWidth is the narrowest terminal pinned details are shown in, next to the list
+// and the details of the selected item
+const minCompareWidth = 90
+
+// pinnedDetails are the details of an item frozen in their own pane, to compare with the
+// item under the cursor. They are kept as they were when pinned, also across reloads
+type pinnedDetails struct {
+	key     string
+	title   string
+	details string
+}
+
+// useSplitView reports whether details are shown in a pane next to the list
+func (m Model) useSplitView() bool {
+	return (m.splitView || m.comparing()) && m.width >= minSplitWidth
+}
+
+// comparing reports whether the pinned details are shown
+func (m Model) comparing() bool {
+	return m.pinned != nil && m.width >= minCompareWidth
+}
+
+// splitWidths returns the widths of the list and detail panes, which are separated by " │ ".
+// When comparing, the pinned pane has the width of the detail pane
+func (m Model) splitWidths() (int, int) {
+	if m.comparing() {
+		listWidth := min(max(m.width/4, 24), 50)
+		return listWidth, max(10, (m.width-listWidth-6)/2)
+	}
+	listWidth := min(max(m.width*2/5, 30), 70)
+	return listWidth, max(10, m.width-listWidth-3)
+}
+
+// togglePinnedDetails pins the details of the item under the cursor, or unpins them when
+// they are the ones pinned
+func (m *Model) togglePinnedDetails() {
+	key, title, details := m.selectedDetails()
+	switch {
+	case m.pinned != nil && m.pinned.key == key:
+		m.pinned = nil
+		m.setStatus("Unpinned "+title, false)
+	case key == "":
+		return
+	default:
+		m.pinned = &pinnedDetails{key: key, title: title, details: details}
+		if m.width < minCompareWidth {
+			m.setStatus(fmt.Sprintf("Pinned %s, widen the terminal to %d columns to compare", title, minCompareWidth), false)
+		} else {
+			m.setStatus(fmt.Sprintf("Pinned %s, press p on it again to unpin", title), false)
+		}
+	}
+	m.ensureCursorVisible()
+}
+
+// wrapDetails wraps details to the width of a detail pane
+func (m *Model) wrapDetails(details string) []string {
+	_, width := m.splitWidths()
+	wrapped := lipgloss.NewStyle().Width(width).Render(strings.TrimRight(details, "\n"))
+	return strings.Split(wrapped, "\n")
+}
+
+// selectedDetails returns a key identifying the item under the cursor, its title and details
+func (m *Model) selectedDetails() (string, string, string) {
+	switch m.mode {
+	case viewEndpoints:
+		if group, ok := m.selectedTagGroup(); ok {
+			return "tag " + group.tag, group.tag, m.tagGroupDetails(group)
+		}
+		if ep, ok := m.selectedEndpoint(); ok {
+			return "endpoint " + ep.method + " " + ep.path, m.methodLabel(ep.method) + " " + ep.path, m.endpointDetails(ep)
+		}
+	case viewComponents:
+		if comps := m.getActiveComponents(); m.cursor >= 0 && m.cursor < len(comps) {
+			comp := comps[m.cursor]
+			return "component " + comp.compType + " " + comp.name, comp.compType + ": " + comp.name, comp.details
+		}
+	case viewWebhooks:
+		if hooks := m.getActiveWebhooks(); m.cursor >= 0 && m.cursor < len(hooks) {
+			hook := hooks[m.cursor]
+			return "webhook " + hook.method + " " + hook.name, m.methodLabel(hook.method) + " " + hook.name, formatWebhookDetails(hook)
+		}
+	case viewTags:
+		if tags := m.getActiveTags(); m.cursor >= 0 && m.cursor < len(tags) {
+			tag := tags[m.cursor]
+			return "tag " + tag.name, "Tag: " + tag.name, m.formatTagDetails(tag)
+		}
+	}
+	return "", "", ""
+}
+
+// detailLines returns the details of the selected item wrapped to the detail pane
+func (m *Model) detailLines() []string {
+	_, _, details := m.selectedDetails()
+	if details == "" {
+		return nil
+	}
+	return m.wrapDetails(details)
+}
+
+// detailOffset is the scroll position of the detail pane, which starts at the top for every
+// newly selected item
+func (m *Model) detailOffset() int {
+	if key, _, _ := m.selectedDetails(); key != m.detailKey {
+		return 0
+	}
+	return m.detailScroll
+}
+
+// scrollDetails scrolls the detail pane independently of the list. The pinned pane scrolls
+// along, so both show the same part of their details
+func (m *Model) scrollDetails(delta int) {
+	key, _, _ := m.selectedDetails()
+	offset := m.detailOffset()
+	lines := len(m.detailLines())
+	if m.comparing() {
+		lines = max(lines, len(m.wrapDetails(m.pinned.details)))
+	}
+	maxOffset := max(0, lines-m.detailHeight())
+	m.detailKey = key
+	m.detailScroll = min(max(0, offset+delta), maxOffset)
+}
+
+// detailHeight is the number of detail lines shown below the pane title, matching the lines
+// View leaves between the header and the footer
+func (m *Model) detailHeight() int {
+	used := strings.Count(m.renderHeader(), "\n") + strings.Count(m.renderFooter(), "\n") + 1
+	return max(1, m.height-used-3)
+}
+
+// renderSplitView puts the rendered list on the left and the details of the selected item on
+// the right, filling the height lines available for content
+func (m Model) renderSplitView(list string, height int) string {
+	listWidth, detailWidth := m.splitWidths()
+	// The content ends with a newline, which counts as a line of its own
+	height = max(1, height-1)
+
+	left := strings.Split(strings.TrimRight(list, "\n"), "\n")
+	truncate := lipgloss.NewStyle().MaxWidth(listWidth)
+	for i, line := range left {
+		line = truncate.Render(line)
+		left[i] = line + strings.Repeat(" ", max(0, listWidth-lipgloss.Width(line)))
+	}
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple)).
+		MaxWidth(detailWidth)
+
+	detailStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorDetailGray))
+
+	indicatorStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray))
+
+	// pane lays out a title and the part of the details at the scroll position
+	pane := func(title string, lines []string) []string {
+		visible := max(1, height-2)
+		offset := min(m.detailOffset(), max(0, len(lines)-visible))
+		end := min(len(lines), offset+visible)
+		out := []string{titleStyle.Render(title), ""}
+		for _, line := range lines[offset:end] {
+			out = append(out, detailStyle.Render(line))
+		}
+		if offset > 0 || end < len(lines) {
+			out[0] = titleStyle.Render(title) + indicatorStyle.Render("  J/K to scroll")
+		}
+		return out
+	}
+
+	var right []string
+	if _, title, _ := m.selectedDetails(); title != "" {
+		right = pane(title, m.detailLines())
+	}
+	var pinned []string
+	if m.comparing() {
+		pinned = pane("Pinned: "+m.pinned.title, m.wrapDetails(m.pinned.details))
+	}
+
+	separator := lipgloss.NewStyle().Foreground(lipgloss.Color(colorBackground)).Render(" │ ")
+	var s strings.Builder
+	for i := 0; i < height; i++ {
+		line := strings.Repeat(" ", listWidth)
+		if i < len(left) {
+			line = left[i]
+		}
+		s.WriteString(line + separator)
+		if m.comparing() {
+			detail := ""
+			if i < len(right) {
+				detail = right[i]
+			}
+			s.WriteString(detail + strings.Repeat(" ", max(0, detailWidth-lipgloss.Width(detail))) + separator)
+			if i < len(pinned) {
+				s.WriteString(pinned[i])
+			}
+		} else if i < len(right) {
+			s.WriteString(right[i])
+		}
+		s.WriteString("\n")
+	}
+	return s.String()
+}
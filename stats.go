@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// coverage is how many of total items document something
+type coverage struct {
+	Count   int     `json:"count"`
+	Total   int     `json:"total"`
+	Percent float64 `json:"percent"`
+}
+
+func newCoverage(count, total int) coverage {
+	c := coverage{Count: count, Total: total}
+	if total > 0 {
+		c.Percent = float64(int(float64(count)/float64(total)*1000+0.5)) / 10
+	}
+	return c
+}
+
+func (c coverage) String() string {
+	return fmt.Sprintf("%d/%d (%.1f%%)", c.Count, c.Total, c.Percent)
+}
+
+// specStats are the documentation metrics of a spec. Field names are part of the JSON
+// output, so keep them stable for dashboards that track them over time
+type specStats struct {
+	Title       string         `json:"title"`
+	Version     string         `json:"version"`
+	OpenAPI     string         `json:"openapi"`
+	Fingerprint string         `json:"fingerprint"`
+	Paths       int            `json:"paths"`
+	Operations  int            `json:"operations"`
+	Webhooks    int            `json:"webhooks"`
+	Methods     map[string]int `json:"methods"`
+	Tags        map[string]int `json:"tags"`
+	Components  map[string]int `json:"components"`
+	Deprecated  int            `json:"deprecated"`
+	Coverage    struct {
+		Summary       coverage `json:"summary"`
+		Description   coverage `json:"description"`
+		OperationID   coverage `json:"operationId"`
+		Tagged        coverage `json:"tagged"`
+		Examples      coverage `json:"examples"`
+		SchemaDocs    coverage `json:"schemaDescriptions"`
+		ParameterDocs coverage `json:"parameterDescriptions"`
+		SuccessBodies coverage `json:"successResponseBodies"`
+	} `json:"coverage"`
+	// BudgetWarnings lists the configured budgets the spec exceeds
+	BudgetWarnings []string `json:"budgetWarnings,omitempty"`
+
+	// DeprecatedComponents counts the deprecated components by type
+	DeprecatedComponents map[string]int `json:"deprecatedComponents"`
+}
+
+// computeStats walks the operations and components of doc
+func computeStats(doc *v3.Document, content []byte) specStats {
+	stats := specStats{
+		OpenAPI:              doc.Version,
+		Fingerprint:          specFingerprint(content),
+		Methods:              map[string]int{},
+		Tags:                 map[string]int{},
+		Components:           map[string]int{},
+		DeprecatedComponents: map[string]int{},
+	}
+	if doc.Info != nil {
+		stats.Title = doc.Info.Title
+		stats.Version = doc.Info.Version
+	}
+	if doc.Paths != nil && doc.Paths.PathItems != nil {
+		stats.Paths = doc.Paths.PathItems.Len()
+	}
+	stats.Webhooks = len(extractWebhooks(doc))
+
+	eps := extractEndpoints(doc)
+	stats.Operations = len(eps)
+
+	var summary, description, operationID, tagged, examples int
+	var params, documentedParams, responses, responsesWithBody int
+	for _, ep := range eps {
+		op := ep.op
+		stats.Methods[ep.method]++
+		for _, tag := range op.Tags {
+			stats.Tags[tag]++
+		}
+		if op.Deprecated != nil && *op.Deprecated {
+			stats.Deprecated++
+		}
+		if op.Summary != "" {
+			summary++
+		}
+		if op.Description != "" {
+			description++
+		}
+		if op.OperationId != "" {
+			operationID++
+		}
+		if len(op.Tags) > 0 {
+			tagged++
+		}
+		if !countExamples(op).missing() {
+			examples++
+		}
+
+		for _, param := range op.Parameters {
+			if param == nil {
+				continue
+			}
+			params++
+			if param.Description != "" {
+				documentedParams++
+			}
+		}
+
+		if op.Responses != nil && op.Responses.Codes != nil {
+			for pair := op.Responses.Codes.First(); pair != nil; pair = pair.Next() {
+				resp := pair.Value()
+				// Only successful responses are expected to carry a body
+				if resp == nil || !strings.HasPrefix(pair.Key(), "2") || pair.Key() == "204" {
+					continue
+				}
+				responses++
+				if resp.Content != nil && resp.Content.Len() > 0 {
+					responsesWithBody++
+				}
+			}
+		}
+	}
+
+	var schemas, documentedSchemas int
+	for _, comp := range extractComponents(doc) {
+		stats.Components[comp.compType]++
+		if comp.deprecated {
+			stats.DeprecatedComponents[comp.compType]++
+		}
+		if comp.compType == "Schema" {
+			schemas++
+			if comp.description != "" {
+				documentedSchemas++
+			}
+		}
+	}
+
+	stats.Coverage.Summary = newCoverage(summary, len(eps))
+	stats.Coverage.Description = newCoverage(description, len(eps))
+	stats.Coverage.OperationID = newCoverage(operationID, len(eps))
+	stats.Coverage.Tagged = newCoverage(tagged, len(eps))
+	stats.Coverage.Examples = newCoverage(examples, len(eps))
+	stats.Coverage.SchemaDocs = newCoverage(documentedSchemas, schemas)
+	stats.Coverage.ParameterDocs = newCoverage(documentedParams, params)
+	stats.Coverage.SuccessBodies = newCoverage(responsesWithBody, responses)
+	return stats
+}
+
+// runStats implements `oq stats [--format text|json] [spec]`
+func runStats(ctx context.Context, cfg *Config, args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: oq stats [flags] [spec]\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 1 || (*format != "text" && *format != "json") {
+		fs.Usage()
+		return 2
+	}
+
+	content, doc, err := loadSpec(ctx, fs.Arg(0))
+	if err != nil {
+		return reportError(err)
+	}
+
+	stats := computeStats(doc, content)
+	budgets := budgetsFromConfig(cfg)
+	stats.BudgetWarnings = checkBudgets(doc, len(content), budgets, oversizedPayloads(doc, budgets.maxPayloadSize))
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(stats); err != nil {
+			return reportError(err)
+		}
+		return 0
+	}
+	writeStats(os.Stdout, stats)
+	return 0
+}
+
+func writeStats(w io.Writer, stats specStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Spec\t%s %s (OpenAPI %s, #%s)\n", stats.Title, stats.Version, stats.OpenAPI, stats.Fingerprint)
+	fmt.Fprintf(tw, "Paths\t%d\n", stats.Paths)
+	fmt.Fprintf(tw, "Operations\t%d (%d deprecated)\n", stats.Operations, stats.Deprecated)
+	for _, method := range sortedKeys(stats.Methods) {
+		fmt.Fprintf(tw, "  %s\t%d\n", method, stats.Methods[method])
+	}
+	fmt.Fprintf(tw, "Webhooks\t%d\n", stats.Webhooks)
+	fmt.Fprintf(tw, "Tags\t%d\n", len(stats.Tags))
+	for _, kind := range sortedKeys(stats.Components) {
+		if deprecated := stats.DeprecatedComponents[kind]; deprecated > 0 {
+			fmt.Fprintf(tw, "Components: %s\t%d (%d deprecated)\n", kind, stats.Components[kind], deprecated)
+		} else {
+			fmt.Fprintf(tw, "Components: %s\t%d\n", kind, stats.Components[kind])
+		}
+	}
+
+	fmt.Fprintf(tw, "\nCoverage\t\n")
+	fmt.Fprintf(tw, "  Summary\t%s\n", stats.Coverage.Summary)
+	fmt.Fprintf(tw, "  Description\t%s\n", stats.Coverage.Description)
+	fmt.Fprintf(tw, "  Operation ID\t%s\n", stats.Coverage.OperationID)
+	fmt.Fprintf(tw, "  Tagged\t%s\n", stats.Coverage.Tagged)
+	fmt.Fprintf(tw, "  Examples\t%s\n", stats.Coverage.Examples)
+	fmt.Fprintf(tw, "  Schema descriptions\t%s\n", stats.Coverage.SchemaDocs)
+	fmt.Fprintf(tw, "  Parameter descriptions\t%s\n", stats.Coverage.ParameterDocs)
+	fmt.Fprintf(tw, "  2xx response bodies\t%s\n", stats.Coverage.SuccessBodies)
+	if len(stats.BudgetWarnings) > 0 {
+		fmt.Fprintf(tw, "\nOver budget\n")
+		for _, warning := range stats.BudgetWarnings {
+			fmt.Fprintf(tw, "  %s\n", warning)
+		}
+	}
+	tw.Flush()
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
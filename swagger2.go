@@ -0,0 +1,587 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// swaggerParameterSchemaKeys are the fields of a Swagger 2.0 parameter, header or items object
+// that move into the schema in OpenAPI 3
+var swaggerParameterSchemaKeys = []string{
+	"type", "format", "items", "default", "maximum", "exclusiveMaximum", "minimum", "exclusiveMinimum",
+	"maxLength", "minLength", "pattern", "maxItems", "minItems", "uniqueItems", "enum", "multipleOf",
+}
+
+// swaggerOAuthFlows maps Swagger 2.0 OAuth2 flows to their OpenAPI 3 names
+var swaggerOAuthFlows = map[string]string{
+	"implicit":    "implicit",
+	"password":    "password",
+	"application": "clientCredentials",
+	"accessCode":  "authorizationCode",
+}
+
+// swaggerConverter upgrades a Swagger 2.0 document to OpenAPI 3.0, following the mapping
+// of the OpenAPI 3.0 migration guide for everything oq renders
+type swaggerConverter struct {
+	consumes []*yaml.Node
+	produces []*yaml.Node
+	// Top-level body parameters become requestBodies components, formData parameters are
+	// inlined into the form schema of every operation using them
+	bodyParams map[string]bool
+	formParams map[string]*yaml.Node
+}
+
+// convertSwagger2 converts the Swagger 2.0 spec in content to an equivalent OpenAPI 3.0 spec
+func convertSwagger2(content []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected a mapping at the top level")
+	}
+
+	out := upgradeSwagger2(doc.Content[0])
+	literalScalars(out)
+	return marshalFragment(out)
+}
+
+// upgradeSwagger2 builds the OpenAPI 3.0 tree for a Swagger 2.0 root. The source tree is
+// modified along the way
+func upgradeSwagger2(src *yaml.Node) *yaml.Node {
+	c := &swaggerConverter{
+		consumes:   sequenceItems(mappingValue(src, "consumes")),
+		produces:   sequenceItems(mappingValue(src, "produces")),
+		bodyParams: map[string]bool{},
+		formParams: map[string]*yaml.Node{},
+	}
+
+	components := newMapping()
+	if params := mappingValue(src, "parameters"); params != nil && params.Kind == yaml.MappingNode {
+		parameters, bodies := newMapping(), newMapping()
+		for i := 0; i+1 < len(params.Content); i += 2 {
+			name, param := params.Content[i].Value, params.Content[i+1]
+			switch scalarValue(param, "in") {
+			case "body":
+				c.bodyParams[name] = true
+				setMapping(bodies, name, c.requestBody([]*yaml.Node{param}, c.consumes))
+			case "formData":
+				c.formParams[name] = param
+			default:
+				setMapping(parameters, name, c.parameter(param))
+			}
+		}
+		addNonEmpty(components, "parameters", parameters)
+		addNonEmpty(components, "requestBodies", bodies)
+	}
+	if definitions := mappingValue(src, "definitions"); definitions != nil {
+		upgradeSchemas(definitions)
+		components.Content = append([]*yaml.Node{scalarNode("schemas"), definitions}, components.Content...)
+	}
+	if responses := mappingValue(src, "responses"); responses != nil && responses.Kind == yaml.MappingNode {
+		converted := newMapping()
+		for i := 0; i+1 < len(responses.Content); i += 2 {
+			setMapping(converted, responses.Content[i].Value, c.response(responses.Content[i+1], c.produces))
+		}
+		addNonEmpty(components, "responses", converted)
+	}
+	if schemes := mappingValue(src, "securityDefinitions"); schemes != nil && schemes.Kind == yaml.MappingNode {
+		converted := newMapping()
+		for i := 0; i+1 < len(schemes.Content); i += 2 {
+			setMapping(converted, schemes.Content[i].Value, swaggerSecurityScheme(schemes.Content[i+1]))
+		}
+		addNonEmpty(components, "securitySchemes", converted)
+	}
+
+	out := newMapping()
+	setMapping(out, "openapi", scalarNode("3.0.3"))
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i].Value, src.Content[i+1]
+		switch key {
+		case "swagger", "host", "basePath", "schemes", "consumes", "produces",
+			"definitions", "parameters", "responses", "securityDefinitions":
+		case "info":
+			setMapping(out, key, value)
+			if servers := swaggerServers(src); servers != nil {
+				setMapping(out, "servers", servers)
+			}
+		case "paths":
+			setMapping(out, key, c.paths(value))
+		default:
+			setMapping(out, key, value)
+		}
+	}
+	if mappingValue(out, "servers") == nil {
+		if servers := swaggerServers(src); servers != nil {
+			setMapping(out, "servers", servers)
+		}
+	}
+	addNonEmpty(out, "components", components)
+
+	rewriteSwaggerRefs(out)
+	return out
+}
+
+// swaggerServers builds the servers from host, basePath and schemes
+func swaggerServers(src *yaml.Node) *yaml.Node {
+	host, basePath := scalarValue(src, "host"), scalarValue(src, "basePath")
+	if host == "" && basePath == "" {
+		return nil
+	}
+
+	servers := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	addServer := func(url string) {
+		server := newMapping()
+		setMapping(server, "url", scalarNode(url))
+		servers.Content = append(servers.Content, server)
+	}
+	if host == "" {
+		addServer(basePath)
+		return servers
+	}
+
+	schemes := sequenceItems(mappingValue(src, "schemes"))
+	if len(schemes) == 0 {
+		schemes = []*yaml.Node{scalarNode("https")}
+	}
+	for _, scheme := range schemes {
+		addServer(scheme.Value + "://" + host + strings.TrimSuffix(basePath, "/"))
+	}
+	return servers
+}
+
+func (c *swaggerConverter) paths(paths *yaml.Node) *yaml.Node {
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return paths
+	}
+
+	out := newMapping()
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		item := paths.Content[i+1]
+		if item.Kind != yaml.MappingNode {
+			setMapping(out, paths.Content[i].Value, item)
+			continue
+		}
+
+		// Body and form parameters of the path item move into the request body of each operation
+		params, inherited := c.splitParameters(mappingValue(item, "parameters"))
+		converted := newMapping()
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			key, value := item.Content[j].Value, item.Content[j+1]
+			switch {
+			case key == "parameters":
+				addNonEmpty(converted, key, params)
+			case standardOperationKeys[key] && value.Kind == yaml.MappingNode:
+				setMapping(converted, key, c.operation(value, inherited))
+			default:
+				setMapping(converted, key, value)
+			}
+		}
+		setMapping(out, paths.Content[i].Value, converted)
+	}
+	return out
+}
+
+// splitParameters converts the regular parameters of a list and returns the body and
+// formData ones separately, they make up the request body
+func (c *swaggerConverter) splitParameters(list *yaml.Node) (*yaml.Node, []*yaml.Node) {
+	params := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	var body []*yaml.Node
+	for _, param := range sequenceItems(list) {
+		if ref, ok := strings.CutPrefix(scalarValue(param, "$ref"), "#/parameters/"); ok {
+			if form, ok := c.formParams[ref]; ok {
+				param = form
+			} else if c.bodyParams[ref] {
+				body = append(body, param)
+				continue
+			}
+		}
+		switch scalarValue(param, "in") {
+		case "body", "formData":
+			body = append(body, param)
+		default:
+			params.Content = append(params.Content, c.parameter(param))
+		}
+	}
+	return params, body
+}
+
+func (c *swaggerConverter) operation(op *yaml.Node, inherited []*yaml.Node) *yaml.Node {
+	consumes := c.consumes
+	if list := mappingValue(op, "consumes"); list != nil {
+		consumes = sequenceItems(list)
+	}
+	produces := c.produces
+	if list := mappingValue(op, "produces"); list != nil {
+		produces = sequenceItems(list)
+	}
+
+	params, body := c.splitParameters(mappingValue(op, "parameters"))
+	// Operation parameters override path item ones of the same kind
+	if len(body) == 0 {
+		body = inherited
+	}
+
+	out := newMapping()
+	addBody := func() {
+		if len(body) > 0 {
+			setMapping(out, "requestBody", c.requestBody(body, consumes))
+		}
+	}
+	for i := 0; i+1 < len(op.Content); i += 2 {
+		key, value := op.Content[i].Value, op.Content[i+1]
+		switch key {
+		case "consumes", "produces", "schemes":
+		case "parameters":
+			addNonEmpty(out, key, params)
+			addBody()
+		case "responses":
+			if mappingValue(out, "requestBody") == nil {
+				addBody()
+			}
+			converted := newMapping()
+			if value.Kind == yaml.MappingNode {
+				for j := 0; j+1 < len(value.Content); j += 2 {
+					setMapping(converted, value.Content[j].Value, c.response(value.Content[j+1], produces))
+				}
+			}
+			setMapping(out, key, converted)
+		default:
+			setMapping(out, key, value)
+		}
+	}
+	if mappingValue(out, "requestBody") == nil {
+		addBody()
+	}
+	return out
+}
+
+// parameter converts a query, header, path or cookie parameter
+func (c *swaggerConverter) parameter(param *yaml.Node) *yaml.Node {
+	if param.Kind != yaml.MappingNode || mappingValue(param, "$ref") != nil {
+		return param
+	}
+
+	out := newMapping()
+	for i := 0; i+1 < len(param.Content); i += 2 {
+		key, value := param.Content[i].Value, param.Content[i+1]
+		if !slices.Contains(swaggerParameterSchemaKeys, key) && key != "collectionFormat" {
+			setMapping(out, key, value)
+		}
+	}
+
+	switch scalarValue(param, "collectionFormat") {
+	case "csv":
+		if in := scalarValue(param, "in"); in == "query" || in == "cookie" {
+			setMapping(out, "style", scalarNode("form"))
+			setMapping(out, "explode", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"})
+		}
+	case "ssv":
+		setMapping(out, "style", scalarNode("spaceDelimited"))
+	case "pipes":
+		setMapping(out, "style", scalarNode("pipeDelimited"))
+	case "multi":
+		setMapping(out, "style", scalarNode("form"))
+		setMapping(out, "explode", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+	}
+	setMapping(out, "schema", parameterSchema(param))
+	return out
+}
+
+// parameterSchema collects the schema fields of a parameter, header or items object
+func parameterSchema(param *yaml.Node) *yaml.Node {
+	schema := newMapping()
+	for _, key := range swaggerParameterSchemaKeys {
+		value := mappingValue(param, key)
+		if value == nil {
+			continue
+		}
+		if key == "items" && value.Kind == yaml.MappingNode && mappingValue(value, "$ref") == nil {
+			value = parameterSchema(value)
+		}
+		setMapping(schema, key, value)
+	}
+	upgradeSchemas(schema)
+	return schema
+}
+
+// requestBody merges body and formData parameters into a request body
+func (c *swaggerConverter) requestBody(params []*yaml.Node, consumes []*yaml.Node) *yaml.Node {
+	out := newMapping()
+	var schema *yaml.Node
+	var mediaTypes []string
+
+	if param := params[0]; scalarValue(param, "in") == "body" || mappingValue(param, "$ref") != nil {
+		if ref, ok := strings.CutPrefix(scalarValue(param, "$ref"), "#/parameters/"); ok {
+			setMapping(out, "$ref", scalarNode("#/components/requestBodies/"+ref))
+			return out
+		}
+		if description := mappingValue(param, "description"); description != nil {
+			setMapping(out, "description", description)
+		}
+		if required := mappingValue(param, "required"); required != nil {
+			setMapping(out, "required", required)
+		}
+		schema = mappingValue(param, "schema")
+		if schema == nil {
+			schema = newMapping()
+		}
+		upgradeSchemas(schema)
+		for _, mediaType := range consumes {
+			if !isFormMediaType(mediaType.Value) {
+				mediaTypes = append(mediaTypes, mediaType.Value)
+			}
+		}
+		if len(mediaTypes) == 0 {
+			mediaTypes = []string{"application/json"}
+		}
+	} else {
+		schema = newMapping()
+		setMapping(schema, "type", scalarNode("object"))
+		properties := newMapping()
+		required := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		hasFile := false
+		for _, param := range params {
+			if scalarValue(param, "in") != "formData" {
+				continue
+			}
+			name := scalarValue(param, "name")
+			property := parameterSchema(param)
+			if description := mappingValue(param, "description"); description != nil {
+				setMapping(property, "description", description)
+			}
+			setMapping(properties, name, property)
+			if scalarValue(param, "required") == "true" {
+				required.Content = append(required.Content, scalarNode(name))
+			}
+			hasFile = hasFile || scalarValue(param, "type") == "file"
+		}
+		setMapping(schema, "properties", properties)
+		addNonEmpty(schema, "required", required)
+
+		for _, mediaType := range consumes {
+			if isFormMediaType(mediaType.Value) {
+				mediaTypes = append(mediaTypes, mediaType.Value)
+			}
+		}
+		if len(mediaTypes) == 0 {
+			mediaTypes = []string{"application/x-www-form-urlencoded"}
+			if hasFile {
+				mediaTypes = []string{"multipart/form-data"}
+			}
+		}
+	}
+
+	content := newMapping()
+	for _, mediaType := range mediaTypes {
+		entry := newMapping()
+		setMapping(entry, "schema", schema)
+		setMapping(content, mediaType, entry)
+	}
+	setMapping(out, "content", content)
+	return out
+}
+
+func isFormMediaType(mediaType string) bool {
+	return mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data"
+}
+
+// response moves the schema and examples of a response into content for each produced
+// media type and the header fields into schemas
+func (c *swaggerConverter) response(response *yaml.Node, produces []*yaml.Node) *yaml.Node {
+	if response.Kind != yaml.MappingNode || mappingValue(response, "$ref") != nil {
+		return response
+	}
+
+	out := newMapping()
+	setMapping(out, "description", scalarNode(""))
+	content := newMapping()
+	for i := 0; i+1 < len(response.Content); i += 2 {
+		key, value := response.Content[i].Value, response.Content[i+1]
+		switch key {
+		case "schema":
+			upgradeSchemas(value)
+			mediaTypes := produces
+			if len(mediaTypes) == 0 {
+				mediaTypes = []*yaml.Node{scalarNode("application/json")}
+			}
+			for _, mediaType := range mediaTypes {
+				entry := mappingValue(content, mediaType.Value)
+				if entry == nil {
+					entry = newMapping()
+					setMapping(content, mediaType.Value, entry)
+				}
+				setMapping(entry, "schema", value)
+			}
+		case "examples":
+			if value.Kind != yaml.MappingNode {
+				continue
+			}
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				entry := mappingValue(content, value.Content[j].Value)
+				if entry == nil {
+					entry = newMapping()
+					setMapping(content, value.Content[j].Value, entry)
+				}
+				setMapping(entry, "example", value.Content[j+1])
+			}
+		case "headers":
+			headers := newMapping()
+			if value.Kind == yaml.MappingNode {
+				for j := 0; j+1 < len(value.Content); j += 2 {
+					setMapping(headers, value.Content[j].Value, swaggerHeader(value.Content[j+1]))
+				}
+			}
+			setMapping(out, key, headers)
+		default:
+			setMapping(out, key, value)
+		}
+	}
+	addNonEmpty(out, "content", content)
+	return out
+}
+
+func swaggerHeader(h *yaml.Node) *yaml.Node {
+	if h.Kind != yaml.MappingNode {
+		return h
+	}
+	out := newMapping()
+	for i := 0; i+1 < len(h.Content); i += 2 {
+		key := h.Content[i].Value
+		if !slices.Contains(swaggerParameterSchemaKeys, key) && key != "collectionFormat" {
+			setMapping(out, key, h.Content[i+1])
+		}
+	}
+	setMapping(out, "schema", parameterSchema(h))
+	return out
+}
+
+func swaggerSecurityScheme(scheme *yaml.Node) *yaml.Node {
+	if scheme.Kind != yaml.MappingNode {
+		return scheme
+	}
+
+	out := newMapping()
+	switch scalarValue(scheme, "type") {
+	case "basic":
+		setMapping(out, "type", scalarNode("http"))
+		setMapping(out, "scheme", scalarNode("basic"))
+	case "oauth2":
+		setMapping(out, "type", scalarNode("oauth2"))
+		flowName, ok := swaggerOAuthFlows[scalarValue(scheme, "flow")]
+		if !ok {
+			flowName = "implicit"
+		}
+		flow := newMapping()
+		for _, key := range []string{"authorizationUrl", "tokenUrl"} {
+			if value := mappingValue(scheme, key); value != nil {
+				setMapping(flow, key, value)
+			}
+		}
+		scopes := mappingValue(scheme, "scopes")
+		if scopes == nil {
+			scopes = newMapping()
+		}
+		setMapping(flow, "scopes", scopes)
+		flows := newMapping()
+		setMapping(flows, flowName, flow)
+		setMapping(out, "flows", flows)
+	default:
+		setMapping(out, "type", mappingValue(scheme, "type"))
+		for _, key := range []string{"name", "in"} {
+			if value := mappingValue(scheme, key); value != nil {
+				setMapping(out, key, value)
+			}
+		}
+	}
+
+	for i := 0; i+1 < len(scheme.Content); i += 2 {
+		if key := scheme.Content[i].Value; key == "description" || strings.HasPrefix(key, "x-") {
+			setMapping(out, key, scheme.Content[i+1])
+		}
+	}
+	return out
+}
+
+// upgradeSchemas rewrites the Swagger 2.0 schema features that changed in OpenAPI 3 below
+// node: file types, x-nullable and string discriminators
+func upgradeSchemas(node *yaml.Node) {
+	if node == nil {
+		return
+	}
+	for _, child := range node.Content {
+		upgradeSchemas(child)
+	}
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	if t := mappingValue(node, "type"); t != nil && t.Kind == yaml.ScalarNode && t.Value == "file" {
+		setMapping(node, "type", scalarNode("string"))
+		setMapping(node, "format", scalarNode("binary"))
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch {
+		case key.Value == "x-nullable" && value.Kind == yaml.ScalarNode:
+			key.Value = "nullable"
+		case key.Value == "discriminator" && value.Kind == yaml.ScalarNode:
+			discriminator := newMapping()
+			setMapping(discriminator, "propertyName", value)
+			node.Content[i+1] = discriminator
+		}
+	}
+}
+
+// rewriteSwaggerRefs points local references at the components they moved to
+func rewriteSwaggerRefs(node *yaml.Node) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if value := node.Content[i+1]; node.Content[i].Value == "$ref" && value.Kind == yaml.ScalarNode {
+				for _, prefix := range [][2]string{
+					{"#/definitions/", "#/components/schemas/"},
+					{"#/parameters/", "#/components/parameters/"},
+					{"#/responses/", "#/components/responses/"},
+				} {
+					if rest, ok := strings.CutPrefix(value.Value, prefix[0]); ok {
+						value.Value = prefix[1] + rest
+					}
+				}
+			}
+		}
+	}
+	for _, child := range node.Content {
+		rewriteSwaggerRefs(child)
+	}
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func scalarValue(node *yaml.Node, key string) string {
+	if value := mappingValue(node, key); value != nil && value.Kind == yaml.ScalarNode {
+		return value.Value
+	}
+	return ""
+}
+
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
+
+// addNonEmpty sets key unless value is an empty mapping or sequence
+func addNonEmpty(node *yaml.Node, key string, value *yaml.Node) {
+	if value != nil && len(value.Content) > 0 {
+		setMapping(node, key, value)
+	}
+}
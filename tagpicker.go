@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// tagPicker edits the tags of one operation, choosing from the tags used in the spec
+type tagPicker struct {
+	ep       endpoint
+	tags     []string
+	selected map[string]bool
+	cursor   int
+	input    textinput.Model
+}
+
+// specTags returns the tags declared at the top level followed by the ones only used on
+// operations, in first-seen order
+func specTags(doc *v3.Document, eps []endpoint) []string {
+	var tags []string
+	add := func(tag string) {
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	for _, tag := range doc.Tags {
+		if tag != nil {
+			add(tag.Name)
+		}
+	}
+	for _, ep := range eps {
+		for _, tag := range ep.op.Tags {
+			add(tag)
+		}
+	}
+	return tags
+}
+
+// openTagPicker opens the tag picker for the endpoint under the cursor
+func (m *Model) openTagPicker() {
+	if !m.requireWriteMode() {
+		return
+	}
+	ep, ok := m.selectedEndpoint()
+	if !ok {
+		return
+	}
+
+	input := textinput.New()
+	input.Placeholder = "filter or new tag"
+	input.CharLimit = 100
+	input.Width = 40
+	input.Focus()
+
+	selected := map[string]bool{}
+	for _, tag := range ep.op.Tags {
+		selected[tag] = true
+	}
+	m.tagPicker = &tagPicker{ep: ep, tags: specTags(m.doc, m.endpoints), selected: selected, input: input}
+}
+
+// visible returns the tags matching the filter input
+func (p *tagPicker) visible() []string {
+	query := strings.ToLower(strings.TrimSpace(p.input.Value()))
+	var tags []string
+	for _, tag := range p.tags {
+		if strings.Contains(strings.ToLower(tag), query) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// result returns the selected tags, keeping the operation's existing order first
+func (p *tagPicker) result() []string {
+	var tags []string
+	for _, tag := range p.ep.op.Tags {
+		if p.selected[tag] {
+			tags = append(tags, tag)
+		}
+	}
+	for _, tag := range p.tags {
+		if p.selected[tag] && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// updateTagPicker handles keys while the tag picker is open
+func (m *Model) updateTagPicker(msg tea.KeyMsg) tea.Cmd {
+	p := m.tagPicker
+	visible := p.visible()
+
+	switch msg.String() {
+	case "esc":
+		m.tagPicker = nil
+	case "up", "ctrl+p":
+		if len(visible) > 0 {
+			p.cursor = (p.cursor - 1 + len(visible)) % len(visible)
+		}
+	case "down", "ctrl+n":
+		if len(visible) > 0 {
+			p.cursor = (p.cursor + 1) % len(visible)
+		}
+	case "enter":
+		value := strings.TrimSpace(p.input.Value())
+		switch {
+		case value != "" && !slices.Contains(p.tags, value):
+			// Typing a tag that doesn't exist yet adds it
+			p.tags = append(p.tags, value)
+			p.selected[value] = true
+			p.input.SetValue("")
+			p.cursor = 0
+		case len(visible) > 0:
+			tag := visible[min(p.cursor, len(visible)-1)]
+			p.selected[tag] = !p.selected[tag]
+		}
+	case "ctrl+s":
+		m.tagPicker = nil
+		return m.saveOperationTags(p.ep, p.result())
+	default:
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		p.cursor = 0
+		return cmd
+	}
+	return nil
+}
+
+// saveOperationTags writes the tags of ep to the spec file and reloads it
+func (m *Model) saveOperationTags(ep endpoint, tags []string) tea.Cmd {
+	if slices.Equal(tags, ep.op.Tags) {
+		m.setStatus("Tags unchanged", false)
+		return nil
+	}
+
+	_, err := editSpecFile(m.specPath, func(root *yaml.Node) error {
+		op := operationNode(root, ep.path, ep.method)
+		if op == nil || op.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s %s not found in %s", ep.method, ep.path, m.specPath)
+		}
+		if len(tags) == 0 {
+			for i := 0; i+1 < len(op.Content); i += 2 {
+				if op.Content[i].Value == "tags" {
+					op.Content = slices.Delete(op.Content, i, i+2)
+					break
+				}
+			}
+			return nil
+		}
+
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if old := mappingValue(op, "tags"); old != nil {
+			seq.Style = old.Style
+		}
+		for _, tag := range tags {
+			seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: tag})
+		}
+		if mappingValue(op, "tags") == nil {
+			// Tags conventionally come first in an operation
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "tags"}
+			op.Content = append([]*yaml.Node{key, seq}, op.Content...)
+			return nil
+		}
+		setMapping(op, "tags", seq)
+		return nil
+	})
+	if err != nil {
+		m.setStatus(fmt.Sprintf("Error saving tags: %v", err), true)
+		return nil
+	}
+
+	m.setStatus(fmt.Sprintf("Tags of %s %s: %s", ep.method, ep.path, strings.Join(tags, ", ")), false)
+	return reloadSpec(m.specPath, m.patch, nil)
+}
+
+func (m Model) renderTagPicker() string {
+	p := m.tagPicker
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(colorThemePurple)).
+		Padding(1, 2).
+		Width(min(m.width-4, 60))
+
+	visible := p.visible()
+	// Keep the list within the screen, scrolled to the cursor
+	rowsHeight := max(3, m.height-14)
+	start := max(0, min(p.cursor-rowsHeight+1, len(visible)-rowsHeight))
+
+	var rows []string
+	for i := start; i < len(visible) && i < start+rowsHeight; i++ {
+		tag := visible[i]
+		box := "[ ]"
+		if p.selected[tag] {
+			box = "[x]"
+		}
+		line := box + " " + tag
+		if i == p.cursor {
+			line = highlight(lipgloss.NewStyle()).Bold(true).Render(line)
+		}
+		rows = append(rows, line)
+	}
+	if len(visible) == 0 {
+		rows = append(rows, instructionStyle.Render("No matching tags, Enter adds it"))
+	}
+
+	title := titleStyle.Render(fmt.Sprintf("Tags of %s %s", p.ep.method, p.ep.path))
+	instruction := instructionStyle.Render("↑/↓ move • Enter toggle • Ctrl+S save • Esc cancel")
+	modal := modalStyle.Render(title + "\n\n" + p.input.View() + "\n\n" + strings.Join(rows, "\n") + "\n\n" + instruction)
+
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+}
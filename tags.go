@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// tagEntry is a row of the tags view: a tag declared at the top level, or one only used on
+// operations when declared is false
+type tagEntry struct {
+	name            string
+	description     string
+	docsURL         string
+	docsDescription string
+	declared        bool
+	folded          bool
+}
+
+// extractTags lists the tags in the order specTags returns them
+func extractTags(doc *v3.Document, eps []endpoint) []tagEntry {
+	declared := map[string]*tagEntry{}
+	for _, tag := range doc.Tags {
+		if tag == nil || tag.Name == "" || declared[tag.Name] != nil {
+			continue
+		}
+		entry := &tagEntry{name: tag.Name, description: strings.TrimSpace(tag.Description), declared: true}
+		if tag.ExternalDocs != nil {
+			entry.docsURL, entry.docsDescription = tag.ExternalDocs.URL, tag.ExternalDocs.Description
+		}
+		declared[tag.Name] = entry
+	}
+
+	var tags []tagEntry
+	for _, name := range specTags(doc, eps) {
+		entry := tagEntry{name: name}
+		if d := declared[name]; d != nil {
+			entry = *d
+		}
+		entry.folded = true
+		tags = append(tags, entry)
+	}
+	return tags
+}
+
+func (m *Model) getActiveTags() []tagEntry {
+	if m.isFiltering() {
+		return m.filteredTags
+	}
+	return m.tags
+}
+
+// filterTags keeps the tags whose name or description matches the query
+func (m *Model) filterTags(query searchQuery) {
+	m.filteredTags = nil
+	for _, tag := range m.tags {
+		if query.matchesTag(tag.name, tag.description) {
+			m.filteredTags = append(m.filteredTags, tag)
+		}
+	}
+}
+
+// tagOperations returns the endpoints with a tag that pass the active filters
+func (m *Model) tagOperations(name string) []endpoint {
+	var eps []endpoint
+	for _, ep := range m.endpoints {
+		if slices.Contains(ep.op.Tags, name) && m.filters.matchesOperation(ep.method, ep.op) {
+			eps = append(eps, ep)
+		}
+	}
+	return eps
+}
+
+// formatTagDetails describes a tag with its external docs and the operations that belong to it
+func (m *Model) formatTagDetails(tag tagEntry) string {
+	var details []string
+	if tag.description != "" {
+		details = append(details, "Description: "+tag.description)
+	}
+	if !tag.declared {
+		details = append(details, "Not declared in the top-level tags")
+	}
+	if tag.docsURL != "" {
+		docs := "External docs: " + tag.docsURL
+		if tag.docsDescription != "" {
+			docs += " (" + tag.docsDescription + ")"
+		}
+		details = append(details, docs)
+	}
+
+	eps := m.tagOperations(tag.name)
+	details = append(details, fmt.Sprintf("Operations (%d):", len(eps)))
+	for _, ep := range eps {
+		details = append(details, "  "+ep.method+" "+ep.path+summarySuffix(ep.op.Summary))
+	}
+	return strings.Join(details, "\n")
+}
+
+// toggleTagDetails expands or collapses the tag under the cursor
+func (m *Model) toggleTagDetails() {
+	tags := m.getActiveTags()
+	if m.cursor < 0 || m.cursor >= len(tags) {
+		return
+	}
+	for i := range m.tags {
+		if m.tags[i].name == tags[m.cursor].name {
+			m.tags[i].folded = !m.tags[i].folded
+			m.filterItems()
+			break
+		}
+	}
+}
+
+// showTagEndpoints drills down from the tag under the cursor into the endpoints view,
+// filtered to that tag
+func (m *Model) showTagEndpoints() {
+	tags := m.getActiveTags()
+	if m.cursor < 0 || m.cursor >= len(tags) {
+		return
+	}
+	m.filters.tag = tags[m.cursor].name
+	m.searchInput.SetValue("")
+	m.mode = viewEndpoints
+	m.refilter()
+}
+
+func (m Model) renderTags() string {
+	var s strings.Builder
+
+	contentHeight := m.contentHeight()
+	contentWidth := calculateContentWidth(m.width)
+
+	tags := m.getActiveTags()
+	nameWidth := 0
+	for _, tag := range tags {
+		nameWidth = max(nameWidth, lipgloss.Width(tag.name))
+	}
+	nameWidth = min(nameWidth, 30)
+
+	startIdx := m.scrollOffset
+	endIdx := min(m.scrollOffset+contentHeight, len(tags))
+
+	if m.scrollOffset > 0 {
+		indicator := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(colorGray)).
+			Render("⬆ More items above...")
+		s.WriteString(indicator)
+		s.WriteString("\n")
+	}
+
+	for i := startIdx; i < endIdx; i++ {
+		tag := tags[i]
+		style := lipgloss.NewStyle()
+		if i == m.cursor {
+			style = highlight(style)
+		}
+		nameStyle := style.Foreground(lipgloss.Color(colorThemePurple)).Bold(true).Width(nameWidth).MaxWidth(nameWidth)
+		if !tag.declared {
+			nameStyle = nameStyle.Foreground(lipgloss.Color(colorGray)).Italic(true)
+		}
+
+		foldIcon := "▶"
+		if !tag.folded && !m.useSplitView() {
+			foldIcon = "▼"
+		}
+
+		count := len(m.tagOperations(tag.name))
+		var line strings.Builder
+		line.WriteString(style.Render(foldIcon + " "))
+		line.WriteString(nameStyle.Render(tag.name))
+		line.WriteString(style.Foreground(lipgloss.Color(colorGray)).Render(fmt.Sprintf("  %d %s", count, plural(count, "operation", "operations"))))
+		if tag.description != "" {
+			line.WriteString(style.Render(" - " + strings.SplitN(tag.description, "\n", 2)[0]))
+		}
+		line.WriteString(style.Render(strings.Repeat(" ", contentWidth)))
+
+		s.WriteString(style.Render(line.String()))
+		s.WriteString("\n")
+
+		if !tag.folded {
+			detailStyle := lipgloss.NewStyle().
+				PaddingLeft(2).
+				Foreground(lipgloss.Color(colorDetailGray))
+			s.WriteString(detailStyle.Render(m.formatTagDetails(tag)))
+			s.WriteString("\n")
+		}
+	}
+
+	if endIdx < len(tags) {
+		indicator := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(colorGray)).
+			Render("⬇ More items below...")
+		s.WriteString(indicator)
+		s.WriteString("\n")
+	}
+
+	return s.String()
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// teamFileName is the team-shared file with pinned endpoints and notes, committed to the
+// repository of the service the spec describes
+var teamFileName = filepath.Join(".oq", "team.yaml")
+
+// teamFile holds the endpoints a team pinned and the notes it shares. path is where the file
+// is, or where it is created on the first pin when exists is false
+type teamFile struct {
+	path   string
+	exists bool
+	pins   []string
+	notes  specNotes
+}
+
+type teamFileContent struct {
+	Pinned []string             `yaml:"pinned"`
+	Notes  map[string]yaml.Node `yaml:"notes"`
+}
+
+// findTeamFile looks for .oq/team.yaml in the spec's directory and its parents up to the
+// repository root. Without one, it returns where a new file goes: the repository root, or the
+// spec's directory outside a repository
+func findTeamFile(specPath string) (path string, exists bool) {
+	start, err := os.Getwd()
+	if specPath != "" && !isRemoteSpec(specPath) {
+		start, err = filepath.Abs(filepath.Dir(specPath))
+	}
+	if err != nil {
+		return "", false
+	}
+	for dir := start; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, teamFileName)); err == nil {
+			return filepath.Join(dir, teamFileName), true
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return filepath.Join(dir, teamFileName), false
+		}
+		if filepath.Dir(dir) == dir {
+			return filepath.Join(start, teamFileName), false
+		}
+	}
+}
+
+// loadTeamFile reads the team file for a spec, if there is one
+func loadTeamFile(specPath string) (*teamFile, error) {
+	path, exists := findTeamFile(specPath)
+	if path == "" {
+		return nil, nil
+	}
+	team := &teamFile{path: path, exists: exists}
+	if !exists {
+		return team, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		team.exists = false
+		return team, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading team file: %w", err)
+	}
+	var raw teamFileContent
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("Error parsing team file %s: %w", path, err)
+	}
+	team.pins = raw.Pinned
+	if team.notes, err = parseNotes(raw.Notes, path); err != nil {
+		return nil, err
+	}
+	debugLog.Debug("loaded team file", "path", path, "pinned", len(team.pins), "notes", len(team.notes))
+	return team, nil
+}
+
+// endpointKeys are the keys an endpoint is pinned or annotated under, most specific first
+func endpointKeys(ep endpoint) []string {
+	var keys []string
+	if ep.op.OperationId != "" {
+		keys = append(keys, ep.op.OperationId)
+	}
+	return append(keys, ep.method+" "+ep.path, ep.path)
+}
+
+func (t *teamFile) pinned(ep endpoint) bool {
+	if t == nil {
+		return false
+	}
+	return slices.ContainsFunc(endpointKeys(ep), func(key string) bool { return slices.Contains(t.pins, key) })
+}
+
+// mergeNotes adds the team notes after the ones from the notes file
+func mergeNotes(notes, team specNotes) specNotes {
+	if len(team) == 0 {
+		return notes
+	}
+	merged := specNotes{}
+	for key, values := range notes {
+		merged[key] = append(merged[key], values...)
+	}
+	for key, values := range team {
+		merged[key] = append(merged[key], values...)
+	}
+	return merged
+}
+
+// togglePin pins the endpoint under the cursor in the team file, or unpins it. It is pinned by
+// operationId when it has one, so the pin survives a path change
+func (m *Model) togglePin() {
+	ep, ok := m.selectedEndpoint()
+	if !ok || m.team == nil {
+		return
+	}
+
+	pins := slices.DeleteFunc(slices.Clone(m.team.pins), func(key string) bool {
+		return slices.Contains(endpointKeys(ep), key)
+	})
+	pinned := len(pins) == len(m.team.pins)
+	if pinned {
+		pins = append(pins, endpointKeys(ep)[0])
+	}
+
+	if err := m.team.save(pins); err != nil {
+		m.setStatus(fmt.Sprintf("Error saving %s: %v", m.team.path, err), true)
+		return
+	}
+	m.team.pins = pins
+	if m.filters.pinned {
+		// An unpinned endpoint leaves the list
+		m.filterItems()
+		m.cursor = min(m.cursor, max(0, len(m.endpointRows())-1))
+		m.ensureCursorVisible()
+	}
+	if pinned {
+		m.setStatus(fmt.Sprintf("Pinned %s %s in %s", ep.method, ep.path, m.team.path), false)
+	} else {
+		m.setStatus(fmt.Sprintf("Unpinned %s %s", ep.method, ep.path), false)
+	}
+}
+
+// save writes the pinned list, keeping the notes and comments of an existing file
+func (t *teamFile) save(pins []string) error {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, pin := range pins {
+		seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: pin})
+	}
+
+	if !t.exists {
+		root := newMapping()
+		setMapping(root, "pinned", seq)
+		content, err := yaml.Marshal(root)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(t.path, content, 0o644); err != nil {
+			return err
+		}
+		t.exists = true
+		return nil
+	}
+
+	_, err := editSpecFile(t.path, func(root *yaml.Node) error {
+		if root.Kind != yaml.MappingNode {
+			return fmt.Errorf("expected a mapping with pinned and notes")
+		}
+		setMapping(root, "pinned", seq)
+		return nil
+	})
+	return err
+}
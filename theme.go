@@ -0,0 +1,75 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// palette is the set of colors the views use
+type palette struct {
+	green, blue, yellow, red, purple, gray string
+	accent, background, detail, footerText string
+	text                                   string
+}
+
+// themes are picked with the theme setting, --theme or OQ_THEME. dark suits the usual dark
+// terminal background, light keeps text and the selection readable on a light one. Without
+// a theme, the one matching the terminal background is used. solarized follows the
+// Solarized dark palette, monochrome has no colors and marks the selection in reverse video
+var themes = map[string]palette{
+	"dark": {
+		green: "#10B981", blue: "#3B82F6", yellow: "#F59E0B", red: "#EF4444", purple: "#8B5CF6", gray: "#6B7280",
+		accent: "#7C3AED", background: "#374151", detail: "#9CA3AF", footerText: "#000000", text: "#FFFFFF",
+	},
+	"light": {
+		green: "#047857", blue: "#1D4ED8", yellow: "#B45309", red: "#B91C1C", purple: "#6D28D9", gray: "#6B7280",
+		accent: "#6D28D9", background: "#E5E7EB", detail: "#4B5563", footerText: "#FFFFFF", text: "#111827",
+	},
+	"solarized": {
+		green: "#859900", blue: "#268BD2", yellow: "#B58900", red: "#DC322F", purple: "#D33682", gray: "#586E75",
+		accent: "#6C71C4", background: "#073642", detail: "#839496", footerText: "#002B36", text: "#93A1A1",
+	},
+	"monochrome": {},
+}
+
+func themeNames() string {
+	return strings.Join(slices.Sorted(maps.Keys(themes)), ", ")
+}
+
+// resolveTheme picks the theme to use. NO_COLOR turns colors off whatever theme is set,
+// otherwise the named theme is used, or the one matching the terminal background
+func resolveTheme(name string, noColor bool, darkBackground func() bool) string {
+	switch {
+	case noColor:
+		return "monochrome"
+	case name != "":
+		return name
+	case darkBackground():
+		return "dark"
+	}
+	return "light"
+}
+
+// highlight marks the selected row with the theme's selection background, or in reverse
+// video when the theme has no colors
+func highlight(style lipgloss.Style) lipgloss.Style {
+	if colorBackground == "" {
+		return style.Reverse(true)
+	}
+	return style.Background(lipgloss.Color(colorBackground))
+}
+
+// applyTheme switches the colors to a theme, unknown names keep the current one
+func applyTheme(name string) {
+	p, ok := themes[name]
+	if !ok {
+		return
+	}
+	colorGreen, colorBlue, colorYellow, colorRed, colorPurple, colorGray = p.green, p.blue, p.yellow, p.red, p.purple, p.gray
+	colorThemePurple, colorBackground, colorDetailGray, colorFooterText = p.accent, p.background, p.detail, p.footerText
+	colorWhite = p.text
+	methodColors = themeMethodColors()
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// usagesPane lists the operations referencing a schema, with a preview of the selected one
+type usagesPane struct {
+	schema    string
+	endpoints []endpoint
+	cursor    int
+	// offset is the first endpoint shown when they don't all fit
+	offset int
+}
+
+// schemaUsages returns the endpoints whose parameters, bodies or responses use the named
+// component schema, directly or through other schemas
+func schemaUsages(eps []endpoint, schema string) []endpoint {
+	var usages []endpoint
+	for _, ep := range eps {
+		if operationSchemaRefs(ep.op)[schema] {
+			usages = append(usages, ep)
+		}
+	}
+	return usages
+}
+
+// openUsages opens the usages pane for the component under the cursor
+func (m *Model) openUsages() {
+	comps := m.getActiveComponents()
+	if m.cursor < 0 || m.cursor >= len(comps) {
+		return
+	}
+	comp := comps[m.cursor]
+	if comp.compType != "Schema" {
+		m.setStatus("Usages are only available for schemas", true)
+		return
+	}
+
+	usages := schemaUsages(m.endpoints, comp.name)
+	if len(usages) == 0 {
+		m.setStatus(fmt.Sprintf("No operations reference %s", comp.name), false)
+		return
+	}
+	m.usages = &usagesPane{schema: comp.name, endpoints: usages}
+}
+
+// jumpToEndpoint shows ep expanded in the endpoints view, clearing filters that hide it
+func (m *Model) jumpToEndpoint(ep endpoint) {
+	m.mode = viewEndpoints
+	m.filterItems()
+
+	find := func() int {
+		for i, e := range m.getActiveEndpoints() {
+			if e.path == ep.path && e.method == ep.method {
+				return i
+			}
+		}
+		return -1
+	}
+
+	index := find()
+	if index < 0 {
+		m.filters = listFilters{}
+		m.searchInput.SetValue("")
+		m.filterItems()
+		index = find()
+	}
+	if index < 0 {
+		return
+	}
+
+	for i := range m.endpoints {
+		if m.endpoints[i].path == ep.path && m.endpoints[i].method == ep.method {
+			m.endpoints[i].folded = false
+			break
+		}
+	}
+	m.filterItems()
+
+	m.cursor = m.endpointRowIndex(index)
+	m.ensureCursorVisible()
+}
+
+// jumpToComponent shows a component expanded in the components view, clearing filters that
+// hide it
+func (m *Model) jumpToComponent(compType, name string) bool {
+	m.loadComponents()
+	find := func() int {
+		for i, c := range m.getActiveComponents() {
+			if c.compType == compType && c.name == name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	index := find()
+	if index < 0 {
+		m.filters = listFilters{}
+		m.searchInput.SetValue("")
+		m.filterItems()
+		index = find()
+	}
+	if index < 0 {
+		return false
+	}
+
+	for i := range m.components {
+		if m.components[i].compType == compType && m.components[i].name == name {
+			m.components[i].folded = false
+			break
+		}
+	}
+	m.filterItems()
+
+	m.mode = viewComponents
+	m.cursor = find()
+	m.ensureCursorVisible()
+	return true
+}
+
+// updateUsages handles keys while the usages pane is open
+func (m *Model) updateUsages(key string) {
+	pane := m.usages
+	switch key {
+	case "esc", "q", "u":
+		m.usages = nil
+	case "up", "k":
+		pane.cursor = (pane.cursor - 1 + len(pane.endpoints)) % len(pane.endpoints)
+		pane.keepCursorVisible(m.usagesHeight())
+	case "down", "j":
+		pane.cursor = (pane.cursor + 1) % len(pane.endpoints)
+		pane.keepCursorVisible(m.usagesHeight())
+	case "enter":
+		ep := pane.endpoints[pane.cursor]
+		m.usages = nil
+		m.jumpToEndpoint(ep)
+	}
+}
+
+// keepCursorVisible scrolls the list just enough to show the cursor in height rows
+func (p *usagesPane) keepCursorVisible(height int) {
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+height {
+		p.offset = p.cursor - height + 1
+	}
+	p.offset = max(0, min(p.offset, len(p.endpoints)-height))
+}
+
+// usagesHeight is the number of rows of the usages list and preview
+func (m Model) usagesHeight() int {
+	return max(1, m.height-4)
+}
+
+func (m Model) renderUsagesPane() string {
+	pane := m.usages
+
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+
+	listWidth := min(40, max(20, m.width/3))
+	previewWidth := max(20, m.width-listWidth-3)
+	bodyHeight := m.usagesHeight()
+	// The terminal may have been resized since the cursor last moved
+	pane.keepCursorVisible(bodyHeight)
+
+	methodWidth := m.methodWidth(endpointMethods(pane.endpoints))
+
+	var rows []string
+	for i := pane.offset; i < min(len(pane.endpoints), pane.offset+bodyHeight); i++ {
+		ep := pane.endpoints[i]
+		methodStyle := lipgloss.NewStyle().
+			Foreground(m.methodColor(ep.method)).
+			Bold(true).
+			Width(methodWidth)
+		line := methodStyle.Render(m.methodLabel(ep.method)) + " " + ep.path
+		lineStyle := lipgloss.NewStyle().Width(listWidth).MaxWidth(listWidth)
+		if i == pane.cursor {
+			lineStyle = highlight(lineStyle)
+		}
+		rows = append(rows, lineStyle.Render(line))
+	}
+	list := m.truncateContent(strings.Join(rows, "\n"), bodyHeight)
+
+	preview := lipgloss.NewStyle().
+		Width(previewWidth).
+		Foreground(lipgloss.Color(colorDetailGray)).
+		Render(m.endpointDetails(pane.endpoints[pane.cursor]))
+	preview = m.truncateContent(preview, bodyHeight)
+
+	separator := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Render(strings.TrimSuffix(strings.Repeat("│\n", bodyHeight), "\n"))
+
+	title := titleStyle.Render(fmt.Sprintf("Usages of %s (%d/%d)", pane.schema, pane.cursor+1, len(pane.endpoints)))
+	instruction := instructionStyle.Render("j/k cycle · Enter jump to endpoint · Esc close")
+	body := lipgloss.JoinHorizontal(lipgloss.Top, list, " ", separator, " ", preview)
+
+	return lipgloss.NewStyle().MaxHeight(m.height).Render(title + "\n\n" + body + "\n" + instruction)
+}
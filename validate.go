@@ -0,0 +1,212 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/index"
+	"github.com/pb33f/libopenapi/utils"
+	"go.yaml.in/yaml/v4"
+)
+
+var pathTemplateParam = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// validateSpec checks the spec as written and collects the problems oq finds without a
+// ruleset: the errors libopenapi reported while building the model (buildErr), plus path
+// parameters that don't match the path, operations without responses and duplicate
+// operationIds
+func validateSpec(content []byte, buildErr error) []lintIssue {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil || len(doc.Content) == 0 {
+		return buildIssues(nil, buildErr)
+	}
+	root := doc.Content[0]
+	issues := append(buildIssues(root, buildErr), checkOperations(root)...)
+	sortIssues(issues)
+	return issues
+}
+
+// sortIssues orders issues by position, leaving those without one at the end
+func sortIssues(issues []lintIssue) {
+	slices.SortStableFunc(issues, func(a, b lintIssue) int {
+		switch {
+		case a.line == 0 || b.line == 0:
+			return cmp.Compare(b.line, a.line)
+		case a.line != b.line:
+			return cmp.Compare(a.line, b.line)
+		}
+		return cmp.Compare(a.column, b.column)
+	})
+}
+
+// buildIssues turns the errors of BuildV3Model into issues, placed at the node they carry
+func buildIssues(root *yaml.Node, buildErr error) []lintIssue {
+	if buildErr == nil {
+		return nil
+	}
+	// Swagger 2.0 specs are built after conversion, so positions don't match the file
+	var positions map[[2]int][]string
+	if root != nil && mappingValue(root, "swagger") == nil {
+		positions = map[[2]int][]string{}
+		indexPositions(root, nil, positions)
+	}
+
+	var issues []lintIssue
+	for _, err := range utils.UnwrapErrors(buildErr) {
+		issue := lintIssue{rule: "oas-build", severity: severityError, message: err.Error()}
+
+		var node *yaml.Node
+		var indexing *index.IndexingError
+		var resolving *index.ResolvingError
+		switch {
+		case errors.As(err, &resolving):
+			node = resolving.Node
+			issue.rule = "oas-resolve"
+			issue.message = resolving.ErrorRef.Error()
+			if resolving.CircularReference != nil {
+				issue.rule = "oas-circular-ref"
+				issue.message = "Circular reference: " + resolving.Path
+			}
+		case errors.As(err, &indexing):
+			node = indexing.KeyNode
+			if node == nil {
+				node = indexing.Node
+			}
+			issue.rule = "oas-resolve"
+		}
+		if node != nil && positions != nil {
+			if path, ok := positions[[2]int{node.Line, node.Column}]; ok {
+				issue.path, issue.line, issue.column = path, node.Line, node.Column
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// indexPositions maps the position of every key and value to the path of the value
+func indexPositions(node *yaml.Node, path []string, positions map[[2]int][]string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := append(path[:len(path):len(path)], key.Value)
+			positions[[2]int{key.Line, key.Column}] = child
+			if _, ok := positions[[2]int{value.Line, value.Column}]; !ok {
+				positions[[2]int{value.Line, value.Column}] = child
+			}
+			indexPositions(value, child, positions)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			child := append(path[:len(path):len(path)], strconv.Itoa(i))
+			if _, ok := positions[[2]int{item.Line, item.Column}]; !ok {
+				positions[[2]int{item.Line, item.Column}] = child
+			}
+			indexPositions(item, child, positions)
+		}
+	}
+}
+
+// checkOperations runs the built-in checks on every operation under paths
+func checkOperations(root *yaml.Node) []lintIssue {
+	paths := mappingValue(root, "paths")
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var issues []lintIssue
+	problem := func(rule string, severity lintSeverity, at *yaml.Node, path []string, format string, args ...any) {
+		issues = append(issues, lintIssue{
+			rule:     rule,
+			severity: severity,
+			message:  fmt.Sprintf(format, args...),
+			path:     path,
+			line:     at.Line,
+			column:   at.Column,
+		})
+	}
+
+	operationIds := map[string]string{}
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		pathKey, item := paths.Content[i], paths.Content[i+1]
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		var templateParams []string
+		for _, match := range pathTemplateParam.FindAllStringSubmatch(pathKey.Value, -1) {
+			templateParams = append(templateParams, match[1])
+		}
+		shared := pathParameters(root, mappingValue(item, "parameters"))
+		for name, node := range shared {
+			if !slices.Contains(templateParams, name) {
+				problem("path-params", severityWarn, node, []string{"paths", pathKey.Value, "parameters"}, "Path parameter %s is not in %s", name, pathKey.Value)
+			}
+		}
+
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			methodKey, op := item.Content[j], item.Content[j+1]
+			if !standardOperationKeys[methodKey.Value] || op.Kind != yaml.MappingNode {
+				continue
+			}
+			opPath := []string{"paths", pathKey.Value, methodKey.Value}
+			label := strings.ToUpper(methodKey.Value) + " " + pathKey.Value
+
+			if id := mappingValue(op, "operationId"); id != nil && id.Value != "" {
+				if other, ok := operationIds[id.Value]; ok {
+					problem("operation-operationId-unique", severityError, id, append(opPath, "operationId"), "operationId %s is also used by %s", id.Value, other)
+				} else {
+					operationIds[id.Value] = label
+				}
+			}
+
+			if responses := mappingValue(op, "responses"); responses == nil || len(responses.Content) == 0 {
+				problem("operation-responses", severityError, methodKey, opPath, "%s has no responses", label)
+			}
+
+			own := pathParameters(root, mappingValue(op, "parameters"))
+			for _, name := range templateParams {
+				if shared[name] == nil && own[name] == nil {
+					problem("path-params", severityError, methodKey, opPath, "Path parameter {%s} of %s is not declared", name, label)
+				}
+			}
+			for name, node := range own {
+				if !slices.Contains(templateParams, name) {
+					problem("path-params", severityWarn, node, opPath, "Path parameter %s is not in %s", name, pathKey.Value)
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// pathParameters returns the `in: path` parameters of a parameters list by name, following
+// local $refs
+func pathParameters(root, params *yaml.Node) map[string]*yaml.Node {
+	found := map[string]*yaml.Node{}
+	if params == nil || params.Kind != yaml.SequenceNode {
+		return found
+	}
+	for _, param := range params.Content {
+		resolved := param
+		if ref := mappingValue(param, "$ref"); ref != nil && strings.HasPrefix(ref.Value, "#/") {
+			tokens, err := parsePointer(strings.TrimPrefix(ref.Value, "#"))
+			if err != nil {
+				continue
+			}
+			if resolved = resolvePointer(root, tokens); resolved == nil {
+				continue
+			}
+		}
+		name, in := mappingValue(resolved, "name"), mappingValue(resolved, "in")
+		if name != nil && in != nil && in.Value == "path" {
+			found[name.Value] = param
+		}
+	}
+	return found
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+)
+
+// numberLocale separates the digits of the numbers shown in details, such as the range of a
+// schema. Example bodies, snippets and requests always use JSON numbers
+type numberLocale struct {
+	decimal, thousands string
+}
+
+// displayLocale is set from the locale setting before the TUI starts, plain by default
+var displayLocale = numberLocale{decimal: "."}
+
+// numberLocales are the separators by language, and by region where it differs. Spaces
+// between thousands are no-break spaces, so wrapping doesn't split numbers
+var numberLocales = map[string]numberLocale{
+	"en": {".", ","}, "ja": {".", ","}, "ko": {".", ","}, "zh": {".", ","},
+	"de": {",", "."}, "es": {",", "."}, "it": {",", "."}, "nl": {",", "."},
+	"pt": {",", "."}, "da": {",", "."}, "id": {",", "."}, "tr": {",", "."},
+	"fr": {",", "\u202f"}, "ru": {",", "\u00a0"}, "pl": {",", "\u00a0"}, "cs": {",", "\u00a0"},
+	"sv": {",", "\u00a0"}, "nb": {",", "\u00a0"}, "fi": {",", "\u00a0"}, "uk": {",", "\u00a0"},
+	"de-ch": {".", "'"}, "pt-br": {",", "."},
+}
+
+// parseLocale reads a locale setting: empty for plain numbers, auto for the one of LC_ALL,
+// LC_NUMERIC or LANG, or a tag such as de or fr-CA
+func parseLocale(value string) (numberLocale, bool) {
+	if value == "" {
+		return numberLocale{decimal: "."}, true
+	}
+	if value == "auto" {
+		for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+			env := os.Getenv(name)
+			if env == "" {
+				continue
+			}
+			// en_US.UTF-8 names the locale as en-US, C and POSIX keep plain numbers
+			tag, _, _ := strings.Cut(env, ".")
+			if locale, ok := numberLocales[strings.ToLower(strings.ReplaceAll(tag, "_", "-"))]; ok {
+				return locale, true
+			}
+			language, _, _ := strings.Cut(strings.ToLower(tag), "_")
+			if locale, ok := numberLocales[language]; ok {
+				return locale, true
+			}
+			break
+		}
+		return numberLocale{decimal: "."}, true
+	}
+	tag := strings.ToLower(value)
+	if locale, ok := numberLocales[tag]; ok {
+		return locale, true
+	}
+	language, _, _ := strings.Cut(tag, "-")
+	locale, ok := numberLocales[language]
+	return locale, ok
+}
+
+// formatNumber writes a number for display with the configured separators, with as many
+// decimals as precision or as it needs when precision is -1. It is never in exponent form
+func formatNumber(value float64, precision int) string {
+	digits := strconv.FormatFloat(value, 'f', precision, 64)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if displayLocale.thousands != "" {
+		var grouped strings.Builder
+		for i, digit := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				grouped.WriteString(displayLocale.thousands)
+			}
+			grouped.WriteRune(digit)
+		}
+		whole = grouped.String()
+	}
+	if fraction != "" {
+		return sign + whole + displayLocale.decimal + fraction
+	}
+	return sign + whole
+}
+
+// decimalPlaces is the precision numbers of a schema are written with: the decimals of its
+// multipleOf, as 2 for 0.01, or -1 for as many as needed
+func decimalPlaces(s *base.Schema) int {
+	if exampleType(s) == "integer" {
+		return 0
+	}
+	if s.MultipleOf == nil || *s.MultipleOf <= 0 {
+		return -1
+	}
+	_, fraction, _ := strings.Cut(strconv.FormatFloat(*s.MultipleOf, 'f', -1, 64), ".")
+	return len(fraction)
+}
+
+// exampleNumber is an example number meeting the schema's bounds and multipleOf, as a JSON
+// number with the schema's precision: 0, or the value closest to it that is allowed
+func exampleNumber(s *base.Schema) string {
+	step := 1.0
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		step = *s.MultipleOf
+	}
+	value := 0.0
+	if limit, ok := exclusiveLimit(s.ExclusiveMinimum, s.Minimum); ok {
+		value = math.Max(value, limit+step)
+	} else if s.Minimum != nil {
+		value = math.Max(value, *s.Minimum)
+	}
+	if limit, ok := exclusiveLimit(s.ExclusiveMaximum, s.Maximum); ok {
+		value = math.Min(value, limit-step)
+	} else if s.Maximum != nil {
+		value = math.Min(value, *s.Maximum)
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		value = math.Ceil(value/step) * step
+	}
+	if exampleType(s) == "integer" {
+		value = math.Ceil(value)
+	}
+	// Avoids -0 for bounds such as maximum: -0.5 rounded up
+	if value == 0 {
+		value = 0
+	}
+	return strconv.FormatFloat(value, 'f', decimalPlaces(s), 64)
+}
+
+// schemaRange describes the bounds and multipleOf of a number schema, such as
+// "1.00 to 500.00, multiple of 0.01", or "" when it has none
+func schemaRange(s *base.Schema) string {
+	precision := decimalPlaces(s)
+	minimum, exclusiveMin := s.Minimum, false
+	if limit, ok := exclusiveLimit(s.ExclusiveMinimum, s.Minimum); ok {
+		minimum, exclusiveMin = &limit, true
+	}
+	maximum, exclusiveMax := s.Maximum, false
+	if limit, ok := exclusiveLimit(s.ExclusiveMaximum, s.Maximum); ok {
+		maximum, exclusiveMax = &limit, true
+	}
+
+	var parts []string
+	switch {
+	case minimum != nil && maximum != nil && !exclusiveMin && !exclusiveMax:
+		parts = append(parts, formatNumber(*minimum, precision)+" to "+formatNumber(*maximum, precision))
+	default:
+		if minimum != nil {
+			bound := "at least "
+			if exclusiveMin {
+				bound = "more than "
+			}
+			parts = append(parts, bound+formatNumber(*minimum, precision))
+		}
+		if maximum != nil {
+			bound := "at most "
+			if exclusiveMax {
+				bound = "less than "
+			}
+			parts = append(parts, bound+formatNumber(*maximum, precision))
+		}
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		parts = append(parts, "multiple of "+formatNumber(*s.MultipleOf, -1))
+	}
+	return strings.Join(parts, ", ")
+}
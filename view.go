@@ -2,12 +2,16 @@ package main
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
-const (
+// The colors of the active theme, set from the theme config setting before the TUI starts
+var (
 	colorGreen       = "#10B981"
 	colorBlue        = "#3B82F6"
 	colorYellow      = "#F59E0B"
@@ -21,28 +25,35 @@ const (
 	colorWhite       = "#FFFFFF"
 )
 
-var methodColors = map[string]lipgloss.Color{
-	"GET":     colorGreen,
-	"POST":    colorBlue,
-	"PUT":     colorYellow,
-	"DELETE":  colorRed,
-	"PATCH":   colorPurple,
-	"HEAD":    colorGray,
-	"OPTIONS": colorGray,
-	"TRACE":   colorGray,
+var methodColors = themeMethodColors()
+
+// themeMethodColors returns the colors of the standard methods in the active theme
+func themeMethodColors() map[string]lipgloss.Color {
+	return map[string]lipgloss.Color{
+		"GET":     lipgloss.Color(colorGreen),
+		"POST":    lipgloss.Color(colorBlue),
+		"PUT":     lipgloss.Color(colorYellow),
+		"DELETE":  lipgloss.Color(colorRed),
+		"PATCH":   lipgloss.Color(colorPurple),
+		"HEAD":    lipgloss.Color(colorGray),
+		"OPTIONS": lipgloss.Color(colorGray),
+		"TRACE":   lipgloss.Color(colorGray),
+	}
 }
 
 func (m Model) renderEndpoints() string {
 	var s strings.Builder
 
 	// Calculate available content height and width
-	contentHeight := calculateContentHeight(m.height)
+	contentHeight := m.contentHeight()
 	contentWidth := calculateContentWidth(m.width)
 
 	eps := m.getActiveEndpoints()
+	methodWidth := m.methodWidth(endpointMethods(eps))
+	rows := m.endpointRows()
 
 	startIdx := m.scrollOffset
-	endIdx := min(m.scrollOffset+contentHeight, len(eps))
+	endIdx := min(m.scrollOffset+contentHeight, len(rows))
 
 	// Add scroll indicator for items above
 	if m.scrollOffset > 0 {
@@ -54,40 +65,57 @@ func (m Model) renderEndpoints() string {
 	}
 
 	for i := startIdx; i < endIdx; i++ {
-		ep := eps[i]
-		style := lipgloss.NewStyle()
-
-		methodColor := methodColors[ep.method]
-		if methodColor == "" {
-			methodColor = colorGray
+		if rows[i].isHeader() {
+			s.WriteString(m.renderTagHeader(rows[i], i == m.cursor, contentWidth))
+			s.WriteString("\n")
+			continue
 		}
+		ep := eps[rows[i].index]
+		style := lipgloss.NewStyle()
 
 		methodStyle := lipgloss.NewStyle().
-			Foreground(methodColor).
+			Foreground(m.methodColor(ep.method)).
 			Bold(true).
-			Width(7)
+			Width(methodWidth)
 
 		if i == m.cursor {
-			style = style.Background(lipgloss.Color(colorBackground))
-			methodStyle = methodStyle.Background(lipgloss.Color(colorBackground))
+			style = highlight(style)
+			methodStyle = highlight(methodStyle)
 		}
 
 		foldIcon := "▶"
-		if !ep.folded {
+		if !ep.folded && !m.useSplitView() {
 			foldIcon = "▼"
 		}
 
 		var line strings.Builder
+		if m.groupedByTag {
+			line.WriteString("  ")
+		}
 		line.WriteString(style.Render(foldIcon + " "))
-		line.WriteString(methodStyle.Render(ep.method))
-		line.WriteString(style.Render(" " + ep.path))
+		line.WriteString(methodStyle.Render(m.methodLabel(ep.method)))
+		if m.team.pinned(ep) {
+			line.WriteString(style.Foreground(lipgloss.Color(colorYellow)).Render(" ★"))
+		}
+		line.WriteString(style.Render(" ") + deprecatedStyle(style, isDeprecated(ep.op)).Render(ep.path))
+		line.WriteString(m.renderChangelogBadges(findChangelog(ep.op), isDeprecated(ep.op), style))
+		line.WriteString(m.renderPatchBadge("endpoint "+ep.method+" "+ep.path, style))
+		line.WriteString(m.renderLatencyBadge(ep, style))
+		line.WriteString(m.renderPayloadBadge(ep, style))
 		line.WriteString(style.Render(strings.Repeat(" ", contentWidth)))
 
 		s.WriteString(style.Render(line.String()))
 		s.WriteString("\n")
 
 		if !ep.folded {
-			details := formatEndpointDetails(ep)
+			details, skipped := m.inlineDetails(ep, i == m.cursor)
+			if skipped > 0 {
+				s.WriteString(lipgloss.NewStyle().
+					PaddingLeft(2).
+					Foreground(lipgloss.Color(colorGray)).
+					Render(fmt.Sprintf("⬆ %d %s above, o to jump", skipped, plural(skipped, "line", "lines"))))
+				s.WriteString("\n")
+			}
 			detailStyle := lipgloss.NewStyle().
 				PaddingLeft(2).
 				Foreground(lipgloss.Color(colorDetailGray))
@@ -97,7 +125,7 @@ func (m Model) renderEndpoints() string {
 	}
 
 	// Add scroll indicator for items below
-	if endIdx < len(eps) {
+	if endIdx < len(rows) {
 		indicator := lipgloss.NewStyle().
 			Foreground(lipgloss.Color(colorGray)).
 			Render("⬇ More items below...")
@@ -111,7 +139,7 @@ func (m Model) renderEndpoints() string {
 func (m Model) renderComponents() string {
 	var s strings.Builder
 
-	componentColors := map[string]lipgloss.Color{
+	componentColors := map[string]string{
 		"Schema":         colorGreen,
 		"RequestBody":    colorBlue,
 		"Response":       colorYellow,
@@ -121,7 +149,7 @@ func (m Model) renderComponents() string {
 	}
 
 	// Calculate available content height and width
-	contentHeight := calculateContentHeight(m.height)
+	contentHeight := m.contentHeight()
 	contentWidth := calculateContentWidth(m.width)
 
 	comps := m.getActiveComponents()
@@ -148,24 +176,30 @@ func (m Model) renderComponents() string {
 		}
 
 		typeStyle := lipgloss.NewStyle().
-			Foreground(componentColor).
+			Foreground(lipgloss.Color(componentColor)).
 			Bold(true).
 			Width(16)
 
 		if i == m.cursor {
-			style = style.Background(lipgloss.Color(colorBackground))
-			typeStyle = typeStyle.Background(lipgloss.Color(colorBackground))
+			style = highlight(style)
+			typeStyle = highlight(typeStyle)
 		}
 
 		foldIcon := "▶"
-		if !comp.folded {
+		if !comp.folded && !m.useSplitView() {
 			foldIcon = "▼"
 		}
 
 		var line strings.Builder
 		line.WriteString(style.Render(foldIcon + " "))
 		line.WriteString(typeStyle.Render(comp.compType + ":"))
-		line.WriteString(style.Render(comp.name + " "))
+		line.WriteString(deprecatedStyle(style, comp.deprecated).Render(comp.name))
+		line.WriteString(m.renderChangelogBadges(changelog{}, comp.deprecated, style))
+		line.WriteString(m.renderPatchBadge("component "+comp.compType+" "+comp.name, style))
+		if comp.cycle != "" {
+			line.WriteString(style.Render(" ") + style.Foreground(lipgloss.Color(colorYellow)).Render("[recursive]"))
+		}
+		line.WriteString(style.Render(" "))
 		line.WriteString(style.Render(strings.Repeat(" ", contentWidth)))
 
 		if comp.description != "" {
@@ -200,10 +234,15 @@ func (m Model) renderWebhooks() string {
 	var s strings.Builder
 
 	// Calculate available content height and width
-	contentHeight := calculateContentHeight(m.height)
+	contentHeight := m.contentHeight()
 	contentWidth := calculateContentWidth(m.width)
 
 	hooks := m.getActiveWebhooks()
+	hookMethods := make([]string, len(hooks))
+	for i, hook := range hooks {
+		hookMethods[i] = hook.method
+	}
+	methodWidth := m.methodWidth(hookMethods)
 
 	startIdx := m.scrollOffset
 	endIdx := min(m.scrollOffset+contentHeight, len(hooks))
@@ -221,30 +260,28 @@ func (m Model) renderWebhooks() string {
 		hook := hooks[i]
 		style := lipgloss.NewStyle()
 
-		methodColor := methodColors[hook.method]
-		if methodColor == "" {
-			methodColor = colorGray
-		}
-
 		methodStyle := lipgloss.NewStyle().
-			Foreground(methodColor).
+			Foreground(m.methodColor(hook.method)).
 			Bold(true).
-			Width(7)
+			Width(methodWidth)
 
 		if i == m.cursor {
-			style = style.Background(lipgloss.Color(colorBackground))
-			methodStyle = methodStyle.Background(lipgloss.Color(colorBackground))
+			style = highlight(style)
+			methodStyle = highlight(methodStyle)
 		}
 
 		foldIcon := "▶"
-		if !hook.folded {
+		if !hook.folded && !m.useSplitView() {
 			foldIcon = "▼"
 		}
 
 		var line strings.Builder
 		line.WriteString(style.Render(foldIcon + " "))
-		line.WriteString(methodStyle.Render(hook.method + " "))
-		line.WriteString(style.Render(hook.name + " "))
+		line.WriteString(methodStyle.Render(m.methodLabel(hook.method) + " "))
+		line.WriteString(deprecatedStyle(style, isDeprecated(hook.op)).Render(hook.name))
+		line.WriteString(m.renderChangelogBadges(findChangelog(hook.op), isDeprecated(hook.op), style))
+		line.WriteString(m.renderPatchBadge("webhook "+hook.method+" "+hook.name, style))
+		line.WriteString(style.Render(" "))
 		line.WriteString(style.Render(strings.Repeat(" ", contentWidth)))
 
 		s.WriteString(style.Render(line.String()))
@@ -279,7 +316,22 @@ func (m Model) renderHeader() string {
 			Foreground(lipgloss.Color(colorWhite)).
 			Bold(true)
 		searchPrompt := searchStyle.Render("/") + " " + m.searchInput.View()
-		return searchPrompt + "\n\n"
+		scope := "this view · tab next view · ctrl+g all views"
+		if m.searchAll {
+			scope = "all views · ↑/↓ select · Enter open · ctrl+g this view"
+		}
+		if count := m.renderSearchMatchCount(); count != "" {
+			searchPrompt += "  " + count
+		}
+		searchPrompt += lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray)).Render("  " + scope)
+		return m.withFilterChips(searchPrompt + "\n\n")
+	}
+
+	if m.commandMode {
+		promptStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(colorWhite)).
+			Bold(true)
+		return m.withFilterChips(promptStyle.Render(":") + " " + m.commandInput.View() + "\n\n")
 	}
 
 	// Button styles for navigation
@@ -297,37 +349,41 @@ func (m Model) renderHeader() string {
 		Bold(true).
 		Foreground(lipgloss.Color(colorThemePurple))
 
-	// Build navigation buttons
-	var buttons []string
-
-	// Endpoints button
-	if m.mode == viewEndpoints {
-		buttons = append(buttons, activeButtonStyle.Render("Requests"))
-	} else {
-		buttons = append(buttons, buttonStyle.Render("Requests"))
-	}
-
-	// Webhooks button (only if available)
-	if m.hasWebhooks() {
-		if m.mode == viewWebhooks {
-			buttons = append(buttons, activeButtonStyle.Render("Webhooks"))
-		} else {
-			buttons = append(buttons, buttonStyle.Render("Webhooks"))
+	// Build the tabs, each with the number of items in its view unless they don't fit
+	renderTabs := func(counts bool) string {
+		var buttons []string
+		for _, tab := range m.headerTabs() {
+			label := tab.name
+			if counts {
+				label += " " + tab.count
+			}
+			if m.mode == tab.mode {
+				buttons = append(buttons, activeButtonStyle.Render(label))
+			} else {
+				buttons = append(buttons, buttonStyle.Render(label))
+			}
 		}
+		return strings.Join(buttons, " │ ")
 	}
-
-	// Components button
-	if m.mode == viewComponents {
-		buttons = append(buttons, activeButtonStyle.Render("Components"))
-	} else {
-		buttons = append(buttons, buttonStyle.Render("Components"))
+	navSection := renderTabs(true)
+	if lipgloss.Width(navSection) > m.width {
+		navSection = renderTabs(false)
 	}
 
-	// Join buttons with separators
-	navSection := strings.Join(buttons, " │ ")
+	// Why the list looks the way it does: its sort order and grouping
+	if state := m.listState(); state != "" && lipgloss.Width(navSection)+len(state)+2 <= m.width {
+		navSection += "  " + lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray)).Render(state)
+	}
 
-	// App title for right side
-	appTitle := titleStyle.Render("oq - OpenAPI Spec Viewer")
+	// Spec title and version on the right, prefixed with the spec fingerprint when known
+	title := "oq - OpenAPI Spec Viewer"
+	if m.doc != nil && m.doc.Info != nil && m.doc.Info.Title != "" {
+		title = m.footerTitle()
+	}
+	appTitle := titleStyle.Render(title)
+	if fingerprint := m.renderSpecFingerprint(); fingerprint != "" {
+		appTitle = fingerprint + "  " + appTitle
+	}
 
 	// Calculate total width for proper spacing
 	navWidth := lipgloss.Width(navSection)
@@ -345,39 +401,156 @@ func (m Model) renderHeader() string {
 		headerLine = navSection
 	}
 
-	// Return header with one empty line below
-	return headerLine + "\n\n"
+	// Return header with one line below, used for the file change banner
+	return m.withFilterChips(headerLine + "\n" + m.renderSpecBanner() + "\n")
 }
 
-func (m Model) renderFooter() string {
-	schemaInfo := fmt.Sprintf("%s v%s", m.doc.Info.Title, m.doc.Info.Version)
+// headerTab is a view listed in the header, with the number of items it shows
+type headerTab struct {
+	name  string
+	mode  viewMode
+	count string
+}
 
-	helpText := "Press '?' for help | '/' to search"
-	if m.showHelp {
-		helpText = ""
+// headerTabs lists the views with their counts, as "(3/12)" while a filter hides some items
+func (m Model) headerTabs() []headerTab {
+	count := func(shown, total int) string {
+		if m.isFiltering() && shown != total {
+			return fmt.Sprintf("(%d/%d)", shown, total)
+		}
+		return fmt.Sprintf("(%d)", total)
+	}
+
+	tabs := []headerTab{{"Endpoints", viewEndpoints, count(len(m.getActiveEndpoints()), len(m.endpoints))}}
+	if m.hasWebhooks() {
+		tabs = append(tabs, headerTab{"Webhooks", viewWebhooks, count(len(m.getActiveWebhooks()), len(m.webhooks))})
+	}
+	// Components not extracted yet can't be filtered
+	components := m.componentCount()
+	shownComponents := components
+	if m.componentsLoaded {
+		shownComponents = len(m.getActiveComponents())
+	}
+	tabs = append(tabs,
+		headerTab{"Components", viewComponents, count(shownComponents, components)},
+		headerTab{"Tags", viewTags, count(len(m.getActiveTags()), len(m.tags))},
+	)
+	return tabs
+}
+
+// listState describes how the endpoints are ordered, when not in the default path,method order
+func (m Model) listState() string {
+	if m.mode != viewEndpoints {
+		return ""
+	}
+	var state []string
+	if m.sortKeys != nil {
+		state = append(state, "sorted by "+formatSortSpec(m.sortKeys))
+	}
+	if m.groupedByTag {
+		state = append(state, "grouped by tag")
+	}
+	return strings.Join(state, " · ")
+}
+
+// withFilterChips appends the active filter chips line to a header
+func (m Model) withFilterChips(header string) string {
+	if chips := m.renderFilterChips(); chips != "" {
+		header += chips + "\n"
+	}
+	return header
+}
+
+// renderSpecFingerprint shows the short content hash and modification time of the loaded spec
+func (m Model) renderSpecFingerprint() string {
+	if m.specHash == "" {
+		return ""
+	}
+
+	info := "#" + m.specHash
+	if !m.specModTime.IsZero() {
+		layout := "2006-01-02 15:04"
+		if now := time.Now(); m.specModTime.YearDay() == now.YearDay() && m.specModTime.Year() == now.Year() {
+			layout = "15:04:05"
+		}
+		info += " · " + m.specModTime.Format(layout)
+	}
+
+	info = lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray)).Render(info)
+	if !m.specRefreshedAt.IsZero() {
+		// The spec URL served new content on one of the periodic refreshes
+		info = lipgloss.NewStyle().Foreground(lipgloss.Color(colorYellow)).Render("● changed "+m.specRefreshedAt.Format("15:04:05")) + " " + info
+	}
+	return info
+}
+
+// renderSpecBanner warns about a spec that changed on disk or failed to reload,
+// and otherwise shows the result of the last command
+func (m Model) renderSpecBanner() string {
+	var banner string
+	var color string
+	switch {
+	case m.reloadErr != nil:
+		banner = fmt.Sprintf("Reload failed: %v", m.reloadErr)
+		color = colorRed
+	case m.specChanged:
+		banner = "File changed on disk — press R to reload"
+		color = colorYellow
+	case m.statusMessage != "" && m.statusError:
+		banner = m.statusMessage
+		color = colorRed
+	case m.statusMessage != "":
+		banner = m.statusMessage
+		color = colorGray
+	case m.patch != nil:
+		banner = fmt.Sprintf("Previewing %s (%s): %s, nothing is written", m.patch.file, m.patch.kind(), patchSummary(m.patchBadges))
+		color = colorYellow
+	case len(m.budgetWarnings) > 0:
+		banner = "Over budget: " + strings.Join(m.budgetWarnings, ", ")
+		color = colorYellow
+	default:
+		return ""
 	}
 
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color(color)).
+		MaxWidth(m.width).
+		Render(strings.ReplaceAll(banner, "\n", " "))
+}
+
+func (m Model) renderFooter() string {
+	layout := m.footer
+	if layout.left == nil && layout.right == nil {
+		layout, _ = parseFooter(defaultFooter)
+	}
+	helpText := m.renderModules(layout.left)
+	schemaInfo := m.renderModules(layout.right)
+
 	footerStyle := lipgloss.NewStyle().
 		Background(lipgloss.Color(colorGray)).
 		Foreground(lipgloss.Color(colorFooterText)).
 		Padding(0, 1).
 		Width(m.width).
 		Align(lipgloss.Left)
+	if colorGray == "" {
+		// Without colors the footer bar is drawn in reverse video
+		footerStyle = footerStyle.Reverse(true)
+	}
 
-	availableWidth := m.width - len(schemaInfo) - 4
-	if len(helpText) > availableWidth {
+	availableWidth := m.width - lipgloss.Width(schemaInfo) - 4
+	if lipgloss.Width(helpText) > availableWidth {
 		helpText = ""
 	}
 
 	footerContent := fmt.Sprintf("%s%s%s",
 		helpText,
-		strings.Repeat(" ", m.width-len(helpText)-len(schemaInfo)-2),
+		strings.Repeat(" ", max(0, m.width-lipgloss.Width(helpText)-lipgloss.Width(schemaInfo)-2)),
 		schemaInfo)
 
 	return "\n" + footerStyle.Render(footerContent)
 }
 
-func (m Model) renderHelpModal() string {
+func (m Model) helpModal() modalContent {
 	keyStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color(colorBlue)).
 		Bold(true)
@@ -394,13 +567,55 @@ func (m Model) renderHelpModal() string {
 		{"Ctrl-D", "Scroll down by half a screen"},
 		{"Tab/L", "Cycle forward through views"},
 		{"Shift+Tab/H", "Cycle backward through views"},
-		{"/", "Search"},
-		{"r", "Generate curl command"},
+		{"/", "Search, e.g. tag:x status:500"},
+		{"Ctrl+G", "Search all views (while searching)"},
+		{"Tab", "Search the next view (while searching)"},
+		{":sort", "Sort, e.g. :sort tag,path"},
+		{":filter", "Filter by tag/method/deprecated/since"},
+		{"1-9", "Remove a filter chip"},
+		{"e/X", "Filter: examples, deprecated"},
+		{"F g/p/u/a/d", "Filter: GET/POST/PUT/PATCH/DELETE"},
+		{"u", "Schema usages (components)"},
+		{"r", "Generate curl or code snippet"},
+		{"E", "Response examples per status code"},
+		{"x", "Send the request"},
+		{"O", "Export endpoint as a spec"},
+		{"T", "Edit tags (--write)"},
+		{"A", "Scope matrix"},
+		{"M", "Media types consumed and produced"},
+		{"S/D", "Servers / spec overview"},
+		{"P", "Likely PII in schemas"},
+		{"I", "Problems: spec errors and ruleset issues"},
+		{"W", "Switch spec, search all open specs"},
+		{"y/Y", "Copy curl, path or JSON / JSON Pointer"},
+		{"b", "Pin endpoint for the team (.oq/team.yaml)"},
+		{"R", "Reload spec from disk"},
 		{"Enter/Space", "Toggle details"},
+		{"Enter", "Show a tag's endpoints (tags)"},
+		{"Enter", "Browse a schema as a tree (components)"},
+		{"t", "Group endpoints by tag"},
+		{"v", "Toggle split view"},
+		{"J/K, o", "Scroll details (split view), jump to a section"},
+		{"p", "Pin details to compare with others"},
 		{"?", "Toggle help"},
 		{"Esc/q", "Close help"},
 		{"Ctrl+C", "Quit"},
 	}
+	// Bindings from the config are listed after the built-in keys
+	for _, key := range slices.Sorted(maps.Keys(m.keyBindings)) {
+		name, target := key, m.keyBindings[key]
+		if name == " " {
+			name = "space"
+		}
+		switch target {
+		case "none":
+			helpData = append(helpData, []string{name, "Disabled"})
+		case " ":
+			helpData = append(helpData, []string{name, "Same as space"})
+		default:
+			helpData = append(helpData, []string{name, "Same as " + target})
+		}
+	}
 
 	// Find max width for first column
 	maxKeyWidth := 0
@@ -419,25 +634,16 @@ func (m Model) renderHelpModal() string {
 
 	helpContent := strings.Join(helpItems, "\n")
 
-	modalStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color(colorThemePurple)).
-		Padding(1, 2).
-		Width(45)
-
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color(colorThemePurple)).
 		Align(lipgloss.Center).
 		Width(28)
 
-	title := titleStyle.Render("Help")
-	modal := modalStyle.Render(title + "\n\n" + helpContent)
-
-	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+	return modalContent{title: titleStyle.Render("Help"), body: helpContent, width: 45}
 }
 
-func (m Model) renderCurlModal() string {
+func (m Model) snippetModal() modalContent {
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color(colorThemePurple)).
@@ -447,21 +653,31 @@ func (m Model) renderCurlModal() string {
 		Foreground(lipgloss.Color(colorWhite)).
 		Padding(1, 0)
 
-	modalStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color(colorThemePurple)).
-		Padding(1, 2).
-		Width(min(m.width-4, 100))
-
-	instructionStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color(colorGray)).
-		Italic(true)
+	grayStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray))
 
-	title := titleStyle.Render("Generated curl Command")
-	instruction := instructionStyle.Render("Press Esc to close")
+	targets := snippetEmitters(m.curl)
+	names := make([]string, len(targets))
+	for i, target := range targets {
+		names[i] = grayStyle.Render(target.name())
+		if i == m.snippetTarget {
+			names[i] = titleStyle.Render(target.name())
+		}
+	}
+	title := titleStyle.Render("Generated Snippet") + "\n\n" + strings.Join(names, grayStyle.Italic(true).Render(" · "))
 	curlContent := curlStyle.Render(m.curlCommand)
 
-	modal := modalStyle.Render(title + "\n\n" + curlContent + "\n\n" + instruction)
-
-	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, modal)
+	var body string
+	if m.curlWarning != "" {
+		warningStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(colorYellow)).
+			Bold(true)
+		body += warningStyle.Render("⚠ "+m.curlWarning) + "\n"
+	}
+	return modalContent{
+		title:       title,
+		body:        body + curlContent,
+		instruction: "←/→ switch target · y to copy · Esc to close",
+		width:       min(m.width-4, 100),
+	}
 }
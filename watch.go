@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"go.yaml.in/yaml/v4"
+)
+
+// specWatchInterval is how often the spec file is checked for changes on disk
+const specWatchInterval = 2 * time.Second
+
+// reloadHighlight is how long the items a reload added or changed stay badged
+const reloadHighlight = 20 * time.Second
+
+type specCheckMsg struct{}
+
+// specRefreshMsg asks to fetch a spec URL again, every --refresh-every
+type specRefreshMsg struct{}
+
+type specReloadedMsg struct {
+	path        string
+	doc         *v3.Document
+	hash        string
+	size        int
+	content     []byte
+	patchBadges map[string]string
+	problems    []lintIssue
+	modTime     time.Time
+	err         error
+	// refreshed is set for the periodic check of a spec URL, unchanged when the content
+	// was the same
+	refreshed bool
+	unchanged bool
+	// changes badges the items that differ from the version shown before
+	changes map[string]string
+}
+
+// reloadHighlightDoneMsg ends the highlight of the reload that loaded hash
+type reloadHighlightDoneMsg struct {
+	hash string
+}
+
+// specFingerprint returns a short content hash used to tell spec versions apart
+func specFingerprint(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:4])
+}
+
+// watchSpec records where the spec came from so the header can show its fingerprint
+// and the file can be checked for changes. path is empty when the spec was read from stdin
+func (m *Model) watchSpec(path string, content []byte, autoReload bool) {
+	m.specPath = path
+	m.specHash = specFingerprint(content)
+	m.specSize = len(content)
+	m.specContent = content
+	m.autoReload = autoReload
+	if path != "" {
+		if info, err := os.Stat(specFile(path)); err == nil {
+			m.specModTime = info.ModTime()
+		}
+	}
+	m.updateBudgetWarnings()
+}
+
+func checkSpecLater() tea.Cmd {
+	return tea.Tick(specWatchInterval, func(time.Time) tea.Msg {
+		return specCheckMsg{}
+	})
+}
+
+// checkSpec compares the file on disk with the loaded spec. The file is only hashed when
+// its modification time moved, and a touch without content changes is ignored
+func (m *Model) checkSpec() tea.Cmd {
+	info, err := os.Stat(specFile(m.specPath))
+	if err != nil || info.ModTime().Equal(m.specModTime) {
+		return checkSpecLater()
+	}
+
+	content, err := readSpecFile(m.specPath)
+	if err != nil {
+		return checkSpecLater()
+	}
+
+	if specFingerprint(content) == m.specHash {
+		m.specModTime = info.ModTime()
+		return checkSpecLater()
+	}
+
+	debugLog.Debug("spec changed on disk", "path", m.specPath, "autoReload", m.autoReload)
+	if m.autoReload {
+		return tea.Batch(reloadSpec(m.specPath, m.patch, m.specContent), checkSpecLater())
+	}
+	m.specChanged = true
+	return checkSpecLater()
+}
+
+// reloadSpec reads the spec again, applying the previewed patch when there is one. The
+// fingerprint stays that of the file so checkSpec can compare it. What changed since the
+// previous content is badged, nil skips the comparison
+func reloadSpec(path string, patch *specPatch, previous []byte) tea.Cmd {
+	return func() tea.Msg {
+		content, err := readSpec(context.Background(), path)
+		if err != nil {
+			return specReloadedMsg{path: path, err: err}
+		}
+		return buildReloadedSpec(path, content, patch, previous)
+	}
+}
+
+// buildReloadedSpec builds the model of the spec read again, with the patch applied, and
+// compares it with the previous content
+func buildReloadedSpec(path string, content []byte, patch *specPatch, previous []byte) specReloadedMsg {
+	hash := specFingerprint(content)
+
+	var badges map[string]string
+	var err error
+	if patch != nil {
+		if content, badges, err = patch.apply(content); err != nil {
+			return specReloadedMsg{path: path, err: err}
+		}
+	}
+
+	var modTime time.Time
+	if info, err := os.Stat(specFile(path)); err == nil {
+		modTime = info.ModTime()
+	}
+
+	v3Model, err := buildModel(context.Background(), content, path)
+	if v3Model == nil {
+		return specReloadedMsg{path: path, err: err}
+	}
+	return specReloadedMsg{path: path, doc: &v3Model.Model, hash: hash, size: len(content), content: content, patchBadges: badges, problems: validateSpec(content, err), modTime: modTime, changes: reloadChanges(previous, content)}
+}
+
+func refreshSpecLater(interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return specRefreshMsg{}
+	})
+}
+
+// refreshSpec fetches a spec URL again and only rebuilds the model when the content changed
+func refreshSpec(path string, patch *specPatch, hash string, previous []byte) tea.Cmd {
+	return func() tea.Msg {
+		content, err := readSpec(context.Background(), path)
+		if err != nil {
+			return specReloadedMsg{path: path, err: err, refreshed: true}
+		}
+		if specFingerprint(content) == hash {
+			return specReloadedMsg{path: path, refreshed: true, unchanged: true}
+		}
+		msg := buildReloadedSpec(path, content, patch, previous)
+		msg.refreshed = true
+		return msg
+	}
+}
+
+// replaceDocument swaps in a freshly loaded document, keeping the active view and filter, and
+// the runner when its operation is still there
+func (m *Model) replaceDocument(doc *v3.Document) {
+	m.doc = doc
+	m.usages = nil
+	m.responses = nil
+	m.tagPicker = nil
+	m.operationIDs = nil
+	m.outline = nil
+	m.scopes = nil
+	m.mediaTypes = nil
+	m.servers = nil
+	m.schemaTree = nil
+	m.specSwitcher = nil
+	m.pii = nil
+	m.issues = nil
+	m.overview = nil
+	m.endpoints = extractEndpoints(doc)
+	m.reloadRunner()
+	m.components, m.componentsLoaded = nil, false
+	if m.mode == viewComponents {
+		m.loadComponents()
+	}
+	m.detailCache = map[string]string{}
+	m.webhooks = extractWebhooks(doc)
+	m.tags = extractTags(doc, m.endpoints)
+	attachNotes(m.endpoints, m.notes)
+	if m.sortKeys != nil {
+		sortEndpoints(m.endpoints, m.sortKeys)
+	}
+	if m.mode == viewWebhooks && !m.hasWebhooks() {
+		m.mode = viewEndpoints
+	}
+	m.filterItems()
+
+	m.cursor = min(m.cursor, max(0, m.getMaxItems()))
+	m.ensureCursorVisible()
+}
+
+// reloadChanges badges the operations, webhooks and components that differ between two
+// versions of the spec, as the patch preview does
+func reloadChanges(before, after []byte) map[string]string {
+	var oldDoc, newDoc yaml.Node
+	if yaml.Unmarshal(before, &oldDoc) != nil || yaml.Unmarshal(after, &newDoc) != nil || len(oldDoc.Content) == 0 || len(newDoc.Content) == 0 {
+		return nil
+	}
+	oldRoot, newRoot := oldDoc.Content[0], newDoc.Content[0]
+
+	// Only the keys that differ are touched, so an edit to one operation doesn't badge the
+	// others on its path, while a change to the path's shared parameters badges them all
+	var touched [][]string
+	differs := func(path []string) {
+		oldNode, newNode := nodeAt(oldRoot, path), nodeAt(newRoot, path)
+		if oldNode == nil || newNode == nil || canonicalNode(oldNode) != canonicalNode(newNode) {
+			touched = append(touched, path)
+		}
+	}
+	keys := func(path []string) []string {
+		var names []string
+		for _, root := range []*yaml.Node{oldRoot, newRoot} {
+			if node := nodeAt(root, path); node != nil && node.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					if !slices.Contains(names, node.Content[i].Value) {
+						names = append(names, node.Content[i].Value)
+					}
+				}
+			}
+		}
+		return names
+	}
+
+	for _, section := range []string{"paths", "webhooks"} {
+		for _, path := range keys([]string{section}) {
+			for _, key := range keys([]string{section, path}) {
+				differs([]string{section, path, key})
+			}
+		}
+	}
+	for _, compType := range keys([]string{"components"}) {
+		if _, ok := componentTypes[compType]; ok {
+			for _, name := range keys([]string{"components", compType}) {
+				differs([]string{"components", compType, name})
+			}
+		}
+	}
+	return patchBadges(oldRoot, newRoot, touched)
+}
+
+func reloadHighlightDone(hash string) tea.Cmd {
+	return tea.Tick(reloadHighlight, func(time.Time) tea.Msg {
+		return reloadHighlightDoneMsg{hash: hash}
+	})
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+)
+
+// workspaceSpec is a spec opened in the TUI, with the state kept for it while another spec
+// of the workspace is shown
+type workspaceSpec struct {
+	path string
+	// content is the file as read, specContent the spec shown, with the patch applied
+	content      []byte
+	specContent  []byte
+	patchBadges  map[string]string
+	doc          *v3.Document
+	endpoints    []endpoint
+	modTime      time.Time
+	problems     []lintIssue
+	notes        specNotes
+	team         *teamFile
+	session      *sessionState
+	activeServer string
+	serverValues map[string]map[string]string
+}
+
+// workspace holds the specs opened together, as in `oq api/*.yaml`
+type workspace struct {
+	specs  []*workspaceSpec
+	active int
+}
+
+// expandSpecArgs returns the specs named on the command line, expanding glob patterns the
+// shell left alone. URLs and existing files are kept as given
+func expandSpecArgs(args []string) ([]string, error) {
+	var paths []string
+	add := func(path string) {
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	for _, arg := range args {
+		if isRemoteSpec(arg) || !strings.ContainsAny(arg, "*?[") {
+			add(arg)
+			continue
+		}
+		if _, err := os.Stat(arg); err == nil {
+			add(arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("Error expanding %s: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("Error: no spec matches %s", arg)
+		}
+		for _, match := range matches {
+			add(match)
+		}
+	}
+	return paths, nil
+}
+
+// openSpec reads and builds a spec for the TUI, with its patch, problems, notes and team
+// file. Specs with validation errors are still opened, the errors are listed in the
+// problems pane
+func openSpec(ctx context.Context, path string, patch *specPatch, notesFile string, step func(string)) (*workspaceSpec, error) {
+	content, err := readSpec(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	spec := &workspaceSpec{path: path, content: content, specContent: content}
+	if patch != nil {
+		step("Applying " + patch.file + "...")
+		if spec.specContent, spec.patchBadges, err = patch.apply(content); err != nil {
+			return nil, err
+		}
+	}
+
+	step("Resolving references...")
+	v3Model, buildErr := buildModel(ctx, spec.specContent, path)
+	if v3Model == nil {
+		return nil, buildErr
+	}
+	if buildErr != nil {
+		debugLog.Warn("spec has validation errors", "path", path, "error", buildErr)
+	}
+	spec.doc = &v3Model.Model
+	spec.endpoints = extractEndpoints(spec.doc)
+	step("Validating...")
+	spec.problems = validateSpec(spec.specContent, buildErr)
+
+	if spec.notes, err = loadSpecNotes(path, notesFile); err != nil {
+		return nil, err
+	}
+	if spec.team, err = loadTeamFile(path); err != nil {
+		return nil, err
+	}
+	if spec.team != nil {
+		spec.notes = mergeNotes(spec.notes, spec.team.notes)
+	}
+	if path != "" && !isRemoteSpec(path) {
+		if info, err := os.Stat(specFile(path)); err == nil {
+			spec.modTime = info.ModTime()
+		}
+	}
+	return spec, nil
+}
+
+// title is the spec's title and version, as shown in the footer
+func (s *workspaceSpec) title() string {
+	if s.doc.Info == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s v%s", s.doc.Info.Title, s.doc.Info.Version)
+}
+
+// switchSpec shows another spec of the workspace where the user left it, keeping the state
+// of the one shown until now for when they come back
+func (m *Model) switchSpec(i int) {
+	ws := m.workspace
+	if i == ws.active {
+		return
+	}
+	current := ws.specs[ws.active]
+	state := m.captureSession()
+	current.session = &state
+	if current.doc != m.doc {
+		current.endpoints = extractEndpoints(m.doc)
+	}
+	current.doc, current.content, current.specContent = m.doc, m.specContent, m.specContent
+	current.modTime, current.problems = m.specModTime, m.problems
+	current.activeServer, current.serverValues = m.activeServer, m.serverValues
+
+	next := ws.specs[i]
+	ws.active = i
+	m.team, m.notes = next.team, next.notes
+	m.filters = listFilters{}
+	m.searchInput.SetValue("")
+	m.pinned, m.detailScroll = nil, 0
+	m.closeRunner()
+	m.replaceDocument(next.doc)
+	m.watchSpec(next.path, next.content, m.autoReload)
+	// Changes made to the file in the meantime are picked up by the next check
+	m.specModTime = next.modTime
+	m.specChanged, m.reloadErr, m.reloadBadges, m.patchBadges = false, nil, nil, nil
+	m.problems = next.problems
+	m.activeServer, m.serverValues = next.activeServer, next.serverValues
+	if next.session != nil {
+		m.restoreSession(*next.session)
+	} else {
+		m.cursor, m.scrollOffset = 0, 0
+	}
+	m.setStatus(fmt.Sprintf("%s (%d/%d)", next.path, i+1, len(ws.specs)), false)
+}
+
+// sessions returns the state to save of every spec that was open, by spec path
+func (m *Model) sessions() map[string]sessionState {
+	sessions := map[string]sessionState{m.specPath: m.captureSession()}
+	if m.workspace != nil {
+		for i, spec := range m.workspace.specs {
+			if i != m.workspace.active && spec.session != nil {
+				sessions[spec.path] = *spec.session
+			}
+		}
+	}
+	return sessions
+}
+
+// specSwitcher lists the specs of the workspace, like a buffer list. Typing searches the
+// endpoints of every spec
+type specSwitcher struct {
+	input  textinput.Model
+	cursor int
+}
+
+// switcherRow is a spec of the workspace, or one of its endpoints matching the search
+type switcherRow struct {
+	spec int
+	ep   *endpoint
+}
+
+func (m *Model) openSpecSwitcher() {
+	if m.workspace == nil {
+		m.setStatus("Only one spec is open, pass several to switch between them, e.g. oq api/*.yaml", false)
+		return
+	}
+	input := textinput.New()
+	input.Placeholder = "search endpoints in all specs"
+	input.CharLimit = 100
+	input.Width = 40
+	input.Focus()
+	m.specSwitcher = &specSwitcher{input: input, cursor: m.workspace.active}
+}
+
+// switcherRows lists the specs, and under each the endpoints matching the search. Specs
+// are left out while searching unless their path, title or an endpoint matches
+func (m *Model) switcherRows() []switcherRow {
+	query := strings.ToLower(strings.TrimSpace(m.specSwitcher.input.Value()))
+	terms := parseSearchQuery(strings.TrimSpace(m.specSwitcher.input.Value()))
+	var rows []switcherRow
+	for i, spec := range m.workspace.specs {
+		eps := spec.endpoints
+		if i == m.workspace.active {
+			eps = m.endpoints
+		}
+		var matches []switcherRow
+		if query != "" {
+			for j := range eps {
+				if endpointMatches(eps[j], terms) {
+					matches = append(matches, switcherRow{spec: i, ep: &eps[j]})
+				}
+			}
+		}
+		name := strings.ToLower(spec.path + " " + spec.title())
+		if query == "" || len(matches) > 0 || strings.Contains(name, query) {
+			rows = append(rows, switcherRow{spec: i})
+			rows = append(rows, matches...)
+		}
+	}
+	return rows
+}
+
+// updateSpecSwitcher handles keys while the spec switcher is open
+func (m *Model) updateSpecSwitcher(msg tea.KeyMsg) tea.Cmd {
+	p := m.specSwitcher
+	rows := m.switcherRows()
+
+	switch msg.String() {
+	case "esc":
+		m.specSwitcher = nil
+	case "up", "ctrl+p":
+		if len(rows) > 0 {
+			p.cursor = (p.cursor - 1 + len(rows)) % len(rows)
+		}
+	case "down", "ctrl+n", "tab":
+		if len(rows) > 0 {
+			p.cursor = (p.cursor + 1) % len(rows)
+		}
+	case "enter":
+		if len(rows) == 0 {
+			return nil
+		}
+		row := rows[min(p.cursor, len(rows)-1)]
+		m.specSwitcher = nil
+		var ep endpoint
+		if row.ep != nil {
+			ep = *row.ep
+		}
+		m.switchSpec(row.spec)
+		if row.ep != nil {
+			m.jumpToEndpoint(ep)
+		}
+	default:
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		p.cursor = 0
+		return cmd
+	}
+	return nil
+}
+
+func (m Model) renderSpecSwitcher() string {
+	titleStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colorThemePurple))
+	instructionStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(colorGray)).
+		Italic(true)
+	grayStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(colorGray))
+
+	rows := m.switcherRows()
+	var methods []string
+	for _, row := range rows {
+		if row.ep != nil {
+			methods = append(methods, row.ep.method)
+		}
+	}
+	methodWidth := m.methodWidth(methods)
+
+	bodyHeight := max(1, m.height-7)
+	cursor := min(m.specSwitcher.cursor, max(0, len(rows)-1))
+	start := max(0, min(cursor-bodyHeight+1, len(rows)-bodyHeight))
+	var lines []string
+	for i := start; i < len(rows) && i < start+bodyHeight; i++ {
+		row := rows[i]
+		spec := m.workspace.specs[row.spec]
+		background := lipgloss.NewStyle()
+		if i == cursor {
+			background = highlight(background)
+		}
+
+		var line string
+		if row.ep == nil {
+			marker := "  "
+			if row.spec == m.workspace.active {
+				marker = "● "
+			}
+			count := len(spec.endpoints)
+			if row.spec == m.workspace.active {
+				count = len(m.endpoints)
+			}
+			line = background.Foreground(lipgloss.Color(colorGreen)).Render(marker)
+			line += background.Bold(true).Render(spec.path)
+			line += background.Foreground(lipgloss.Color(colorGray)).Render(fmt.Sprintf("  %s · %d %s", spec.title(), count, plural(count, "endpoint", "endpoints")))
+		} else {
+			line = background.Render("    ")
+			line += background.Foreground(m.methodColor(row.ep.method)).Bold(true).Width(methodWidth).Render(m.methodLabel(row.ep.method))
+			line += background.Render(" " + row.ep.path)
+			if row.ep.op.Summary != "" {
+				line += background.Foreground(lipgloss.Color(colorGray)).Render("  " + row.ep.op.Summary)
+			}
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(m.width).Render(line))
+	}
+	if len(rows) == 0 {
+		lines = append(lines, grayStyle.Render("No spec or endpoint matches"))
+	}
+
+	title := titleStyle.Render(fmt.Sprintf("Specs (%d)", len(m.workspace.specs)))
+	instruction := instructionStyle.Render("↑/↓ move · type to search endpoints · Enter open · Esc close")
+	return lipgloss.NewStyle().MaxHeight(m.height).Render(title + "\n\n" + m.specSwitcher.input.View() + "\n\n" + strings.Join(lines, "\n") + "\n\n" + instruction)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// editSpecFile applies edit to the YAML tree of the spec file and writes the result back in
+// the same format and indentation. Comments and key order are kept, quoting and line
+// wrapping follow the encoder
+func editSpecFile(path string, edit func(root *yaml.Node) error) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %w", path, err)
+	}
+	if len(root.Content) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	if err := edit(root.Content[0]); err != nil {
+		return nil, err
+	}
+	literalScalars(&root)
+
+	indent := detectIndent(content)
+	var out []byte
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		var compact bytes.Buffer
+		if err := writeJSONNode(&compact, &root); err != nil {
+			return nil, err
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, compact.Bytes(), "", strings.Repeat(" ", indent)); err != nil {
+			return nil, err
+		}
+		indented.WriteByte('\n')
+		out = indented.Bytes()
+	} else {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(indent)
+		if err := enc.Encode(&root); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		out = buf.Bytes()
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// detectIndent returns the indentation of the first nested line, 2 when there is none
+func detectIndent(content []byte) int {
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if n := len(line) - len(trimmed); n > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return min(n, 8)
+		}
+	}
+	return 2
+}
+
+// operationNode finds the operation of method on path in the YAML tree of a spec, including
+// additionalOperations and x-methods
+func operationNode(root *yaml.Node, path, method string) *yaml.Node {
+	item := mappingValue(mappingValue(root, "paths"), path)
+	if item == nil {
+		return nil
+	}
+	if lower := strings.ToLower(method); standardOperationKeys[lower] {
+		return mappingValue(item, lower)
+	}
+	if _, op := mappingValueFold(mappingValue(item, "additionalOperations"), method); op != nil {
+		return op
+	}
+	_, op := mappingValueFold(mappingValue(item, customMethodsExtension), method)
+	return op
+}
+
+// requireWriteMode reports whether edits are allowed, explaining how to enable them if not
+func (m *Model) requireWriteMode() bool {
+	if m.writeMode && m.specPath != "" {
+		return true
+	}
+	m.setStatus("Editing needs write mode, start oq with --write <spec file>", true)
+	return false
+}